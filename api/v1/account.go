@@ -0,0 +1,18 @@
+package v1
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"1234@gmail.com"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6" example:"123456"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerifyEmailRequest struct {
+	Email string `json:"email" binding:"required,email" example:"1234@gmail.com"`
+}
@@ -0,0 +1,37 @@
+package v1
+
+import "time"
+
+type CreateApiKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=255" example:"ci"`
+	Scopes        []string `json:"scopes" binding:"required,min=1" example:"GET /v1/user/list"` // "METHOD /path"，METHOD 可以是 *，path 支持 /* 和 :id 通配
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0" example:"90"`                // 0 表示不过期
+}
+
+type ApiKeyData struct {
+	Id         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateApiKeyResponseData struct {
+	ApiKeyData
+	Key string `json:"key"` // 完整的 key 只在创建时返回一次
+}
+type CreateApiKeyResponse struct {
+	Response
+	Data CreateApiKeyResponseData
+}
+
+type ListApiKeysResponseData struct {
+	List []ApiKeyData `json:"list"`
+}
+type ListApiKeysResponse struct {
+	Response
+	Data ListApiKeysResponseData
+}
@@ -3,6 +3,7 @@ package v1
 type UploadPresignedUrlRequest struct {
 	FileExt     string `json:"file_ext" example:"alan"`
 	UploadScene int    `json:"upload_scene" binding:"required" example:"1"`
+	FileSize    int64  `json:"file_size" binding:"omitempty,min=1" example:"102400"` // 字节，头像必填，上传时 Content-Length 必须一致
 }
 
 type UploadPresignedUrlResponseData struct {
@@ -9,7 +9,26 @@ var (
 	ErrInternalServerError = newError(500, "Internal Server Error")
 
 	// more biz errors
-	ErrUnauthorized     = newError(2000, "Unauthorized")
-	ErrEmailAlreadyUse  = newError(1001, "The email is already in use.")
-	ErrEmailAlreadyUse1 = newError(1002, "T211212he email is already in use.")
+	ErrUnauthorized            = newError(2000, "Unauthorized")
+	ErrEmailAlreadyUse         = newError(1001, "The email is already in use.")
+	ErrEmailAlreadyUse1        = newError(1002, "T211212he email is already in use.")
+	ErrEmailNotVerified        = newError(1003, "The email address has not been verified.")
+	ErrInvalidLink             = newError(1004, "The link is invalid or has expired.")
+	ErrMfaCodeInvalid          = newError(1005, "The verification code is invalid.")
+	ErrMfaRequired             = newError(1006, "Two-factor authentication is required for this account.")
+	ErrMfaNotEnrolled          = newError(1007, "Two-factor authentication is not set up.")
+	ErrMfaAlreadyActive        = newError(1008, "Two-factor authentication is already enabled.")
+	ErrOAuthFailed             = newError(1009, "Third-party login failed.")
+	ErrIdentityInUse           = newError(1010, "This third-party account is already linked to another user.")
+	ErrApiKeyScopeInvalid      = newError(1011, "The API key scope is invalid.")
+	ErrRoleCycle               = newError(1012, "Role inheritance cannot form a cycle.")
+	ErrPermissionHasChildren   = newError(1013, "The permission still has child permissions.")
+	ErrRoleInUse               = newError(1014, "The role is still assigned to users or inherited by other roles.")
+	ErrPermissionParentInvalid = newError(1015, "The parent permission is invalid.")
+	ErrRbacDocumentInvalid     = newError(1016, "The RBAC document is invalid.")
+	ErrUserDisabled            = newError(1017, "The account has been disabled.")
+	ErrImportFileInvalid       = newError(1018, "The import file is invalid.")
+	ErrAvatarInvalid           = newError(1019, "The avatar image is invalid.")
+	ErrRoleSidExists           = newError(1020, "The role key is already in use.")
+	ErrRoleKeyRequired         = newError(1021, "The role key is required.")
 )
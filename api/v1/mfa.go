@@ -0,0 +1,57 @@
+package v1
+
+type EnrollTotpResponseData struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"` // 前端据此生成二维码
+}
+type EnrollTotpResponse struct {
+	Response
+	Data EnrollTotpResponseData
+}
+
+type MfaCodeRequest struct {
+	Code         string `json:"code" example:"123456"`
+	RecoveryCode string `json:"recovery_code" example:"abcd-efgh"`
+}
+
+type RecoveryCodesResponseData struct {
+	RecoveryCodes []string `json:"recovery_codes"` // 只展示这一次
+}
+type RecoveryCodesResponse struct {
+	Response
+	Data RecoveryCodesResponseData
+}
+
+type GetMfaStatusResponseData struct {
+	TotpEnabled   bool `json:"totp_enabled"`
+	Required      bool `json:"required"`
+	RecoveryCodes int  `json:"recovery_codes"` // 剩余可用恢复码数量
+}
+type GetMfaStatusResponse struct {
+	Response
+	Data GetMfaStatusResponseData
+}
+
+// LoginMfaRequest 登录第二步，code 和 recovery_code 二选一
+type LoginMfaRequest struct {
+	MfaToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" example:"123456"`
+	RecoveryCode string `json:"recovery_code" example:"abcd-efgh"`
+}
+
+type LoginMfaEnrollRequest struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+}
+
+type LoginMfaActivateRequest struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+type LoginMfaActivateResponseData struct {
+	LoginResponseData
+	RecoveryCodesResponseData
+}
+type LoginMfaActivateResponse struct {
+	Response
+	Data LoginMfaActivateResponseData
+}
@@ -0,0 +1,37 @@
+package v1
+
+import "go-nunu/internal/model"
+
+type OAuthProvider struct {
+	Name string `json:"name" example:"google"`
+	Type string `json:"type" example:"oidc"`
+}
+type ListOAuthProvidersResponseData struct {
+	List []OAuthProvider `json:"list"`
+}
+type ListOAuthProvidersResponse struct {
+	Response
+	Data ListOAuthProvidersResponseData
+}
+
+type OAuthAuthorizeResponseData struct {
+	AuthUrl string `json:"auth_url"` // 前端跳转到该地址进行授权
+}
+type OAuthAuthorizeResponse struct {
+	Response
+	Data OAuthAuthorizeResponseData
+}
+
+// OAuthCallbackRequest 前端回调页从 URL 中取出 code 和 state 提交
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type ListIdentitiesResponseData struct {
+	List []model.UserIdentity `json:"list"`
+}
+type ListIdentitiesResponse struct {
+	Response
+	Data ListIdentitiesResponseData
+}
@@ -32,7 +32,45 @@ type CreatePermissionRequest struct {
 	Component string `json:"component"` // 前端组件路径: "views/system/user/index"
 	Api       string `json:"api"`       // 接口路径: "/v1/user/:id"
 }
+type UpdatePermissionRequest struct {
+	ID        uint   `json:"id" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Key       string `json:"key" binding:"required"`
+	Path      string `json:"path"`   // 前端路由地址: "/system/user"
+	Method    string `json:"method"` // 请求方法: "GET", "POST", "DELETE"，"*" 表示任意方法
+	ParentID  int    `json:"parent_id"`
+	Sort      int    `json:"sort"` // 排序: 数字越小越靠前
+	Type      int    `json:"type" binding:"required,oneof=1 2 3"`
+	Component string `json:"component"` // 前端组件路径: "views/system/user/index"
+	Api       string `json:"api"`       // 接口路径: "/v1/user/:id"
+}
+
 type CreatePermissionResponse struct {
 	Response
 	Data model.Permission `json:"data"`
 }
+
+type PermissionTreeNode struct {
+	model.Permission
+	Children []*PermissionTreeNode `json:"children"`
+}
+type GetPermissionTreeResponseData struct {
+	List []*PermissionTreeNode `json:"list"`
+}
+type GetPermissionTreeResponse struct {
+	Response
+	Data GetPermissionTreeResponseData `json:"data"`
+}
+
+type MenuTreeNode struct {
+	model.Permission
+	Buttons  []string        `json:"buttons"` // 该菜单下当前用户拥有的按钮 Key，前端据此隐藏按钮
+	Children []*MenuTreeNode `json:"children"`
+}
+type GetMyMenusResponseData struct {
+	List []*MenuTreeNode `json:"list"`
+}
+type GetMyMenusResponse struct {
+	Response
+	Data GetMyMenusResponseData `json:"data"`
+}
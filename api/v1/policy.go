@@ -0,0 +1,92 @@
+package v1
+
+// PolicyDiff 关系表推导出的 Casbin 规则与 casbin_rule 表的差异
+// 每条规则第一个元素为 ptype，例如 ["p", "admin", "api:/v1/user/list", "GET"]、["g", "user:1", "admin"]
+type PolicyDiff struct {
+	Missing [][]string `json:"missing"` // 关系表中有、casbin_rule 中缺少的规则
+	Extra   [][]string `json:"extra"`   // casbin_rule 中多出的规则
+	Applied bool       `json:"applied"` // 差异是否已写回 Casbin
+}
+
+type PolicyDiffResponse struct {
+	Response
+	Data PolicyDiff
+}
+
+// ApiRoute 挂载了 AuthMiddleware 的路由，Path 为完整路径，与权限的 Api 字段一致
+type ApiRoute struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`    // "/v1/role/:id"
+	Handler string `json:"handler"` // "DeleteRole"
+}
+
+type StalePermission struct {
+	ID     uint   `json:"id"`
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Api    string `json:"api"`
+	Method string `json:"method"`
+}
+
+type SyncApiPermissionsRequest struct {
+	DryRun bool `json:"dry_run"` // 只返回结果，不写入
+}
+
+type SyncApiPermissionsData struct {
+	Created []ApiRoute        `json:"created"` // 没有对应按钮权限的路由，写入时为每个路由新增一条
+	Stale   []StalePermission `json:"stale"`   // Api 已没有对应路由的按钮权限，写入时标记 stale，不会删除
+	Applied bool              `json:"applied"`
+}
+type SyncApiPermissionsResponse struct {
+	Response
+	Data SyncApiPermissionsData
+}
+
+type CheckPermissionRequest struct {
+	UserId string `json:"user_id"`                                         // 与 role 二选一
+	Role   string `json:"role"`                                            // 角色 Sid
+	Path   string `json:"path" binding:"required" example:"/v1/user/list"` // 实际请求路径
+	Method string `json:"method" binding:"required" example:"POST"`
+}
+
+// PolicyMatch 命中的 p 规则，以及主体通过哪些角色继承到这条规则
+type PolicyMatch struct {
+	Policy []string `json:"policy"` // sid, obj, act
+	Chain  []string `json:"chain"`  // 如 ["user:xxx", "dev", "base"]，最后一个是规则所属的角色
+}
+
+type CheckPermissionData struct {
+	Allowed     bool         `json:"allowed"`
+	Subject     string       `json:"subject"`
+	Object      string       `json:"object"`
+	Action      string       `json:"action"`
+	AdminBypass bool         `json:"admin_bypass"` // 超管跳过 API 权限检查
+	Match       *PolicyMatch `json:"match"`        // 未命中时为空
+	DataScope   int          `json:"data_scope"`   // 1全部 2本部门 3仅本人
+	DeptId      uint         `json:"dept_id,omitempty"`
+}
+type CheckPermissionResponse struct {
+	Response
+	Data CheckPermissionData
+}
+
+type WhoCanRequest struct {
+	Path   string `form:"path" binding:"required" example:"/v1/user/list"`
+	Method string `form:"method" binding:"required" example:"POST"`
+}
+
+type WhoCanRole struct {
+	Sid   string      `json:"sid"`
+	Match PolicyMatch `json:"match"`
+}
+
+type WhoCanData struct {
+	Object string       `json:"object"`
+	Action string       `json:"action"`
+	Roles  []WhoCanRole `json:"roles"`
+	Users  []string     `json:"users"` // 拥有上述任一角色的用户 UserId，超管不需要角色，不在其中
+}
+type WhoCanResponse struct {
+	Response
+	Data WhoCanData
+}
@@ -0,0 +1,47 @@
+package v1
+
+const RbacDocumentVersion = 1
+
+// RbacDocument 权限树、角色及其关联的 YAML 导出格式
+// 权限按 Key、角色按 Sid 关联，不包含数据库 ID，可以在不同环境之间导入
+type RbacDocument struct {
+	Version     int              `yaml:"version"`
+	Permissions []RbacPermission `yaml:"permissions"` // 父权限排在子权限前面
+	Roles       []RbacRole       `yaml:"roles"`
+}
+
+type RbacPermission struct {
+	Key       string `yaml:"key"`
+	Name      string `yaml:"name"`
+	Type      int    `yaml:"type"`             // 1目录 2菜单 3按钮
+	Parent    string `yaml:"parent,omitempty"` // 父权限的 Key
+	Sort      int    `yaml:"sort,omitempty"`
+	Path      string `yaml:"path,omitempty"`
+	Component string `yaml:"component,omitempty"`
+	Api       string `yaml:"api,omitempty"`
+	Method    string `yaml:"method,omitempty"`
+}
+
+type RbacRole struct {
+	Sid         string   `yaml:"sid"`
+	Name        string   `yaml:"name"`
+	DataScope   int      `yaml:"data_scope"`        // 1全部 2本部门 3仅本人
+	Parents     []string `yaml:"parents,omitempty"` // 继承的角色 Sid
+	Permissions []string `yaml:"permissions"`       // 直接拥有的权限 Key
+}
+
+type RbacChange struct {
+	Kind   string   `json:"kind"`             // permission 或 role
+	Key    string   `json:"key"`              // Permission.Key 或 Role.Sid
+	Action string   `json:"action"`           // create 或 update
+	Fields []string `json:"fields,omitempty"` // update 时变化的字段
+}
+
+type ImportRbacData struct {
+	Changes []RbacChange `json:"changes"`
+	Applied bool         `json:"applied"`
+}
+type ImportRbacResponse struct {
+	Response
+	Data ImportRbacData
+}
@@ -7,9 +7,11 @@ import (
 
 type CreateRoleRequest struct {
 	Name          string `json:"name"`
-	Key           string `json:"key"`
+	Key           string `json:"key" binding:"required"` // 角色标识 Sid，即 Casbin 中的角色名
 	Status        int    `json:"status"`
 	PermissionIds []uint `json:"permission_ids"`
+	ParentIds     []uint `json:"parent_ids"`                                // 继承的角色
+	DataScope     int    `json:"data_scope" binding:"omitempty,oneof=1 2 3"` // 数据权限 1全部 2本部门 3仅本人，默认全部
 }
 
 type GetRoleListRequest struct {
@@ -29,10 +31,47 @@ type GetRoleListResponse struct {
 }
 
 type UpdateRolePermissionsRequest struct {
-	ID            int  `json:"id"`
+	ID            int    `json:"id" binding:"required"`
 	PermissionIds []uint `json:"permission_ids"`
 }
 type CreateRoleResponse struct {
 	Response
 	Data model.Role `json:"data"`
 }
+
+type UpdateRoleRequest struct {
+	ID   int    `json:"id" binding:"required"`
+	Name string `json:"name"` // 为空表示不修改
+	Key  string `json:"key"`  // 角色标识 Sid，为空表示不修改；修改后 Casbin 中的规则随之改名
+}
+
+type UpdateRoleParentsRequest struct {
+	ID        int    `json:"id" binding:"required"`
+	ParentIds []uint `json:"parent_ids"` // 为空表示不再继承任何角色
+}
+
+type UpdateRoleDataScopeRequest struct {
+	ID        int `json:"id" binding:"required"`
+	DataScope int `json:"data_scope" binding:"required,oneof=1 2 3" example:"2"` // 1全部 2本部门 3仅本人
+}
+
+const (
+	PermissionSourceDirect    = "direct"
+	PermissionSourceInherited = "inherited"
+)
+
+type EffectivePermission struct {
+	model.Permission
+	Source        string   `json:"source"`         // direct: 直接分配给该角色; inherited: 仅通过继承获得
+	InheritedFrom []string `json:"inherited_from"` // 通过继承提供该权限的祖先角色 Sid
+}
+
+type GetRolePermissionsResponseData struct {
+	Role      model.Role            `json:"role"`
+	Ancestors []string              `json:"ancestors"` // 全部祖先角色 Sid，由近到远
+	List      []EffectivePermission `json:"list"`
+}
+type GetRolePermissionsResponse struct {
+	Response
+	Data GetRolePermissionsResponseData `json:"data"`
+}
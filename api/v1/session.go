@@ -0,0 +1,40 @@
+package v1
+
+import (
+	"go-nunu/api"
+	"go-nunu/internal/model"
+)
+
+type ListSessionsResponseData struct {
+	List             []model.Session `json:"list"`
+	CurrentSessionId uint            `json:"current_session_id"` // 发起请求的会话，只在查看自己的会话时返回
+}
+type ListSessionsResponse struct {
+	Response
+	Data ListSessionsResponseData
+}
+
+type GetLoginLogListRequest struct {
+	api.PageRequest
+	UserId string `json:"user_id" form:"user_id"`
+	Email  string `json:"email" form:"email"`
+	Result string `json:"result" form:"result"` // success, failed, locked, email_not_verified, mfa_failed, disabled
+}
+type GetLoginLogListResponseData struct {
+	api.PageResponse
+	List []model.LoginLog `json:"list"`
+}
+type GetLoginLogListResponse struct {
+	Response
+	Data GetLoginLogListResponseData
+}
+
+type ListUserSessionsRequest struct {
+	UserId string `json:"user_id" form:"user_id" binding:"required"`
+}
+
+// RevokeUserSessionRequest 管理员强制下线，session_id 为空时下线该用户的全部会话
+type RevokeUserSessionRequest struct {
+	UserId    string `json:"user_id" binding:"required"`
+	SessionId uint   `json:"session_id"`
+}
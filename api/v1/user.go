@@ -1,6 +1,9 @@
 package v1
 
-import "go-nunu/internal/model"
+import (
+	"go-nunu/api"
+	"go-nunu/internal/model"
+)
 
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email" example:"1234@gmail.com"`
@@ -12,13 +15,31 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required" example:"123456"`
 }
 type LoginResponseData struct {
-	AccessToken string `json:"accessToken"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	ExpiresIn    int    `json:"expiresIn,omitempty" example:"900"` // access token 有效期(秒)
+
+	// 开启了二次验证时不返回 token，而是返回 mfaToken，用它调用 /login/mfa 完成登录
+	MfaRequired       bool   `json:"mfaRequired,omitempty"`
+	MfaEnrollRequired bool   `json:"mfaEnrollRequired,omitempty"` // 账号必须先绑定 TOTP，调用 /login/mfa/enroll
+	MfaToken          string `json:"mfaToken,omitempty"`
 }
 type LoginResponse struct {
 	Response
 	Data LoginResponseData
 }
 
+// GuestLoginRequest udid 由客户端生成并持久保存，同一设备重复登录得到同一个访客
+type GuestLoginRequest struct {
+	Udid string `json:"udid" binding:"required,max=255" example:"6F9619FF-8B86-D011-B42D-00C04FC964FF"`
+}
+
+// UpgradeGuestRequest 邮箱未注册时直接绑定到访客账号；已注册时需要该账号的密码，访客的数据合并到该账号
+type UpgradeGuestRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"1234@gmail.com"`
+	Password string `json:"password" binding:"required" example:"123456"`
+}
+
 type UpdateProfileRequest struct {
 	Name  string `json:"name" example:"alan"`
 	Email string `json:"email" example:"1234@gmail.com"`
@@ -29,13 +50,29 @@ type GetProfileResponseData struct {
 	Name   string `json:"name" example:"alan"`
 	Email  string `json:"email" example:"alan"`
 	Image  string `json:"image"`
+	// Avatars 服务端处理后的头像地址，key 为 original 或缩略图边长，如 "64"、"128"、"512"
+	Avatars map[string]string `json:"avatars"`
 }
 type GetProfileResponse struct {
 	Response
 	Data GetProfileResponseData
 }
 
+// GetUserListRequest 字符串条件为不区分大小写的模糊匹配，数值条件为 0 时不过滤
+type GetUserListRequest struct {
+	api.PageRequest
+	Email          string `json:"email" form:"email"`
+	Name           string `json:"name" form:"name"`
+	Status         int    `json:"status" form:"status" binding:"omitempty,oneof=1 2 3"`                                                                                                                         // 1正常 2禁用 3注销
+	UserType       int    `json:"user_type" form:"user_type" binding:"omitempty,oneof=1 2 3"`                                                                                                                   // 1访客用户 2注册用户 3假用户
+	RoleId         uint   `json:"role_id" form:"role_id"`                                                                                                                                                       // 直接分配了该角色的用户
+	RegisteredFrom string `json:"registered_from" form:"registered_from" binding:"omitempty,datetime=2006-01-02" example:"2025-01-01"`                                                                          // 按返回的 created_at 过滤，register_time 在部分注册方式下为 0
+	RegisteredTo   string `json:"registered_to" form:"registered_to" binding:"omitempty,datetime=2006-01-02" example:"2025-12-31"`                                                                              // 按返回的 created_at 过滤，包含当天
+	SortBy         string `json:"sort_by" form:"sort_by" binding:"omitempty,oneof=id email name status user_type created_at last_login_time" enums:"id,email,name,status,user_type,created_at,last_login_time"` // 与返回的字段同名，按注册时间排序用 created_at
+	SortOrder      string `json:"sort_order" form:"sort_order" binding:"omitempty,oneof=asc desc" enums:"asc,desc"`                                                                                             // 默认 desc
+}
 type GetUserListResponseData struct {
+	api.PageResponse
 	List []model.User `json:"list"`
 }
 type GetUserListResponse struct {
@@ -43,10 +80,69 @@ type GetUserListResponse struct {
 	Data GetUserListResponseData
 }
 
+type UnlockUserRequest struct {
+	Email string `json:"email" binding:"required,email" example:"1234@gmail.com"`
+}
+
 type UpdateUserRequest struct {
 	UserId  string `json:"user_id" binding:"required"`
 	Name    string `json:"name" example:"alan"`
 	Email   string `json:"email"`
 	Image   string `json:"image"`
 	RoleIds []uint `json:"role_ids"`
+	DeptId  *uint  `json:"dept_id"` // 不传表示不修改，0 表示移出部门
+}
+
+// CreateUserRequest 管理员创建的用户邮箱视为已验证
+type CreateUserRequest struct {
+	Email    string `json:"email" binding:"required,email" example:"1234@gmail.com"`
+	Password string `json:"password" binding:"required,min=6" example:"123456"`
+	Name     string `json:"name" example:"alan"`
+	RoleIds  []uint `json:"role_ids"`
+	DeptId   uint   `json:"dept_id"`
+}
+type CreateUserResponseData struct {
+	UserId string `json:"user_id"`
+}
+type CreateUserResponse struct {
+	Response
+	Data CreateUserResponseData
+}
+
+// UserIdRequest 禁用、启用、注销、恢复用户
+type UserIdRequest struct {
+	UserId string `json:"user_id" binding:"required"`
+}
+
+type GetDeletedUserListRequest struct {
+	api.PageRequest
+	Email string `json:"email" form:"email"`
+}
+
+// ImportUsersRequest 随文件一起以 multipart 表单提交
+type ImportUsersRequest struct {
+	DryRun    bool `form:"dry_run"`    // 只校验不写入
+	SendEmail bool `form:"send_email"` // 导入后给每个用户发送设置密码的链接
+}
+type ImportUserError struct {
+	Row    int    `json:"row"` // 文件中的行号，表头为第 1 行；0 表示整个文件的问题
+	Email  string `json:"email,omitempty"`
+	Reason string `json:"reason"`
+}
+type ImportUsersData struct {
+	Total      int               `json:"total"`   // 数据行数，不含表头和空行
+	Created    int               `json:"created"` // 创建（dry_run 时为可以创建）的用户数
+	EmailsSent int               `json:"emails_sent"`
+	Errors     []ImportUserError `json:"errors"` // 有任何错误时整个文件都不会导入
+	Applied    bool              `json:"applied"`
+}
+type ImportUsersResponse struct {
+	Response
+	Data ImportUsersData
+}
+
+// ExportUsersRequest 过滤条件与用户列表相同，导出全部符合条件的用户，按 id 排序
+type ExportUsersRequest struct {
+	GetUserListRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"` // 默认 csv
 }
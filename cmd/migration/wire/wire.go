@@ -5,7 +5,9 @@ package wire
 
 import (
 	"go-nunu/internal/repository"
+	"go-nunu/internal/router"
 	"go-nunu/internal/server"
+	"go-nunu/internal/service"
 	"go-nunu/pkg/app"
 	"go-nunu/pkg/log"
 	CasbinPkg "go-nunu/pkg/casbin"
@@ -17,12 +19,18 @@ import (
 
 var repositorySet = wire.NewSet(
 	repository.NewDB,
+	repository.NewPolicyWatcher,
+	repository.NewRedisProvider,
 	//repository.NewRedis,
 	repository.NewRepository,
 	repository.NewUserRepository,
 	repository.NewRoleRepository,
 	repository.NewPermissionRepository,
 )
+var serviceSet = wire.NewSet(
+	service.NewPolicyService,
+	router.NewRouteSource,
+)
 var serverSet = wire.NewSet(
 	server.NewMigrateServer,
 )
@@ -45,6 +53,7 @@ var casbinSet = wire.NewSet(
 func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 	panic(wire.Build(
 		repositorySet,
+		serviceSet,
 		serverSet,
 		casbinSet,
 		newApp,
@@ -10,7 +10,9 @@ import (
 	"github.com/google/wire"
 	"github.com/spf13/viper"
 	"go-nunu/internal/repository"
+	"go-nunu/internal/router"
 	"go-nunu/internal/server"
+	"go-nunu/internal/service"
 	"go-nunu/pkg/app"
 	"go-nunu/pkg/casbin"
 	"go-nunu/pkg/log"
@@ -20,16 +22,28 @@ import (
 
 func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 	db := repository.NewDB(viperViper, logger)
-	cachedEnforcer := casbinPkg.NewEnforcer(db)
-	migrateServer := server.NewMigrateServer(db, logger, cachedEnforcer)
+	redisProvider, cleanup := repository.NewRedisProvider(viperViper)
+	watcher, cleanup2 := repository.NewPolicyWatcher(viperViper, db, redisProvider)
+	syncedCachedEnforcer := casbinPkg.NewEnforcer(db, watcher)
+	repositoryRepository := repository.NewRepository(logger, db, syncedCachedEnforcer)
+	roleRepository := repository.NewRoleRepository(repositoryRepository)
+	userRepository := repository.NewUserRepository(repositoryRepository)
+	permissionRepository := repository.NewPermissionRepository(repositoryRepository)
+	routeSource := router.NewRouteSource(viperViper, logger)
+	policyService := service.NewPolicyService(logger, syncedCachedEnforcer, roleRepository, userRepository, permissionRepository, routeSource)
+	migrateServer := server.NewMigrateServer(db, logger, syncedCachedEnforcer, policyService)
 	appApp := newApp(migrateServer)
 	return appApp, func() {
+		cleanup2()
+		cleanup()
 	}, nil
 }
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewUserRepository, repository.NewRoleRepository, repository.NewPermissionRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewPolicyWatcher, repository.NewRedisProvider, repository.NewRepository, repository.NewUserRepository, repository.NewRoleRepository, repository.NewPermissionRepository)
+
+var serviceSet = wire.NewSet(service.NewPolicyService, router.NewRouteSource)
 
 var serverSet = wire.NewSet(server.NewMigrateServer)
 
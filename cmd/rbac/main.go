@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/cmd/rbac/wire"
+	"go-nunu/internal/service"
+	"go-nunu/pkg/config"
+	"go-nunu/pkg/log"
+	"os"
+	"strings"
+)
+
+// 检查 casbin_rule 是否与角色、权限、用户角色关系表一致；-routes 时改为检查接口权限是否与路由一致
+// 默认只打印差异，存在差异时退出码为 1；-apply 时写入修复
+// -export 把权限和角色导出为 YAML，-import 导入 YAML，同样需要 -apply 才会写入
+func main() {
+	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	var apply = flag.Bool("apply", false, "write the fix instead of only printing the difference")
+	var routes = flag.Bool("routes", false, "sync button permissions with the routes behind AuthMiddleware")
+	var exportFile = flag.String("export", "", "export permissions and roles to a YAML file, - for stdout")
+	var importFile = flag.String("import", "", "import permissions and roles from a YAML file")
+	flag.Parse()
+	conf := config.NewConfig(*envConf)
+
+	logger := log.NewLog(conf)
+
+	services, cleanup, err := wire.NewWire(conf, logger)
+	defer cleanup()
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := context.Background()
+	var inSync bool
+	switch {
+	case *exportFile != "":
+		inSync, err = exportRbac(ctx, services.Rbac, *exportFile)
+	case *importFile != "":
+		inSync, err = importRbac(ctx, services.Rbac, *importFile, *apply)
+	case *routes:
+		inSync, err = syncRoutes(ctx, services.Policy, *apply)
+	default:
+		inSync, err = reconcile(ctx, services.Policy, *apply)
+	}
+	if err != nil {
+		panic(err)
+	}
+	if !inSync {
+		os.Exit(1)
+	}
+}
+
+func reconcile(ctx context.Context, policyService service.PolicyService, apply bool) (bool, error) {
+	var (
+		diff *v1.PolicyDiff
+		err  error
+	)
+	if apply {
+		diff, err = policyService.ReconcilePolicies(ctx)
+	} else {
+		diff, err = policyService.DiffPolicies(ctx)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, rule := range diff.Missing {
+		fmt.Println("+ " + strings.Join(rule, ", "))
+	}
+	for _, rule := range diff.Extra {
+		fmt.Println("- " + strings.Join(rule, ", "))
+	}
+	switch {
+	case len(diff.Missing) == 0 && len(diff.Extra) == 0:
+		fmt.Println("casbin_rule is in sync")
+	case diff.Applied:
+		fmt.Printf("applied: %d added, %d removed\n", len(diff.Missing), len(diff.Extra))
+	default:
+		fmt.Printf("drift: %d missing, %d extra, run with -apply to fix\n", len(diff.Missing), len(diff.Extra))
+		return false, nil
+	}
+	return true, nil
+}
+
+func syncRoutes(ctx context.Context, policyService service.PolicyService, apply bool) (bool, error) {
+	data, err := policyService.SyncApiPermissions(ctx, apply)
+	if err != nil {
+		return false, err
+	}
+
+	for _, route := range data.Created {
+		fmt.Printf("+ %s %s (%s)\n", route.Method, route.Path, route.Handler)
+	}
+	for _, perm := range data.Stale {
+		fmt.Printf("! %s %s (#%d %s)\n", perm.Method, perm.Api, perm.ID, perm.Key)
+	}
+	switch {
+	case len(data.Created) == 0 && len(data.Stale) == 0:
+		fmt.Println("permissions match the routes")
+	case data.Applied:
+		// stale 的权限只标记，删除需要管理员确认；标记后就不算未同步
+		fmt.Printf("applied: %d created, %d marked stale\n", len(data.Created), len(data.Stale))
+	default:
+		fmt.Printf("%d routes without permission, %d stale permissions, run with -apply to fix\n", len(data.Created), len(data.Stale))
+		return false, nil
+	}
+	return true, nil
+}
+
+func exportRbac(ctx context.Context, rbacService service.RbacService, file string) (bool, error) {
+	data, err := rbacService.ExportRbac(ctx)
+	if err != nil {
+		return false, err
+	}
+	if file == "-" {
+		_, err = os.Stdout.Write(data)
+		return err == nil, err
+	}
+	err = os.WriteFile(file, data, 0o644)
+	return err == nil, err
+}
+
+func importRbac(ctx context.Context, rbacService service.RbacService, file string, apply bool) (bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	result, err := rbacService.ImportRbac(ctx, data, apply)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range result.Changes {
+		line := fmt.Sprintf("%s %s %s", c.Action, c.Kind, c.Key)
+		if len(c.Fields) > 0 {
+			line += " (" + strings.Join(c.Fields, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+	switch {
+	case len(result.Changes) == 0:
+		fmt.Println("database matches the document")
+	case result.Applied:
+		fmt.Printf("applied: %d changes\n", len(result.Changes))
+	default:
+		fmt.Printf("%d changes, run with -apply to import\n", len(result.Changes))
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,9 @@
+package wire
+
+import "go-nunu/internal/service"
+
+// Services rbac 命令用到的服务
+type Services struct {
+	Policy service.PolicyService
+	Rbac   service.RbacService
+}
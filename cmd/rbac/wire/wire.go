@@ -0,0 +1,41 @@
+//go:build wireinject
+// +build wireinject
+
+package wire
+
+import (
+	"go-nunu/internal/repository"
+	"go-nunu/internal/router"
+	"go-nunu/internal/service"
+	CasbinPkg "go-nunu/pkg/casbin"
+	"go-nunu/pkg/log"
+
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+)
+
+var repositorySet = wire.NewSet(
+	repository.NewDB,
+	repository.NewPolicyWatcher,
+	repository.NewRedisProvider,
+	repository.NewRepository,
+	repository.NewTransaction,
+	repository.NewUserRepository,
+	repository.NewRoleRepository,
+	repository.NewPermissionRepository,
+)
+
+var serviceSet = wire.NewSet(
+	service.NewPolicyService,
+	service.NewRbacService,
+	router.NewRouteSource,
+)
+
+func NewWire(*viper.Viper, *log.Logger) (*Services, func(), error) {
+	panic(wire.Build(
+		wire.Struct(new(Services), "*"),
+		repositorySet,
+		serviceSet,
+		CasbinPkg.NewEnforcer,
+	))
+}
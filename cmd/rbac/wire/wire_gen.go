@@ -0,0 +1,48 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package wire
+
+import (
+	"github.com/google/wire"
+	"github.com/spf13/viper"
+	"go-nunu/internal/repository"
+	"go-nunu/internal/router"
+	"go-nunu/internal/service"
+	"go-nunu/pkg/casbin"
+	"go-nunu/pkg/log"
+)
+
+// Injectors from wire.go:
+
+func NewWire(viperViper *viper.Viper, logger *log.Logger) (*Services, func(), error) {
+	db := repository.NewDB(viperViper, logger)
+	redisProvider, cleanup := repository.NewRedisProvider(viperViper)
+	watcher, cleanup2 := repository.NewPolicyWatcher(viperViper, db, redisProvider)
+	syncedCachedEnforcer := casbinPkg.NewEnforcer(db, watcher)
+	repositoryRepository := repository.NewRepository(logger, db, syncedCachedEnforcer)
+	roleRepository := repository.NewRoleRepository(repositoryRepository)
+	userRepository := repository.NewUserRepository(repositoryRepository)
+	permissionRepository := repository.NewPermissionRepository(repositoryRepository)
+	routeSource := router.NewRouteSource(viperViper, logger)
+	policyService := service.NewPolicyService(logger, syncedCachedEnforcer, roleRepository, userRepository, permissionRepository, routeSource)
+	transaction := repository.NewTransaction(repositoryRepository)
+	rbacService := service.NewRbacService(transaction, permissionRepository, roleRepository, policyService)
+	services := &Services{
+		Policy: policyService,
+		Rbac:   rbacService,
+	}
+	return services, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+// wire.go:
+
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewPolicyWatcher, repository.NewRedisProvider, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewRoleRepository, repository.NewPermissionRepository)
+
+var serviceSet = wire.NewSet(service.NewPolicyService, service.NewRbacService, router.NewRouteSource)
@@ -6,6 +6,7 @@ package wire
 import (
 	"go-nunu/internal/handler"
 	"go-nunu/internal/job"
+	"go-nunu/internal/middleware"
 	"go-nunu/internal/repository"
 	"go-nunu/internal/router"
 	"go-nunu/internal/server"
@@ -13,9 +14,13 @@ import (
 	"go-nunu/pkg/app"
 	"go-nunu/pkg/aws"
 	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/lockout"
 	"go-nunu/pkg/log"
+	"go-nunu/pkg/mail"
+	"go-nunu/pkg/oauth"
 	"go-nunu/pkg/server/http"
 	"go-nunu/pkg/sid"
+	"go-nunu/pkg/sign"
 	CasbinPkg "go-nunu/pkg/casbin"
 
 	"github.com/google/wire"
@@ -24,6 +29,8 @@ import (
 
 var repositorySet = wire.NewSet(
 	repository.NewDB,
+	repository.NewPolicyWatcher,
+	repository.NewRedisProvider,
 	//repository.NewRedis,
 	//repository.NewMongo,
 	repository.NewRepository,
@@ -31,6 +38,15 @@ var repositorySet = wire.NewSet(
 	repository.NewUserRepository,
 	repository.NewRoleRepository,
 	repository.NewPermissionRepository,
+	repository.NewTokenRepository,
+	repository.NewVerificationTokenRepository,
+	repository.NewMfaRepository,
+	repository.NewIdentityRepository,
+	repository.NewSessionRepository,
+	repository.NewLoginAttemptStore,
+	repository.NewNonceStore,
+	repository.NewApiKeyRepository,
+	wire.Bind(new(jwt.RevocationStore), new(repository.TokenRepository)),
 )
 
 var serviceSet = wire.NewSet(
@@ -39,6 +55,19 @@ var serviceSet = wire.NewSet(
 	service.NewRoleService,
 	service.NewPermissionService,
 	service.NewCommonService,
+	service.NewTokenService,
+	service.NewAccountService,
+	service.NewMfaService,
+	service.NewOAuthService,
+	service.NewSessionService,
+	service.NewApiKeyService,
+	service.NewPolicyService,
+	service.NewRbacService,
+	service.NewUserBulkService,
+	service.NewAvatarService,
+	router.NewRouteTable,
+	wire.Bind(new(service.RouteSource), new(*router.RouteTable)),
+	wire.Bind(new(middleware.ApiKeyVerifier), new(service.ApiKeyService)),
 )
 
 var handlerSet = wire.NewSet(
@@ -47,6 +76,15 @@ var handlerSet = wire.NewSet(
 	handler.NewRoleHandler,
 	handler.NewPermissionHandler,
 	handler.NewCommonHandler,
+	handler.NewTokenHandler,
+	handler.NewAccountHandler,
+	handler.NewMfaHandler,
+	handler.NewOAuthHandler,
+	handler.NewSessionHandler,
+	handler.NewApiKeyHandler,
+	handler.NewPolicyHandler,
+	handler.NewRbacHandler,
+	handler.NewUserBulkHandler,
 )
 
 var jobSet = wire.NewSet(
@@ -73,6 +111,7 @@ func newApp(
 // 声明 R2 构造函数
 var awsSet = wire.NewSet(
 	aws.NewR2Client, // Wire 会自动处理 *viper.Viper 和 *log.Logger 的注入
+	wire.Bind(new(service.ObjectStorage), new(*aws.CloudflareR2)),
 )
 
 // 声明 R2 构造函数
@@ -90,6 +129,10 @@ func NewWire(cfg *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 		wire.Struct(new(router.RouterDeps), "*"),
 		sid.NewSid,
 		jwt.NewJwt,
+		mail.NewSender,
+		oauth.NewRegistry,
+		lockout.NewGuard,
+		sign.NewVerifier,
 		awsSet,
 		casbinSet,
 		newApp,
@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/viper"
 	"go-nunu/internal/handler"
 	"go-nunu/internal/job"
+	"go-nunu/internal/middleware"
 	"go-nunu/internal/repository"
 	"go-nunu/internal/router"
 	"go-nunu/internal/server"
@@ -19,46 +20,98 @@ import (
 	"go-nunu/pkg/aws"
 	"go-nunu/pkg/casbin"
 	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/lockout"
 	"go-nunu/pkg/log"
+	"go-nunu/pkg/mail"
+	"go-nunu/pkg/oauth"
 	"go-nunu/pkg/server/http"
 	"go-nunu/pkg/sid"
+	"go-nunu/pkg/sign"
 )
 
 // Injectors from wire.go:
 
 func NewWire(cfg *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
-	jwtJWT := jwt.NewJwt(cfg)
 	db := repository.NewDB(cfg, logger)
-	cachedEnforcer := casbinPkg.NewEnforcer(db)
+	redisProvider, cleanup := repository.NewRedisProvider(cfg)
+	watcher, cleanup2 := repository.NewPolicyWatcher(cfg, db, redisProvider)
+	syncedCachedEnforcer := casbinPkg.NewEnforcer(db, watcher)
+	repositoryRepository := repository.NewRepository(logger, db, syncedCachedEnforcer)
+	tokenRepository := repository.NewTokenRepository(repositoryRepository)
+	jwtJWT := jwt.NewJwt(cfg, tokenRepository)
 	handlerHandler := handler.NewHandler(logger)
-	repositoryRepository := repository.NewRepository(logger, db, cachedEnforcer)
 	transaction := repository.NewTransaction(repositoryRepository)
 	sidSid := sid.NewSid()
-	serviceService := service.NewService(db, transaction, logger, sidSid, jwtJWT)
+	serviceService := service.NewService(db, transaction, logger, sidSid, jwtJWT, syncedCachedEnforcer)
 	userRepository := repository.NewUserRepository(repositoryRepository)
-	userService := service.NewUserService(serviceService, userRepository)
-	userHandler := handler.NewUserHandler(handlerHandler, userService)
-	cloudflareR2, cleanup, err := aws.NewR2Client(cfg)
+	roleRepository := repository.NewRoleRepository(repositoryRepository)
+	sessionRepository := repository.NewSessionRepository(repositoryRepository)
+	tokenService := service.NewTokenService(serviceService, cfg, tokenRepository, userRepository, sessionRepository)
+	verificationTokenRepository := repository.NewVerificationTokenRepository(repositoryRepository)
+	sender := mail.NewSender(cfg, logger)
+	accountService := service.NewAccountService(serviceService, cfg, userRepository, verificationTokenRepository, tokenService, sender)
+	mfaRepository := repository.NewMfaRepository(repositoryRepository)
+	sessionService := service.NewSessionService(serviceService, sessionRepository, tokenService)
+	store := repository.NewLoginAttemptStore(cfg, redisProvider)
+	guard := lockout.NewGuard(cfg, store)
+	mfaService := service.NewMfaService(serviceService, cfg, userRepository, mfaRepository, tokenRepository, tokenService, sessionService, guard)
+	cloudflareR2, cleanup3, err := aws.NewR2Client(cfg)
 	if err != nil {
+		cleanup2()
+		cleanup()
 		return nil, nil, err
 	}
-	commonService := service.NewCommonService(cloudflareR2)
+	avatarService := service.NewAvatarService(serviceService, cfg, cloudflareR2)
+	userService := service.NewUserService(serviceService, cfg, userRepository, roleRepository, tokenService, accountService, mfaService, sessionService, avatarService, guard)
+	userHandler := handler.NewUserHandler(handlerHandler, userService)
+	userBulkService := service.NewUserBulkService(serviceService, userRepository, roleRepository, accountService)
+	userBulkHandler := handler.NewUserBulkHandler(handlerHandler, userBulkService)
+	commonService := service.NewCommonService(cloudflareR2, cfg)
 	commonHandler := handler.NewCommonHandler(handlerHandler, commonService, cloudflareR2)
-	roleRepository := repository.NewRoleRepository(repositoryRepository)
-	roleService := service.NewRoleService(serviceService, roleRepository, cachedEnforcer)
+	roleService := service.NewRoleService(serviceService, roleRepository, syncedCachedEnforcer)
 	roleHandler := handler.NewRoleHandler(handlerHandler, roleService)
 	permissionRepository := repository.NewPermissionRepository(repositoryRepository)
-	permissionService := service.NewPermissionService(serviceService, permissionRepository)
+	permissionService := service.NewPermissionService(serviceService, permissionRepository, roleRepository, userRepository)
 	permissionHandler := handler.NewPermissionHandler(handlerHandler, permissionService)
+	routeTable := router.NewRouteTable()
+	policyService := service.NewPolicyService(logger, syncedCachedEnforcer, roleRepository, userRepository, permissionRepository, routeTable)
+	policyHandler := handler.NewPolicyHandler(handlerHandler, policyService)
+	rbacService := service.NewRbacService(transaction, permissionRepository, roleRepository, policyService)
+	rbacHandler := handler.NewRbacHandler(handlerHandler, rbacService)
+	tokenHandler := handler.NewTokenHandler(handlerHandler, tokenService)
+	accountHandler := handler.NewAccountHandler(handlerHandler, accountService)
+	mfaHandler := handler.NewMfaHandler(handlerHandler, mfaService)
+	registry := oauth.NewRegistry(cfg)
+	identityRepository := repository.NewIdentityRepository(repositoryRepository)
+	oAuthService := service.NewOAuthService(serviceService, cfg, registry, userRepository, identityRepository, tokenService, mfaService, sessionService)
+	oAuthHandler := handler.NewOAuthHandler(handlerHandler, oAuthService)
+	sessionHandler := handler.NewSessionHandler(handlerHandler, sessionService)
+	apiKeyRepository := repository.NewApiKeyRepository(repositoryRepository)
+	apiKeyService := service.NewApiKeyService(serviceService, apiKeyRepository, userRepository)
+	apiKeyHandler := handler.NewApiKeyHandler(handlerHandler, apiKeyService)
+	nonceStore := repository.NewNonceStore(cfg, redisProvider)
+	verifier := sign.NewVerifier(cfg, nonceStore)
 	routerDeps := router.RouterDeps{
 		Logger:            logger,
 		Config:            cfg,
 		JWT:               jwtJWT,
-		Casbin:            cachedEnforcer,
+		Casbin:            syncedCachedEnforcer,
 		UserHandler:       userHandler,
+		UserBulkHandler:   userBulkHandler,
 		CommonHandler:     commonHandler,
 		RoleHandler:       roleHandler,
 		PermissionHandler: permissionHandler,
+		PolicyHandler:     policyHandler,
+		RbacHandler:       rbacHandler,
+		TokenHandler:      tokenHandler,
+		AccountHandler:    accountHandler,
+		MfaHandler:        mfaHandler,
+		OAuthHandler:      oAuthHandler,
+		SessionHandler:    sessionHandler,
+		ApiKeyHandler:     apiKeyHandler,
+		ApiKeyVerifier:    apiKeyService,
+		Signer:            verifier,
+		Routes:            routeTable,
 	}
 	httpServer := server.NewHTTPServer(routerDeps)
 	jobJob := job.NewJob(transaction, logger, sidSid)
@@ -66,17 +119,19 @@ func NewWire(cfg *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 	jobServer := server.NewJobServer(logger, userJob)
 	appApp := newApp(httpServer, jobServer)
 	return appApp, func() {
+		cleanup3()
+		cleanup2()
 		cleanup()
 	}, nil
 }
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewRoleRepository, repository.NewPermissionRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewPolicyWatcher, repository.NewRedisProvider, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewRoleRepository, repository.NewPermissionRepository, repository.NewTokenRepository, repository.NewVerificationTokenRepository, repository.NewMfaRepository, repository.NewIdentityRepository, repository.NewSessionRepository, repository.NewLoginAttemptStore, repository.NewNonceStore, repository.NewApiKeyRepository, wire.Bind(new(jwt.RevocationStore), new(repository.TokenRepository)))
 
-var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewRoleService, service.NewPermissionService, service.NewCommonService)
+var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewRoleService, service.NewPermissionService, service.NewCommonService, service.NewTokenService, service.NewAccountService, service.NewMfaService, service.NewOAuthService, service.NewSessionService, service.NewApiKeyService, service.NewPolicyService, service.NewRbacService, service.NewUserBulkService, service.NewAvatarService, router.NewRouteTable, wire.Bind(new(service.RouteSource), new(*router.RouteTable)), wire.Bind(new(middleware.ApiKeyVerifier), new(service.ApiKeyService)))
 
-var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewRoleHandler, handler.NewPermissionHandler, handler.NewCommonHandler)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewRoleHandler, handler.NewPermissionHandler, handler.NewCommonHandler, handler.NewTokenHandler, handler.NewAccountHandler, handler.NewMfaHandler, handler.NewOAuthHandler, handler.NewSessionHandler, handler.NewApiKeyHandler, handler.NewPolicyHandler, handler.NewRbacHandler, handler.NewUserBulkHandler)
 
 var jobSet = wire.NewSet(job.NewJob, job.NewUserJob)
 
@@ -92,7 +147,7 @@ func newApp(
 }
 
 // 声明 R2 构造函数
-var awsSet = wire.NewSet(aws.NewR2Client)
+var awsSet = wire.NewSet(aws.NewR2Client, wire.Bind(new(service.ObjectStorage), new(*aws.CloudflareR2)))
 
 // 声明 R2 构造函数
 var casbinSet = wire.NewSet(casbinPkg.NewEnforcer)
@@ -5,9 +5,12 @@ package wire
 
 import (
 	"go-nunu/internal/repository"
+	"go-nunu/internal/router"
 	"go-nunu/internal/server"
+	"go-nunu/internal/service"
 	"go-nunu/internal/task"
 	"go-nunu/pkg/app"
+	CasbinPkg "go-nunu/pkg/casbin"
 	"go-nunu/pkg/log"
 	"go-nunu/pkg/sid"
 
@@ -17,15 +20,25 @@ import (
 
 var repositorySet = wire.NewSet(
 	repository.NewDB,
+	repository.NewPolicyWatcher,
+	repository.NewRedisProvider,
 	//repository.NewRedis,
 	repository.NewRepository,
 	repository.NewTransaction,
 	repository.NewUserRepository,
+	repository.NewRoleRepository,
+	repository.NewPermissionRepository,
+)
+
+var serviceSet = wire.NewSet(
+	service.NewPolicyService,
+	router.NewRouteSource,
 )
 
 var taskSet = wire.NewSet(
 	task.NewTask,
 	task.NewUserTask,
+	task.NewPolicyTask,
 )
 var serverSet = wire.NewSet(
 	server.NewTaskServer,
@@ -44,9 +57,11 @@ func newApp(
 func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 	panic(wire.Build(
 		repositorySet,
+		serviceSet,
 		taskSet,
 		serverSet,
 		newApp,
 		sid.NewSid,
+		CasbinPkg.NewEnforcer,
 	))
 }
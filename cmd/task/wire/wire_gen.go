@@ -7,38 +7,52 @@
 package wire
 
 import (
+	"github.com/google/wire"
+	"github.com/spf13/viper"
 	"go-nunu/internal/repository"
+	"go-nunu/internal/router"
 	"go-nunu/internal/server"
+	"go-nunu/internal/service"
 	"go-nunu/internal/task"
 	"go-nunu/pkg/app"
+	"go-nunu/pkg/casbin"
 	"go-nunu/pkg/log"
 	"go-nunu/pkg/sid"
-
-	"github.com/google/wire"
-	"github.com/spf13/viper"
 )
 
 // Injectors from wire.go:
 
 func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), error) {
 	db := repository.NewDB(viperViper, logger)
-	repositoryRepository := repository.NewRepository(logger, db)
+	redisProvider, cleanup := repository.NewRedisProvider(viperViper)
+	watcher, cleanup2 := repository.NewPolicyWatcher(viperViper, db, redisProvider)
+	syncedCachedEnforcer := casbinPkg.NewEnforcer(db, watcher)
+	repositoryRepository := repository.NewRepository(logger, db, syncedCachedEnforcer)
 	transaction := repository.NewTransaction(repositoryRepository)
 	sidSid := sid.NewSid()
 	taskTask := task.NewTask(transaction, logger, sidSid)
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userTask := task.NewUserTask(taskTask, userRepository)
-	taskServer := server.NewTaskServer(logger, userTask)
+	roleRepository := repository.NewRoleRepository(repositoryRepository)
+	permissionRepository := repository.NewPermissionRepository(repositoryRepository)
+	routeSource := router.NewRouteSource(viperViper, logger)
+	policyService := service.NewPolicyService(logger, syncedCachedEnforcer, roleRepository, userRepository, permissionRepository, routeSource)
+	policyTask := task.NewPolicyTask(taskTask, policyService)
+	taskServer := server.NewTaskServer(logger, viperViper, userTask, policyTask)
 	appApp := newApp(taskServer)
 	return appApp, func() {
+		cleanup2()
+		cleanup()
 	}, nil
 }
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewPolicyWatcher, repository.NewRedisProvider, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewRoleRepository, repository.NewPermissionRepository)
+
+var serviceSet = wire.NewSet(service.NewPolicyService, router.NewRouteSource)
 
-var taskSet = wire.NewSet(task.NewTask, task.NewUserTask)
+var taskSet = wire.NewSet(task.NewTask, task.NewUserTask, task.NewPolicyTask)
 
 var serverSet = wire.NewSet(server.NewTaskServer)
 
@@ -24,7 +24,107 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/login": {
+        "/api-keys": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API key"
+                ],
+                "summary": "我的 API key",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ListApiKeysResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "完整的 key 只在创建时返回一次，调用时使用 \"Authorization: ApiKey \u003ckey\u003e\"。实际权限为 scopes 与用户自身权限的交集",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API key"
+                ],
+                "summary": "创建 API key",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateApiKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateApiKeyResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/api-keys/{id}": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API key"
+                ],
+                "summary": "删除 API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "api key id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/app/login": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -57,9 +157,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
+        "/app/login/mfa": {
             "post": {
-                "description": "目前只支持邮箱登录",
+                "description": "使用登录返回的 mfa_token 加验证码或恢复码换取正式 token",
                 "consumes": [
                     "application/json"
                 ],
@@ -67,9 +167,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "用户模块"
+                    "二次验证"
                 ],
-                "summary": "用户注册",
+                "summary": "登录二次验证",
                 "parameters": [
                     {
                         "description": "params",
@@ -77,7 +177,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.RegisterRequest"
+                            "$ref": "#/definitions/v1.LoginMfaRequest"
                         }
                     }
                 ],
@@ -85,19 +185,53 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.Response"
+                            "$ref": "#/definitions/v1.LoginResponse"
                         }
                     }
                 }
             }
         },
-        "/role/list": {
-            "get": {
+        "/app/logout": {
+            "post": {
+                "description": "吊销当前 access token 及其刷新令牌",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "退出登录",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
                 "security": [
                     {
                         "Bearer": []
                     }
-                ],
+                ]
+            }
+        },
+        "/app/register": {
+            "post": {
+                "description": "目前只支持邮箱登录",
                 "consumes": [
                     "application/json"
                 ],
@@ -105,26 +239,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role模块"
+                    "用户模块"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RegisterRequest"
+                        }
+                    }
                 ],
-                "summary": "获取用户信息",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.GetRoleListRequest"
+                            "$ref": "#/definitions/v1.Response"
                         }
                     }
                 }
             }
         },
-        "/user": {
-            "get": {
-                "security": [
-                    {
-                        "Bearer": []
-                    }
-                ],
+        "/app/token/refresh": {
+            "post": {
+                "description": "刷新令牌只能使用一次，重复使用会吊销整条令牌链",
                 "consumes": [
                     "application/json"
                 ],
@@ -134,22 +275,63 @@ const docTemplate = `{
                 "tags": [
                     "用户模块"
                 ],
-                "summary": "获取用户信息",
+                "summary": "刷新 access token",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RefreshTokenRequest"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.GetProfileResponse"
+                            "$ref": "#/definitions/v1.LoginResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
+            }
+        },
+        "/email/verify": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "验证邮箱",
+                "parameters": [
                     {
-                        "Bearer": []
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.VerifyEmailRequest"
+                        }
                     }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/email/verify/resend": {
+            "post": {
                 "consumes": [
                     "application/json"
                 ],
@@ -159,7 +341,7 @@ const docTemplate = `{
                 "tags": [
                     "用户模块"
                 ],
-                "summary": "修改用户信息",
+                "summary": "重新发送验证邮件",
                 "parameters": [
                     {
                         "description": "params",
@@ -167,7 +349,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.UpdateProfileRequest"
+                            "$ref": "#/definitions/v1.ResendVerifyEmailRequest"
                         }
                     }
                 ],
@@ -180,94 +362,3567 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "v1.GetProfileResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
-                "data": {
-                    "$ref": "#/definitions/v1.GetProfileResponseData"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
         },
-        "v1.GetProfileResponseData": {
-            "type": "object",
-            "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "alan"
-                },
-                "image": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string",
-                    "example": "alan"
-                },
-                "userId": {
-                    "type": "string"
+        "/guest/login": {
+            "post": {
+                "description": "按设备 udid 登录，首次登录自动创建访客。返回的 token 只能访问访客接口，需要请求签名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "访客"
+                ],
+                "summary": "访客登录",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GuestLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
                 }
             }
         },
-        "v1.GetRoleListRequest": {
-            "type": "object",
-            "properties": {
+        "/guest/upgrade": {
+            "post": {
+                "description": "邮箱未注册时绑定到当前访客，UserId 不变；已注册时校验该账号的密码，把访客合并到该账号。成功后返回正式 token，访客 token 失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "访客"
+                ],
+                "summary": "访客升级为注册用户",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpgradeGuestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/guest/upgrade/{provider}": {
+            "post": {
+                "description": "第三方账号未注册时绑定到当前访客，UserId 不变；已注册（或已验证的邮箱匹配）时把访客合并到该账号",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "访客"
+                ],
+                "summary": "访客通过第三方账号升级",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OAuthCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/guest/upgrade/{provider}/authorize": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "访客"
+                ],
+                "summary": "获取访客升级的第三方授权地址",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.OAuthAuthorizeResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/identities": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "已绑定的第三方账号",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ListIdentitiesResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/identities/{id}": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "解绑第三方账号",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "identity id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/identities/{provider}/authorize": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "获取绑定第三方账号的授权地址",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.OAuthAuthorizeResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/identities/{provider}/link": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "绑定第三方账号",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OAuthCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/login": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "账号登录",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa": {
+            "post": {
+                "description": "使用登录返回的 mfa_token 加验证码或恢复码换取正式 token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "登录二次验证",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginMfaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa/activate": {
+            "post": {
+                "description": "完成绑定后直接签发正式 token，同时返回一次性恢复码",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "登录时激活 TOTP",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginMfaActivateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginMfaActivateResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa/enroll": {
+            "post": {
+                "description": "强制开启二次验证但尚未绑定的账号，使用 mfa_token 获取 TOTP 密钥",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "登录时绑定 TOTP",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginMfaEnrollRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.EnrollTotpResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "description": "吊销当前 access token 及其刷新令牌",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "退出登录",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/menus/mine": {
+            "get": {
+                "description": "当前用户可见的目录和菜单树，buttons 为该菜单下拥有的按钮 Key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "我的菜单",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/mfa": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "获取二次验证状态",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetMfaStatusResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/mfa/recovery-codes": {
+            "post": {
+                "description": "旧的恢复码全部作废，需要提交 TOTP 验证码",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "重新生成恢复码",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.MfaCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.RecoveryCodesResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/mfa/totp/activate": {
+            "post": {
+                "description": "提交验证器中的验证码完成绑定，返回一次性恢复码",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "激活 TOTP",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.MfaCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.RecoveryCodesResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/mfa/totp/disable": {
+            "post": {
+                "description": "需要提交验证码或恢复码；强制开启二次验证的账号不能关闭",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "关闭 TOTP",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.MfaCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/mfa/totp/enroll": {
+            "post": {
+                "description": "生成新的 TOTP 密钥，需调用激活接口提交验证码后才生效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "二次验证"
+                ],
+                "summary": "绑定 TOTP",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.EnrollTotpResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/oauth/providers": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "第三方登录方式列表",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ListOAuthProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/{provider}/authorize": {
+            "get": {
+                "description": "授权码模式 + PKCE，前端跳转到返回的地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "获取第三方登录授权地址",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.OAuthAuthorizeResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/{provider}/callback": {
+            "post": {
+                "description": "首次登录时按已验证的邮箱绑定已有账号，找不到则自动注册",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "第三方登录"
+                ],
+                "summary": "第三方登录回调",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OAuthCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "发送重置密码邮件，邮箱不存在时同样返回成功",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "忘记密码",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "使用邮件中的一次性令牌设置新密码，成功后已登录的会话全部失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "重置密码",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/permission": {
+            "put": {
+                "description": "拥有该权限的角色的 Casbin 策略随之更新",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "修改权限",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdatePermissionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/permission/tree": {
+            "get": {
+                "description": "全部目录、菜单和按钮按 parent_id 组成的树，同级按 sort 排序",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "权限树",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/permission/{id}": {
+            "delete": {
+                "description": "同时从所有角色中移除；仍有子权限时不能删除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "删除权限",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "permission id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/policy/check": {
+            "post": {
+                "description": "按 user_id 或角色 Sid 判断能否访问接口，返回命中的规则、角色继承链和生效的数据权限",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "模拟权限检查",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CheckPermissionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CheckPermissionResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/policy/drift": {
+            "get": {
+                "description": "比较角色、权限、用户角色关系表推导出的规则与 casbin_rule 表，只返回差异",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "检查 Casbin 策略漂移",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.PolicyDiffResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/policy/reconcile": {
+            "post": {
+                "description": "以关系表为准补齐缺失的规则、删除多余的规则，返回修复前的差异",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "修复 Casbin 策略漂移",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.PolicyDiffResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/policy/sync-routes": {
+            "post": {
+                "description": "为挂载了 RBAC 鉴权但没有按钮权限的路由新增权限，Api 已没有对应路由的权限标记为 stale",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "同步接口权限",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SyncApiPermissionsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.SyncApiPermissionsResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/policy/who-can": {
+            "get": {
+                "description": "列出能访问该接口的角色（含继承）和拥有这些角色的用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "反查接口权限",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "POST",
+                        "name": "method",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "/v1/user/list",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.WhoCanResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/profile": {
+            "get": {
+                "description": "avatars 为处理后各尺寸头像的地址",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "获取用户信息",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetProfileResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "image 为上传接口返回的地址，服务端校验后去掉 EXIF 并生成缩略图；原样提交当前地址表示不修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "修改用户信息",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/rbac/export": {
+            "get": {
+                "description": "以 YAML 导出权限树、角色、角色权限和数据权限，权限按 Key、角色按 Sid 关联",
+                "produces": [
+                    "application/x-yaml"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "导出 RBAC 配置",
+                "responses": {
+                    "200": {
+                        "description": "YAML 文档",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/rbac/import": {
+            "post": {
+                "description": "按 Key、Sid 新增或更新权限和角色，重复导入同一文档不产生变更；dry_run=true 时只返回变更",
+                "consumes": [
+                    "application/x-yaml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Permission模块"
+                ],
+                "summary": "导入 RBAC 配置",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "只比较不写入",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "导出的 YAML 文档",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ImportRbacResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "目前只支持邮箱登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/role/data-scope": {
+            "put": {
+                "description": "控制用户列表、角色列表等接口能看到的数据范围: 1全部 2本部门 3仅本人。用户有多个角色时取范围最大的一个",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role模块"
+                ],
+                "summary": "设置角色数据权限",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateRoleDataScopeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/role/info": {
+            "put": {
+                "description": "修改名称或标识（key），标识变化时 Casbin 中的规则同步改名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role模块"
+                ],
+                "summary": "修改角色",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/role/list": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role模块"
+                ],
+                "summary": "获取用户信息",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetRoleListRequest"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/role/parents": {
+            "put": {
+                "description": "角色拥有父角色的全部权限，可以多级继承，不允许形成环",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role模块"
+                ],
+                "summary": "设置角色继承",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateRoleParentsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/role/{id}": {
+            "delete": {
+                "description": "仍分配给用户或被其他角色继承时返回 1014",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role模块"
+                ],
+                "summary": "删除角色",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/role/{id}/permissions": {
+            "get": {
+                "description": "包含直接分配和从祖先角色继承的权限，source 标明来源",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role模块"
+                ],
+                "summary": "角色的有效权限",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "role id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/sessions": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "我的登录会话",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ListSessionsResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/sessions/login-logs": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "我的登录记录",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "page",
+                        "name": "current_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetLoginLogListResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/sessions/{id}": {
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "下线我的会话",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "session id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "刷新令牌只能使用一次，重复使用会吊销整条令牌链",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "刷新 access token",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.LoginResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "post": {
+                "description": "管理员创建用户并分配初始角色，邮箱视为已验证",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "创建用户",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateUserResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/deactivate": {
+            "post": {
+                "description": "软删除用户并吊销全部 token，可以在已删除列表中恢复",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "注销用户",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UserIdRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/deleted": {
+            "get": {
+                "description": "分页查询已注销（软删除）的用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "已删除用户列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "current_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/disable": {
+            "post": {
+                "description": "禁用后立即不能登录和访问接口，已有会话全部吊销",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "禁用用户",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UserIdRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/enable": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "启用用户",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UserIdRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/export": {
+            "get": {
+                "description": "过滤条件与用户列表相同，导出全部符合条件的用户",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "导出用户",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "current_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "description": "默认 csv",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-01-01",
+                        "description": "按返回的 created_at 过滤，register_time 在部分注册方式下为 0",
+                        "name": "registered_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2025-12-31",
+                        "description": "按返回的 created_at 过滤，包含当天",
+                        "name": "registered_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "直接分配了该角色的用户",
+                        "name": "role_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "email",
+                            "name",
+                            "status",
+                            "user_type",
+                            "created_at",
+                            "last_login_time"
+                        ],
+                        "type": "string",
+                        "description": "与返回的字段同名，按注册时间排序用 created_at",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "默认 desc",
+                        "name": "sort_order",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            1,
+                            2,
+                            3
+                        ],
+                        "type": "integer",
+                        "description": "1正常 2禁用 3注销",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            1,
+                            2,
+                            3
+                        ],
+                        "type": "integer",
+                        "description": "1访客用户 2注册用户 3假用户",
+                        "name": "user_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/import": {
+            "post": {
+                "description": "上传 CSV 或 XLSX，表头包含 email，可选 name、roles（角色 Sid，逗号分隔）。先校验全部行，有错误时返回逐行的错误且不导入任何用户",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "批量导入用户",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV 或 XLSX 文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "只校验不写入",
+                        "name": "dry_run",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "发送设置密码的链接",
+                        "name": "send_email",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ImportUsersResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/list": {
+            "post": {
+                "description": "分页查询用户，支持按邮箱、姓名、状态、用户类型、角色、注册日期过滤和排序，返回总数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "获取用户列表",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetUserListRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetUserListResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/login-logs": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "登录日志",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "current_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "success, failed, locked, email_not_verified, mfa_failed, disabled",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetLoginLogListResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/restore": {
+            "post": {
+                "description": "恢复已注销的用户及其角色，需要重新登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "恢复用户",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UserIdRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/sessions": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "查看用户的登录会话",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ListSessionsResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/sessions/revoke": {
+            "post": {
+                "description": "不传 session_id 时下线该用户的全部会话",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "会话管理"
+                ],
+                "summary": "强制用户下线",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.RevokeUserSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/unlock": {
+            "post": {
+                "description": "清除账号的登录失败次数和锁定状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "解锁账号",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UnlockUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        },
+        "/user/{id}": {
+            "delete": {
+                "description": "只能删除已注销的用户，同时删除其会话、第三方账号、二次验证和 API key，登录日志保留",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "用户模块"
+                ],
+                "summary": "永久删除用户",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user_id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Response"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
+        "model.LoginLog": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID 统一为小写的 id",
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "login_type": {
+                    "description": "同 User.LastLoginType",
+                    "type": "integer"
+                },
+                "result": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "邮箱不存在时为空",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID 统一为小写的 id",
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_active_at": {
+                    "type": "string"
+                },
+                "login_type": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.User": {
+            "type": "object",
+            "properties": {
+                "country_id": {
+                    "description": "国家ID",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "创建人 UserId，自助注册为空",
+                    "type": "string"
+                },
+                "deactivate_time": {
+                    "description": "注销时间",
+                    "type": "integer"
+                },
+                "dept_id": {
+                    "description": "部门ID 0未分配，用于本部门数据权限",
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_verify_time": {
+                    "description": "邮箱验证时间 0未验证",
+                    "type": "integer"
+                },
+                "gender": {
+                    "description": "性别 1.男 2.女",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "ID 统一为小写的 id",
+                    "type": "integer"
+                },
+                "image": {
+                    "description": "image",
+                    "type": "string"
+                },
+                "is_submit_profile": {
+                    "description": "是否已经上传过个人资料 1.是 2.否",
+                    "type": "integer"
+                },
+                "last_login_ip": {
+                    "description": "最后登录IP",
+                    "type": "string"
+                },
+                "last_login_time": {
+                    "description": "最后登录时间",
+                    "type": "integer"
+                },
+                "last_login_type": {
+                    "description": "最后登录类型 0游客 1微信服务号 2微信小程序 3第三方登录 4邮箱",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "ID              int                   ` + "`" + `gorm:\"column:id;type:int;primaryKey;autoIncrement:true\" json:\"id\"` + "`" + `                 // 主键ID",
+                    "type": "string"
+                },
+                "register_ip": {
+                    "description": "注册IP",
+                    "type": "string"
+                },
+                "register_time": {
+                    "description": "注册时间（Unix 秒），邮箱注册和初始化数据为 0，列表按 created_at 过滤和排序",
+                    "type": "integer"
+                },
+                "register_type": {
+                    "description": "注册类型 0游客 1微信服务号 2微信小程序 3第三方登录 4邮箱",
+                    "type": "integer"
+                },
+                "roles": {
+                    "description": "role",
+                    "type": "array",
+                    "items": {
+                        "type": "object"
+                    }
+                },
+                "status": {
+                    "description": "状态 0未知状态 1正常 2禁用 3注销",
+                    "type": "integer"
+                },
+                "udid": {
+                    "description": "设备唯一标识",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "user_type": {
+                    "description": "用户类型 1访客用户 2注册用户 3假用户",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.UserIdentity": {
+            "type": "object",
+            "properties": {
+                "avatar": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID 统一为小写的 id",
+                    "type": "integer"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "subject": {
+                    "description": "provider 内的用户唯一标识",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ApiKeyData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.ApiRoute": {
+            "type": "object",
+            "properties": {
+                "handler": {
+                    "description": "\"DeleteRole\"",
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "description": "\"/v1/role/:id\"",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CheckPermissionData": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "admin_bypass": {
+                    "description": "超管跳过 API 权限检查",
+                    "type": "boolean"
+                },
+                "allowed": {
+                    "type": "boolean"
+                },
+                "data_scope": {
+                    "description": "1全部 2本部门 3仅本人",
+                    "type": "integer"
+                },
+                "dept_id": {
+                    "type": "integer"
+                },
+                "match": {
+                    "description": "未命中时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.PolicyMatch"
+                        }
+                    ]
+                },
+                "object": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CheckPermissionRequest": {
+            "type": "object",
+            "required": [
+                "method",
+                "path"
+            ],
+            "properties": {
+                "method": {
+                    "type": "string",
+                    "example": "POST"
+                },
+                "path": {
+                    "description": "实际请求路径",
+                    "type": "string",
+                    "example": "/v1/user/list"
+                },
+                "role": {
+                    "description": "角色 Sid",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "与 role 二选一",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CheckPermissionResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.CheckPermissionData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CreateApiKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "0 表示不过期",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 90
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "ci"
+                },
+                "scopes": {
+                    "description": "\"METHOD /path\"，METHOD 可以是 *，path 支持 /* 和 :id 通配",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "GET /v1/user/list"
+                    ]
+                }
+            }
+        },
+        "v1.CreateApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.CreateApiKeyResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CreateApiKeyResponseData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "完整的 key 只在创建时返回一次",
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "dept_id": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "1234@gmail.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "alan"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "123456"
+                },
+                "role_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "v1.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.CreateUserResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.CreateUserResponseData": {
+            "type": "object",
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.EnrollTotpResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.EnrollTotpResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.EnrollTotpResponseData": {
+            "type": "object",
+            "properties": {
+                "otpauth_uri": {
+                    "description": "前端据此生成二维码",
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "1234@gmail.com"
+                }
+            }
+        },
+        "v1.GetLoginLogListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.GetLoginLogListResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.GetLoginLogListResponseData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.LoginLog"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.GetMfaStatusResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.GetMfaStatusResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.GetMfaStatusResponseData": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "description": "剩余可用恢复码数量",
+                    "type": "integer"
+                },
+                "required": {
+                    "type": "boolean"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.GetProfileResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.GetProfileResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.GetProfileResponseData": {
+            "type": "object",
+            "properties": {
+                "avatars": {
+                    "description": "Avatars 服务端处理后的头像地址，key 为 original 或缩略图边长，如 \"64\"、\"128\"、\"512\"",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "email": {
+                    "type": "string",
+                    "example": "alan"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "alan"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.GetRoleListRequest": {
+            "type": "object",
+            "properties": {
+                "current_page": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "page_size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.GetUserListRequest": {
+            "type": "object",
+            "properties": {
                 "current_page": {
                     "type": "integer"
                 },
-                "id": {
-                    "type": "integer"
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "registered_from": {
+                    "description": "按返回的 created_at 过滤，register_time 在部分注册方式下为 0",
+                    "type": "string",
+                    "example": "2025-01-01"
+                },
+                "registered_to": {
+                    "description": "按返回的 created_at 过滤，包含当天",
+                    "type": "string",
+                    "example": "2025-12-31"
+                },
+                "role_id": {
+                    "description": "直接分配了该角色的用户",
+                    "type": "integer"
+                },
+                "sort_by": {
+                    "description": "与返回的字段同名，按注册时间排序用 created_at",
+                    "type": "string",
+                    "enum": [
+                        "id",
+                        "email",
+                        "name",
+                        "status",
+                        "user_type",
+                        "created_at",
+                        "last_login_time"
+                    ]
+                },
+                "sort_order": {
+                    "description": "默认 desc",
+                    "type": "string",
+                    "enum": [
+                        "asc",
+                        "desc"
+                    ]
+                },
+                "status": {
+                    "description": "1正常 2禁用 3注销",
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "user_type": {
+                    "description": "1访客用户 2注册用户 3假用户",
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
+        "v1.GetUserListResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.GetUserListResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.GetUserListResponseData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.User"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.GuestLoginRequest": {
+            "type": "object",
+            "required": [
+                "udid"
+            ],
+            "properties": {
+                "udid": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "6F9619FF-8B86-D011-B42D-00C04FC964FF"
+                }
+            }
+        },
+        "v1.ImportRbacData": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RbacChange"
+                    }
+                }
+            }
+        },
+        "v1.ImportRbacResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.ImportRbacData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ImportUserError": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "row": {
+                    "description": "文件中的行号，表头为第 1 行；0 表示整个文件的问题",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ImportUsersData": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "created": {
+                    "description": "创建（dry_run 时为可以创建）的用户数",
+                    "type": "integer"
+                },
+                "emails_sent": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "有任何错误时整个文件都不会导入",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ImportUserError"
+                    }
+                },
+                "total": {
+                    "description": "数据行数，不含表头和空行",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ImportUsersResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.ImportUsersData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ListApiKeysResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.ListApiKeysResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ListApiKeysResponseData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ApiKeyData"
+                    }
+                }
+            }
+        },
+        "v1.ListIdentitiesResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.ListIdentitiesResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ListIdentitiesResponseData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.UserIdentity"
+                    }
+                }
+            }
+        },
+        "v1.ListOAuthProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.ListOAuthProvidersResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ListOAuthProvidersResponseData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.OAuthProvider"
+                    }
+                }
+            }
+        },
+        "v1.ListSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.ListSessionsResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ListSessionsResponseData": {
+            "type": "object",
+            "properties": {
+                "current_session_id": {
+                    "description": "发起请求的会话，只在查看自己的会话时返回",
+                    "type": "integer"
+                },
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Session"
+                    }
+                }
+            }
+        },
+        "v1.LoginMfaActivateRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LoginMfaActivateResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.LoginMfaActivateResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LoginMfaActivateResponseData": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "description": "access token 有效期(秒)",
+                    "type": "integer",
+                    "example": 900
+                },
+                "mfaEnrollRequired": {
+                    "description": "账号必须先绑定 TOTP，调用 /login/mfa/enroll",
+                    "type": "boolean"
+                },
+                "mfaRequired": {
+                    "description": "开启了二次验证时不返回 token，而是返回 mfaToken，用它调用 /login/mfa 完成登录",
+                    "type": "boolean"
+                },
+                "mfaToken": {
+                    "type": "string"
+                },
+                "recovery_codes": {
+                    "description": "只展示这一次",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LoginMfaEnrollRequest": {
+            "type": "object",
+            "required": [
+                "mfa_token"
+            ],
+            "properties": {
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LoginMfaRequest": {
+            "type": "object",
+            "required": [
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string",
+                    "example": "abcd-efgh"
+                }
+            }
+        },
+        "v1.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "1234@gmail.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "v1.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.LoginResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LoginResponseData": {
+            "type": "object",
+            "properties": {
+                "accessToken": {
+                    "type": "string"
+                },
+                "expiresIn": {
+                    "description": "access token 有效期(秒)",
+                    "type": "integer",
+                    "example": 900
+                },
+                "mfaEnrollRequired": {
+                    "description": "账号必须先绑定 TOTP，调用 /login/mfa/enroll",
+                    "type": "boolean"
+                },
+                "mfaRequired": {
+                    "description": "开启了二次验证时不返回 token，而是返回 mfaToken，用它调用 /login/mfa 完成登录",
+                    "type": "boolean"
+                },
+                "mfaToken": {
+                    "type": "string"
+                },
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.MfaCodeRequest": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                },
+                "recovery_code": {
+                    "type": "string",
+                    "example": "abcd-efgh"
+                }
+            }
+        },
+        "v1.OAuthAuthorizeResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.OAuthAuthorizeResponseData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.OAuthAuthorizeResponseData": {
+            "type": "object",
+            "properties": {
+                "auth_url": {
+                    "description": "前端跳转到该地址进行授权",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.OAuthCallbackRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "state"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.OAuthProvider": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "google"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "oidc"
+                }
+            }
+        },
+        "v1.PolicyDiff": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "差异是否已写回 Casbin",
+                    "type": "boolean"
+                },
+                "extra": {
+                    "description": "casbin_rule 中多出的规则",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "missing": {
+                    "description": "关系表中有、casbin_rule 中缺少的规则",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "v1.PolicyDiffResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.PolicyDiff"
                 },
-                "name": {
+                "message": {
                     "type": "string"
+                }
+            }
+        },
+        "v1.PolicyMatch": {
+            "type": "object",
+            "properties": {
+                "chain": {
+                    "description": "如 [\"user:xxx\", \"dev\", \"base\"]，最后一个是规则所属的角色",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "page_size": {
-                    "type": "integer"
+                "policy": {
+                    "description": "sid, obj, act",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "v1.LoginRequest": {
+        "v1.RbacChange": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "1234@gmail.com"
+                "action": {
+                    "description": "create 或 update",
+                    "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "123456"
+                "fields": {
+                    "description": "update 时变化的字段",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "key": {
+                    "description": "Permission.Key 或 Role.Sid",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "permission 或 role",
+                    "type": "string"
                 }
             }
         },
-        "v1.LoginResponse": {
+        "v1.RecoveryCodesResponse": {
             "type": "object",
             "properties": {
                 "code": {
                     "type": "integer"
                 },
                 "data": {
-                    "$ref": "#/definitions/v1.LoginResponseData"
+                    "$ref": "#/definitions/v1.RecoveryCodesResponseData"
                 },
                 "message": {
                     "type": "string"
                 }
             }
         },
-        "v1.LoginResponseData": {
+        "v1.RecoveryCodesResponseData": {
             "type": "object",
             "properties": {
-                "accessToken": {
+                "recovery_codes": {
+                    "description": "只展示这一次",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
                     "type": "string"
                 }
             }
@@ -289,6 +3944,35 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.ResendVerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "1234@gmail.com"
+                }
+            }
+        },
+        "v1.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "123456"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.Response": {
             "type": "object",
             "properties": {
@@ -301,6 +3985,148 @@ const docTemplate = `{
                 }
             }
         },
+        "v1.RevokeUserSessionRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "session_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.StalePermission": {
+            "type": "object",
+            "properties": {
+                "api": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SyncApiPermissionsData": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean"
+                },
+                "created": {
+                    "description": "没有对应按钮权限的路由，写入时为每个路由新增一条",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ApiRoute"
+                    }
+                },
+                "stale": {
+                    "description": "Api 已没有对应路由的按钮权限，写入时标记 stale，不会删除",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.StalePermission"
+                    }
+                }
+            }
+        },
+        "v1.SyncApiPermissionsRequest": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "description": "只返回结果，不写入",
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.SyncApiPermissionsResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.SyncApiPermissionsData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.UnlockUserRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "1234@gmail.com"
+                }
+            }
+        },
+        "v1.UpdatePermissionRequest": {
+            "type": "object",
+            "required": [
+                "id",
+                "key",
+                "name",
+                "type"
+            ],
+            "properties": {
+                "api": {
+                    "description": "接口路径: \"/v1/user/:id\"",
+                    "type": "string"
+                },
+                "component": {
+                    "description": "前端组件路径: \"views/system/user/index\"",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "请求方法: \"GET\", \"POST\", \"DELETE\"，\"*\" 表示任意方法",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "path": {
+                    "description": "前端路由地址: \"/system/user\"",
+                    "type": "string"
+                },
+                "sort": {
+                    "description": "排序: 数字越小越靠前",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
         "v1.UpdateProfileRequest": {
             "type": "object",
             "properties": {
@@ -316,6 +4142,153 @@ const docTemplate = `{
                     "example": "alan"
                 }
             }
+        },
+        "v1.UpdateRoleDataScopeRequest": {
+            "type": "object",
+            "required": [
+                "data_scope",
+                "id"
+            ],
+            "properties": {
+                "data_scope": {
+                    "description": "1全部 2本部门 3仅本人",
+                    "type": "integer",
+                    "enum": [
+                        1,
+                        2,
+                        3
+                    ],
+                    "example": 2
+                },
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.UpdateRoleParentsRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "parent_ids": {
+                    "description": "为空表示不再继承任何角色",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "v1.UpdateRoleRequest": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "角色标识 Sid，为空表示不修改；修改后 Casbin 中的规则随之改名",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "为空表示不修改",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.UpgradeGuestRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "1234@gmail.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "v1.UserIdRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.WhoCanData": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "object": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WhoCanRole"
+                    }
+                },
+                "users": {
+                    "description": "拥有上述任一角色的用户 UserId，超管不需要角色，不在其中",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.WhoCanResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "data": {
+                    "$ref": "#/definitions/v1.WhoCanData"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.WhoCanRole": {
+            "type": "object",
+            "properties": {
+                "match": {
+                    "$ref": "#/definitions/v1.PolicyMatch"
+                },
+                "sid": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AccountHandler struct {
+	*Handler
+	accountService service.AccountService
+}
+
+func NewAccountHandler(handler *Handler, accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		Handler:        handler,
+		accountService: accountService,
+	}
+}
+
+// ForgotPassword godoc
+//
+//	@Summary	忘记密码
+//	@Schemes
+//	@Description	发送重置密码邮件，邮箱不存在时同样返回成功
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.ForgotPasswordRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/password/forgot [post]
+func (h *AccountHandler) ForgotPassword(ctx *gin.Context) {
+	var req v1.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.accountService.ForgotPassword(ctx, &req); err != nil {
+		h.logger.WithContext(ctx).Error("accountService.ForgotPassword error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResetPassword godoc
+//
+//	@Summary	重置密码
+//	@Schemes
+//	@Description	使用邮件中的一次性令牌设置新密码，成功后已登录的会话全部失效
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.ResetPasswordRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/password/reset [post]
+func (h *AccountHandler) ResetPassword(ctx *gin.Context) {
+	var req v1.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.accountService.ResetPassword(ctx, &req); err != nil {
+		h.handleLinkError(ctx, "accountService.ResetPassword error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// VerifyEmail godoc
+//
+//	@Summary	验证邮箱
+//	@Schemes
+//	@Description
+//	@Tags		用户模块
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		v1.VerifyEmailRequest	true	"params"
+//	@Success	200		{object}	v1.Response
+//	@Router		/email/verify [post]
+func (h *AccountHandler) VerifyEmail(ctx *gin.Context) {
+	var req v1.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.accountService.VerifyEmail(ctx, &req); err != nil {
+		h.handleLinkError(ctx, "accountService.VerifyEmail error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ResendVerifyEmail godoc
+//
+//	@Summary	重新发送验证邮件
+//	@Schemes
+//	@Description
+//	@Tags		用户模块
+//	@Accept		json
+//	@Produce	json
+//	@Param		request	body		v1.ResendVerifyEmailRequest	true	"params"
+//	@Success	200		{object}	v1.Response
+//	@Router		/email/verify/resend [post]
+func (h *AccountHandler) ResendVerifyEmail(ctx *gin.Context) {
+	var req v1.ResendVerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.accountService.ResendVerifyEmail(ctx, &req); err != nil {
+		h.logger.WithContext(ctx).Error("accountService.ResendVerifyEmail error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+func (h *AccountHandler) handleLinkError(ctx *gin.Context, msg string, err error) {
+	if errors.Is(err, v1.ErrInvalidLink) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrInvalidLink, nil)
+		return
+	}
+	h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+	v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ApiKeyHandler struct {
+	*Handler
+	apiKeyService service.ApiKeyService
+}
+
+func NewApiKeyHandler(handler *Handler, apiKeyService service.ApiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		Handler:       handler,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// ListApiKeys godoc
+//
+//	@Summary	我的 API key
+//	@Schemes
+//	@Description
+//	@Tags		API key
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	v1.ListApiKeysResponse
+//	@Router		/api-keys [get]
+func (h *ApiKeyHandler) ListApiKeys(ctx *gin.Context) {
+	data, err := h.apiKeyService.ListApiKeys(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("apiKeyService.ListApiKeys error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreateApiKey godoc
+//
+//	@Summary	创建 API key
+//	@Schemes
+//	@Description	完整的 key 只在创建时返回一次，调用时使用 "Authorization: ApiKey <key>"。实际权限为 scopes 与用户自身权限的交集
+//	@Tags			API key
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.CreateApiKeyRequest	true	"params"
+//	@Success		200		{object}	v1.CreateApiKeyResponse
+//	@Router			/api-keys [post]
+func (h *ApiKeyHandler) CreateApiKey(ctx *gin.Context) {
+	var req v1.CreateApiKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.apiKeyService.CreateApiKey(ctx, GetUserIdFromCtx(ctx), &req)
+	if err != nil {
+		h.handleApiKeyError(ctx, "apiKeyService.CreateApiKey error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteApiKey godoc
+//
+//	@Summary	删除 API key
+//	@Schemes
+//	@Description
+//	@Tags		API key
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		id	path		int	true	"api key id"
+//	@Success	200	{object}	v1.Response
+//	@Router		/api-keys/{id} [delete]
+func (h *ApiKeyHandler) DeleteApiKey(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err = h.apiKeyService.DeleteApiKey(ctx, GetUserIdFromCtx(ctx), uint(id)); err != nil {
+		h.handleApiKeyError(ctx, "apiKeyService.DeleteApiKey error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+func (h *ApiKeyHandler) handleApiKeyError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, v1.ErrApiKeyScopeInvalid):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrApiKeyScopeInvalid, nil)
+	case errors.Is(err, v1.ErrNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+	default:
+		h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	}
+}
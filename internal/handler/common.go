@@ -28,7 +28,7 @@ func (h *CommonHandler) UploadPresignedUrl(ctx *gin.Context) {
 		return
 	}
 
-	preSignedUrl, endpointUrl, err := h.CommonService.UploadPresignedUrl(req.FileExt, req.UploadScene)
+	preSignedUrl, endpointUrl, err := h.CommonService.UploadPresignedUrl(GetUserIdFromCtx(ctx), req.FileExt, req.UploadScene, req.FileSize)
 
 	if err != nil {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
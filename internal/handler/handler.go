@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"go-nunu/internal/model"
 	"go-nunu/pkg/jwt"
 	"go-nunu/pkg/log"
 
@@ -25,3 +26,23 @@ func GetUserIdFromCtx(ctx *gin.Context) string {
 	}
 	return v.(*jwt.MyCustomClaims).UserId
 }
+
+func GetClaimsFromCtx(ctx *gin.Context) *jwt.MyCustomClaims {
+	v, exists := ctx.Get("claims")
+	if !exists {
+		return nil
+	}
+	return v.(*jwt.MyCustomClaims)
+}
+
+// GetClientInfo 取出客户端 IP 和 User-Agent，用于登录日志和会话
+func GetClientInfo(ctx *gin.Context) *model.ClientInfo {
+	userAgent := ctx.Request.UserAgent()
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+	return &model.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: userAgent,
+	}
+}
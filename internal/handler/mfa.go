@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type MfaHandler struct {
+	*Handler
+	mfaService service.MfaService
+}
+
+func NewMfaHandler(handler *Handler, mfaService service.MfaService) *MfaHandler {
+	return &MfaHandler{
+		Handler:    handler,
+		mfaService: mfaService,
+	}
+}
+
+// GetMfaStatus godoc
+//
+//	@Summary	获取二次验证状态
+//	@Schemes
+//	@Description
+//	@Tags		二次验证
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	v1.GetMfaStatusResponse
+//	@Router		/mfa [get]
+func (h *MfaHandler) GetMfaStatus(ctx *gin.Context) {
+	data, err := h.mfaService.GetStatus(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.GetStatus error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// EnrollTotp godoc
+//
+//	@Summary	绑定 TOTP
+//	@Schemes
+//	@Description	生成新的 TOTP 密钥，需调用激活接口提交验证码后才生效
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	v1.EnrollTotpResponse
+//	@Router			/mfa/totp/enroll [post]
+func (h *MfaHandler) EnrollTotp(ctx *gin.Context) {
+	data, err := h.mfaService.EnrollTotp(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.EnrollTotp error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ActivateTotp godoc
+//
+//	@Summary	激活 TOTP
+//	@Schemes
+//	@Description	提交验证器中的验证码完成绑定，返回一次性恢复码
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.MfaCodeRequest	true	"params"
+//	@Success		200		{object}	v1.RecoveryCodesResponse
+//	@Router			/mfa/totp/activate [post]
+func (h *MfaHandler) ActivateTotp(ctx *gin.Context) {
+	var req v1.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.mfaService.ActivateTotp(ctx, GetUserIdFromCtx(ctx), req.Code)
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.ActivateTotp error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// DisableTotp godoc
+//
+//	@Summary	关闭 TOTP
+//	@Schemes
+//	@Description	需要提交验证码或恢复码；强制开启二次验证的账号不能关闭
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.MfaCodeRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/mfa/totp/disable [post]
+func (h *MfaHandler) DisableTotp(ctx *gin.Context) {
+	var req v1.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.mfaService.DisableTotp(ctx, GetUserIdFromCtx(ctx), &req); err != nil {
+		h.handleMfaError(ctx, "mfaService.DisableTotp error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RegenerateRecoveryCodes godoc
+//
+//	@Summary	重新生成恢复码
+//	@Schemes
+//	@Description	旧的恢复码全部作废，需要提交 TOTP 验证码
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.MfaCodeRequest	true	"params"
+//	@Success		200		{object}	v1.RecoveryCodesResponse
+//	@Router			/mfa/recovery-codes [post]
+func (h *MfaHandler) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req v1.MfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.mfaService.RegenerateRecoveryCodes(ctx, GetUserIdFromCtx(ctx), &req)
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.RegenerateRecoveryCodes error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// LoginMfa godoc
+//
+//	@Summary	登录二次验证
+//	@Schemes
+//	@Description	使用登录返回的 mfa_token 加验证码或恢复码换取正式 token
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.LoginMfaRequest	true	"params"
+//	@Success		200		{object}	v1.LoginResponse
+//	@Router			/login/mfa [post]
+//	@Router			/app/login/mfa [post]
+func (h *MfaHandler) LoginMfa(ctx *gin.Context) {
+	var req v1.LoginMfaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.mfaService.LoginMfa(ctx, &req, GetClientInfo(ctx))
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.LoginMfa error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// LoginMfaEnroll godoc
+//
+//	@Summary	登录时绑定 TOTP
+//	@Schemes
+//	@Description	强制开启二次验证但尚未绑定的账号，使用 mfa_token 获取 TOTP 密钥
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.LoginMfaEnrollRequest	true	"params"
+//	@Success		200		{object}	v1.EnrollTotpResponse
+//	@Router			/login/mfa/enroll [post]
+func (h *MfaHandler) LoginMfaEnroll(ctx *gin.Context) {
+	var req v1.LoginMfaEnrollRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.mfaService.LoginMfaEnroll(ctx, &req)
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.LoginMfaEnroll error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// LoginMfaActivate godoc
+//
+//	@Summary	登录时激活 TOTP
+//	@Schemes
+//	@Description	完成绑定后直接签发正式 token，同时返回一次性恢复码
+//	@Tags			二次验证
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.LoginMfaActivateRequest	true	"params"
+//	@Success		200		{object}	v1.LoginMfaActivateResponse
+//	@Router			/login/mfa/activate [post]
+func (h *MfaHandler) LoginMfaActivate(ctx *gin.Context) {
+	var req v1.LoginMfaActivateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.mfaService.LoginMfaActivate(ctx, &req, GetClientInfo(ctx))
+	if err != nil {
+		h.handleMfaError(ctx, "mfaService.LoginMfaActivate error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+func (h *MfaHandler) handleMfaError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+	case errors.Is(err, v1.ErrMfaCodeInvalid):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrMfaCodeInvalid, nil)
+	case errors.Is(err, v1.ErrMfaNotEnrolled):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrMfaNotEnrolled, nil)
+	case errors.Is(err, v1.ErrMfaAlreadyActive):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrMfaAlreadyActive, nil)
+	case errors.Is(err, v1.ErrMfaRequired):
+		v1.HandleError(ctx, http.StatusForbidden, v1.ErrMfaRequired, nil)
+	default:
+		h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	}
+}
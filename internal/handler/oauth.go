@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OAuthHandler struct {
+	*Handler
+	oauthService service.OAuthService
+}
+
+func NewOAuthHandler(handler *Handler, oauthService service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{
+		Handler:      handler,
+		oauthService: oauthService,
+	}
+}
+
+// ListProviders godoc
+//
+//	@Summary	第三方登录方式列表
+//	@Schemes
+//	@Description
+//	@Tags		第三方登录
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	v1.ListOAuthProvidersResponse
+//	@Router		/oauth/providers [get]
+func (h *OAuthHandler) ListProviders(ctx *gin.Context) {
+	v1.HandleSuccess(ctx, h.oauthService.ListProviders(ctx))
+}
+
+// Authorize godoc
+//
+//	@Summary	获取第三方登录授权地址
+//	@Schemes
+//	@Description	授权码模式 + PKCE，前端跳转到返回的地址
+//	@Tags			第三方登录
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string	true	"provider name"
+//	@Success		200			{object}	v1.OAuthAuthorizeResponse
+//	@Router			/oauth/{provider}/authorize [get]
+func (h *OAuthHandler) Authorize(ctx *gin.Context) {
+	data, err := h.oauthService.Authorize(ctx, ctx.Param("provider"), "")
+	if err != nil {
+		h.handleOAuthError(ctx, "oauthService.Authorize error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// Callback godoc
+//
+//	@Summary	第三方登录回调
+//	@Schemes
+//	@Description	首次登录时按已验证的邮箱绑定已有账号，找不到则自动注册
+//	@Tags			第三方登录
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string					true	"provider name"
+//	@Param			request		body		v1.OAuthCallbackRequest	true	"params"
+//	@Success		200			{object}	v1.LoginResponse
+//	@Router			/oauth/{provider}/callback [post]
+func (h *OAuthHandler) Callback(ctx *gin.Context) {
+	var req v1.OAuthCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.oauthService.Login(ctx, ctx.Param("provider"), &req, GetClientInfo(ctx))
+	if err != nil {
+		h.handleOAuthError(ctx, "oauthService.Login error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListIdentities godoc
+//
+//	@Summary	已绑定的第三方账号
+//	@Schemes
+//	@Description
+//	@Tags		第三方登录
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	v1.ListIdentitiesResponse
+//	@Router		/identities [get]
+func (h *OAuthHandler) ListIdentities(ctx *gin.Context) {
+	data, err := h.oauthService.ListIdentities(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.handleOAuthError(ctx, "oauthService.ListIdentities error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// LinkAuthorize godoc
+//
+//	@Summary	获取绑定第三方账号的授权地址
+//	@Schemes
+//	@Description
+//	@Tags		第三方登录
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		provider	path		string	true	"provider name"
+//	@Success	200			{object}	v1.OAuthAuthorizeResponse
+//	@Router		/identities/{provider}/authorize [get]
+func (h *OAuthHandler) LinkAuthorize(ctx *gin.Context) {
+	data, err := h.oauthService.Authorize(ctx, ctx.Param("provider"), GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.handleOAuthError(ctx, "oauthService.Authorize error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// Link godoc
+//
+//	@Summary	绑定第三方账号
+//	@Schemes
+//	@Description
+//	@Tags		第三方登录
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		provider	path		string					true	"provider name"
+//	@Param		request		body		v1.OAuthCallbackRequest	true	"params"
+//	@Success	200			{object}	v1.Response
+//	@Router		/identities/{provider}/link [post]
+func (h *OAuthHandler) Link(ctx *gin.Context) {
+	var req v1.OAuthCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.oauthService.Link(ctx, GetUserIdFromCtx(ctx), ctx.Param("provider"), &req); err != nil {
+		h.handleOAuthError(ctx, "oauthService.Link error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// Unlink godoc
+//
+//	@Summary	解绑第三方账号
+//	@Schemes
+//	@Description
+//	@Tags		第三方登录
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		id	path		int	true	"identity id"
+//	@Success	200	{object}	v1.Response
+//	@Router		/identities/{id} [delete]
+func (h *OAuthHandler) Unlink(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err = h.oauthService.Unlink(ctx, GetUserIdFromCtx(ctx), uint(id)); err != nil {
+		h.handleOAuthError(ctx, "oauthService.Unlink error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GuestAuthorize godoc
+//
+//	@Summary	获取访客升级的第三方授权地址
+//	@Schemes
+//	@Description
+//	@Tags		访客
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		provider	path		string	true	"provider name"
+//	@Success	200			{object}	v1.OAuthAuthorizeResponse
+//	@Router		/guest/upgrade/{provider}/authorize [get]
+func (h *OAuthHandler) GuestAuthorize(ctx *gin.Context) {
+	data, err := h.oauthService.Authorize(ctx, ctx.Param("provider"), GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.handleOAuthError(ctx, "oauthService.Authorize error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpgradeGuest godoc
+//
+//	@Summary	访客通过第三方账号升级
+//	@Schemes
+//	@Description	第三方账号未注册时绑定到当前访客，UserId 不变；已注册（或已验证的邮箱匹配）时把访客合并到该账号
+//	@Tags			访客
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			provider	path		string					true	"provider name"
+//	@Param			request		body		v1.OAuthCallbackRequest	true	"params"
+//	@Success		200			{object}	v1.LoginResponse
+//	@Router			/guest/upgrade/{provider} [post]
+func (h *OAuthHandler) UpgradeGuest(ctx *gin.Context) {
+	var req v1.OAuthCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.oauthService.UpgradeGuest(ctx, GetUserIdFromCtx(ctx), ctx.Param("provider"), &req, GetClientInfo(ctx))
+	if err != nil {
+		h.handleOAuthError(ctx, "oauthService.UpgradeGuest error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+func (h *OAuthHandler) handleOAuthError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, v1.ErrNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+	case errors.Is(err, v1.ErrBadRequest):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+	case errors.Is(err, v1.ErrOAuthFailed):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrOAuthFailed, nil)
+	case errors.Is(err, v1.ErrIdentityInUse):
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrIdentityInUse, nil)
+	case errors.Is(err, v1.ErrEmailAlreadyUse):
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrEmailAlreadyUse, nil)
+	case errors.Is(err, v1.ErrUserDisabled):
+		v1.HandleError(ctx, http.StatusForbidden, v1.ErrUserDisabled, nil)
+	default:
+		h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	}
+}
@@ -1,13 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"go-nunu/api"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type PermissionHandler struct {
@@ -61,3 +64,112 @@ func (h *PermissionHandler) CreatePermission(ctx *gin.Context) {
 
 	v1.HandleSuccess(ctx, permission)
 }
+
+// GetPermissionTree godoc
+//
+//	@Summary	权限树
+//	@Schemes
+//	@Description	全部目录、菜单和按钮按 parent_id 组成的树，同级按 sort 排序
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	v1.Response
+//	@Router			/permission/tree [get]
+func (h *PermissionHandler) GetPermissionTree(ctx *gin.Context) {
+	data, err := h.permissionService.GetPermissionTree(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("permissionService.GetPermissionTree error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetMyMenus godoc
+//
+//	@Summary	我的菜单
+//	@Schemes
+//	@Description	当前用户可见的目录和菜单树，buttons 为该菜单下拥有的按钮 Key
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	v1.Response
+//	@Router			/menus/mine [get]
+func (h *PermissionHandler) GetMyMenus(ctx *gin.Context) {
+	data, err := h.permissionService.GetMyMenus(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("permissionService.GetMyMenus error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdatePermission godoc
+//
+//	@Summary	修改权限
+//	@Schemes
+//	@Description	拥有该权限的角色的 Casbin 策略随之更新
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UpdatePermissionRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/permission [put]
+func (h *PermissionHandler) UpdatePermission(ctx *gin.Context) {
+	var req v1.UpdatePermissionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	permission, err := h.permissionService.UpdatePermission(ctx, &req)
+	if err != nil {
+		h.handlePermissionError(ctx, "permissionService.UpdatePermission error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, permission)
+}
+
+// DeletePermission godoc
+//
+//	@Summary	删除权限
+//	@Schemes
+//	@Description	同时从所有角色中移除；仍有子权限时不能删除
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id	path		int	true	"permission id"
+//	@Success		200	{object}	v1.Response
+//	@Router			/permission/{id} [delete]
+func (h *PermissionHandler) DeletePermission(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err = h.permissionService.DeletePermission(ctx, uint(id)); err != nil {
+		h.handlePermissionError(ctx, "permissionService.DeletePermission error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+func (h *PermissionHandler) handlePermissionError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, v1.ErrPermissionHasChildren):
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrPermissionHasChildren, nil)
+	case errors.Is(err, v1.ErrPermissionParentInvalid):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrPermissionParentInvalid, nil)
+	case errors.Is(err, v1.ErrNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+	default:
+		h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PolicyHandler struct {
+	*Handler
+	policyService service.PolicyService
+}
+
+func NewPolicyHandler(handler *Handler, policyService service.PolicyService) *PolicyHandler {
+	return &PolicyHandler{
+		Handler:       handler,
+		policyService: policyService,
+	}
+}
+
+// GetPolicyDrift godoc
+//
+//	@Summary	检查 Casbin 策略漂移
+//	@Schemes
+//	@Description	比较角色、权限、用户角色关系表推导出的规则与 casbin_rule 表，只返回差异
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	v1.PolicyDiffResponse
+//	@Router			/policy/drift [get]
+func (h *PolicyHandler) GetPolicyDrift(ctx *gin.Context) {
+	diff, err := h.policyService.DiffPolicies(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("policyService.DiffPolicies error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, diff)
+}
+
+// ReconcilePolicies godoc
+//
+//	@Summary	修复 Casbin 策略漂移
+//	@Schemes
+//	@Description	以关系表为准补齐缺失的规则、删除多余的规则，返回修复前的差异
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	v1.PolicyDiffResponse
+//	@Router			/policy/reconcile [post]
+func (h *PolicyHandler) ReconcilePolicies(ctx *gin.Context) {
+	diff, err := h.policyService.ReconcilePolicies(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("policyService.ReconcilePolicies error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, diff)
+}
+
+// SyncApiPermissions godoc
+//
+//	@Summary	同步接口权限
+//	@Schemes
+//	@Description	为挂载了 RBAC 鉴权但没有按钮权限的路由新增权限，Api 已没有对应路由的权限标记为 stale
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.SyncApiPermissionsRequest	true	"params"
+//	@Success		200		{object}	v1.SyncApiPermissionsResponse
+//	@Router			/policy/sync-routes [post]
+func (h *PolicyHandler) SyncApiPermissions(ctx *gin.Context) {
+	var req v1.SyncApiPermissionsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.policyService.SyncApiPermissions(ctx, !req.DryRun)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("policyService.SyncApiPermissions error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// CheckPermission godoc
+//
+//	@Summary	模拟权限检查
+//	@Schemes
+//	@Description	按 user_id 或角色 Sid 判断能否访问接口，返回命中的规则、角色继承链和生效的数据权限
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.CheckPermissionRequest	true	"params"
+//	@Success		200		{object}	v1.CheckPermissionResponse
+//	@Router			/policy/check [post]
+func (h *PolicyHandler) CheckPermission(ctx *gin.Context) {
+	var req v1.CheckPermissionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.policyService.CheckPermission(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, v1.ErrBadRequest):
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		case errors.Is(err, v1.ErrNotFound):
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+		default:
+			h.logger.WithContext(ctx).Error("policyService.CheckPermission error", zap.Error(err))
+			v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		}
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// WhoCan godoc
+//
+//	@Summary	反查接口权限
+//	@Schemes
+//	@Description	列出能访问该接口的角色（含继承）和拥有这些角色的用户
+//	@Tags			Permission模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	query		v1.WhoCanRequest	true	"params"
+//	@Success		200		{object}	v1.WhoCanResponse
+//	@Router			/policy/who-can [get]
+func (h *PolicyHandler) WhoCan(ctx *gin.Context) {
+	var req v1.WhoCanRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.policyService.WhoCan(ctx, &req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("policyService.WhoCan error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxRbacDocumentSize 导入文档的大小上限
+const maxRbacDocumentSize = 4 << 20
+
+type RbacHandler struct {
+	*Handler
+	rbacService service.RbacService
+}
+
+func NewRbacHandler(handler *Handler, rbacService service.RbacService) *RbacHandler {
+	return &RbacHandler{
+		Handler:     handler,
+		rbacService: rbacService,
+	}
+}
+
+// ExportRbac godoc
+//
+//	@Summary	导出 RBAC 配置
+//	@Schemes
+//	@Description	以 YAML 导出权限树、角色、角色权限和数据权限，权限按 Key、角色按 Sid 关联
+//	@Tags			Permission模块
+//	@Produce		application/x-yaml
+//	@Security		Bearer
+//	@Success		200	{string}	string	"YAML 文档"
+//	@Router			/rbac/export [get]
+func (h *RbacHandler) ExportRbac(ctx *gin.Context) {
+	data, err := h.rbacService.ExportRbac(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rbacService.ExportRbac error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="rbac.yaml"`)
+	ctx.Data(http.StatusOK, "application/x-yaml; charset=utf-8", data)
+}
+
+// ImportRbac godoc
+//
+//	@Summary	导入 RBAC 配置
+//	@Schemes
+//	@Description	按 Key、Sid 新增或更新权限和角色，重复导入同一文档不产生变更；dry_run=true 时只返回变更
+//	@Tags			Permission模块
+//	@Accept			application/x-yaml
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			dry_run	query		bool	false	"只比较不写入"
+//	@Param			request	body		string	true	"导出的 YAML 文档"
+//	@Success		200		{object}	v1.ImportRbacResponse
+//	@Router			/rbac/import [post]
+func (h *RbacHandler) ImportRbac(ctx *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxRbacDocumentSize))
+	if err != nil || len(body) == 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.rbacService.ImportRbac(ctx, body, ctx.Query("dry_run") != "true")
+	if err != nil {
+		if errors.Is(err, v1.ErrRbacDocumentInvalid) {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrRbacDocumentInvalid, map[string]string{"reason": err.Error()})
+			return
+		}
+		h.logger.WithContext(ctx).Error("rbacService.ImportRbac error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
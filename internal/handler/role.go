@@ -1,13 +1,15 @@
 package handler
 
 import (
-	"fmt"
+	"errors"
 	"go-nunu/api"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 type RoleHandler struct {
@@ -62,10 +64,9 @@ func (h *RoleHandler) CreateRole(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
 		return
 	}
-	role, err := h.roleService.CreateRole(ctx, req)
+	role, err := h.roleService.CreateRole(ctx, GetUserIdFromCtx(ctx), req)
 	if err != nil {
-		fmt.Println("error:", err)
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		h.handleRoleError(ctx, "roleService.CreateRole error", err)
 		return
 	}
 
@@ -91,3 +92,153 @@ func (h *RoleHandler) UpdateRolePermissions(ctx *gin.Context) {
 	// For now, we'll just return a success response.
 	v1.HandleSuccess(ctx, nil)
 }
+
+// UpdateRoleParents godoc
+//
+//	@Summary	设置角色继承
+//	@Schemes
+//	@Description	角色拥有父角色的全部权限，可以多级继承，不允许形成环
+//	@Tags			Role模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UpdateRoleParentsRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/role/parents [put]
+func (h *RoleHandler) UpdateRoleParents(ctx *gin.Context) {
+	var req v1.UpdateRoleParentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.roleService.UpdateRoleParents(ctx, int64(req.ID), req.ParentIds); err != nil {
+		h.handleRoleError(ctx, "roleService.UpdateRoleParents error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetRolePermissions godoc
+//
+//	@Summary	角色的有效权限
+//	@Schemes
+//	@Description	包含直接分配和从祖先角色继承的权限，source 标明来源
+//	@Tags			Role模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id	path		int	true	"role id"
+//	@Success		200	{object}	v1.Response
+//	@Router			/role/{id}/permissions [get]
+func (h *RoleHandler) GetRolePermissions(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.roleService.GetRolePermissions(ctx, id)
+	if err != nil {
+		h.handleRoleError(ctx, "roleService.GetRolePermissions error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateRoleDataScope godoc
+//
+//	@Summary	设置角色数据权限
+//	@Schemes
+//	@Description	控制用户列表、角色列表等接口能看到的数据范围: 1全部 2本部门 3仅本人。用户有多个角色时取范围最大的一个
+//	@Tags			Role模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UpdateRoleDataScopeRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/role/data-scope [put]
+func (h *RoleHandler) UpdateRoleDataScope(ctx *gin.Context) {
+	var req v1.UpdateRoleDataScopeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.roleService.UpdateRoleDataScope(ctx, &req); err != nil {
+		h.handleRoleError(ctx, "roleService.UpdateRoleDataScope error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateRole godoc
+//
+//	@Summary	修改角色
+//	@Schemes
+//	@Description	修改名称或标识（key），标识变化时 Casbin 中的规则同步改名
+//	@Tags			Role模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UpdateRoleRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/role/info [put]
+func (h *RoleHandler) UpdateRole(ctx *gin.Context) {
+	var req v1.UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(ctx, &req)
+	if err != nil {
+		h.handleRoleError(ctx, "roleService.UpdateRole error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, role)
+}
+
+// DeleteRole godoc
+//
+//	@Summary	删除角色
+//	@Schemes
+//	@Description	仍分配给用户或被其他角色继承时返回 1014
+//	@Tags			Role模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id	path		int	true	"role id"
+//	@Success		200	{object}	v1.Response
+//	@Router			/role/{id} [delete]
+func (h *RoleHandler) DeleteRole(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err = h.roleService.DeleteRole(ctx, id); err != nil {
+		h.handleRoleError(ctx, "roleService.DeleteRole error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+func (h *RoleHandler) handleRoleError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, v1.ErrRoleCycle):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrRoleCycle, nil)
+	case errors.Is(err, v1.ErrRoleInUse):
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrRoleInUse, nil)
+	case errors.Is(err, v1.ErrRoleSidExists):
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrRoleSidExists, nil)
+	case errors.Is(err, v1.ErrRoleKeyRequired):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrRoleKeyRequired, nil)
+	case errors.Is(err, v1.ErrNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+	default:
+		h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	}
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"errors"
+	"go-nunu/api"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SessionHandler struct {
+	*Handler
+	sessionService service.SessionService
+}
+
+func NewSessionHandler(handler *Handler, sessionService service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		Handler:        handler,
+		sessionService: sessionService,
+	}
+}
+
+// ListSessions godoc
+//
+//	@Summary	我的登录会话
+//	@Schemes
+//	@Description
+//	@Tags		会话管理
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Success	200	{object}	v1.ListSessionsResponse
+//	@Router		/sessions [get]
+func (h *SessionHandler) ListSessions(ctx *gin.Context) {
+	claims := GetClaimsFromCtx(ctx)
+	if claims == nil {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	data, err := h.sessionService.ListSessions(ctx, claims.UserId, claims.ID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("sessionService.ListSessions error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RevokeSession godoc
+//
+//	@Summary	下线我的会话
+//	@Schemes
+//	@Description
+//	@Tags		会话管理
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		id	path		int	true	"session id"
+//	@Success	200	{object}	v1.Response
+//	@Router		/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err = h.sessionService.RevokeSession(ctx, GetUserIdFromCtx(ctx), uint(id)); err != nil {
+		h.handleSessionError(ctx, "sessionService.RevokeSession error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListLoginLogs godoc
+//
+//	@Summary	我的登录记录
+//	@Schemes
+//	@Description
+//	@Tags		会话管理
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		current_page	query		int	false	"page"
+//	@Param		page_size		query		int	false	"page size"
+//	@Success	200				{object}	v1.GetLoginLogListResponse
+//	@Router		/sessions/login-logs [get]
+func (h *SessionHandler) ListLoginLogs(ctx *gin.Context) {
+	var page api.PageRequest
+	if err := ctx.ShouldBindQuery(&page); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.sessionService.GetLoginLogList(ctx, &v1.GetLoginLogListRequest{
+		PageRequest: page,
+		UserId:      GetUserIdFromCtx(ctx),
+	})
+	if err != nil {
+		h.logger.WithContext(ctx).Error("sessionService.GetLoginLogList error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListUserSessions godoc
+//
+//	@Summary	查看用户的登录会话
+//	@Schemes
+//	@Description
+//	@Tags		会话管理
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		request	query		v1.ListUserSessionsRequest	true	"params"
+//	@Success	200		{object}	v1.ListSessionsResponse
+//	@Router		/user/sessions [get]
+func (h *SessionHandler) ListUserSessions(ctx *gin.Context) {
+	var req v1.ListUserSessionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.sessionService.ListSessions(ctx, req.UserId, "")
+	if err != nil {
+		h.logger.WithContext(ctx).Error("sessionService.ListSessions error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RevokeUserSession godoc
+//
+//	@Summary	强制用户下线
+//	@Schemes
+//	@Description	不传 session_id 时下线该用户的全部会话
+//	@Tags			会话管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.RevokeUserSessionRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/user/sessions/revoke [post]
+func (h *SessionHandler) RevokeUserSession(ctx *gin.Context) {
+	var req v1.RevokeUserSessionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	var err error
+	if req.SessionId == 0 {
+		err = h.sessionService.RevokeAllSessions(ctx, req.UserId)
+	} else {
+		err = h.sessionService.RevokeSession(ctx, req.UserId, req.SessionId)
+	}
+	if err != nil {
+		h.handleSessionError(ctx, "sessionService.RevokeSession error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetLoginLogList godoc
+//
+//	@Summary	登录日志
+//	@Schemes
+//	@Description
+//	@Tags		会话管理
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		request	query		v1.GetLoginLogListRequest	false	"params"
+//	@Success	200		{object}	v1.GetLoginLogListResponse
+//	@Router		/user/login-logs [get]
+func (h *SessionHandler) GetLoginLogList(ctx *gin.Context) {
+	var req v1.GetLoginLogListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.sessionService.GetLoginLogList(ctx, &req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("sessionService.GetLoginLogList error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+func (h *SessionHandler) handleSessionError(ctx *gin.Context, msg string, err error) {
+	if errors.Is(err, v1.ErrNotFound) {
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+		return
+	}
+	h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+	v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+}
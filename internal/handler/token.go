@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TokenHandler struct {
+	*Handler
+	tokenService service.TokenService
+}
+
+func NewTokenHandler(handler *Handler, tokenService service.TokenService) *TokenHandler {
+	return &TokenHandler{
+		Handler:      handler,
+		tokenService: tokenService,
+	}
+}
+
+// RefreshToken godoc
+//
+//	@Summary	刷新 access token
+//	@Schemes
+//	@Description	刷新令牌只能使用一次，重复使用会吊销整条令牌链
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.RefreshTokenRequest	true	"params"
+//	@Success		200		{object}	v1.LoginResponse
+//	@Router			/token/refresh [post]
+//	@Router			/app/token/refresh [post]
+func (h *TokenHandler) RefreshToken(ctx *gin.Context) {
+	var req v1.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.tokenService.RefreshToken(ctx, &req, GetClientInfo(ctx))
+	if err != nil {
+		if !errors.Is(err, v1.ErrUnauthorized) {
+			h.logger.WithContext(ctx).Error("tokenService.RefreshToken error", zap.Error(err))
+		}
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// Logout godoc
+//
+//	@Summary	退出登录
+//	@Schemes
+//	@Description	吊销当前 access token 及其刷新令牌
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.LogoutRequest	false	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/logout [post]
+//	@Router			/app/logout [post]
+func (h *TokenHandler) Logout(ctx *gin.Context) {
+	claims := GetClaimsFromCtx(ctx)
+	if claims == nil {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		return
+	}
+
+	var req v1.LogoutRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+			return
+		}
+	}
+
+	if err := h.tokenService.Logout(ctx, claims, &req); err != nil {
+		h.logger.WithContext(ctx).Error("tokenService.Logout error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
@@ -1,10 +1,11 @@
 package handler
 
 import (
-	"fmt"
+	"errors"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/service"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -33,6 +34,7 @@ func NewUserHandler(handler *Handler, userService service.UserService) *UserHand
 //	@Param			request	body		v1.RegisterRequest	true	"params"
 //	@Success		200		{object}	v1.Response
 //	@Router			/register [post]
+//	@Router			/app/register [post]
 func (h *UserHandler) Register(ctx *gin.Context) {
 	req := new(v1.RegisterRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
@@ -60,6 +62,7 @@ func (h *UserHandler) Register(ctx *gin.Context) {
 //	@Param		request	body		v1.LoginRequest	true	"params"
 //	@Success	200		{object}	v1.LoginResponse
 //	@Router		/login [post]
+//	@Router		/app/login [post]
 func (h *UserHandler) Login(ctx *gin.Context) {
 	var req v1.LoginRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -67,28 +70,101 @@ func (h *UserHandler) Login(ctx *gin.Context) {
 		return
 	}
 
-	token, err := h.userService.Login(ctx, &req)
+	data, err := h.userService.Login(ctx, &req, GetClientInfo(ctx))
 	if err != nil {
-		fmt.Println(err)
+		if errors.Is(err, v1.ErrEmailNotVerified) || errors.Is(err, v1.ErrUserDisabled) {
+			v1.HandleError(ctx, http.StatusForbidden, err, nil)
+			return
+		}
+		// 其余失败一律返回 ErrUnauthorized，不暴露邮箱是否存在、账号是否被锁定
+		if !errors.Is(err, v1.ErrUnauthorized) {
+			h.logger.WithContext(ctx).Error("userService.Login error", zap.Error(err))
+		}
 		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
 		return
 	}
-	v1.HandleSuccess(ctx, v1.LoginResponseData{
-		AccessToken: token,
-	})
+	v1.HandleSuccess(ctx, data)
+}
+
+// GuestLogin godoc
+//
+//	@Summary	访客登录
+//	@Schemes
+//	@Description	按设备 udid 登录，首次登录自动创建访客。返回的 token 只能访问访客接口，需要请求签名
+//	@Tags			访客
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		v1.GuestLoginRequest	true	"params"
+//	@Success		200		{object}	v1.LoginResponse
+//	@Router			/guest/login [post]
+func (h *UserHandler) GuestLogin(ctx *gin.Context) {
+	var req v1.GuestLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.userService.GuestLogin(ctx, &req, GetClientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, v1.ErrUserDisabled) {
+			v1.HandleError(ctx, http.StatusForbidden, err, nil)
+			return
+		}
+		h.logger.WithContext(ctx).Error("userService.GuestLogin error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpgradeGuest godoc
+//
+//	@Summary	访客升级为注册用户
+//	@Schemes
+//	@Description	邮箱未注册时绑定到当前访客，UserId 不变；已注册时校验该账号的密码，把访客合并到该账号。成功后返回正式 token，访客 token 失效
+//	@Tags			访客
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UpgradeGuestRequest	true	"params"
+//	@Success		200		{object}	v1.LoginResponse
+//	@Router			/guest/upgrade [post]
+func (h *UserHandler) UpgradeGuest(ctx *gin.Context) {
+	var req v1.UpgradeGuestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.userService.UpgradeGuest(ctx, GetUserIdFromCtx(ctx), &req, GetClientInfo(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, v1.ErrBadRequest):
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		case errors.Is(err, v1.ErrEmailNotVerified), errors.Is(err, v1.ErrUserDisabled):
+			v1.HandleError(ctx, http.StatusForbidden, err, nil)
+		case errors.Is(err, v1.ErrUnauthorized):
+			v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+		default:
+			h.logger.WithContext(ctx).Error("userService.UpgradeGuest error", zap.Error(err))
+			v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		}
+		return
+	}
+	v1.HandleSuccess(ctx, data)
 }
 
 // GetProfile godoc
 //
 //	@Summary	获取用户信息
 //	@Schemes
-//	@Description
-//	@Tags		用户模块
-//	@Accept		json
-//	@Produce	json
-//	@Security	Bearer
-//	@Success	200	{object}	v1.GetProfileResponse
-//	@Router		/user [get]
+//	@Description	avatars 为处理后各尺寸头像的地址
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Success		200	{object}	v1.GetProfileResponse
+//	@Router			/profile [get]
 func (h *UserHandler) GetProfile(ctx *gin.Context) {
 	userId := GetUserIdFromCtx(ctx)
 	if userId == "" {
@@ -105,24 +181,32 @@ func (h *UserHandler) GetProfile(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, user)
 }
 
-// GetProfile godoc
+// GetUserList godoc
 //
-//	@Summary	获取用户信息
+//	@Summary	获取用户列表
 //	@Schemes
-//	@Description
+//	@Description	分页查询用户，支持按邮箱、姓名、状态、用户类型、角色、注册日期过滤和排序，返回总数
 //	@Tags		用户模块
 //	@Accept		json
 //	@Produce	json
 //	@Security	Bearer
-//	@Success	200	{object}	v1.GetProfileResponse
-//	@Router		/user [get]
+//	@Param		request	body		v1.GetUserListRequest	true	"params"
+//	@Success	200		{object}	v1.GetUserListResponse
+//	@Router		/user/list [post]
 func (h *UserHandler) GetUserList(ctx *gin.Context) {
-	user, err := h.userService.GetUserList(ctx)
-	if err != nil {
+	var req v1.GetUserListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
 		return
 	}
 
+	user, err := h.userService.GetUserList(ctx, &req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("userService.GetUserList error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+
 	v1.HandleSuccess(ctx, user)
 }
 
@@ -130,14 +214,14 @@ func (h *UserHandler) GetUserList(ctx *gin.Context) {
 //
 //	@Summary	修改用户信息
 //	@Schemes
-//	@Description
-//	@Tags		用户模块
-//	@Accept		json
-//	@Produce	json
-//	@Security	Bearer
-//	@Param		request	body		v1.UpdateProfileRequest	true	"params"
-//	@Success	200		{object}	v1.Response
-//	@Router		/user [put]
+//	@Description	image 为上传接口返回的地址，服务端校验后去掉 EXIF 并生成缩略图；原样提交当前地址表示不修改
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UpdateProfileRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/profile [put]
 func (h *UserHandler) UpdateProfile(ctx *gin.Context) {
 	userId := GetUserIdFromCtx(ctx)
 
@@ -148,7 +232,7 @@ func (h *UserHandler) UpdateProfile(ctx *gin.Context) {
 	}
 
 	if err := h.userService.UpdateProfile(ctx, userId, &req); err != nil {
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		h.handleAvatarError(ctx, "userService.UpdateProfile error", err)
 		return
 	}
 
@@ -162,10 +246,240 @@ func (h *UserHandler) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.userService.UpdateUser(ctx, &req); err != nil {
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	if err := h.userService.UpdateUser(ctx, GetUserIdFromCtx(ctx), &req); err != nil {
+		h.handleAvatarError(ctx, "userService.UpdateUser error", err)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UnlockUser godoc
+//
+//	@Summary	解锁账号
+//	@Schemes
+//	@Description	清除账号的登录失败次数和锁定状态
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UnlockUserRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/user/unlock [post]
+func (h *UserHandler) UnlockUser(ctx *gin.Context) {
+	var req v1.UnlockUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.userService.UnlockUser(ctx, &req); err != nil {
+		h.handleUserAdminError(ctx, "userService.UnlockUser error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// CreateUser godoc
+//
+//	@Summary	创建用户
+//	@Schemes
+//	@Description	管理员创建用户并分配初始角色，邮箱视为已验证
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.CreateUserRequest	true	"params"
+//	@Success		200		{object}	v1.CreateUserResponse
+//	@Router			/user [post]
+func (h *UserHandler) CreateUser(ctx *gin.Context) {
+	var req v1.CreateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.userService.CreateUser(ctx, GetUserIdFromCtx(ctx), &req)
+	if err != nil {
+		h.handleUserAdminError(ctx, "userService.CreateUser error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// DisableUser godoc
+//
+//	@Summary	禁用用户
+//	@Schemes
+//	@Description	禁用后立即不能登录和访问接口，已有会话全部吊销
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UserIdRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/user/disable [post]
+func (h *UserHandler) DisableUser(ctx *gin.Context) {
+	var req v1.UserIdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.userService.DisableUser(ctx, GetUserIdFromCtx(ctx), req.UserId); err != nil {
+		h.handleUserAdminError(ctx, "userService.DisableUser error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// EnableUser godoc
+//
+//	@Summary	启用用户
+//	@Schemes
+//	@Description
+//	@Tags		用户模块
+//	@Accept		json
+//	@Produce	json
+//	@Security	Bearer
+//	@Param		request	body		v1.UserIdRequest	true	"params"
+//	@Success	200		{object}	v1.Response
+//	@Router		/user/enable [post]
+func (h *UserHandler) EnableUser(ctx *gin.Context) {
+	var req v1.UserIdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.userService.EnableUser(ctx, req.UserId); err != nil {
+		h.handleUserAdminError(ctx, "userService.EnableUser error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeactivateUser godoc
+//
+//	@Summary	注销用户
+//	@Schemes
+//	@Description	软删除用户并吊销全部 token，可以在已删除列表中恢复
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UserIdRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/user/deactivate [post]
+func (h *UserHandler) DeactivateUser(ctx *gin.Context) {
+	var req v1.UserIdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
 		return
 	}
 
+	if err := h.userService.DeactivateUser(ctx, GetUserIdFromCtx(ctx), req.UserId); err != nil {
+		h.handleUserAdminError(ctx, "userService.DeactivateUser error", err)
+		return
+	}
 	v1.HandleSuccess(ctx, nil)
 }
+
+// GetDeletedUserList godoc
+//
+//	@Summary	已删除用户列表
+//	@Schemes
+//	@Description	分页查询已注销（软删除）的用户
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	query		v1.GetDeletedUserListRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/user/deleted [get]
+func (h *UserHandler) GetDeletedUserList(ctx *gin.Context) {
+	var req v1.GetDeletedUserListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.userService.GetDeletedUserList(ctx, &req)
+	if err != nil {
+		h.handleUserAdminError(ctx, "userService.GetDeletedUserList error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// RestoreUser godoc
+//
+//	@Summary	恢复用户
+//	@Schemes
+//	@Description	恢复已注销的用户及其角色，需要重新登录
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			request	body		v1.UserIdRequest	true	"params"
+//	@Success		200		{object}	v1.Response
+//	@Router			/user/restore [post]
+func (h *UserHandler) RestoreUser(ctx *gin.Context) {
+	var req v1.UserIdRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.userService.RestoreUser(ctx, GetUserIdFromCtx(ctx), req.UserId); err != nil {
+		h.handleUserAdminError(ctx, "userService.RestoreUser error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+// PurgeUser godoc
+//
+//	@Summary	永久删除用户
+//	@Schemes
+//	@Description	只能删除已注销的用户，同时删除其会话、第三方账号、二次验证和 API key，登录日志保留
+//	@Tags			用户模块
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			id	path		string	true	"user_id"
+//	@Success		200	{object}	v1.Response
+//	@Router			/user/{id} [delete]
+func (h *UserHandler) PurgeUser(ctx *gin.Context) {
+	if err := h.userService.PurgeUser(ctx, GetUserIdFromCtx(ctx), ctx.Param("id")); err != nil {
+		h.handleUserAdminError(ctx, "userService.PurgeUser error", err)
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
+
+func (h *UserHandler) handleUserAdminError(ctx *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, v1.ErrBadRequest):
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+	case errors.Is(err, v1.ErrForbidden):
+		v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, nil)
+	case errors.Is(err, v1.ErrNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, nil)
+	case errors.Is(err, v1.ErrEmailAlreadyUse):
+		v1.HandleError(ctx, http.StatusConflict, v1.ErrEmailAlreadyUse, nil)
+	default:
+		h.logger.WithContext(ctx).Error(msg, zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+	}
+}
+
+// handleAvatarError 头像不合格时返回具体原因，其余错误同 handleUserAdminError
+func (h *UserHandler) handleAvatarError(ctx *gin.Context, msg string, err error) {
+	if errors.Is(err, v1.ErrAvatarInvalid) {
+		reason := strings.TrimPrefix(err.Error(), v1.ErrAvatarInvalid.Error()+": ")
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrAvatarInvalid, map[string]string{"reason": reason})
+		return
+	}
+	h.handleUserAdminError(ctx, msg, err)
+}
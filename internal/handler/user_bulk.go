@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/service"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxUserImportSize 导入文件的大小上限
+const maxUserImportSize = 10 << 20
+
+type UserBulkHandler struct {
+	*Handler
+	userBulkService service.UserBulkService
+}
+
+func NewUserBulkHandler(handler *Handler, userBulkService service.UserBulkService) *UserBulkHandler {
+	return &UserBulkHandler{
+		Handler:         handler,
+		userBulkService: userBulkService,
+	}
+}
+
+// ImportUsers godoc
+//
+//	@Summary	批量导入用户
+//	@Schemes
+//	@Description	上传 CSV 或 XLSX，表头包含 email，可选 name、roles（角色 Sid，逗号分隔）。先校验全部行，有错误时返回逐行的错误且不导入任何用户
+//	@Tags			用户模块
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Security		Bearer
+//	@Param			file		formData	file	true	"CSV 或 XLSX 文件"
+//	@Param			dry_run		formData	bool	false	"只校验不写入"
+//	@Param			send_email	formData	bool	false	"发送设置密码的链接"
+//	@Success		200			{object}	v1.ImportUsersResponse
+//	@Router			/user/import [post]
+func (h *UserBulkHandler) ImportUsers(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxUserImportSize)
+	var req v1.ImportUsersRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	if format != service.UserFileCSV && format != service.UserFileXLSX {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrImportFileInvalid, map[string]string{"reason": "only .csv and .xlsx files are supported"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := h.userBulkService.ImportUsers(ctx, GetUserIdFromCtx(ctx), format, file, &req)
+	if err != nil {
+		if errors.Is(err, v1.ErrImportFileInvalid) {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrImportFileInvalid, data)
+			return
+		}
+		h.logger.WithContext(ctx).Error("userBulkService.ImportUsers error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
+
+// ExportUsers godoc
+//
+//	@Summary	导出用户
+//	@Schemes
+//	@Description	过滤条件与用户列表相同，导出全部符合条件的用户
+//	@Tags			用户模块
+//	@Produce		text/csv
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Security		Bearer
+//	@Param			request	query		v1.ExportUsersRequest	true	"params"
+//	@Success		200		{file}		file
+//	@Router			/user/export [get]
+func (h *UserBulkHandler) ExportUsers(ctx *gin.Context) {
+	var req v1.ExportUsersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if req.Format == "" {
+		req.Format = service.UserFileCSV
+	}
+	// 写出响应头之前确认格式，之后出错只能中断连接
+	if req.Format != service.UserFileCSV && req.Format != service.UserFileXLSX {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == service.UserFileXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.%s"`, time.Now().Format("20060102"), req.Format))
+	ctx.Status(http.StatusOK)
+	// 响应头已经发出，出错时只能记录日志并中断连接
+	if err := h.userBulkService.ExportUsers(ctx, &req, ctx.Writer); err != nil {
+		h.logger.WithContext(ctx).Error("userBulkService.ExportUsers error", zap.Error(err))
+		ctx.Abort()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/log"
+	"net/http"
+	"strings"
+
+	"github.com/casbin/casbin/v2/util"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyAuthPrefix = "ApiKey "
+	ctxApiKey        = "apiKey"
+)
+
+// ApiKeyVerifier 校验 API key，由 service.ApiKeyService 实现
+type ApiKeyVerifier interface {
+	VerifyApiKey(ctx context.Context, key string, ip string) (*model.ApiKey, error)
+}
+
+// ApiKeyAuth 接受 "Authorization: ApiKey <key>"，其余请求交给 StrictAuth 校验 JWT
+// 通过 key 认证的请求在 AuthMiddleware 中还要满足 key 的 scope
+func ApiKeyAuth(verifier ApiKeyVerifier, j *jwt.JWT, logger *log.Logger) gin.HandlerFunc {
+	strictAuth := StrictAuth(j, logger)
+	return func(ctx *gin.Context) {
+		header := ctx.Request.Header.Get("Authorization")
+		if !strings.HasPrefix(header, apiKeyAuthPrefix) {
+			strictAuth(ctx)
+			return
+		}
+
+		key, err := verifier.VerifyApiKey(ctx, strings.TrimPrefix(header, apiKeyAuthPrefix), ctx.ClientIP())
+		if err != nil {
+			logger.WithContext(ctx).Warn("api key error", zap.Any("data", map[string]interface{}{
+				"url":    ctx.Request.URL,
+				"params": ctx.Params,
+			}), zap.Error(err))
+			v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+			ctx.Abort()
+			return
+		}
+
+		setClaims(ctx, &jwt.MyCustomClaims{UserId: key.UserId, Scope: jwt.ScopeApiKey})
+		ctx.Set(ctxApiKey, key)
+		recoveryLoggerFunc(ctx, logger)
+		ctx.Next()
+	}
+}
+
+// apiKeyAllows 判断请求是否落在 key 的 scope 内，path 按 keyMatch2 匹配
+func apiKeyAllows(key *model.ApiKey, method string, path string) bool {
+	for _, scope := range key.ScopeList() {
+		m, p, err := model.ParseApiKeyScope(scope)
+		if err != nil {
+			continue
+		}
+		if (m == model.ApiKeyScopeAll || m == method) && util.KeyMatch2(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,16 +1,19 @@
 package middleware
 
 import (
+	"fmt"
 	v1 "go-nunu/api/v1"
 	"go-nunu/pkg/jwt"
 	"go-nunu/pkg/log"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func StrictAuth(j *jwt.JWT, logger *log.Logger) gin.HandlerFunc {
+// StrictAuth 只接受正式的 access token，scopes 为额外允许的受限 token，例如访客接口传入 jwt.ScopeGuest
+func StrictAuth(j *jwt.JWT, logger *log.Logger, scopes ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		tokenString := ctx.Request.Header.Get("Authorization")
 		if tokenString == "" {
@@ -24,6 +27,9 @@ func StrictAuth(j *jwt.JWT, logger *log.Logger) gin.HandlerFunc {
 		}
 
 		claims, err := j.ParseToken(tokenString)
+		if err == nil && claims.Scope != "" && !slices.Contains(scopes, claims.Scope) {
+			err = fmt.Errorf("token scope %q is not allowed", claims.Scope)
+		}
 		if err != nil {
 			logger.WithContext(ctx).Error("token error", zap.Any("data", map[string]interface{}{
 				"url":    ctx.Request.URL,
@@ -34,7 +40,18 @@ func StrictAuth(j *jwt.JWT, logger *log.Logger) gin.HandlerFunc {
 			return
 		}
 
-		ctx.Set("claims", claims)
+		revoked, err := j.IsRevoked(ctx, claims)
+		if err != nil || revoked {
+			logger.WithContext(ctx).Warn("token revoked", zap.Any("data", map[string]interface{}{
+				"url":      ctx.Request.URL,
+				"token_id": claims.ID,
+			}), zap.Error(err))
+			v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, nil)
+			ctx.Abort()
+			return
+		}
+
+		setClaims(ctx, claims)
 		recoveryLoggerFunc(ctx, logger)
 		ctx.Next()
 	}
@@ -55,17 +72,24 @@ func NoStrictAuth(j *jwt.JWT, logger *log.Logger) gin.HandlerFunc {
 		}
 
 		claims, err := j.ParseToken(tokenString)
-		if err != nil {
+		// 受限 token 按未登录处理
+		if err != nil || claims.Scope != "" {
 			ctx.Next()
 			return
 		}
 
-		ctx.Set("claims", claims)
+		setClaims(ctx, claims)
 		recoveryLoggerFunc(ctx, logger)
 		ctx.Next()
 	}
 }
 
+// setClaims 同时写入请求的 context，下游通过 jwt.ClaimsFromContext 读取，需要 engine 开启 ContextWithFallback
+func setClaims(ctx *gin.Context, claims *jwt.MyCustomClaims) {
+	ctx.Set("claims", claims)
+	ctx.Request = ctx.Request.WithContext(jwt.WithClaims(ctx.Request.Context(), claims))
+}
+
 func recoveryLoggerFunc(ctx *gin.Context, logger *log.Logger) {
 	if userInfo, ok := ctx.MustGet("claims").(*jwt.MyCustomClaims); ok {
 		logger.WithValue(ctx, zap.String("UserId", userInfo.UserId))
@@ -1,27 +1,19 @@
 package middleware
 
 import (
-	"fmt"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
 	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/log"
 	"net/http"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/duke-git/lancet/v2/convertor"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-func Check(e *casbin.CachedEnforcer, sub, obj, act string) {
-	ok, _ := e.Enforce(sub, obj, act)
-	if ok {
-		fmt.Printf("%s CAN %s %s\n", sub, act, obj)
-	} else {
-		fmt.Printf("%s CANNOT %s %s\n", sub, act, obj)
-	}
-}
-
-func AuthMiddleware(e *casbin.CachedEnforcer) gin.HandlerFunc {
+func AuthMiddleware(e *casbin.SyncedCachedEnforcer, logger *log.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 从上下文获取用户信息（假设通过 JWT 或其他方式设置）
 		v, exists := ctx.Get("claims")
@@ -31,26 +23,33 @@ func AuthMiddleware(e *casbin.CachedEnforcer) gin.HandlerFunc {
 			return
 		}
 		uid := v.(*jwt.MyCustomClaims).UserId
+		// API key 的 scope 与用户自身权限取交集，超管的 key 同样受 scope 限制
+		if key, ok := ctx.Get(ctxApiKey); ok && !apiKeyAllows(key.(*model.ApiKey), ctx.Request.Method, ctx.Request.URL.Path) {
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, nil)
+			ctx.Abort()
+			return
+		}
 		if convertor.ToString(uid) == model.AdminUserID {
 			// 防呆设计，超管跳过API权限检查
 			ctx.Next()
 			return
 		}
-		// TODO: 这里可以根据需要添加更多的上下文信息，例如用户角色等
-		// 获取请求的资源和操作
-		role := v.(*jwt.MyCustomClaims).Role
-		sub := convertor.ToString(role)
+		// 以用户为主体，角色通过数据库同步过来的 g 规则解析，任一角色允许即放行
+		// 这样角色变更立即生效，不依赖 token 中签发时的角色
+		sub := model.UserSubject(uid)
+		// 用实际请求路径，策略中的 :id、/* 由 model.conf 的 keyMatch2 匹配
 		obj := model.ApiResourcePrefix + ctx.Request.URL.Path
 		act := ctx.Request.Method
 
 		// 检查权限
 		allowed, err := e.Enforce(sub, obj, act)
-		fmt.Printf("RBAC Check: sub=%s, obj=%s, act=%s, allowed=%v\n", sub, obj, act, allowed)
 		if err != nil {
+			logger.WithContext(ctx).Error("casbin enforce error", zap.String("sub", sub), zap.String("obj", obj), zap.String("act", act), zap.Error(err))
 			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, nil)
 			ctx.Abort()
 			return
 		}
+		logger.WithContext(ctx).Debug("rbac check", zap.String("sub", sub), zap.String("obj", obj), zap.String("act", act), zap.Bool("allowed", allowed))
 		if !allowed {
 			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, nil)
 			ctx.Abort()
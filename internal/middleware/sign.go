@@ -1,51 +1,39 @@
 package middleware
 
 import (
+	"errors"
 	v1 "go-nunu/api/v1"
 	"go-nunu/pkg/log"
+	"go-nunu/pkg/sign"
 	"net/http"
-	"sort"
-	"strings"
 
-	"github.com/duke-git/lancet/v2/cryptor"
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
-func SignMiddleware(logger *log.Logger, conf *viper.Viper) gin.HandlerFunc {
+// SignMiddleware 校验 App-Key、Timestamp、Nonce、Sign、App-Version 请求头，拒绝过期和重放的请求
+// 按路由组挂载，例如只给移动端接口开启，管理后台不需要签名:
+//
+//	r.Group("/app").Use(middleware.SignMiddleware(deps.Logger, deps.Signer))
+func SignMiddleware(logger *log.Logger, verifier *sign.Verifier) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		requiredHeaders := []string{"Timestamp", "Nonce", "Sign", "App-Version"}
-
-		for _, header := range requiredHeaders {
-			value, ok := ctx.Request.Header[header]
-			if !ok || len(value) == 0 {
+		err := verifier.Verify(ctx, sign.Headers{
+			AppKey:     ctx.Request.Header.Get("App-Key"),
+			Timestamp:  ctx.Request.Header.Get("Timestamp"),
+			Nonce:      ctx.Request.Header.Get("Nonce"),
+			AppVersion: ctx.Request.Header.Get("App-Version"),
+			Sign:       ctx.Request.Header.Get("Sign"),
+		})
+		if err != nil {
+			if errors.Is(err, sign.ErrInvalid) {
+				logger.WithContext(ctx).Warn("sign error", zap.Any("data", map[string]interface{}{
+					"url": ctx.Request.URL,
+				}), zap.Error(err))
 				v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
-				ctx.Abort()
-				return
+			} else {
+				logger.WithContext(ctx).Error("verifier.Verify error", zap.Error(err))
+				v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, nil)
 			}
-		}
-
-		data := map[string]string{
-			"AppKey":     conf.GetString("security.api_sign.app_key"),
-			"Timestamp":  ctx.Request.Header.Get("Timestamp"),
-			"Nonce":      ctx.Request.Header.Get("Nonce"),
-			"AppVersion": ctx.Request.Header.Get("App-Version"),
-		}
-
-		var keys []string
-		for k := range data {
-			keys = append(keys, k)
-		}
-		sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
-
-		var str string
-		for _, k := range keys {
-			str += k + data[k]
-		}
-		str += conf.GetString("security.api_sign.app_security")
-
-		if ctx.Request.Header.Get("Sign") != strings.ToUpper(cryptor.Md5String(str)) {
-			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
 			ctx.Abort()
 			return
 		}
@@ -6,4 +6,12 @@ const (
 	// MenuResourcePrefix = "menu:"
 	// ApiResourcePrefix  = "api:"
 	PermSep            = ","
-)
\ No newline at end of file
+)
+
+// UserSubjectPrefix Casbin 中用户主体的前缀，避免与角色 Sid 冲突
+// 用户与角色的关系写成 g 规则: g, user:<UserId>, <Role.Sid>
+const UserSubjectPrefix = "user:"
+
+func UserSubject(userId string) string {
+	return UserSubjectPrefix + userId
+}
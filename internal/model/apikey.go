@@ -0,0 +1,56 @@
+package model
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ApiKeyScopeAll 匹配任意请求方法
+const ApiKeyScopeAll = "*"
+
+// ApiKey 个人 API key，用于脚本等机器调用，只存 hash
+// Scopes 为逗号分隔的 "METHOD /path"，path 支持 keyMatch2 通配 (例如 "GET /v1/user/*")
+type ApiKey struct {
+	BaseModel
+	UserId     string     `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	Name       string     `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Prefix     string     `gorm:"column:prefix;type:varchar(16);not null" json:"prefix"` // key 的前几位，便于用户辨认
+	KeyHash    string     `gorm:"column:key_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"column:scopes;type:text;not null" json:"-"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expires_at"` // 为空表示不过期
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	LastUsedIP string     `gorm:"column:last_used_ip;type:varchar(45);not null;default:''" json:"last_used_ip"`
+}
+
+func (m *ApiKey) TableName() string {
+	return "api_keys"
+}
+
+func (m *ApiKey) ScopeList() []string {
+	if m.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(m.Scopes, PermSep)
+}
+
+func (m *ApiKey) Expired() bool {
+	return m.ExpiresAt != nil && time.Now().After(*m.ExpiresAt)
+}
+
+// ParseApiKeyScope 解析 "METHOD /path"
+func ParseApiKeyScope(scope string) (method string, path string, err error) {
+	method, path, ok := strings.Cut(strings.TrimSpace(scope), " ")
+	path = strings.TrimSpace(path)
+	if !ok || !strings.HasPrefix(path, "/") || strings.Contains(path, PermSep) {
+		return "", "", fmt.Errorf("invalid scope %q, want \"METHOD /path\"", scope)
+	}
+	method = strings.ToUpper(method)
+	switch method {
+	case ApiKeyScopeAll, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return "", "", fmt.Errorf("invalid scope %q, unsupported method %s", scope, method)
+	}
+	return method, path, nil
+}
@@ -0,0 +1,14 @@
+package model
+
+import "strconv"
+
+// AvatarOriginal 去掉 EXIF 后重新编码的原图
+const AvatarOriginal = "original"
+
+// AvatarKeys 头像在对象存储中的 key，AvatarOriginal 或缩略图边长（如 "128"）-> key
+type AvatarKeys map[string]string
+
+// AvatarSizeName 缩略图在 AvatarKeys 中的名称
+func AvatarSizeName(size int) string {
+	return strconv.Itoa(size)
+}
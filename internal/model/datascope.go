@@ -0,0 +1,9 @@
+package model
+
+// Role.DataScope 行级数据权限，数值越小范围越大
+// 用户有多个角色（含继承的角色）时取范围最大的一个
+const (
+	DataScopeAll  = 1 // 全部数据
+	DataScopeDept = 2 // 本部门数据
+	DataScopeSelf = 3 // 仅本人数据
+)
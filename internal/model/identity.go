@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// UserIdentity 用户绑定的第三方账号，一个用户可以绑定多个
+type UserIdentity struct {
+	BaseModel
+	UserId      string     `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	Provider    string     `gorm:"column:provider;type:varchar(64);not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string     `gorm:"column:subject;type:varchar(255);not null;uniqueIndex:idx_identity_provider_subject" json:"subject"` // provider 内的用户唯一标识
+	Email       string     `gorm:"column:email;type:varchar(255);not null;default:''" json:"email"`
+	Name        string     `gorm:"column:name;type:varchar(255);not null;default:''" json:"name"`
+	Avatar      string     `gorm:"column:avatar;type:varchar(1024);not null;default:''" json:"avatar"`
+	LastLoginAt *time.Time `gorm:"column:last_login_at" json:"last_login_at"`
+}
+
+func (m *UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// OAuthState 授权请求的 state，保存 PKCE code_verifier 和 nonce，回调时一次性消费
+type OAuthState struct {
+	BaseModel
+	StateHash    string     `gorm:"column:state_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	Provider     string     `gorm:"column:provider;type:varchar(64);not null" json:"provider"`
+	CodeVerifier string     `gorm:"column:code_verifier;type:varchar(128);not null" json:"-"`
+	Nonce        string     `gorm:"column:nonce;type:varchar(128);not null" json:"-"`
+	UserId       string     `gorm:"column:user_id;type:varchar(64);not null;default:''" json:"user_id"` // 非空表示已登录用户绑定新的第三方账号
+	ExpiresAt    time.Time  `gorm:"column:expires_at;not null;index" json:"expires_at"`
+	UsedAt       *time.Time `gorm:"column:used_at" json:"used_at"`
+}
+
+func (m *OAuthState) TableName() string {
+	return "oauth_states"
+}
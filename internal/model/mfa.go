@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// UserTotp 用户的 TOTP 二次验证配置
+// EnabledAt 为空表示已生成密钥但尚未用验证码确认绑定
+type UserTotp struct {
+	BaseModel
+	UserId       string     `gorm:"column:user_id;type:varchar(64);not null;uniqueIndex" json:"user_id"`
+	Secret       string     `gorm:"column:secret;type:varchar(64);not null" json:"-"`
+	EnabledAt    *time.Time `gorm:"column:enabled_at" json:"enabled_at"`
+	LastUsedStep int64      `gorm:"column:last_used_step;not null;default:0" json:"-"` // 最近一次通过校验的时间步，防止验证码重放
+}
+
+func (m *UserTotp) TableName() string {
+	return "user_totp"
+}
+
+func (m *UserTotp) Enabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
+
+// MfaRecoveryCode 一次性恢复码，只存 hash
+type MfaRecoveryCode struct {
+	BaseModel
+	UserId   string     `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	CodeHash string     `gorm:"column:code_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	UsedAt   *time.Time `gorm:"column:used_at" json:"used_at"`
+}
+
+func (m *MfaRecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
@@ -38,10 +38,31 @@ type Permission struct {
 
 	// 6. 后端鉴权信息 (Type=3 按钮时必填)
 	// Casbin 或 中间件鉴权时，就匹配这两个字段
-	Api    string `gorm:"column:api;type:varchar(255)" json:"api"`      // 接口路径: "/v1/user/:id"
-	Method string `gorm:"column:method;type:varchar(10)" json:"method"` // 请求方法: "GET", "POST", "DELETE"
+	Api    string `gorm:"column:api;type:varchar(255)" json:"api"`      // 接口路径: "/v1/user/:id"，支持 "/v1/user/*" 通配
+	Method string `gorm:"column:method;type:varchar(10)" json:"method"` // 请求方法: "GET", "POST", "DELETE"，"*" 表示任意方法
+	// Stale 路由同步时发现 Api 已没有对应的路由，保留记录由管理员确认后删除
+	Stale bool `gorm:"column:stale;not null;default:false" json:"stale"`
 }
 
 func (m *Permission) TableName() string {
 	return "permission"
 }
+
+// PolicyObject Casbin 策略中的 obj，菜单和目录取前端路由，按钮取接口路径；缺少必填字段时返回空
+func (m *Permission) PolicyObject() string {
+	switch m.Type {
+	case PermissionTypeDirectory:
+		if m.Path != "" {
+			return DirectoryResourcePrefix + m.Path
+		}
+	case PermissionTypeMenu:
+		if m.Path != "" {
+			return MenuResourcePrefix + m.Path
+		}
+	case PermissionTypeButton:
+		if m.Api != "" {
+			return ApiResourcePrefix + m.Api
+		}
+	}
+	return ""
+}
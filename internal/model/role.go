@@ -8,9 +8,27 @@ type Role struct {
 	Sid  string `json:"sid" gorm:"column:sid;type:varchar(100);uniqueIndex;comment:角色标识"`
 	// Key    string `gorm:"column:key;type:varchar(50);not null;unique" json:"key"`
 	// Status int    `gorm:"column:status;type:tinyint;default:1" json:"status"`
+	// DataScope 行级数据权限，见 DataScopeAll 等常量
+	DataScope   int          `json:"data_scope" gorm:"column:data_scope;type:tinyint;not null;default:1;comment:数据权限"`
+	CreatedBy   string       `json:"created_by" gorm:"column:created_by;type:varchar(64);not null;default:'';index;comment:创建人"`
 	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
+	// Parents 继承的角色，拥有父角色的全部权限，同步为 Casbin g 规则: g, <Sid>, <Parent.Sid>
+	Parents []Role `json:"parents" gorm:"many2many:role_parents;joinForeignKey:RoleID;joinReferences:ParentID"`
 }
 
 func (m *Role) TableName() string {
 	return "roles"
 }
+
+// RolePolicies 角色权限对应的 Casbin p 规则: <Sid>, <obj>, <method>，缺少 obj 或 method 的权限不参与鉴权
+func RolePolicies(sid string, permissions []Permission) [][]string {
+	var rules [][]string
+	for _, perm := range permissions {
+		obj := perm.PolicyObject()
+		if obj == "" || perm.Method == "" {
+			continue
+		}
+		rules = append(rules, []string{sid, obj, perm.Method})
+	}
+	return rules
+}
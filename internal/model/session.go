@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// ClientInfo 发起登录请求的客户端，由 handler 从请求中取出
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+const (
+	LoginResultSuccess          = "success"
+	LoginResultFailed           = "failed" // 密码错误或邮箱不存在
+	LoginResultLocked           = "locked"
+	LoginResultEmailNotVerified = "email_not_verified"
+	LoginResultMfaFailed        = "mfa_failed"
+	LoginResultDisabled         = "disabled"
+)
+
+// LoginLog 登录记录，成功和失败都会记录
+type LoginLog struct {
+	BaseModel
+	UserId    string `gorm:"column:user_id;type:varchar(64);not null;default:'';index" json:"user_id"` // 邮箱不存在时为空
+	Email     string `gorm:"column:email;type:varchar(255);not null;default:''" json:"email"`
+	IP        string `gorm:"column:ip;type:varchar(45);not null;default:''" json:"ip"`
+	UserAgent string `gorm:"column:user_agent;type:varchar(512);not null;default:''" json:"user_agent"`
+	LoginType int    `gorm:"column:login_type;type:tinyint;not null;default:0" json:"login_type"` // 同 User.LastLoginType
+	Result    string `gorm:"column:result;type:varchar(32);not null;index" json:"result"`
+}
+
+func (m *LoginLog) TableName() string {
+	return "login_logs"
+}
+
+// Session 一次登录对应一个会话，与刷新令牌 family 一一对应
+// TokenId 是当前有效的 access token ID (jti)，刷新后随之更新
+type Session struct {
+	BaseModel
+	UserId       string     `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	TokenId      string     `gorm:"column:token_id;type:varchar(64);not null;uniqueIndex" json:"-"`
+	FamilyId     string     `gorm:"column:family_id;type:varchar(64);not null;uniqueIndex" json:"-"`
+	IP           string     `gorm:"column:ip;type:varchar(45);not null;default:''" json:"ip"`
+	UserAgent    string     `gorm:"column:user_agent;type:varchar(512);not null;default:''" json:"user_agent"`
+	LoginType    int        `gorm:"column:login_type;type:tinyint;not null;default:0" json:"login_type"`
+	LastActiveAt time.Time  `gorm:"column:last_active_at;not null" json:"last_active_at"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	RevokedAt    *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+}
+
+func (m *Session) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// RefreshToken 服务端保存的刷新令牌，只存 hash
+// 同一次登录派生出的刷新令牌共享 FamilyId，检测到重放时整个 family 一起吊销
+type RefreshToken struct {
+	BaseModel
+	UserId        string     `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	FamilyId      string     `gorm:"column:family_id;type:varchar(64);not null;index" json:"family_id"`
+	TokenHash     string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	AccessTokenId string     `gorm:"column:access_token_id;type:varchar(64);index" json:"access_token_id"` // 与该刷新令牌一同签发的 access token ID (jti)
+	ExpiresAt     time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt        *time.Time `gorm:"column:used_at" json:"used_at"`       // 已轮换，再次使用即视为重放
+	RevokedAt     *time.Time `gorm:"column:revoked_at" json:"revoked_at"` // 已吊销
+}
+
+func (m *RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RevokedToken access token 黑名单，ExpiresAt 之后即可清理
+type RevokedToken struct {
+	BaseModel
+	TokenId   string    `gorm:"column:token_id;type:varchar(64);not null;uniqueIndex" json:"token_id"`
+	UserId    string    `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;index" json:"expires_at"`
+}
+
+func (m *RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
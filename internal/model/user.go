@@ -1,5 +1,7 @@
 package model
 
+import "strings"
+
 type User struct {
 	BaseModel
 	UserId   string `gorm:"unique;not null" json:"user_id"`
@@ -10,22 +12,62 @@ type User struct {
 	// DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	// ID              int                   `gorm:"column:id;type:int;primaryKey;autoIncrement:true" json:"id"`                 // 主键ID
-	Name            string `gorm:"column:name;type:varchar(255);not null" json:"name"`                      // 用户姓名
-	Image           string `gorm:"column:image;type:varchar(255);not null" json:"image"`                    // image
-	Gender          int    `gorm:"column:gender;type:tinyint;not null;default 0" json:"gender"`             // 性别 1.男 2.女
-	Udid            string `gorm:"column:udid;type:varchar(255);not null" json:"udid"`                      // 设备唯一标识
-	UserType        int    `gorm:"column:user_type;type:tinyint;not null" json:"user_type"`                 // 用户类型 1访客用户 2注册用户 3假用户
-	IsSubmitProfile int    `gorm:"column:is_submit_profile;type:tinyint;not null" json:"is_submit_profile"` // 是否已经上传过个人资料 1.是 2.否
-	Status          int    `gorm:"column:status;type:tinyint;not null" json:"status"`                       // 状态 0未知状态 1正常 2禁用 3注销
-	CountryID       int    `gorm:"column:country_id;type:int;not null" json:"country_id"`                   // 国家ID
-	RegisterIP      string `gorm:"column:register_ip;type:varchar(45);not null" json:"register_ip"`         // 注册IP
-	RegisterTime    int    `gorm:"column:register_time;type:int;not null" json:"register_time"`             // 注册时间
-	RegisterType    int    `gorm:"column:register_type;type:tinyint;not null" json:"register_type"`         // 注册类型 0游客 1微信服务号 2微信小程序
-	LastLoginIP     string `gorm:"column:last_login_ip;type:varchar(45);not null" json:"last_login_ip"`     // 最后登录IP
-	LastLoginTime   int    `gorm:"column:last_login_time;type:int;not null" json:"last_login_time"`         // 最后登录时间
-	LastLoginType   int    `gorm:"column:last_login_type;type:tinyint;not null" json:"last_login_type"`     // 最后登录类型 0游客 1微信服务号 2微信小程序
-	DeactivateTime  int    `gorm:"column:deactivate_time;type:int;not null" json:"deactivate_time"`         // 注销时间
-	Roles           []Role `gorm:"many2many:sys_user_roles;" json:"roles"`                                  // role
+	Name            string `gorm:"column:name;type:varchar(255);not null" json:"name"`                            // 用户姓名
+	Image           string `gorm:"column:image;type:varchar(255);not null" json:"image"`                          // image
+	Gender          int    `gorm:"column:gender;type:tinyint;not null;default 0" json:"gender"`                   // 性别 1.男 2.女
+	Udid            string `gorm:"column:udid;type:varchar(255);not null" json:"udid"`                            // 设备唯一标识
+	UserType        int    `gorm:"column:user_type;type:tinyint;not null" json:"user_type"`                       // 用户类型 1访客用户 2注册用户 3假用户
+	IsSubmitProfile int    `gorm:"column:is_submit_profile;type:tinyint;not null" json:"is_submit_profile"`       // 是否已经上传过个人资料 1.是 2.否
+	Status          int    `gorm:"column:status;type:tinyint;not null" json:"status"`                             // 状态 0未知状态 1正常 2禁用 3注销
+	CountryID       int    `gorm:"column:country_id;type:int;not null" json:"country_id"`                         // 国家ID
+	RegisterIP      string `gorm:"column:register_ip;type:varchar(45);not null" json:"register_ip"`               // 注册IP
+	RegisterTime    int    `gorm:"column:register_time;type:int;not null" json:"register_time"`                   // 注册时间（Unix 秒），邮箱注册和初始化数据为 0，列表按 created_at 过滤和排序
+	RegisterType    int    `gorm:"column:register_type;type:tinyint;not null" json:"register_type"`               // 注册类型 0游客 1微信服务号 2微信小程序 3第三方登录 4邮箱
+	LastLoginIP     string `gorm:"column:last_login_ip;type:varchar(45);not null" json:"last_login_ip"`           // 最后登录IP
+	LastLoginTime   int    `gorm:"column:last_login_time;type:int;not null" json:"last_login_time"`               // 最后登录时间
+	LastLoginType   int    `gorm:"column:last_login_type;type:tinyint;not null" json:"last_login_type"`           // 最后登录类型 0游客 1微信服务号 2微信小程序 3第三方登录 4邮箱
+	DeactivateTime  int    `gorm:"column:deactivate_time;type:int;not null" json:"deactivate_time"`               // 注销时间
+	EmailVerifyTime int    `gorm:"column:email_verify_time;type:int;not null;default:0" json:"email_verify_time"` // 邮箱验证时间 0未验证
+	DeptId          uint   `gorm:"column:dept_id;not null;default:0;index" json:"dept_id"`                        // 部门ID 0未分配，用于本部门数据权限
+	CreatedBy       string `gorm:"column:created_by;type:varchar(64);not null;default:''" json:"created_by"`      // 创建人 UserId，自助注册为空
+	Roles           []Role `gorm:"many2many:sys_user_roles;" json:"roles" swaggertype:"array,object"`             // role
+
+	// AvatarKeys 服务端处理后的头像，Image 为其中原图的地址
+	AvatarKeys AvatarKeys `gorm:"column:avatar_keys;type:text;serializer:json" json:"-"`
+}
+
+// RegisterType / LastLoginType
+const (
+	LoginTypeGuest          = 0
+	LoginTypeWechatOfficial = 1
+	LoginTypeWechatMini     = 2
+	LoginTypeOAuth          = 3
+	LoginTypeEmail          = 4
+)
+
+// UserType
+const (
+	UserTypeGuest      = 1
+	UserTypeRegistered = 2
+	UserTypeFake       = 3
+)
+
+// Status
+const (
+	UserStatusUnknown     = 0 // 自助注册的历史数据，按正常处理
+	UserStatusNormal      = 1
+	UserStatusDisabled    = 2
+	UserStatusDeactivated = 3 // 注销后同时软删除，可以恢复
+)
+
+// Active 禁用或注销的用户不能登录，已签发的 token 也立即失效
+func (u *User) Active() bool {
+	return u.Status != UserStatusDisabled && u.Status != UserStatusDeactivated
+}
+
+// NormalizeEmail 邮箱统一去掉首尾空格并转为小写后保存和查询
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 func (u *User) TableName() string {
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+const (
+	VerificationPurposePasswordReset = "password_reset"
+	VerificationPurposeEmailVerify   = "email_verify"
+)
+
+// VerificationToken 一次性、会过期的邮件令牌（找回密码、邮箱验证），只存 hash
+type VerificationToken struct {
+	BaseModel
+	UserId    string     `gorm:"column:user_id;type:varchar(64);not null;index" json:"user_id"`
+	Purpose   string     `gorm:"column:purpose;type:varchar(32);not null" json:"purpose"`
+	TokenHash string     `gorm:"column:token_hash;type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at"`
+}
+
+func (m *VerificationToken) TableName() string {
+	return "verification_tokens"
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, key *model.ApiKey) error
+	GetByHash(ctx context.Context, hash string) (*model.ApiKey, error)
+	ListByUser(ctx context.Context, userId string) ([]model.ApiKey, error)
+	Delete(ctx context.Context, userId string, id uint) error
+	TouchLastUsed(ctx context.Context, id uint, ip string, at time.Time) error
+}
+
+func NewApiKeyRepository(
+	r *Repository,
+) ApiKeyRepository {
+	return &apiKeyRepository{
+		Repository: r,
+	}
+}
+
+type apiKeyRepository struct {
+	*Repository
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.ApiKey) error {
+	return r.DB(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*model.ApiKey, error) {
+	var key model.ApiKey
+	if err := r.DB(ctx).Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userId string) ([]model.ApiKey, error) {
+	var keys []model.ApiKey
+	if err := r.DB(ctx).Where("user_id = ?", userId).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, userId string, id uint) error {
+	res := r.DB(ctx).Where("id = ? AND user_id = ?", id, userId).Delete(&model.ApiKey{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return v1.ErrNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, ip string, at time.Time) error {
+	return r.DB(ctx).Model(&model.ApiKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": at,
+		"last_used_ip": ip,
+	}).Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/jwt"
+
+	"gorm.io/gorm"
+)
+
+// DataScopeColumns 表中参与行级数据权限过滤的列
+type DataScopeColumns struct {
+	// Owner 记录归属人 UserId 所在的列，仅本人数据时命中任意一列即可
+	Owner []string
+	// Dept 部门列；为空时通过 Owner 第一列关联到归属人的部门
+	Dept string
+}
+
+var (
+	// 本人数据: 自己以及自己创建的用户
+	userDataScope = DataScopeColumns{Owner: []string{"user_id", "created_by"}, Dept: "dept_id"}
+	roleDataScope = DataScopeColumns{Owner: []string{"created_by"}}
+)
+
+type unscopedKey struct{}
+
+// WithoutDataScope 任务、命令行等没有请求用户的系统内部调用，显式声明不做数据权限过滤
+func WithoutDataScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedKey{}, true)
+}
+
+// DataScope 按当前请求的 claims 过滤数据，查询时通过 db.Scopes 使用
+// 超管和 WithoutDataScope 不做限制；两者都没有时拒绝查询，避免漏传 claims 时返回全部数据
+func (r *Repository) DataScope(ctx context.Context, cols DataScopeColumns) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if unscoped, _ := ctx.Value(unscopedKey{}).(bool); unscoped {
+			return db
+		}
+		claims, ok := jwt.ClaimsFromContext(ctx)
+		if !ok {
+			_ = db.AddError(v1.ErrForbidden)
+			return db
+		}
+		if claims.UserId == model.AdminUserID {
+			return db
+		}
+		scope, deptId, err := r.dataScopeOf(ctx, claims.UserId)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+
+		switch scope {
+		case model.DataScopeAll:
+			return db
+		case model.DataScopeDept:
+			if cols.Dept != "" {
+				return db.Where(cols.Dept+" = ?", deptId)
+			}
+			members := r.db.WithContext(ctx).Model(&model.User{}).Select("user_id").Where("dept_id = ?", deptId)
+			return db.Where(cols.Owner[0]+" IN (?)", members)
+		default:
+			cond := r.db.Where(cols.Owner[0]+" = ?", claims.UserId)
+			for _, col := range cols.Owner[1:] {
+				cond = cond.Or(col+" = ?", claims.UserId)
+			}
+			return db.Where(cond)
+		}
+	}
+}
+
+// dataScopeOf 取用户全部角色（含继承）中范围最大的数据权限
+// 没有角色时只能看本人数据；未分配部门时本部门数据退化为本人数据
+func (r *Repository) dataScopeOf(ctx context.Context, userId string) (int, uint, error) {
+	sids, err := r.e.GetImplicitRolesForUser(model.UserSubject(userId))
+	if err != nil {
+		return 0, 0, err
+	}
+	scope := model.DataScopeSelf
+	if len(sids) > 0 {
+		var scopes []int
+		if err = r.db.WithContext(ctx).Model(&model.Role{}).Where("sid IN ?", sids).Pluck("data_scope", &scopes).Error; err != nil {
+			return 0, 0, err
+		}
+		for _, s := range scopes {
+			if s > 0 {
+				scope = min(scope, s)
+			}
+		}
+	}
+	if scope != model.DataScopeDept {
+		return scope, 0, nil
+	}
+
+	var deptIds []uint
+	if err = r.db.WithContext(ctx).Model(&model.User{}).Where("user_id = ?", userId).Pluck("dept_id", &deptIds).Error; err != nil {
+		return 0, 0, err
+	}
+	if len(deptIds) == 0 || deptIds[0] == 0 {
+		return model.DataScopeSelf, 0, nil
+	}
+	return scope, deptIds[0], nil
+}
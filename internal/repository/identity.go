@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	Update(ctx context.Context, identity *model.UserIdentity) error
+	Get(ctx context.Context, provider string, subject string) (*model.UserIdentity, error)
+	ListByUser(ctx context.Context, userId string) ([]model.UserIdentity, error)
+	Delete(ctx context.Context, userId string, id uint) error
+
+	CreateState(ctx context.Context, state *model.OAuthState) error
+	ConsumeState(ctx context.Context, hash string) (*model.OAuthState, error)
+}
+
+func NewIdentityRepository(
+	r *Repository,
+) IdentityRepository {
+	return &identityRepository{
+		Repository: r,
+	}
+}
+
+type identityRepository struct {
+	*Repository
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	return r.DB(ctx).Create(identity).Error
+}
+
+func (r *identityRepository) Update(ctx context.Context, identity *model.UserIdentity) error {
+	return r.DB(ctx).Save(identity).Error
+}
+
+func (r *identityRepository) Get(ctx context.Context, provider string, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.DB(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) ListByUser(ctx context.Context, userId string) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	if err := r.DB(ctx).Where("user_id = ?", userId).Order("id").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// Delete 物理删除，解绑后同一个第三方账号可以再绑定到其他用户
+func (r *identityRepository) Delete(ctx context.Context, userId string, id uint) error {
+	res := r.DB(ctx).Unscoped().Where("id = ? AND user_id = ?", id, userId).Delete(&model.UserIdentity{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return v1.ErrNotFound
+	}
+	return nil
+}
+
+func (r *identityRepository) CreateState(ctx context.Context, state *model.OAuthState) error {
+	return r.DB(ctx).Create(state).Error
+}
+
+// ConsumeState 原子地标记 state 已使用，不存在、已使用或已过期都返回 ErrNotFound
+func (r *identityRepository) ConsumeState(ctx context.Context, hash string) (*model.OAuthState, error) {
+	var state model.OAuthState
+	if err := r.DB(ctx).Where("state_hash = ?", hash).First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if now.After(state.ExpiresAt) {
+		return nil, v1.ErrNotFound
+	}
+	res := r.DB(ctx).Model(&model.OAuthState{}).
+		Where("id = ? AND used_at IS NULL", state.ID).
+		Update("used_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected != 1 {
+		return nil, v1.ErrNotFound
+	}
+	return &state, nil
+}
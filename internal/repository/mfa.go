@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MfaRepository interface {
+	GetTotp(ctx context.Context, userId string) (*model.UserTotp, error)
+	SaveTotp(ctx context.Context, totp *model.UserTotp) error
+	DeleteTotp(ctx context.Context, userId string) error
+	UseTotpStep(ctx context.Context, userId string, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userId string) (int, error)
+}
+
+func NewMfaRepository(
+	r *Repository,
+) MfaRepository {
+	return &mfaRepository{
+		Repository: r,
+	}
+}
+
+type mfaRepository struct {
+	*Repository
+}
+
+func (r *mfaRepository) GetTotp(ctx context.Context, userId string) (*model.UserTotp, error) {
+	var totp model.UserTotp
+	if err := r.DB(ctx).Where("user_id = ?", userId).First(&totp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &totp, nil
+}
+
+func (r *mfaRepository) SaveTotp(ctx context.Context, totp *model.UserTotp) error {
+	return r.DB(ctx).Save(totp).Error
+}
+
+func (r *mfaRepository) DeleteTotp(ctx context.Context, userId string) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.DB(ctx).Unscoped().Where("user_id = ?", userId).Delete(&model.UserTotp{}).Error; err != nil {
+			return err
+		}
+		return r.DB(ctx).Unscoped().Where("user_id = ?", userId).Delete(&model.MfaRecoveryCode{}).Error
+	})
+}
+
+// UseTotpStep 记录已使用的时间步，同一个或更早的时间步再次出现时返回 false
+func (r *mfaRepository) UseTotpStep(ctx context.Context, userId string, step int64) (bool, error) {
+	res := r.DB(ctx).Model(&model.UserTotp{}).
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userId string, codeHashes []string) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.DB(ctx).Unscoped().Where("user_id = ?", userId).Delete(&model.MfaRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.MfaRecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, model.MfaRecoveryCode{UserId: userId, CodeHash: hash})
+		}
+		return r.DB(ctx).Create(&codes).Error
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userId string, codeHash string) (bool, error) {
+	res := r.DB(ctx).Model(&model.MfaRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userId string) (int, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.MfaRecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&count).Error
+	return int(count), err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
 
@@ -12,6 +13,19 @@ type PermissionRepository interface {
 	GetPermissionList(ctx context.Context, req v1.GetPermissionListRequest) ([]model.Permission, error)
 	GetPermissionCount(ctx context.Context, req v1.GetPermissionListRequest) (int, error)
 	CreatePermission(ctx context.Context, permission *model.Permission) (*model.Permission, error)
+	// ListAllPermissions 按 sort、id 排序返回全部权限，用于构建权限树
+	ListAllPermissions(ctx context.Context) ([]model.Permission, error)
+	GetPermission(ctx context.Context, id uint) (*model.Permission, error)
+	UpdatePermission(ctx context.Context, permission *model.Permission) error
+	// CountPermissionChildren 以该权限为父节点的权限数
+	CountPermissionChildren(ctx context.Context, id uint) (int64, error)
+	// ListPermissionRoles 拥有该权限的角色，预加载角色的全部权限用于重新同步 Casbin
+	ListPermissionRoles(ctx context.Context, id uint) ([]model.Role, error)
+	// DeletePermission 删除权限及其在 role_permissions 中的关联，Key 可以重新使用
+	DeletePermission(ctx context.Context, id uint) error
+	CreatePermissions(ctx context.Context, permissions []model.Permission) error
+	// MarkStale 把 ids 对应的按钮权限标记为失效，其余按钮权限清除标记
+	MarkStale(ctx context.Context, ids []uint) error
 }
 
 func NewPermissionRepository(
@@ -65,6 +79,77 @@ func (r *permissionRepository) GetPermissionCount(ctx context.Context, req v1.Ge
 }
 
 func (r *permissionRepository) CreatePermission(ctx context.Context, permission *model.Permission) (*model.Permission, error) {
-	err := r.db.WithContext(ctx).Create(permission).Error
+	err := r.DB(ctx).Create(permission).Error
 	return permission, err
 }
+
+func (r *permissionRepository) ListAllPermissions(ctx context.Context) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if err := r.DB(ctx).Order("sort, id").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *permissionRepository) GetPermission(ctx context.Context, id uint) (*model.Permission, error) {
+	var permission model.Permission
+	if err := r.DB(ctx).First(&permission, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+func (r *permissionRepository) UpdatePermission(ctx context.Context, permission *model.Permission) error {
+	return r.DB(ctx).Save(permission).Error
+}
+
+func (r *permissionRepository) CountPermissionChildren(ctx context.Context, id uint) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.Permission{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *permissionRepository) ListPermissionRoles(ctx context.Context, id uint) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.DB(ctx).Preload("Permissions").
+		Joins("JOIN role_permissions ON role_permissions.role_id = roles.id").
+		Where("role_permissions.permission_id = ?", id).
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *permissionRepository) DeletePermission(ctx context.Context, id uint) error {
+	if err := r.DB(ctx).Exec("DELETE FROM role_permissions WHERE permission_id = ?", id).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Unscoped().Delete(&model.Permission{}, id).Error
+}
+
+func (r *permissionRepository) CreatePermissions(ctx context.Context, permissions []model.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Create(&permissions).Error
+}
+
+func (r *permissionRepository) MarkStale(ctx context.Context, ids []uint) error {
+	reset := r.DB(ctx).Model(&model.Permission{}).Where("type = ? AND stale = ?", model.PermissionTypeButton, true)
+	if len(ids) > 0 {
+		reset = reset.Where("id NOT IN ?", ids)
+	}
+	if err := reset.Update("stale", false).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Model(&model.Permission{}).
+		Where("type = ? AND id IN ?", model.PermissionTypeButton, ids).
+		Update("stale", true).Error
+}
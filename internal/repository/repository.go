@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"fmt"
+	casbinPkg "go-nunu/pkg/casbin"
+	"go-nunu/pkg/lockout"
 	"go-nunu/pkg/log"
+	"go-nunu/pkg/sign"
 	"go-nunu/pkg/zapgorm2"
+	"sync"
 	"time"
 
 	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/persist"
 	"github.com/glebarez/sqlite"
 	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
@@ -23,7 +28,7 @@ const ctxTxKey = "TxKey"
 type Repository struct {
 	db     *gorm.DB
 	logger *log.Logger
-	e      *casbin.CachedEnforcer
+	e      *casbin.SyncedCachedEnforcer
 	//rdb    *redis.Client
 	//mongo  *mongo.Client
 }
@@ -31,7 +36,7 @@ type Repository struct {
 func NewRepository(
 	logger *log.Logger,
 	db *gorm.DB,
-	e *casbin.CachedEnforcer,
+	e *casbin.SyncedCachedEnforcer,
 	// rdb *redis.Client,
 	//
 	//	mongo *mongo.Client,
@@ -65,8 +70,9 @@ func (r *Repository) DB(ctx context.Context) *gorm.DB {
 	return r.db.WithContext(ctx)
 }
 
+// Transaction 已在事务中时加入外层事务（gorm 使用 SAVEPOINT），不会另开连接
 func (r *Repository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return r.DB(ctx).Transaction(func(tx *gorm.DB) error {
 		ctx = context.WithValue(ctx, ctxTxKey, tx)
 		return fn(ctx)
 	})
@@ -86,15 +92,16 @@ func NewDB(conf *viper.Viper, l *log.Logger) *gorm.DB {
 	switch driver {
 	case "mysql":
 		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger,
+			Logger:         logger,
+			TranslateError: true,
 		})
 	case "postgres":
 		db, err = gorm.Open(postgres.New(postgres.Config{
 			DSN:                  dsn,
 			PreferSimpleProtocol: true, // disables implicit prepared statement usage
-		}), &gorm.Config{})
+		}), &gorm.Config{TranslateError: true})
 	case "sqlite":
-		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
 	default:
 		panic("unknown db driver")
 	}
@@ -130,6 +137,80 @@ func NewRedis(conf *viper.Viper) *redis.Client {
 
 	return rdb
 }
+
+// RedisProvider 登录计数、nonce、Casbin watcher 共用一个 *redis.Client
+// 第一次使用时才连接，全部配置为 memory 时不需要 Redis
+type RedisProvider struct {
+	conf *viper.Viper
+	once sync.Once
+	rdb  *redis.Client
+}
+
+func NewRedisProvider(conf *viper.Viper) (*RedisProvider, func()) {
+	p := &RedisProvider{conf: conf}
+	return p, func() {
+		if p.rdb != nil {
+			_ = p.rdb.Close()
+		}
+	}
+}
+
+func (p *RedisProvider) Client() *redis.Client {
+	p.once.Do(func() {
+		p.rdb = NewRedis(p.conf)
+	})
+	return p.rdb
+}
+
+// NewLoginAttemptStore 登录失败计数的存储，security.login_protection.store: memory 或 redis
+func NewLoginAttemptStore(conf *viper.Viper, rdb *RedisProvider) lockout.Store {
+	switch store := conf.GetString("security.login_protection.store"); store {
+	case "redis":
+		return lockout.NewRedisStore(rdb.Client())
+	case "memory", "":
+		return lockout.NewMemoryStore()
+	default:
+		panic(fmt.Sprintf("unknown login_protection store: %s", store))
+	}
+}
+
+func NewNonceStore(conf *viper.Viper, rdb *RedisProvider) sign.NonceStore {
+	switch store := conf.GetString("security.api_sign.nonce_store"); store {
+	case "redis":
+		return sign.NewRedisStore(rdb.Client())
+	case "memory", "":
+		return sign.NewMemoryStore(conf.GetInt("security.api_sign.nonce_cache_size"))
+	default:
+		panic(fmt.Sprintf("unknown api_sign nonce_store: %s", store))
+	}
+}
+
+// NewPolicyWatcher 多实例之间同步 Casbin 策略，security.casbin.watcher: none、redis 或 db
+func NewPolicyWatcher(conf *viper.Viper, db *gorm.DB, rdb *RedisProvider) (persist.Watcher, func()) {
+	var (
+		watcher persist.Watcher
+		err     error
+	)
+	switch kind := conf.GetString("security.casbin.watcher"); kind {
+	case "redis":
+		channel := conf.GetString("security.casbin.channel")
+		if channel == "" {
+			channel = "casbin:policy"
+		}
+		watcher, err = casbinPkg.NewRedisWatcher(rdb.Client(), channel)
+	case "db":
+		watcher, err = casbinPkg.NewDBWatcher(db, conf.GetDuration("security.casbin.poll_interval"))
+	case "none", "":
+		return nil, func() {}
+	default:
+		panic(fmt.Sprintf("unknown casbin watcher: %s", kind))
+	}
+	if err != nil {
+		panic(fmt.Sprintf("casbin watcher error: %s", err.Error()))
+	}
+	return watcher, watcher.Close
+}
+
 func NewMongo(conf *viper.Viper) (*mongo.Client, func(), error) {
 	// https://www.mongodb.com/zh-cn/docs/drivers/go/current/
 	uri := conf.GetString("data.mongo.uri")
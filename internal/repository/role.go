@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RoleRepository interface {
@@ -14,6 +16,18 @@ type RoleRepository interface {
 	GetRoleCount(ctx context.Context, req v1.GetRoleListRequest) (int, error)
 	CreateRole(ctx context.Context, role *model.Role) (*model.Role, error)
 	UpdateRole(ctx context.Context, role *model.Role) (*model.Role, error)
+	GetRolesByIds(ctx context.Context, ids []uint) ([]model.Role, error)
+	GetRolesBySids(ctx context.Context, sids []string) ([]model.Role, error)
+	// ListRoleGraph 返回全部角色及其父角色和直接权限，用于计算继承关系
+	ListRoleGraph(ctx context.Context) ([]model.Role, error)
+	ReplaceParents(ctx context.Context, role *model.Role, parents []model.Role) error
+	ReplacePermissions(ctx context.Context, role *model.Role, permissions []model.Permission) error
+	// CountRoleUsers 仍分配了该角色的用户数
+	CountRoleUsers(ctx context.Context, roleId uint) (int64, error)
+	// CountRoleChildren 继承了该角色的角色数
+	CountRoleChildren(ctx context.Context, roleId uint) (int64, error)
+	// DeleteRole 删除角色及其权限、继承关联，Sid 可以重新使用
+	DeleteRole(ctx context.Context, role *model.Role) error
 }
 
 func NewRoleRepository(
@@ -30,13 +44,18 @@ type roleRepository struct {
 
 func (r *roleRepository) GetRole(ctx context.Context, id int64) (*model.Role, error) {
 	var role model.Role
-
+	if err := r.DB(ctx).Preload("Permissions").Preload("Parents").First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
 	return &role, nil
 }
 
 func (r *roleRepository) Get(ctx context.Context, param v1.GetRoleListRequest) *gorm.DB {
 	var roles []model.Role
-	db := r.db.WithContext(ctx).Model(&roles)
+	db := r.db.WithContext(ctx).Model(&roles).Scopes(r.DataScope(ctx, roleDataScope))
 	if param.PageRequest.CurrentPage > 0 {
 		db = db.Scopes(model.Paginate(param.PageRequest))
 	}
@@ -51,7 +70,7 @@ func (r *roleRepository) Get(ctx context.Context, param v1.GetRoleListRequest) *
 
 func (r *roleRepository) GetRoleList(ctx context.Context, req v1.GetRoleListRequest) ([]model.Role, error) {
 	var roles []model.Role
-	err := r.Get(ctx, req).Preload("Permissions").Find(&roles).Error
+	err := r.Get(ctx, req).Preload("Permissions").Preload("Parents").Find(&roles).Error
 	if err != nil {
 		return nil, err
 	}
@@ -70,11 +89,78 @@ func (r *roleRepository) GetRoleCount(ctx context.Context, req v1.GetRoleListReq
 
 // CreateRole 创建角色
 func (r *roleRepository) CreateRole(ctx context.Context, role *model.Role) (*model.Role, error) {
-	err := r.db.WithContext(ctx).Create(role).Error
+	err := r.DB(ctx).Create(role).Error
 	return role, err
 }
 
 func (r *roleRepository) UpdateRole(ctx context.Context, role *model.Role) (*model.Role, error) {
-	err := r.db.WithContext(ctx).Save(role).Error
+	// 关联由 ReplacePermissions、ReplaceParents 单独维护
+	err := r.DB(ctx).Omit(clause.Associations).Save(role).Error
 	return role, err
 }
+
+func (r *roleRepository) GetRolesBySids(ctx context.Context, sids []string) ([]model.Role, error) {
+	var roles []model.Role
+	if len(sids) == 0 {
+		return roles, nil
+	}
+	if err := r.DB(ctx).Where("sid IN ?", sids).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) GetRolesByIds(ctx context.Context, ids []uint) ([]model.Role, error) {
+	var roles []model.Role
+	if len(ids) == 0 {
+		return roles, nil
+	}
+	if err := r.DB(ctx).Where("id IN ?", ids).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) ListRoleGraph(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	if err := r.DB(ctx).Preload("Permissions").Preload("Parents").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) ReplaceParents(ctx context.Context, role *model.Role, parents []model.Role) error {
+	return r.DB(ctx).Model(role).Association("Parents").Replace(parents)
+}
+
+func (r *roleRepository) ReplacePermissions(ctx context.Context, role *model.Role, permissions []model.Permission) error {
+	return r.DB(ctx).Model(role).Association("Permissions").Replace(permissions)
+}
+
+func (r *roleRepository) CountRoleUsers(ctx context.Context, roleId uint) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.User{}).
+		Joins("JOIN sys_user_roles ON sys_user_roles.user_id = users.id").
+		Where("sys_user_roles.role_id = ?", roleId).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepository) CountRoleChildren(ctx context.Context, roleId uint) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.Role{}).
+		Joins("JOIN role_parents ON role_parents.role_id = roles.id").
+		Where("role_parents.parent_id = ?", roleId).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepository) DeleteRole(ctx context.Context, role *model.Role) error {
+	if err := r.DB(ctx).Model(role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	if err := r.DB(ctx).Model(role).Association("Parents").Clear(); err != nil {
+		return err
+	}
+	return r.DB(ctx).Unscoped().Delete(role).Error
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session) error
+	// RotateSession 刷新令牌轮换后把会话指向新的 access token
+	RotateSession(ctx context.Context, familyId string, tokenId string, expiresAt time.Time, client *model.ClientInfo) error
+	GetSession(ctx context.Context, id uint) (*model.Session, error)
+	ListActiveSessions(ctx context.Context, userId string) ([]model.Session, error)
+
+	CreateLoginLog(ctx context.Context, log *model.LoginLog) error
+	GetLoginLogList(ctx context.Context, req *v1.GetLoginLogListRequest) ([]model.LoginLog, int, error)
+}
+
+func NewSessionRepository(
+	r *Repository,
+) SessionRepository {
+	return &sessionRepository{
+		Repository: r,
+	}
+}
+
+type sessionRepository struct {
+	*Repository
+}
+
+func (r *sessionRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	return r.DB(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) RotateSession(ctx context.Context, familyId string, tokenId string, expiresAt time.Time, client *model.ClientInfo) error {
+	return r.DB(ctx).Model(&model.Session{}).
+		Where("family_id = ?", familyId).
+		Updates(map[string]interface{}{
+			"token_id":       tokenId,
+			"ip":             client.IP,
+			"user_agent":     client.UserAgent,
+			"last_active_at": time.Now(),
+			"expires_at":     expiresAt,
+		}).Error
+}
+
+func (r *sessionRepository) GetSession(ctx context.Context, id uint) (*model.Session, error) {
+	var session model.Session
+	if err := r.DB(ctx).First(&session, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveSessions(ctx context.Context, userId string) ([]model.Session, error) {
+	var sessions []model.Session
+	if err := r.DB(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_active_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) CreateLoginLog(ctx context.Context, log *model.LoginLog) error {
+	return r.DB(ctx).Create(log).Error
+}
+
+func (r *sessionRepository) GetLoginLogList(ctx context.Context, req *v1.GetLoginLogListRequest) ([]model.LoginLog, int, error) {
+	db := r.DB(ctx).Model(&model.LoginLog{})
+	if req.UserId != "" {
+		db = db.Where("user_id = ?", req.UserId)
+	}
+	if req.Email != "" {
+		db = db.Where("email = ?", req.Email)
+	}
+	if req.Result != "" {
+		db = db.Where("result = ?", req.Result)
+	}
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	var logs []model.LoginLog
+	if err := db.Scopes(model.Paginate(req.PageRequest)).Order("id DESC").Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, int(count), nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/jwt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error)
+	GetRefreshTokenByAccessTokenId(ctx context.Context, tokenId string) (*model.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyId string, accessExpiresAt time.Time) error
+	RevokeTokenId(ctx context.Context, tokenId string, userId string, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userId string, accessExpiresAt time.Time) error
+	IsRevoked(ctx context.Context, claims *jwt.MyCustomClaims) (bool, error)
+}
+
+func NewTokenRepository(
+	r *Repository,
+) TokenRepository {
+	return &tokenRepository{
+		Repository: r,
+	}
+}
+
+type tokenRepository struct {
+	*Repository
+}
+
+func (r *tokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	return r.DB(ctx).Create(token).Error
+}
+
+func (r *tokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.DB(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenRepository) GetRefreshTokenByAccessTokenId(ctx context.Context, tokenId string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	if err := r.DB(ctx).Where("access_token_id = ?", tokenId).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed 原子地把刷新令牌标记为已使用，返回 false 说明已被并发使用或已吊销
+func (r *tokenRepository) MarkRefreshTokenUsed(ctx context.Context, id uint) (bool, error) {
+	res := r.DB(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeFamily 吊销同一 family 下的全部刷新令牌，以及它们签发过的 access token 和对应的会话
+func (r *tokenRepository) RevokeFamily(ctx context.Context, familyId string, accessExpiresAt time.Time) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		var tokens []model.RefreshToken
+		if err := r.DB(ctx).Where("family_id = ?", familyId).Find(&tokens).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := r.DB(ctx).Model(&model.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyId).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := r.DB(ctx).Model(&model.Session{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyId).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			if t.AccessTokenId == "" {
+				continue
+			}
+			if err := r.RevokeTokenId(ctx, t.AccessTokenId, t.UserId, accessExpiresAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *tokenRepository) RevokeTokenId(ctx context.Context, tokenId string, userId string, expiresAt time.Time) error {
+	return r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&model.RevokedToken{
+		TokenId:   tokenId,
+		UserId:    userId,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// RevokeUserTokens 吊销用户的全部会话，用于重置密码等场景
+func (r *tokenRepository) RevokeUserTokens(ctx context.Context, userId string, accessExpiresAt time.Time) error {
+	var familyIds []string
+	if err := r.DB(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Distinct("family_id").Pluck("family_id", &familyIds).Error; err != nil {
+		return err
+	}
+	for _, familyId := range familyIds {
+		if err := r.RevokeFamily(ctx, familyId, accessExpiresAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsRevoked 除了黑名单，用户被禁用、注销或删除后其全部 token 也立即视为吊销
+func (r *tokenRepository) IsRevoked(ctx context.Context, claims *jwt.MyCustomClaims) (bool, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.RevokedToken{}).Where("token_id = ?", claims.ID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := r.DB(ctx).Model(&model.User{}).
+		Where("user_id = ? AND status NOT IN ?", claims.UserId, []int{model.UserStatusDisabled, model.UserStatusDeactivated}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
@@ -3,19 +3,50 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
+	"strings"
+	"time"
 
+	"github.com/duke-git/lancet/v2/slice"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	Update(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id string) (*model.User, error)
-	GetUserList(ctx context.Context) (*[]model.User, error)
-	GetUserCount(ctx context.Context) (int, error)
+	// GetScopedByID 同 GetByID，额外按当前请求的数据权限过滤，范围外的用户返回 ErrNotFound
+	// 管理员读取或修改其他用户时使用
+	GetScopedByID(ctx context.Context, id string) (*model.User, error)
+	// GetUserList 按条件分页查询，同时返回符合条件的总数
+	GetUserList(ctx context.Context, req *v1.GetUserListRequest) ([]model.User, int, error)
+	// EachUser 按 id 顺序分批遍历符合列表条件的用户，忽略分页和排序，用于导出
+	EachUser(ctx context.Context, req *v1.GetUserListRequest, batchSize int, fn func(users []model.User) error) error
+	// GetExistingEmails 返回已被使用的邮箱（小写），emails 需为小写
+	GetExistingEmails(ctx context.Context, emails []string) ([]string, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	// GetGuestByUdid 查找设备对应的访客，不存在时返回 nil
+	GetGuestByUdid(ctx context.Context, udid string) (*model.User, error)
+	UpdateLastLogin(ctx context.Context, userId string, ip string, loginType int) error
+	// ListUserRoles 返回全部用户及其角色，不受数据权限限制，用于重建 Casbin g 规则
+	ListUserRoles(ctx context.Context) ([]model.User, error)
+	// GetDataScope 用户生效的数据权限及部门，规则与 DataScope 过滤一致
+	GetDataScope(ctx context.Context, userId string) (int, uint, error)
+	// UpdateStatus 只修改状态，注销时同时记录注销时间
+	UpdateStatus(ctx context.Context, userId string, status int) error
+	// Delete 软删除，可以通过 Restore 恢复
+	Delete(ctx context.Context, userId string) error
+	GetDeletedUserList(ctx context.Context, req *v1.GetDeletedUserListRequest) ([]model.User, int, error)
+	// GetDeletedByID 只查找已软删除的用户，按当前请求的数据权限过滤
+	GetDeletedByID(ctx context.Context, userId string) (*model.User, error)
+	Restore(ctx context.Context, userId string) error
+	// Purge 永久删除用户及其角色、会话、第三方账号、二次验证、API key 等关联数据，登录日志保留
+	Purge(ctx context.Context, userId string) error
+	// MergeGuest 保存 target，把访客的第三方账号转移给 target 后永久删除访客
+	MergeGuest(ctx context.Context, guestId string, target *model.User) error
 }
 
 func NewUserRepository(
@@ -30,23 +61,53 @@ type userRepository struct {
 	*Repository
 }
 
+// userEmailIndex 小写邮箱唯一索引，空邮箱、访客和已软删除的用户不参与
+const userEmailIndex = "idx_users_email_lower"
+
+// CreateUserEmailIndex AutoMigrate 之后调用，GORM 标签无法声明表达式索引
+// MySQL 用函数索引（8.0.13+），条件不满足时取 NULL；SQLite / Postgres 用部分索引
+func CreateUserEmailIndex(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&model.User{}, userEmailIndex) {
+		return nil
+	}
+	var sql string
+	switch db.Dialector.Name() {
+	case "mysql":
+		sql = fmt.Sprintf("CREATE UNIQUE INDEX %s ON users ((CASE WHEN email = '' OR user_type = %d OR deleted_at IS NOT NULL THEN NULL ELSE LOWER(email) END))",
+			userEmailIndex, model.UserTypeGuest)
+	default:
+		sql = fmt.Sprintf("CREATE UNIQUE INDEX %s ON users (LOWER(email)) WHERE email <> '' AND user_type <> %d AND deleted_at IS NULL",
+			userEmailIndex, model.UserTypeGuest)
+	}
+	return db.Exec(sql).Error
+}
+
+// emailConflict 并发写入绕过了服务层的邮箱检查时，由唯一索引兜底
+// 需要 gorm.Config 开启 TranslateError
+func emailConflict(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return v1.ErrEmailAlreadyUse
+	}
+	return err
+}
+
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	if err := r.DB(ctx).Create(user).Error; err != nil {
-		return err
+		return emailConflict(err)
 	}
 	return nil
 }
 
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	if err := r.DB(ctx).Save(user).Error; err != nil {
-		return err
+		return emailConflict(err)
 	}
 	return nil
 }
 
 func (r *userRepository) GetByID(ctx context.Context, userId string) (*model.User, error) {
 	var user model.User
-	if err := r.DB(ctx).Where("user_id = ?", userId).First(&user).Error; err != nil {
+	if err := r.DB(ctx).Where("user_id = ?", userId).Preload("Roles").First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, v1.ErrNotFound
 		}
@@ -55,9 +116,9 @@ func (r *userRepository) GetByID(ctx context.Context, userId string) (*model.Use
 	return &user, nil
 }
 
-func (r *userRepository) GetUserList(ctx context.Context) (*[]model.User, error) {
-	var user []model.User
-	if err := r.DB(ctx).Preload("Roles").Find(&user).Error; err != nil {
+func (r *userRepository) GetScopedByID(ctx context.Context, userId string) (*model.User, error) {
+	var user model.User
+	if err := r.DB(ctx).Scopes(r.DataScope(ctx, userDataScope)).Where("user_id = ?", userId).Preload("Roles").First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, v1.ErrNotFound
 		}
@@ -66,18 +127,109 @@ func (r *userRepository) GetUserList(ctx context.Context) (*[]model.User, error)
 	return &user, nil
 }
 
-func (r *userRepository) GetUserCount(ctx context.Context) (int, error) {
+func (r *userRepository) GetUserList(ctx context.Context, req *v1.GetUserListRequest) ([]model.User, int, error) {
+	db, err := r.userListQuery(ctx, req)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	var count int64
-	err := r.DB(ctx).Preload("Roles").Count(&count).Error
+	if err = db.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	if req.PageSize > 100 {
+		req.PageSize = 100
+	}
+	// 排序字段已在请求中限定为白名单，再按 id 排序保证分页稳定
+	sortBy := "id"
+	if req.SortBy != "" {
+		sortBy = req.SortBy
+	}
+	desc := req.SortOrder != "asc"
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: sortBy}, Desc: desc})
+	if sortBy != "id" {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+	}
+
+	var users []model.User
+	if err = db.Scopes(model.Paginate(req.PageRequest)).Preload("Roles").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, int(count), nil
+}
+
+func (r *userRepository) EachUser(ctx context.Context, req *v1.GetUserListRequest, batchSize int, fn func(users []model.User) error) error {
+	db, err := r.userListQuery(ctx, req)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return int(count), nil
+	var users []model.User
+	return db.Preload("Roles").FindInBatches(&users, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(users)
+	}).Error
+}
+
+func (r *userRepository) GetExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	var existing []string
+	for _, chunk := range slice.Chunk(emails, 500) {
+		var found []string
+		if err := r.DB(ctx).Model(&model.User{}).Where("LOWER(email) IN ?", chunk).Pluck("LOWER(email)", &found).Error; err != nil {
+			return nil, err
+		}
+		existing = append(existing, found...)
+	}
+	return existing, nil
+}
+
+// userListQuery 用户列表和导出共用的过滤条件
+func (r *userRepository) userListQuery(ctx context.Context, req *v1.GetUserListRequest) (*gorm.DB, error) {
+	db := r.DB(ctx).Model(&model.User{}).Scopes(r.DataScope(ctx, userDataScope))
+	// 用 LOWER + LIKE，MySQL、Postgres、SQLite 上都不区分大小写
+	if req.Email != "" {
+		db = db.Where("LOWER(email) LIKE ? ESCAPE '!'", likePattern(req.Email))
+	}
+	if req.Name != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", likePattern(req.Name))
+	}
+	if req.Status != 0 {
+		db = db.Where("status = ?", req.Status)
+	}
+	if req.UserType != 0 {
+		db = db.Where("user_type = ?", req.UserType)
+	}
+	if req.RoleId != 0 {
+		db = db.Where("id IN (?)", r.DB(ctx).Table("sys_user_roles").Select("user_id").Where("role_id = ?", req.RoleId))
+	}
+	if req.RegisteredFrom != "" {
+		from, err := time.ParseInLocation(time.DateOnly, req.RegisteredFrom, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("created_at >= ?", from)
+	}
+	if req.RegisteredTo != "" {
+		to, err := time.ParseInLocation(time.DateOnly, req.RegisteredTo, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+	return db, nil
+}
+
+// likePattern 转义 LIKE 通配符，配合 ESCAPE '!' 使用
+func likePattern(s string) string {
+	s = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(s))
+	return "%" + s + "%"
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := r.DB(ctx).Where("email = ?", email).Preload("Roles").First(&user).Error; err != nil {
+	// 兼容统一小写之前保存的邮箱
+	if err := r.DB(ctx).Where("LOWER(email) = ?", model.NormalizeEmail(email)).Preload("Roles").First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -85,3 +237,132 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 	}
 	return &user, nil
 }
+
+func (r *userRepository) GetGuestByUdid(ctx context.Context, udid string) (*model.User, error) {
+	var user model.User
+	if err := r.DB(ctx).Where("udid = ? AND user_type = ?", udid, model.UserTypeGuest).Order("id").First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) UpdateLastLogin(ctx context.Context, userId string, ip string, loginType int) error {
+	return r.DB(ctx).Model(&model.User{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"last_login_ip":   ip,
+		"last_login_time": time.Now().Unix(),
+		"last_login_type": loginType,
+	}).Error
+}
+
+func (r *userRepository) ListUserRoles(ctx context.Context) ([]model.User, error) {
+	var users []model.User
+	if err := r.DB(ctx).Select("id", "user_id").Preload("Roles").Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepository) GetDataScope(ctx context.Context, userId string) (int, uint, error) {
+	return r.dataScopeOf(ctx, userId)
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, userId string, status int) error {
+	values := map[string]interface{}{"status": status}
+	if status == model.UserStatusDeactivated {
+		values["deactivate_time"] = time.Now().Unix()
+	}
+	return r.DB(ctx).Model(&model.User{}).Where("user_id = ?", userId).Updates(values).Error
+}
+
+func (r *userRepository) Delete(ctx context.Context, userId string) error {
+	return r.DB(ctx).Where("user_id = ?", userId).Delete(&model.User{}).Error
+}
+
+func (r *userRepository) GetDeletedUserList(ctx context.Context, req *v1.GetDeletedUserListRequest) ([]model.User, int, error) {
+	db := r.DB(ctx).Unscoped().Model(&model.User{}).Scopes(r.DataScope(ctx, userDataScope)).Where("deleted_at IS NOT NULL")
+	if req.Email != "" {
+		db = db.Where("LOWER(email) LIKE ? ESCAPE '!'", likePattern(req.Email))
+	}
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+	var users []model.User
+	if err := db.Scopes(model.Paginate(req.PageRequest)).Order("deleted_at DESC").Order("id DESC").Preload("Roles").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, int(count), nil
+}
+
+func (r *userRepository) GetDeletedByID(ctx context.Context, userId string) (*model.User, error) {
+	var user model.User
+	if err := r.DB(ctx).Unscoped().Scopes(r.DataScope(ctx, userDataScope)).Where("user_id = ? AND deleted_at IS NOT NULL", userId).Preload("Roles").First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, userId string) error {
+	return emailConflict(r.DB(ctx).Unscoped().Model(&model.User{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"deleted_at":      nil,
+		"status":          model.UserStatusNormal,
+		"deactivate_time": 0,
+	}).Error)
+}
+
+func (r *userRepository) Purge(ctx context.Context, userId string) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		user, err := r.GetDeletedByID(ctx, userId)
+		if err != nil {
+			return err
+		}
+		return r.purge(ctx, user)
+	})
+}
+
+func (r *userRepository) MergeGuest(ctx context.Context, guestId string, target *model.User) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		guest, err := r.GetByID(ctx, guestId)
+		if err != nil {
+			return err
+		}
+		if err = r.DB(ctx).Omit(clause.Associations).Save(target).Error; err != nil {
+			return emailConflict(err)
+		}
+		if err = r.DB(ctx).Model(&model.UserIdentity{}).Where("user_id = ?", guestId).Update("user_id", target.UserId).Error; err != nil {
+			return err
+		}
+		return r.purge(ctx, guest)
+	})
+}
+
+// purge 需在事务中调用
+func (r *userRepository) purge(ctx context.Context, user *model.User) error {
+	if err := r.DB(ctx).Model(user).Association("Roles").Clear(); err != nil {
+		return err
+	}
+	// revoked_tokens 保留到过期，用户不存在时 IsRevoked 也会拒绝
+	for _, m := range []interface{}{
+		&model.RefreshToken{},
+		&model.Session{},
+		&model.UserIdentity{},
+		&model.UserTotp{},
+		&model.MfaRecoveryCode{},
+		&model.ApiKey{},
+		&model.VerificationToken{},
+	} {
+		if err := r.DB(ctx).Unscoped().Where("user_id = ?", user.UserId).Delete(m).Error; err != nil {
+			return err
+		}
+	}
+	return r.DB(ctx).Unscoped().Delete(user).Error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type VerificationTokenRepository interface {
+	Create(ctx context.Context, token *model.VerificationToken) error
+	GetByHash(ctx context.Context, purpose string, hash string) (*model.VerificationToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	InvalidateUserTokens(ctx context.Context, userId string, purpose string) error
+}
+
+func NewVerificationTokenRepository(
+	r *Repository,
+) VerificationTokenRepository {
+	return &verificationTokenRepository{
+		Repository: r,
+	}
+}
+
+type verificationTokenRepository struct {
+	*Repository
+}
+
+func (r *verificationTokenRepository) Create(ctx context.Context, token *model.VerificationToken) error {
+	return r.DB(ctx).Create(token).Error
+}
+
+func (r *verificationTokenRepository) GetByHash(ctx context.Context, purpose string, hash string) (*model.VerificationToken, error) {
+	var token model.VerificationToken
+	if err := r.DB(ctx).Where("purpose = ? AND token_hash = ?", purpose, hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed 原子地消费令牌，返回 false 说明已被使用
+func (r *verificationTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	res := r.DB(ctx).Model(&model.VerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// InvalidateUserTokens 作废用户某一用途下尚未使用的令牌，保证只有最新发出的链接有效
+func (r *verificationTokenRepository) InvalidateUserTokens(ctx context.Context, userId string, purpose string) error {
+	return r.DB(ctx).Model(&model.VerificationToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userId, purpose).
+		Update("used_at", time.Now()).Error
+}
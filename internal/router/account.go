@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func InitAccountRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// No route group has permission
+	noAuthRouter := r.Group("/")
+	{
+		noAuthRouter.POST("/password/forgot", deps.AccountHandler.ForgotPassword)
+		noAuthRouter.POST("/password/reset", deps.AccountHandler.ResetPassword)
+		noAuthRouter.POST("/email/verify", deps.AccountHandler.VerifyEmail)
+		noAuthRouter.POST("/email/verify/resend", deps.AccountHandler.ResendVerifyEmail)
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitApiKeyRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// Users manage their own keys with a JWT only, a key cannot mint other keys
+	strictAuthRouter := r.Group("/api-keys").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.ApiKeyHandler.ListApiKeys)
+		strictAuthRouter.POST("", deps.ApiKeyHandler.CreateApiKey)
+		strictAuthRouter.DELETE("/:id", deps.ApiKeyHandler.DeleteApiKey)
+	}
+}
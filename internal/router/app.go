@@ -0,0 +1,30 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+	"go-nunu/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitAppRouter 移动端接口，与后台使用的同名接口共用 handler，额外要求请求签名
+func InitAppRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	signedRouter := r.Group("/app").Use(middleware.SignMiddleware(deps.Logger, deps.Signer))
+	{
+		signedRouter.POST("/register", deps.UserHandler.Register)
+		signedRouter.POST("/login", deps.UserHandler.Login)
+		signedRouter.POST("/login/mfa", deps.MfaHandler.LoginMfa)
+		signedRouter.POST("/token/refresh", deps.TokenHandler.RefreshToken)
+	}
+
+	strictAuthRouter := r.Group("/app").Use(
+		middleware.SignMiddleware(deps.Logger, deps.Signer),
+		middleware.StrictAuth(deps.JWT, deps.Logger, jwt.ScopeGuest),
+	)
+	{
+		strictAuthRouter.POST("/logout", deps.TokenHandler.Logout)
+	}
+}
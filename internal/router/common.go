@@ -10,10 +10,10 @@ func InitCommonRouter(
 	deps RouterDeps,
 	r *gin.RouterGroup,
 ) {
-	// Protected routes requiring JWT and RBAC
-	protectedRouter := r.Group("/common").Use(
-		middleware.StrictAuth(deps.JWT, deps.Logger),
-		middleware.AuthMiddleware(deps.Casbin),
+	// Protected routes requiring JWT (or an API key) and RBAC
+	protectedRouter := deps.Routes.Use(r.Group("/common"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
 	)
 	{
 		protectedRouter.POST("/upload", deps.CommonHandler.UploadPresignedUrl)
@@ -0,0 +1,30 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+	"go-nunu/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitGuestRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// 访客接口给移动端使用，要求请求签名
+	signedRouter := r.Group("/guest").Use(middleware.SignMiddleware(deps.Logger, deps.Signer))
+	{
+		signedRouter.POST("/login", deps.UserHandler.GuestLogin)
+	}
+
+	// Guests upgrade their own account, so no RBAC check here
+	guestRouter := r.Group("/guest/upgrade").Use(
+		middleware.SignMiddleware(deps.Logger, deps.Signer),
+		middleware.StrictAuth(deps.JWT, deps.Logger, jwt.ScopeGuest),
+	)
+	{
+		guestRouter.POST("", deps.UserHandler.UpgradeGuest)
+		guestRouter.GET("/:provider/authorize", deps.OAuthHandler.GuestAuthorize)
+		guestRouter.POST("/:provider", deps.OAuthHandler.UpgradeGuest)
+	}
+}
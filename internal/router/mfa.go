@@ -0,0 +1,30 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitMfaRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// 登录第二步只凭 mfa_token 调用
+	noAuthRouter := r.Group("/login/mfa")
+	{
+		noAuthRouter.POST("", deps.MfaHandler.LoginMfa)
+		noAuthRouter.POST("/enroll", deps.MfaHandler.LoginMfaEnroll)
+		noAuthRouter.POST("/activate", deps.MfaHandler.LoginMfaActivate)
+	}
+
+	// 管理自己的二次验证不需要 RBAC 权限
+	strictAuthRouter := r.Group("/mfa").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.MfaHandler.GetMfaStatus)
+		strictAuthRouter.POST("/totp/enroll", deps.MfaHandler.EnrollTotp)
+		strictAuthRouter.POST("/totp/activate", deps.MfaHandler.ActivateTotp)
+		strictAuthRouter.POST("/totp/disable", deps.MfaHandler.DisableTotp)
+		strictAuthRouter.POST("/recovery-codes", deps.MfaHandler.RegenerateRecoveryCodes)
+	}
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitOAuthRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// No route group has permission
+	noAuthRouter := r.Group("/oauth")
+	{
+		noAuthRouter.GET("/providers", deps.OAuthHandler.ListProviders)
+		noAuthRouter.GET("/:provider/authorize", deps.OAuthHandler.Authorize)
+		noAuthRouter.POST("/:provider/callback", deps.OAuthHandler.Callback)
+	}
+
+	// Users manage their own linked identities, so no RBAC check here
+	strictAuthRouter := r.Group("/identities").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.OAuthHandler.ListIdentities)
+		strictAuthRouter.GET("/:provider/authorize", deps.OAuthHandler.LinkAuthorize)
+		strictAuthRouter.POST("/:provider/link", deps.OAuthHandler.Link)
+		strictAuthRouter.DELETE("/:id", deps.OAuthHandler.Unlink)
+	}
+}
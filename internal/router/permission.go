@@ -11,9 +11,9 @@ func InitPermissionRouter(
 	r *gin.RouterGroup,
 ) {
 	// Non-strict permission routing group
-	noStrictAuthRouter := r.Group("/").Use(
+	noStrictAuthRouter := deps.Routes.Use(r.Group("/"),
 		middleware.NoStrictAuth(deps.JWT, deps.Logger),
-		middleware.AuthMiddleware(deps.Casbin),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
 	)
 	{
 		noStrictAuthRouter.GET("/permission/list", deps.PermissionHandler.GetPermissionList)
@@ -21,7 +21,19 @@ func InitPermissionRouter(
 	}
 
 	// Strict permission routing group
-	// strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
-	// {
-	// }
+	strictPermissionRouter := deps.Routes.Use(r.Group("/"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
+	)
+	{
+		strictPermissionRouter.GET("/permission/tree", deps.PermissionHandler.GetPermissionTree)
+		strictPermissionRouter.PUT("/permission", deps.PermissionHandler.UpdatePermission)
+		strictPermissionRouter.DELETE("/permission/:id", deps.PermissionHandler.DeletePermission)
+	}
+
+	// Every logged-in user may ask which menus they can see
+	strictAuthRouter := r.Group("/menus").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/mine", deps.PermissionHandler.GetMyMenus)
+	}
 }
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitPolicyRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// Protected routes requiring JWT (or an API key) and RBAC
+	protectedRouter := deps.Routes.Use(r.Group("/policy"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
+	)
+	{
+		protectedRouter.GET("/drift", deps.PolicyHandler.GetPolicyDrift)
+		protectedRouter.POST("/reconcile", deps.PolicyHandler.ReconcilePolicies)
+		protectedRouter.POST("/sync-routes", deps.PolicyHandler.SyncApiPermissions)
+		protectedRouter.POST("/check", deps.PolicyHandler.CheckPermission)
+		protectedRouter.GET("/who-can", deps.PolicyHandler.WhoCan)
+	}
+}
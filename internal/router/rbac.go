@@ -0,0 +1,22 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitRbacRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// Protected routes requiring JWT (or an API key) and RBAC
+	protectedRouter := deps.Routes.Use(r.Group("/rbac"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
+	)
+	{
+		protectedRouter.GET("/export", deps.RbacHandler.ExportRbac)
+		protectedRouter.POST("/import", deps.RbacHandler.ImportRbac)
+	}
+}
@@ -10,15 +10,20 @@ func InitRoleRouter(
 	deps RouterDeps,
 	r *gin.RouterGroup,
 ) {
-	// Protected routes requiring JWT and RBAC
-	protectedRouter := r.Group("/").Use(
-		middleware.StrictAuth(deps.JWT, deps.Logger),
-		middleware.AuthMiddleware(deps.Casbin),
+	// Protected routes requiring JWT (or an API key) and RBAC
+	protectedRouter := deps.Routes.Use(r.Group("/"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
 	)
 	{
 		protectedRouter.GET("/role/list", deps.RoleHandler.GetRoleList)
 		protectedRouter.POST("/role", deps.RoleHandler.CreateRole)
 		protectedRouter.PUT("/role", deps.RoleHandler.UpdateRolePermissions)
+		protectedRouter.PUT("/role/parents", deps.RoleHandler.UpdateRoleParents)
+		protectedRouter.PUT("/role/data-scope", deps.RoleHandler.UpdateRoleDataScope)
+		protectedRouter.PUT("/role/info", deps.RoleHandler.UpdateRole)
+		protectedRouter.DELETE("/role/:id", deps.RoleHandler.DeleteRole)
+		protectedRouter.GET("/role/:id/permissions", deps.RoleHandler.GetRolePermissions)
 	}
 
 	// Strict permission routing group
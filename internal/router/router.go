@@ -2,10 +2,13 @@ package router
 
 import (
 	"go-nunu/internal/handler"
+	"go-nunu/internal/middleware"
 	"go-nunu/pkg/jwt"
 	"go-nunu/pkg/log"
+	"go-nunu/pkg/sign"
 
 	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 )
 
@@ -13,9 +16,39 @@ type RouterDeps struct {
 	Logger            *log.Logger
 	Config            *viper.Viper
 	JWT               *jwt.JWT
-	Casbin            *casbin.CachedEnforcer
+	Casbin            *casbin.SyncedCachedEnforcer
 	UserHandler       *handler.UserHandler
+	UserBulkHandler   *handler.UserBulkHandler
 	CommonHandler     *handler.CommonHandler
 	RoleHandler       *handler.RoleHandler
 	PermissionHandler *handler.PermissionHandler
+	PolicyHandler     *handler.PolicyHandler
+	RbacHandler       *handler.RbacHandler
+	TokenHandler      *handler.TokenHandler
+	AccountHandler    *handler.AccountHandler
+	MfaHandler        *handler.MfaHandler
+	OAuthHandler      *handler.OAuthHandler
+	SessionHandler    *handler.SessionHandler
+	ApiKeyHandler     *handler.ApiKeyHandler
+	ApiKeyVerifier    middleware.ApiKeyVerifier
+	Signer            *sign.Verifier
+	Routes            *RouteTable
+}
+
+// InitRouters 注册全部 /v1 接口
+func InitRouters(deps RouterDeps, r *gin.RouterGroup) {
+	InitUserRouter(deps, r)
+	InitTokenRouter(deps, r)
+	InitGuestRouter(deps, r)
+	InitAppRouter(deps, r)
+	InitAccountRouter(deps, r)
+	InitMfaRouter(deps, r)
+	InitOAuthRouter(deps, r)
+	InitSessionRouter(deps, r)
+	InitApiKeyRouter(deps, r)
+	InitRoleRouter(deps, r)
+	InitPermissionRouter(deps, r)
+	InitPolicyRouter(deps, r)
+	InitRbacRouter(deps, r)
+	InitCommonRouter(deps, r)
 }
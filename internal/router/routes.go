@@ -0,0 +1,169 @@
+package router
+
+import (
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/middleware"
+	"go-nunu/internal/service"
+	"go-nunu/pkg/log"
+	"net/http"
+	"path"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// RouteTable 挂载了 AuthMiddleware 的接口，在注册路由时按处理链记录
+type RouteTable struct {
+	mu     sync.RWMutex
+	routes []v1.ApiRoute
+}
+
+func NewRouteTable() *RouteTable {
+	return &RouteTable{}
+}
+
+// NewRouteSource 供没有 HTTP 服务的进程（迁移、cmd/rbac、定时任务）使用：只注册一遍 /v1 路由，不处理任何请求
+// 注册时只取 handler 和中间件的函数值，它们的依赖都可以为空
+func NewRouteSource(conf *viper.Viper, logger *log.Logger) service.RouteSource {
+	// 不重复打印路由注册日志
+	mode := gin.Mode()
+	gin.SetMode(gin.ReleaseMode)
+	defer gin.SetMode(mode)
+
+	routes := NewRouteTable()
+	InitRouters(RouterDeps{Config: conf, Logger: logger, Routes: routes}, gin.New().Group("/v1"))
+	return routes
+}
+
+func (t *RouteTable) RBACRoutes() []v1.ApiRoute {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return slices.Clone(t.routes)
+}
+
+// Use 等同于 group.Use，之后通过返回值注册的路由会连同完整的处理链一起记录
+// 挂载 AuthMiddleware 的路由组都要通过它注册，否则 SyncApiPermissions 看不到这些接口
+func (t *RouteTable) Use(group *gin.RouterGroup, middleware ...gin.HandlerFunc) gin.IRoutes {
+	group.Use(middleware...)
+	return &recordedGroup{RouterGroup: group, table: t}
+}
+
+// record 处理链中包含 AuthMiddleware 时记下该接口
+func (t *RouteTable) record(method string, fullPath string, chain gin.HandlersChain) {
+	if t == nil || len(chain) == 0 || !slices.ContainsFunc(chain, isAuthMiddleware) {
+		return
+	}
+	route := v1.ApiRoute{
+		Method:  method,
+		Path:    fullPath,
+		Handler: shortHandlerName(funcName(chain[len(chain)-1])),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := sort.Search(len(t.routes), func(i int) bool {
+		if t.routes[i].Path != route.Path {
+			return t.routes[i].Path > route.Path
+		}
+		return t.routes[i].Method >= route.Method
+	})
+	t.routes = slices.Insert(t.routes, i, route)
+}
+
+// recordedGroup 在 gin.RouterGroup 注册路由前先交给 RouteTable 记录
+type recordedGroup struct {
+	*gin.RouterGroup
+	table *RouteTable
+}
+
+func (g *recordedGroup) Use(middleware ...gin.HandlerFunc) gin.IRoutes {
+	g.RouterGroup.Use(middleware...)
+	return g
+}
+
+func (g *recordedGroup) Handle(method string, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	chain := append(slices.Clone(g.Handlers), handlers...)
+	g.table.record(method, joinPaths(g.BasePath(), relativePath), chain)
+	g.RouterGroup.Handle(method, relativePath, handlers...)
+	return g
+}
+
+func (g *recordedGroup) Match(methods []string, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	for _, method := range methods {
+		g.Handle(method, relativePath, handlers...)
+	}
+	return g
+}
+
+func (g *recordedGroup) Any(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Match([]string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodHead,
+		http.MethodOptions, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+	}, relativePath, handlers...)
+}
+
+func (g *recordedGroup) GET(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodGet, relativePath, handlers...)
+}
+
+func (g *recordedGroup) POST(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPost, relativePath, handlers...)
+}
+
+func (g *recordedGroup) PUT(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPut, relativePath, handlers...)
+}
+
+func (g *recordedGroup) PATCH(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodPatch, relativePath, handlers...)
+}
+
+func (g *recordedGroup) DELETE(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodDelete, relativePath, handlers...)
+}
+
+func (g *recordedGroup) OPTIONS(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodOptions, relativePath, handlers...)
+}
+
+func (g *recordedGroup) HEAD(relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes {
+	return g.Handle(http.MethodHead, relativePath, handlers...)
+}
+
+// joinPaths 与 gin 拼接路由组路径的方式一致，保留结尾的 /
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+var authMiddlewareName = funcName(middleware.AuthMiddleware(nil, nil))
+
+func isAuthMiddleware(f gin.HandlerFunc) bool {
+	return funcName(f) == authMiddlewareName
+}
+
+// funcName 与 gin 的 HandlerNames 使用同样的名字
+func funcName(f gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// shortHandlerName "go-nunu/internal/handler.(*RoleHandler).DeleteRole-fm" -> "DeleteRole"
+func shortHandlerName(name string) string {
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitSessionRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// Users manage their own sessions, so no RBAC check here
+	strictAuthRouter := r.Group("/sessions").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.SessionHandler.ListSessions)
+		strictAuthRouter.GET("/login-logs", deps.SessionHandler.ListLoginLogs)
+		strictAuthRouter.DELETE("/:id", deps.SessionHandler.RevokeSession)
+	}
+
+	// Protected routes requiring JWT (or an API key) and RBAC
+	protectedRouter := deps.Routes.Use(r.Group("/"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
+	)
+	{
+		protectedRouter.GET("/user/sessions", deps.SessionHandler.ListUserSessions)
+		protectedRouter.POST("/user/sessions/revoke", deps.SessionHandler.RevokeUserSession)
+		protectedRouter.GET("/user/login-logs", deps.SessionHandler.GetLoginLogList)
+	}
+}
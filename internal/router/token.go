@@ -0,0 +1,25 @@
+package router
+
+import (
+	"go-nunu/internal/middleware"
+	"go-nunu/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitTokenRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	// No route group has permission
+	noAuthRouter := r.Group("/")
+	{
+		noAuthRouter.POST("/token/refresh", deps.TokenHandler.RefreshToken)
+	}
+
+	// Any signed-in user, guests included, may log out, so no RBAC check here
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger, jwt.ScopeGuest))
+	{
+		strictAuthRouter.POST("/logout", deps.TokenHandler.Logout)
+	}
+}
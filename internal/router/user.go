@@ -16,16 +16,26 @@ func InitUserRouter(
 		noAuthRouter.POST("/register", deps.UserHandler.Register)
 		noAuthRouter.POST("/login", deps.UserHandler.Login)
 	}
-	// Protected routes requiring JWT and RBAC
+	// Protected routes requiring JWT (or an API key) and RBAC
 
-	protectedRouter := r.Group("/").Use(
-		middleware.StrictAuth(deps.JWT, deps.Logger),
-		middleware.AuthMiddleware(deps.Casbin),
+	protectedRouter := deps.Routes.Use(r.Group("/"),
+		middleware.ApiKeyAuth(deps.ApiKeyVerifier, deps.JWT, deps.Logger),
+		middleware.AuthMiddleware(deps.Casbin, deps.Logger),
 	)
 	{
 		protectedRouter.GET("/profile", deps.UserHandler.GetProfile)
 		protectedRouter.POST("/user/list", deps.UserHandler.GetUserList)
 		protectedRouter.PUT("/profile", deps.UserHandler.UpdateProfile)
 		protectedRouter.PUT("/user", deps.UserHandler.UpdateUser)
+		protectedRouter.POST("/user/unlock", deps.UserHandler.UnlockUser)
+		protectedRouter.POST("/user", deps.UserHandler.CreateUser)
+		protectedRouter.POST("/user/disable", deps.UserHandler.DisableUser)
+		protectedRouter.POST("/user/enable", deps.UserHandler.EnableUser)
+		protectedRouter.POST("/user/deactivate", deps.UserHandler.DeactivateUser)
+		protectedRouter.GET("/user/deleted", deps.UserHandler.GetDeletedUserList)
+		protectedRouter.POST("/user/restore", deps.UserHandler.RestoreUser)
+		protectedRouter.DELETE("/user/:id", deps.UserHandler.PurgeUser)
+		protectedRouter.POST("/user/import", deps.UserBulkHandler.ImportUsers)
+		protectedRouter.GET("/user/export", deps.UserBulkHandler.ExportUsers)
 	}
 }
@@ -21,8 +21,12 @@ func NewHTTPServer(
 	if deps.Config.GetString("env") == "prod" {
 		gin.SetMode(gin.ReleaseMode)
 	}
+	engine := gin.New()
+	// ctx.Value 回退到请求的 context，service / repository 才能读到鉴权中间件写入的 claims
+	engine.ContextWithFallback = true
+	engine.Use(gin.Logger(), gin.Recovery())
 	s := http.NewServer(
-		gin.Default(),
+		engine,
 		deps.Logger,
 		http.WithServerHost(deps.Config.GetString("http.host")),
 		http.WithServerPort(deps.Config.GetInt("http.port")),
@@ -56,12 +60,18 @@ func NewHTTPServer(
 		ginSwagger.PersistAuthorization(true),
 	))
 
+	// 公钥集合，供其他服务离线校验 token
+	s.GET("/.well-known/jwks.json", func(ctx *gin.Context) {
+		ctx.Header("Cache-Control", "public, max-age=300")
+		ctx.JSON(nethttp.StatusOK, deps.JWT.JWKS())
+	})
+
 	s.Use(
 		middleware.CORSMiddleware(),
 		middleware.ResponseLogMiddleware(deps.Logger),
 		middleware.RequestLogMiddleware(deps.Logger),
-		//middleware.SignMiddleware(log),
-		// middleware.AuthMiddleware(deps.Casbin),
+		// SignMiddleware 按路由组挂载，不在全局开启
+		// middleware.AuthMiddleware(deps.Casbin, deps.Logger),
 	)
 	s.GET("/", func(ctx *gin.Context) {
 		deps.Logger.WithContext(ctx).Info("hello")
@@ -71,10 +81,7 @@ func NewHTTPServer(
 	})
 
 	v1 := s.Group("/v1")
-	router.InitUserRouter(deps, v1)
-	router.InitRoleRouter(deps, v1)
-	router.InitPermissionRouter(deps, v1)
-	router.InitCommonRouter(deps, v1)
+	router.InitRouters(deps, v1)
 
 	return s
 }
@@ -3,8 +3,12 @@ package server
 import (
 	"context"
 	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/internal/service"
 	"go-nunu/pkg/log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/casbin/casbin/v2"
 	"go.uber.org/zap"
@@ -13,16 +17,18 @@ import (
 )
 
 type MigrateServer struct {
-	db     *gorm.DB
-	log    *log.Logger
-	casbin *casbin.CachedEnforcer
+	db            *gorm.DB
+	log           *log.Logger
+	casbin        *casbin.SyncedCachedEnforcer
+	policyService service.PolicyService
 }
 
-func NewMigrateServer(db *gorm.DB, log *log.Logger, casbin *casbin.CachedEnforcer) *MigrateServer {
+func NewMigrateServer(db *gorm.DB, log *log.Logger, casbin *casbin.SyncedCachedEnforcer, policyService service.PolicyService) *MigrateServer {
 	return &MigrateServer{
-		db:     db,
-		log:    log,
-		casbin: casbin,
+		db:            db,
+		log:           log,
+		casbin:        casbin,
+		policyService: policyService,
 	}
 }
 func (m *MigrateServer) Start(ctx context.Context) error {
@@ -30,23 +36,51 @@ func (m *MigrateServer) Start(ctx context.Context) error {
 		&model.User{},
 		&model.Role{},
 		&model.Permission{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.VerificationToken{},
+		&model.UserTotp{},
+		&model.MfaRecoveryCode{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
+		&model.LoginLog{},
+		&model.Session{},
+		&model.ApiKey{},
 	)
 	if err := m.db.AutoMigrate(
 		&model.User{},
 		&model.Role{},
 		&model.Permission{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.VerificationToken{},
+		&model.UserTotp{},
+		&model.MfaRecoveryCode{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
+		&model.LoginLog{},
+		&model.Session{},
+		&model.ApiKey{},
 	); err != nil {
 		m.log.Error("err: ", zap.Error(err))
 		return err
 	}
+	if err := repository.CreateUserEmailIndex(m.db); err != nil {
+		m.log.Error("CreateUserEmailIndex error", zap.Error(err))
+		return err
+	}
 	if err := m.initAdminUser(); err != nil {
 		m.log.Error("initAdminUser error", zap.Error(err))
 		return err
 	}
-	if err := m.initRBACAndDemoData(); err != nil {
+	if err := m.initRBACAndDemoData(ctx); err != nil {
 		m.log.Error("initRBACAndDemoData error", zap.Error(err))
 		return err
 	}
+	if err := m.backfillUserRoles(ctx); err != nil {
+		m.log.Error("backfillUserRoles error", zap.Error(err))
+		return err
+	}
 	m.log.Info("AutoMigrate success")
 	os.Exit(0)
 	return nil
@@ -67,22 +101,56 @@ func (m *MigrateServer) initAdminUser() error {
 		Password:  string(hashedPassword),
 		Email:     "admin@gmail.com",
 		Name:      "Administrator",
+		// 种子账号视为已验证邮箱
+		EmailVerifyTime: int(time.Now().Unix()),
 	}).Error
 }
 
 // 批量初始化权限、角色、用户、关联关系
-func (m *MigrateServer) initRBACAndDemoData() error {
+func (m *MigrateServer) initRBACAndDemoData(ctx context.Context) error {
 	// 1. 权限定义（菜单和API）
 	permissions := []model.Permission{
+		// 目录
+		{Model: gorm.Model{}, Name: "系统管理", Key: "dir:system", Type: model.PermissionTypeDirectory, Path: "/system"},
 		// 菜单权限
 		{Model: gorm.Model{}, Name: "用户管理", Key: "menu:user", Type: model.PermissionTypeMenu, Path: "/user", Component: "views/user/index"},
 		{Model: gorm.Model{}, Name: "角色管理", Key: "menu:role", Type: model.PermissionTypeMenu, Path: "/role", Component: "views/role/index"},
 		// API权限
-		{Model: gorm.Model{}, Name: "获取用户列表", Key: "api:user:list", Type: model.PermissionTypeButton, Api: "/v1/user/list", Method: "GET"},
+		{Model: gorm.Model{}, Name: "获取用户列表", Key: "api:user:list", Type: model.PermissionTypeButton, Api: "/v1/user/list", Method: "POST"},
 		{Model: gorm.Model{}, Name: "创建用户", Key: "api:user:create", Type: model.PermissionTypeButton, Api: "/v1/user", Method: "POST"},
 		{Model: gorm.Model{}, Name: "更新用户", Key: "api:user:update", Type: model.PermissionTypeButton, Api: "/v1/user", Method: "PUT"},
+		{Model: gorm.Model{}, Name: "解锁用户", Key: "api:user:unlock", Type: model.PermissionTypeButton, Api: "/v1/user/unlock", Method: "POST"},
+		{Model: gorm.Model{}, Name: "禁用用户", Key: "api:user:disable", Type: model.PermissionTypeButton, Api: "/v1/user/disable", Method: "POST"},
+		{Model: gorm.Model{}, Name: "启用用户", Key: "api:user:enable", Type: model.PermissionTypeButton, Api: "/v1/user/enable", Method: "POST"},
+		{Model: gorm.Model{}, Name: "注销用户", Key: "api:user:deactivate", Type: model.PermissionTypeButton, Api: "/v1/user/deactivate", Method: "POST"},
+		{Model: gorm.Model{}, Name: "已删除用户列表", Key: "api:user:deleted", Type: model.PermissionTypeButton, Api: "/v1/user/deleted", Method: "GET"},
+		{Model: gorm.Model{}, Name: "恢复用户", Key: "api:user:restore", Type: model.PermissionTypeButton, Api: "/v1/user/restore", Method: "POST"},
+		{Model: gorm.Model{}, Name: "永久删除用户", Key: "api:user:purge", Type: model.PermissionTypeButton, Api: "/v1/user/:id", Method: "DELETE"},
+		{Model: gorm.Model{}, Name: "批量导入用户", Key: "api:user:import", Type: model.PermissionTypeButton, Api: "/v1/user/import", Method: "POST"},
+		{Model: gorm.Model{}, Name: "导出用户", Key: "api:user:export", Type: model.PermissionTypeButton, Api: "/v1/user/export", Method: "GET"},
+		{Model: gorm.Model{}, Name: "查看用户会话", Key: "api:user:sessions", Type: model.PermissionTypeButton, Api: "/v1/user/sessions", Method: "GET"},
+		{Model: gorm.Model{}, Name: "强制用户下线", Key: "api:user:sessions:revoke", Type: model.PermissionTypeButton, Api: "/v1/user/sessions/revoke", Method: "POST"},
+		{Model: gorm.Model{}, Name: "查看登录日志", Key: "api:user:login-logs", Type: model.PermissionTypeButton, Api: "/v1/user/login-logs", Method: "GET"},
 		{Model: gorm.Model{}, Name: "获取角色列表", Key: "api:role:list", Type: model.PermissionTypeButton, Api: "/v1/role/list", Method: "GET"},
+		{Model: gorm.Model{}, Name: "创建角色", Key: "api:role:create", Type: model.PermissionTypeButton, Api: "/v1/role", Method: "POST"},
+		{Model: gorm.Model{}, Name: "修改角色权限", Key: "api:role:permissions:update", Type: model.PermissionTypeButton, Api: "/v1/role", Method: "PUT"},
+		{Model: gorm.Model{}, Name: "修改角色", Key: "api:role:update", Type: model.PermissionTypeButton, Api: "/v1/role/info", Method: "PUT"},
+		{Model: gorm.Model{}, Name: "删除角色", Key: "api:role:delete", Type: model.PermissionTypeButton, Api: "/v1/role/:id", Method: "DELETE"},
+		{Model: gorm.Model{}, Name: "设置角色继承", Key: "api:role:parents", Type: model.PermissionTypeButton, Api: "/v1/role/parents", Method: "PUT"},
+		{Model: gorm.Model{}, Name: "设置角色数据权限", Key: "api:role:data-scope", Type: model.PermissionTypeButton, Api: "/v1/role/data-scope", Method: "PUT"},
+		{Model: gorm.Model{}, Name: "查看角色有效权限", Key: "api:role:permissions", Type: model.PermissionTypeButton, Api: "/v1/role/:id/permissions", Method: "GET"},
 		{Model: gorm.Model{}, Name: "获取权限列表", Key: "api:permission:list", Type: model.PermissionTypeButton, Api: "/v1/permission/list", Method: "GET"},
+		{Model: gorm.Model{}, Name: "创建权限", Key: "api:permission:create", Type: model.PermissionTypeButton, Api: "/v1/permission", Method: "POST"},
+		{Model: gorm.Model{}, Name: "修改权限", Key: "api:permission:update", Type: model.PermissionTypeButton, Api: "/v1/permission", Method: "PUT"},
+		{Model: gorm.Model{}, Name: "删除权限", Key: "api:permission:delete", Type: model.PermissionTypeButton, Api: "/v1/permission/:id", Method: "DELETE"},
+		{Model: gorm.Model{}, Name: "获取权限树", Key: "api:permission:tree", Type: model.PermissionTypeButton, Api: "/v1/permission/tree", Method: "GET"},
+		{Model: gorm.Model{}, Name: "检查策略漂移", Key: "api:policy:drift", Type: model.PermissionTypeButton, Api: "/v1/policy/drift", Method: "GET"},
+		{Model: gorm.Model{}, Name: "修复策略漂移", Key: "api:policy:reconcile", Type: model.PermissionTypeButton, Api: "/v1/policy/reconcile", Method: "POST"},
+		{Model: gorm.Model{}, Name: "同步接口权限", Key: "api:policy:sync-routes", Type: model.PermissionTypeButton, Api: "/v1/policy/sync-routes", Method: "POST"},
+		{Model: gorm.Model{}, Name: "模拟权限检查", Key: "api:policy:check", Type: model.PermissionTypeButton, Api: "/v1/policy/check", Method: "POST"},
+		{Model: gorm.Model{}, Name: "反查接口权限", Key: "api:policy:who-can", Type: model.PermissionTypeButton, Api: "/v1/policy/who-can", Method: "GET"},
+		{Model: gorm.Model{}, Name: "导出 RBAC 配置", Key: "api:rbac:export", Type: model.PermissionTypeButton, Api: "/v1/rbac/export", Method: "GET"},
+		{Model: gorm.Model{}, Name: "导入 RBAC 配置", Key: "api:rbac:import", Type: model.PermissionTypeButton, Api: "/v1/rbac/import", Method: "POST"},
 		{Model: gorm.Model{}, Name: "上传文件", Key: "api:common:upload", Type: model.PermissionTypeButton, Api: "/v1/common/upload", Method: "POST"},
 		{Model: gorm.Model{}, Name: "获取个人信息", Key: "api:profile:get", Type: model.PermissionTypeButton, Api: "/v1/profile", Method: "GET"},
 		{Model: gorm.Model{}, Name: "更新个人信息", Key: "api:profile:put", Type: model.PermissionTypeButton, Api: "/v1/profile", Method: "PUT"},
@@ -90,10 +158,36 @@ func (m *MigrateServer) initRBACAndDemoData() error {
 	if err := m.db.Create(&permissions).Error; err != nil {
 		return err
 	}
+	// 菜单挂到目录下，按钮按 Key 前缀挂到所属菜单下
+	permissionIds := make(map[string]uint, len(permissions))
+	for _, p := range permissions {
+		permissionIds[p.Key] = p.ID
+	}
+	for i, p := range permissions {
+		var parent string
+		switch {
+		case p.Type == model.PermissionTypeMenu:
+			parent = "dir:system"
+		case strings.HasPrefix(p.Key, "api:user:"):
+			parent = "menu:user"
+		case strings.HasPrefix(p.Key, "api:role:"), strings.HasPrefix(p.Key, "api:permission:"), strings.HasPrefix(p.Key, "api:policy:"), strings.HasPrefix(p.Key, "api:rbac:"):
+			parent = "menu:role"
+		default:
+			continue
+		}
+		if err := m.db.Model(&permissions[i]).Update("parent_id", permissionIds[parent]).Error; err != nil {
+			return err
+		}
+	}
+
+	// 其余挂载了 AuthMiddleware 的路由按路由自动补齐按钮权限，不挂到菜单下
+	if _, err := m.policyService.SyncApiPermissions(ctx, true); err != nil {
+		return err
+	}
 
 	// 2. 角色定义
-	adminRole := model.Role{Name: "管理员", Sid: "admin"}
-	devRole := model.Role{Name: "开发者", Sid: "dev"}
+	adminRole := model.Role{Name: "管理员", Sid: "admin", DataScope: model.DataScopeAll}
+	devRole := model.Role{Name: "开发者", Sid: "dev", DataScope: model.DataScopeSelf}
 	if err := m.db.Create(&adminRole).Error; err != nil {
 		return err
 	}
@@ -106,8 +200,9 @@ func (m *MigrateServer) initRBACAndDemoData() error {
 		h, _ := bcrypt.GenerateFromPassword([]byte(p), bcrypt.DefaultCost)
 		return string(h)
 	}
-	adminUser := model.User{UserId: "admin-uid", Email: "admin@gmail.com", Password: hashPwd("123456"), Name: "Admin"}
-	devUser := model.User{UserId: "user-uid", Email: "user@gmail.com", Password: hashPwd("123456"), Name: "DevUser"}
+	now := int(time.Now().Unix())
+	adminUser := model.User{UserId: "admin-uid", Email: "admin@gmail.com", Password: hashPwd("123456"), Name: "Admin", EmailVerifyTime: now}
+	devUser := model.User{UserId: "user-uid", Email: "user@gmail.com", Password: hashPwd("123456"), Name: "DevUser", EmailVerifyTime: now}
 	if err := m.db.Create(&adminUser).Error; err != nil {
 		return err
 	}
@@ -121,27 +216,23 @@ func (m *MigrateServer) initRBACAndDemoData() error {
 		return err
 	}
 	var devPerms []model.Permission
-	var uploadPerm *model.Permission
-	var updateProfilePerm *model.Permission
-	for i, p := range allPerms {
-		if p.Key == "api:common:upload" {
-			uploadPerm = &allPerms[i]
-		}
-		if p.Key == "api:profile:put" {
-			updateProfilePerm = &allPerms[i]
-		}
-		if p.Method == "GET" || p.Type == model.PermissionTypeMenu {
+	// dev 只分配明确列出的权限，新增的接口不会自动开放给 dev
+	devGrants := map[string]bool{
+		"menu:user":           true,
+		"menu:role":           true,
+		"api:user:list":       true,
+		"api:role:list":       true,
+		"api:permission:list": true,
+		"api:permission:tree": true,
+		"api:profile:get":     true,
+		"api:profile:put":     true,
+		"api:common:upload":   true,
+	}
+	for _, p := range allPerms {
+		if devGrants[p.Key] {
 			devPerms = append(devPerms, p)
 		}
 	}
-	// dev 也分配上传图片权限
-	if uploadPerm != nil {
-		devPerms = append(devPerms, *uploadPerm)
-	}
-	// dev 也分配更新个人信息权限
-	if updateProfilePerm != nil {
-		devPerms = append(devPerms, *updateProfilePerm)
-	}
 
 	// 手动同步 GORM 关联和 Casbin
 	if err := m.db.Model(&adminRole).Association("Permissions").Replace(allPerms); err != nil {
@@ -151,37 +242,16 @@ func (m *MigrateServer) initRBACAndDemoData() error {
 		return err
 	}
 
-	// Casbin 同步
-	// admin
-	m.casbin.RemoveFilteredPolicy(0, adminRole.Sid)
-	for _, p := range allPerms {
-		if p.Method != "" {
-			var obj string
-			switch p.Type {
-			case model.PermissionTypeMenu:
-				obj = "menu:" + p.Path
-			case model.PermissionTypeButton:
-				obj = "api:" + p.Api
-			default:
-				continue
-			}
-			m.casbin.AddPolicy(adminRole.Sid, obj, p.Method)
+	// Casbin 同步，规则与 roleService 的写法一致，PolicyService 对账时也以此为准
+	for _, rp := range []struct {
+		role  model.Role
+		perms []model.Permission
+	}{{adminRole, allPerms}, {devRole, devPerms}} {
+		if _, err := m.casbin.RemoveFilteredPolicy(0, rp.role.Sid); err != nil {
+			return err
 		}
-	}
-	// dev
-	m.casbin.RemoveFilteredPolicy(0, devRole.Sid)
-	for _, p := range devPerms {
-		if p.Method != "" {
-			var obj string
-			switch p.Type {
-			case model.PermissionTypeMenu:
-				obj = "menu:" + p.Path
-			case model.PermissionTypeButton:
-				obj = "api:" + p.Api
-			default:
-				continue
-			}
-			m.casbin.AddPolicy(devRole.Sid, obj, p.Method)
+		if _, err := m.casbin.AddPolicies(model.RolePolicies(rp.role.Sid, rp.perms)); err != nil {
+			return err
 		}
 	}
 
@@ -192,6 +262,15 @@ func (m *MigrateServer) initRBACAndDemoData() error {
 	if err := m.db.Model(&devUser).Association("Roles").Replace([]model.Role{devRole}); err != nil {
 		return err
 	}
+	return nil
+}
 
+// backfillUserRoles 为 sys_user_roles 中的全部用户补齐 g 规则，已存在的规则不会重复写入
+func (m *MigrateServer) backfillUserRoles(ctx context.Context) error {
+	added, err := m.policyService.BackfillUserRoles(ctx)
+	if err != nil {
+		return err
+	}
+	m.log.Info("backfill user roles", zap.Int("added", added))
 	return nil
 }
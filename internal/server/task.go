@@ -7,22 +7,29 @@ import (
 	"time"
 
 	"github.com/go-co-op/gocron"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 type TaskServer struct {
-	log       *log.Logger
-	scheduler *gocron.Scheduler
-	userTask  task.UserTask
+	log        *log.Logger
+	conf       *viper.Viper
+	scheduler  *gocron.Scheduler
+	userTask   task.UserTask
+	policyTask task.PolicyTask
 }
 
 func NewTaskServer(
 	log *log.Logger,
+	conf *viper.Viper,
 	userTask task.UserTask,
+	policyTask task.PolicyTask,
 ) *TaskServer {
 	return &TaskServer{
-		log:      log,
-		userTask: userTask,
+		log:        log,
+		conf:       conf,
+		userTask:   userTask,
+		policyTask: policyTask,
 	}
 }
 func (t *TaskServer) Start(ctx context.Context) error {
@@ -46,6 +53,19 @@ func (t *TaskServer) Start(ctx context.Context) error {
 		t.log.Error("CheckUser error", zap.Error(err))
 	}
 
+	// casbin_rule 与关系表对账，cron 为空时不启用
+	if spec := t.conf.GetString("task.policy_reconcile.cron"); spec != "" {
+		apply := t.conf.GetBool("task.policy_reconcile.apply")
+		_, err = t.scheduler.CronWithSeconds(spec).Do(func() {
+			if err := t.policyTask.ReconcilePolicies(ctx, apply); err != nil {
+				t.log.Error("ReconcilePolicies error", zap.Error(err))
+			}
+		})
+		if err != nil {
+			t.log.Error("ReconcilePolicies error", zap.Error(err))
+		}
+	}
+
 	t.scheduler.StartBlocking()
 	return nil
 }
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/pkg/mail"
+	"net/url"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AccountService interface {
+	ForgotPassword(ctx context.Context, req *v1.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *v1.ResetPasswordRequest) error
+	SendVerifyEmail(ctx context.Context, user *model.User) error
+	ResendVerifyEmail(ctx context.Context, req *v1.ResendVerifyEmailRequest) error
+	VerifyEmail(ctx context.Context, req *v1.VerifyEmailRequest) error
+	// SendSetPasswordEmail 给管理员导入的用户发送设置密码的链接，复用重置密码的令牌
+	SendSetPasswordEmail(ctx context.Context, user *model.User) error
+}
+
+func NewAccountService(
+	service *Service,
+	conf *viper.Viper,
+	userRepo repository.UserRepository,
+	verificationRepo repository.VerificationTokenRepository,
+	tokenService TokenService,
+	mailSender mail.Sender,
+) AccountService {
+	resetTTL := conf.GetDuration("security.account.password_reset_ttl")
+	if resetTTL <= 0 {
+		resetTTL = 30 * time.Minute
+	}
+	verifyTTL := conf.GetDuration("security.account.email_verify_ttl")
+	if verifyTTL <= 0 {
+		verifyTTL = 48 * time.Hour
+	}
+	inviteTTL := conf.GetDuration("security.account.invite_ttl")
+	if inviteTTL <= 0 {
+		inviteTTL = 7 * 24 * time.Hour
+	}
+	return &accountService{
+		Service:          service,
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		tokenService:     tokenService,
+		mailSender:       mailSender,
+		publicUrl:        conf.GetString("http.public_url"),
+		resetTTL:         resetTTL,
+		verifyTTL:        verifyTTL,
+		inviteTTL:        inviteTTL,
+	}
+}
+
+type accountService struct {
+	*Service
+	userRepo         repository.UserRepository
+	verificationRepo repository.VerificationTokenRepository
+	tokenService     TokenService
+	mailSender       mail.Sender
+	publicUrl        string
+	resetTTL         time.Duration
+	verifyTTL        time.Duration
+	inviteTTL        time.Duration
+}
+
+// ForgotPassword 无论邮箱是否存在都返回成功，避免泄露注册信息
+func (s *accountService) ForgotPassword(ctx context.Context, req *v1.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	token, err := s.createToken(ctx, user.UserId, model.VerificationPurposePasswordReset, s.resetTTL)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user.Email, "Reset your password", fmt.Sprintf(
+		"Someone asked to reset the password of your account.\n\nOpen the link below within %s to choose a new password:\n%s\n\nIf it was not you, ignore this email.",
+		s.resetTTL, s.link("/password/reset", token),
+	))
+}
+
+func (s *accountService) ResetPassword(ctx context.Context, req *v1.ResetPasswordRequest) error {
+	token, err := s.consumeToken(ctx, model.VerificationPurposePasswordReset, req.Token)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, token.UserId)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashedPassword)
+	// 能收到邮件说明邮箱属于本人
+	if user.EmailVerifyTime == 0 {
+		user.EmailVerifyTime = int(time.Now().Unix())
+	}
+	if err = s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	// 密码已改，之前签发的会话全部失效
+	return s.tokenService.RevokeUserTokens(ctx, user.UserId)
+}
+
+func (s *accountService) SendVerifyEmail(ctx context.Context, user *model.User) error {
+	if user.EmailVerifyTime > 0 {
+		return nil
+	}
+	token, err := s.createToken(ctx, user.UserId, model.VerificationPurposeEmailVerify, s.verifyTTL)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user.Email, "Verify your email address", fmt.Sprintf(
+		"Open the link below within %s to verify your email address:\n%s",
+		s.verifyTTL, s.link("/email/verify", token),
+	))
+}
+
+func (s *accountService) SendSetPasswordEmail(ctx context.Context, user *model.User) error {
+	token, err := s.createToken(ctx, user.UserId, model.VerificationPurposePasswordReset, s.inviteTTL)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user.Email, "Set up your account", fmt.Sprintf(
+		"An account has been created for you.\n\nOpen the link below within %s to choose your password:\n%s",
+		s.inviteTTL, s.link("/password/reset", token),
+	))
+}
+
+// ResendVerifyEmail 同 ForgotPassword，不暴露邮箱是否存在
+func (s *accountService) ResendVerifyEmail(ctx context.Context, req *v1.ResendVerifyEmailRequest) error {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	return s.SendVerifyEmail(ctx, user)
+}
+
+func (s *accountService) VerifyEmail(ctx context.Context, req *v1.VerifyEmailRequest) error {
+	token, err := s.consumeToken(ctx, model.VerificationPurposeEmailVerify, req.Token)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, token.UserId)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifyTime > 0 {
+		return nil
+	}
+	user.EmailVerifyTime = int(time.Now().Unix())
+	return s.userRepo.Update(ctx, user)
+}
+
+// createToken 新令牌生效前作废同用途的旧令牌
+func (s *accountService) createToken(ctx context.Context, userId string, purpose string, ttl time.Duration) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.verificationRepo.InvalidateUserTokens(ctx, userId, purpose); err != nil {
+			return err
+		}
+		return s.verificationRepo.Create(ctx, &model.VerificationToken{
+			UserId:    userId,
+			Purpose:   purpose,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(ttl),
+		})
+	})
+	return token, err
+}
+
+func (s *accountService) consumeToken(ctx context.Context, purpose string, raw string) (*model.VerificationToken, error) {
+	token, err := s.verificationRepo.GetByHash(ctx, purpose, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrInvalidLink
+		}
+		return nil, err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, v1.ErrInvalidLink
+	}
+	ok, err := s.verificationRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, v1.ErrInvalidLink
+	}
+	return token, nil
+}
+
+func (s *accountService) link(path string, token string) string {
+	return s.publicUrl + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *accountService) send(ctx context.Context, to string, subject string, body string) error {
+	if err := s.mailSender.Send(ctx, &mail.Message{
+		To:      []string{to},
+		Subject: subject,
+		Body:    body,
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("mailSender.Send error", zap.String("subject", subject), zap.Error(err))
+		return err
+	}
+	return nil
+}
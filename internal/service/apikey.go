@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// apiKeyPrefix 便于在日志、代码仓库中识别泄露的 key
+	apiKeyPrefix = "nk_"
+	// apiKeyTouchInterval 最近使用时间的更新间隔，避免每个请求都写库
+	apiKeyTouchInterval = time.Minute
+)
+
+type ApiKeyService interface {
+	CreateApiKey(ctx context.Context, userId string, req *v1.CreateApiKeyRequest) (*v1.CreateApiKeyResponseData, error)
+	ListApiKeys(ctx context.Context, userId string) (*v1.ListApiKeysResponseData, error)
+	DeleteApiKey(ctx context.Context, userId string, id uint) error
+	// VerifyApiKey 校验 Authorization: ApiKey 携带的 key，失败统一返回 ErrUnauthorized
+	VerifyApiKey(ctx context.Context, key string, ip string) (*model.ApiKey, error)
+}
+
+func NewApiKeyService(
+	service *Service,
+	apiKeyRepo repository.ApiKeyRepository,
+	userRepo repository.UserRepository,
+) ApiKeyService {
+	return &apiKeyService{
+		Service:    service,
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+type apiKeyService struct {
+	*Service
+	apiKeyRepo repository.ApiKeyRepository
+	userRepo   repository.UserRepository
+}
+
+func (s *apiKeyService) CreateApiKey(ctx context.Context, userId string, req *v1.CreateApiKeyRequest) (*v1.CreateApiKeyResponseData, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		method, path, err := model.ParseApiKeyScope(scope)
+		if err != nil {
+			return nil, v1.ErrApiKeyScopeInvalid
+		}
+		scopes = append(scopes, method+" "+path)
+	}
+
+	secret, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := apiKeyPrefix + secret
+	key := &model.ApiKey{
+		UserId:  userId,
+		Name:    req.Name,
+		Prefix:  raw[:len(apiKeyPrefix)+6],
+		KeyHash: hashToken(raw),
+		Scopes:  strings.Join(scopes, model.PermSep),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err = s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &v1.CreateApiKeyResponseData{
+		ApiKeyData: toApiKeyData(key),
+		Key:        raw,
+	}, nil
+}
+
+func (s *apiKeyService) ListApiKeys(ctx context.Context, userId string) (*v1.ListApiKeysResponseData, error) {
+	keys, err := s.apiKeyRepo.ListByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.ListApiKeysResponseData{List: make([]v1.ApiKeyData, 0, len(keys))}
+	for i := range keys {
+		data.List = append(data.List, toApiKeyData(&keys[i]))
+	}
+	return data, nil
+}
+
+func (s *apiKeyService) DeleteApiKey(ctx context.Context, userId string, id uint) error {
+	return s.apiKeyRepo.Delete(ctx, userId, id)
+}
+
+func (s *apiKeyService) VerifyApiKey(ctx context.Context, raw string, ip string) (*model.ApiKey, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, apiKeyPrefix) {
+		return nil, v1.ErrUnauthorized
+	}
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashToken(raw))
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrUnauthorized
+		}
+		return nil, err
+	}
+	if key.Expired() {
+		return nil, v1.ErrUnauthorized
+	}
+	// 用户被删除、禁用或注销后 key 随之失效
+	user, err := s.userRepo.GetByID(ctx, key.UserId)
+	if err != nil || !user.Active() {
+		return nil, v1.ErrUnauthorized
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval || key.LastUsedIP != ip {
+		// 最近使用时间只是参考信息，写入失败不影响本次请求
+		if err = s.apiKeyRepo.TouchLastUsed(ctx, key.ID, ip, now); err != nil {
+			s.logger.WithContext(ctx).Error("apiKeyRepo.TouchLastUsed error", zap.Error(err))
+		}
+	}
+	return key, nil
+}
+
+func toApiKeyData(key *model.ApiKey) v1.ApiKeyData {
+	return v1.ApiKeyData{
+		Id:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+}
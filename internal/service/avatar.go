@@ -0,0 +1,316 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/aws"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// avatarUploadPrefix aws.UploadSceneUserProfile 生成的 key 前缀，后面是上传者的 UserId，头像只能引用自己目录下的文件
+const avatarUploadPrefix = "user_profile/"
+
+// ObjectStorage 头像处理用到的对象存储操作，由 *aws.CloudflareR2 实现
+type ObjectStorage interface {
+	// HeadObject 返回对象大小，不存在时返回 aws.ErrObjectNotFound
+	HeadObject(ctx context.Context, key string) (int64, error)
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	PutObject(ctx context.Context, key string, body []byte, contentType string) error
+	DeleteObjects(ctx context.Context, keys ...string) error
+	ObjectURL(key string) string
+	ObjectKey(url string) string
+}
+
+type AvatarService interface {
+	// ProcessAvatar 校验客户端上传的头像（地址或 key）属于 userId，重新编码去掉 EXIF 并生成各尺寸缩略图，返回新文件的 key
+	// 上传的原文件处理后删除
+	ProcessAvatar(ctx context.Context, userId string, avatar string) (model.AvatarKeys, error)
+	// AvatarURL 处理后原图的地址
+	AvatarURL(keys model.AvatarKeys) string
+	// AvatarURLs 按名称返回全部头像地址
+	AvatarURLs(keys model.AvatarKeys) map[string]string
+	// DeleteAvatar 删除不再使用的头像文件，失败只记录日志
+	DeleteAvatar(ctx context.Context, keys model.AvatarKeys)
+}
+
+func NewAvatarService(
+	service *Service,
+	conf *viper.Viper,
+	storage ObjectStorage,
+) AvatarService {
+	maxSize := int64(conf.GetSizeInBytes("avatar.max_size"))
+	if maxSize <= 0 {
+		maxSize = 5 << 20
+	}
+	maxDimension := conf.GetInt("avatar.max_dimension")
+	if maxDimension <= 0 {
+		maxDimension = 4096
+	}
+	sizes := conf.GetIntSlice("avatar.sizes")
+	if len(sizes) == 0 {
+		sizes = []int{64, 128, 512}
+	}
+	return &avatarService{
+		Service:      service,
+		storage:      storage,
+		maxSize:      maxSize,
+		maxDimension: maxDimension,
+		sizes:        sizes,
+	}
+}
+
+type avatarService struct {
+	*Service
+	storage      ObjectStorage
+	maxSize      int64
+	maxDimension int
+	sizes        []int
+}
+
+func (s *avatarService) ProcessAvatar(ctx context.Context, userId string, avatar string) (model.AvatarKeys, error) {
+	key := s.storage.ObjectKey(avatar)
+	// 只能使用自己上传的文件，不能引用他人上传、尚未处理的原图
+	name, ok := strings.CutPrefix(key, avatarUploadPrefix+userId+"/")
+	if userId == "" || !ok || name == "" || strings.Contains(name, "/") || strings.Contains(key, "..") {
+		return nil, fmt.Errorf("%w: not an uploaded avatar", v1.ErrAvatarInvalid)
+	}
+	data, err := s.download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	img, format, err := s.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// 透明背景只有 png 能保留，其余统一转为 jpeg
+	ext, contentType, encode := "jpg", "image/jpeg", func(w io.Writer, m image.Image) error {
+		return jpeg.Encode(w, m, &jpeg.Options{Quality: 90})
+	}
+	if format == "png" {
+		ext, contentType, encode = "png", "image/png", png.Encode
+	}
+
+	dir := fmt.Sprintf("avatar/%s/%s/", userId, uuid.NewString())
+	keys := model.AvatarKeys{}
+	put := func(name string, m image.Image) error {
+		var buf bytes.Buffer
+		if err := encode(&buf, m); err != nil {
+			return err
+		}
+		objectKey := dir + name + "." + ext
+		if err := s.storage.PutObject(ctx, objectKey, buf.Bytes(), contentType); err != nil {
+			return err
+		}
+		keys[name] = objectKey
+		return nil
+	}
+	// 重新编码即去掉了 EXIF 等元数据
+	err = put(model.AvatarOriginal, img)
+	for _, size := range s.sizes {
+		if err != nil {
+			break
+		}
+		err = put(model.AvatarSizeName(size), thumbnail(img, size))
+	}
+	if err != nil {
+		s.DeleteAvatar(ctx, keys)
+		return nil, err
+	}
+
+	// 原文件带有 EXIF，处理完成后不再保留
+	if err = s.storage.DeleteObjects(ctx, key); err != nil {
+		s.logger.WithContext(ctx).Warn("delete uploaded avatar failed", zap.String("key", key), zap.Error(err))
+	}
+	return keys, nil
+}
+
+func (s *avatarService) AvatarURL(keys model.AvatarKeys) string {
+	if key, ok := keys[model.AvatarOriginal]; ok {
+		return s.storage.ObjectURL(key)
+	}
+	return ""
+}
+
+func (s *avatarService) AvatarURLs(keys model.AvatarKeys) map[string]string {
+	urls := make(map[string]string, len(keys))
+	for name, key := range keys {
+		urls[name] = s.storage.ObjectURL(key)
+	}
+	return urls
+}
+
+func (s *avatarService) DeleteAvatar(ctx context.Context, keys model.AvatarKeys) {
+	if len(keys) == 0 {
+		return
+	}
+	objectKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		objectKeys = append(objectKeys, key)
+	}
+	if err := s.storage.DeleteObjects(ctx, objectKeys...); err != nil {
+		s.logger.WithContext(ctx).Warn("delete avatar failed", zap.Strings("keys", objectKeys), zap.Error(err))
+	}
+}
+
+// download 先检查对象是否存在和大小，读取时再限制一次，防止对象在两次请求之间被替换
+func (s *avatarService) download(ctx context.Context, key string) ([]byte, error) {
+	size, err := s.storage.HeadObject(ctx, key)
+	if err != nil {
+		if errors.Is(err, aws.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: the file has not been uploaded", v1.ErrAvatarInvalid)
+		}
+		return nil, err
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", v1.ErrAvatarInvalid, s.maxSize)
+	}
+	body, err := s.storage.GetObject(ctx, key)
+	if err != nil {
+		if errors.Is(err, aws.ErrObjectNotFound) {
+			return nil, fmt.Errorf("%w: the file has not been uploaded", v1.ErrAvatarInvalid)
+		}
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, fmt.Errorf("%w: larger than %d bytes", v1.ErrAvatarInvalid, s.maxSize)
+	}
+	return data, nil
+}
+
+// decode 解码前先读取尺寸，避免超大图片占满内存；jpeg 按 EXIF 方向摆正
+func (s *avatarService) decode(data []byte) (image.Image, string, error) {
+	conf, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: only png, jpeg and webp are supported", v1.ErrAvatarInvalid)
+	}
+	if conf.Width > s.maxDimension || conf.Height > s.maxDimension {
+		return nil, "", fmt.Errorf("%w: larger than %dx%d pixels", v1.ErrAvatarInvalid, s.maxDimension, s.maxDimension)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", v1.ErrAvatarInvalid, err.Error())
+	}
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// thumbnail 居中裁成正方形后缩放到 size，小图不放大
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x, y := b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2
+	size = min(size, side)
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, image.Rect(x, y, x+side, y+side), draw.Src, nil)
+	return dst
+}
+
+// orient 按 EXIF Orientation (1-8) 旋转或翻转，使去掉 EXIF 后方向不变
+func orient(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// jpegOrientation 读取 APP1 中 EXIF 的 Orientation 标签，没有时返回 1
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
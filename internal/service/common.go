@@ -1,27 +1,42 @@
 package service
 
-import "go-nunu/pkg/aws" // 导入 R2 客户端
+import (
+	"fmt"
+	"go-nunu/pkg/aws" // 导入 R2 客户端
+
+	"github.com/spf13/viper"
+)
 
 type CommonService interface {
-	UploadPresignedUrl(fileExt string, scene int) (string, string, error)
+	// UploadPresignedUrl userId 为上传者，头像上传到该用户自己的目录
+	UploadPresignedUrl(userId string, fileExt string, scene int, fileSize int64) (string, string, error)
 }
 
 type commonService struct {
 	// R2 客户端应该是直接依赖，而不是通过 *Service 间接依赖
 	R2Client *aws.CloudflareR2
 	// *Service // 如果不需要 *Service 的其他字段，就移除它
+	avatarMaxSize int64
 }
 
 // NewCommonService 的构造函数，直接接收 R2 客户端
-func NewCommonService(r2Client *aws.CloudflareR2) CommonService {
+func NewCommonService(r2Client *aws.CloudflareR2, conf *viper.Viper) CommonService {
+	avatarMaxSize := int64(conf.GetSizeInBytes("avatar.max_size"))
+	if avatarMaxSize <= 0 {
+		avatarMaxSize = 5 << 20
+	}
 	return &commonService{
-		R2Client: r2Client,
+		R2Client:      r2Client,
+		avatarMaxSize: avatarMaxSize,
 		// Service: service, // 如果保留 *Service，需要确保它也作为参数传入
 	}
 }
 
 // UploadPresignedUrl generates a presigned URL for uploading a file to R2
-func (c *commonService) UploadPresignedUrl(fileExt string, scene int) (string, string, error) {
-	// TODO: Implement the presigned URL generation logic
-	return c.R2Client.UploadPresignedUrl(fileExt, scene)
+func (c *commonService) UploadPresignedUrl(userId string, fileExt string, scene int, fileSize int64) (string, string, error) {
+	// 头像必须声明文件大小，签名后 R2 拒绝大小不一致的上传
+	if scene == aws.UploadSceneUserProfile && (fileSize <= 0 || fileSize > c.avatarMaxSize) {
+		return "", "", fmt.Errorf("头像大小必须在 1 到 %d 字节之间: %d", c.avatarMaxSize, fileSize)
+	}
+	return c.R2Client.UploadPresignedUrl(userId, fileExt, scene, fileSize)
 }
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/lockout"
+	"go-nunu/pkg/totp"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const recoveryCodeCount = 10
+
+type MfaService interface {
+	GetStatus(ctx context.Context, userId string) (*v1.GetMfaStatusResponseData, error)
+	EnrollTotp(ctx context.Context, userId string) (*v1.EnrollTotpResponseData, error)
+	ActivateTotp(ctx context.Context, userId string, code string) (*v1.RecoveryCodesResponseData, error)
+	DisableTotp(ctx context.Context, userId string, req *v1.MfaCodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userId string, req *v1.MfaCodeRequest) (*v1.RecoveryCodesResponseData, error)
+
+	// Challenge 密码校验通过后调用，需要二次验证时返回带 mfaToken 的响应，否则返回 nil
+	// guestId 不为空时记录在 mfaToken 中，二次验证通过后才把该访客合并到 user
+	Challenge(ctx context.Context, user *model.User, loginType int, guestId string) (*v1.LoginResponseData, error)
+	LoginMfa(ctx context.Context, req *v1.LoginMfaRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	LoginMfaEnroll(ctx context.Context, req *v1.LoginMfaEnrollRequest) (*v1.EnrollTotpResponseData, error)
+	LoginMfaActivate(ctx context.Context, req *v1.LoginMfaActivateRequest, client *model.ClientInfo) (*v1.LoginMfaActivateResponseData, error)
+}
+
+func NewMfaService(
+	service *Service,
+	conf *viper.Viper,
+	userRepo repository.UserRepository,
+	mfaRepo repository.MfaRepository,
+	tokenRepo repository.TokenRepository,
+	tokenService TokenService,
+	sessionService SessionService,
+	loginGuard *lockout.Guard,
+) MfaService {
+	pendingTTL := conf.GetDuration("security.mfa.pending_ttl")
+	if pendingTTL <= 0 {
+		pendingTTL = 5 * time.Minute
+	}
+	issuer := conf.GetString("security.mfa.issuer")
+	if issuer == "" {
+		issuer = "go-nunu"
+	}
+	return &mfaService{
+		Service:          service,
+		userRepo:         userRepo,
+		mfaRepo:          mfaRepo,
+		tokenRepo:        tokenRepo,
+		tokenService:     tokenService,
+		sessionService:   sessionService,
+		loginGuard:       loginGuard,
+		issuer:           issuer,
+		pendingTTL:       pendingTTL,
+		requiredForAdmin: conf.GetBool("security.mfa.required_for_admin"),
+	}
+}
+
+type mfaService struct {
+	*Service
+	userRepo         repository.UserRepository
+	mfaRepo          repository.MfaRepository
+	tokenRepo        repository.TokenRepository
+	tokenService     TokenService
+	sessionService   SessionService
+	loginGuard       *lockout.Guard
+	issuer           string
+	pendingTTL       time.Duration
+	requiredForAdmin bool
+}
+
+func (s *mfaService) GetStatus(ctx context.Context, userId string) (*v1.GetMfaStatusResponseData, error) {
+	user, err := s.userRepo.GetByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.mfaRepo.CountRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetMfaStatusResponseData{
+		TotpEnabled:   t.Enabled(),
+		Required:      s.required(user),
+		RecoveryCodes: count,
+	}, nil
+}
+
+// EnrollTotp 生成新密钥，需要再用 ActivateTotp 提交一次验证码才会生效
+func (s *mfaService) EnrollTotp(ctx context.Context, userId string) (*v1.EnrollTotpResponseData, error) {
+	user, err := s.userRepo.GetByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if t.Enabled() {
+		return nil, v1.ErrMfaAlreadyActive
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		t = &model.UserTotp{UserId: userId}
+	}
+	t.Secret = secret
+	t.LastUsedStep = 0
+	if err = s.mfaRepo.SaveTotp(ctx, t); err != nil {
+		return nil, err
+	}
+	return &v1.EnrollTotpResponseData{
+		Secret:     secret,
+		OtpauthUri: totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) ActivateTotp(ctx context.Context, userId string, code string) (*v1.RecoveryCodesResponseData, error) {
+	t, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, v1.ErrMfaNotEnrolled
+	}
+	if t.Enabled() {
+		return nil, v1.ErrMfaAlreadyActive
+	}
+	step, ok := totp.Validate(t.Secret, code, time.Now(), 1)
+	if !ok {
+		return nil, v1.ErrMfaCodeInvalid
+	}
+
+	var codes []string
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		t.EnabledAt = &now
+		t.LastUsedStep = step
+		if err := s.mfaRepo.SaveTotp(ctx, t); err != nil {
+			return err
+		}
+		codes, err = s.replaceRecoveryCodes(ctx, userId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RecoveryCodesResponseData{RecoveryCodes: codes}, nil
+}
+
+func (s *mfaService) DisableTotp(ctx context.Context, userId string, req *v1.MfaCodeRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if s.required(user) {
+		return v1.ErrMfaRequired
+	}
+	t, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !t.Enabled() {
+		return v1.ErrMfaNotEnrolled
+	}
+	if err = s.verify(ctx, t, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+	return s.mfaRepo.DeleteTotp(ctx, userId)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userId string, req *v1.MfaCodeRequest) (*v1.RecoveryCodesResponseData, error) {
+	t, err := s.getTotp(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Enabled() {
+		return nil, v1.ErrMfaNotEnrolled
+	}
+	// 只接受 TOTP 验证码，避免用最后一个恢复码无限续期
+	if err = s.verify(ctx, t, req.Code, ""); err != nil {
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.RecoveryCodesResponseData{RecoveryCodes: codes}, nil
+}
+
+func (s *mfaService) Challenge(ctx context.Context, user *model.User, loginType int, guestId string) (*v1.LoginResponseData, error) {
+	t, err := s.getTotp(ctx, user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	enabled := t.Enabled()
+	if !enabled && !s.required(user) {
+		return nil, nil
+	}
+	mfaToken, err := s.jwt.GenMfaPendingToken(user, loginType, guestId, uuid.NewString(), time.Now().Add(s.pendingTTL))
+	if err != nil {
+		return nil, err
+	}
+	return &v1.LoginResponseData{
+		MfaRequired:       enabled,
+		MfaEnrollRequired: !enabled,
+		MfaToken:          mfaToken,
+	}, nil
+}
+
+func (s *mfaService) LoginMfa(ctx context.Context, req *v1.LoginMfaRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	user, claims, err := s.parsePendingToken(ctx, req.MfaToken)
+	if err != nil {
+		return nil, err
+	}
+	entry := &model.LoginLog{
+		UserId:    user.UserId,
+		Email:     user.Email,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		LoginType: claims.LoginType,
+	}
+	// 验证码与密码共用失败计数，重新提交密码换 mfaToken 不会重置计数
+	account := guardAccount(user)
+	if locked, err := s.lockPendingIfLocked(ctx, claims, account, client.IP); err != nil || locked {
+		if locked {
+			entry.Result = model.LoginResultLocked
+			s.sessionService.RecordLogin(ctx, entry)
+		}
+		return nil, err
+	}
+	t, err := s.getTotp(ctx, user.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Enabled() {
+		return nil, v1.ErrMfaNotEnrolled
+	}
+	if err = s.verify(ctx, t, req.Code, req.RecoveryCode); err != nil {
+		if !errors.Is(err, v1.ErrMfaCodeInvalid) {
+			return nil, err
+		}
+		entry.Result = model.LoginResultMfaFailed
+		s.sessionService.RecordLogin(ctx, entry)
+		if err = s.loginGuard.Fail(ctx, account, client.IP); err != nil {
+			return nil, err
+		}
+		if locked, err := s.lockPendingIfLocked(ctx, claims, account, client.IP); err != nil || locked {
+			return nil, err
+		}
+		return nil, v1.ErrMfaCodeInvalid
+	}
+	if err = s.consumePendingToken(ctx, claims); err != nil {
+		return nil, err
+	}
+	if err = s.loginGuard.Succeed(ctx, account); err != nil {
+		return nil, err
+	}
+	if err = mergeGuest(ctx, s.userRepo, claims.GuestId, user); err != nil {
+		return nil, err
+	}
+	return s.tokenService.IssueTokens(ctx, user, client, claims.LoginType)
+}
+
+// lockPendingIfLocked 账号或 IP 已被锁定时作废 mfaToken，返回与密码错误相同的 ErrUnauthorized
+func (s *mfaService) lockPendingIfLocked(ctx context.Context, claims *jwt.MyCustomClaims, account string, ip string) (bool, error) {
+	lockedFor, err := s.loginGuard.Check(ctx, account, ip)
+	if err != nil {
+		return false, err
+	}
+	if lockedFor <= 0 {
+		return false, nil
+	}
+	s.logger.WithContext(ctx).Warn("mfa login locked",
+		zap.String("user_id", claims.UserId),
+		zap.String("ip", ip),
+		zap.Duration("locked_for", lockedFor),
+	)
+	if err = s.consumePendingToken(ctx, claims); err != nil {
+		return true, err
+	}
+	return true, v1.ErrUnauthorized
+}
+
+// guardAccount 与密码登录使用同一个计数键，没有邮箱的第三方账号按 UserId 计数
+func guardAccount(user *model.User) string {
+	if user.Email != "" {
+		return user.Email
+	}
+	return user.UserId
+}
+
+// LoginMfaEnroll 强制开启二次验证但尚未绑定的账号，在登录过程中完成绑定
+func (s *mfaService) LoginMfaEnroll(ctx context.Context, req *v1.LoginMfaEnrollRequest) (*v1.EnrollTotpResponseData, error) {
+	user, _, err := s.parsePendingToken(ctx, req.MfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.EnrollTotp(ctx, user.UserId)
+}
+
+func (s *mfaService) LoginMfaActivate(ctx context.Context, req *v1.LoginMfaActivateRequest, client *model.ClientInfo) (*v1.LoginMfaActivateResponseData, error) {
+	user, claims, err := s.parsePendingToken(ctx, req.MfaToken)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := s.ActivateTotp(ctx, user.UserId, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.consumePendingToken(ctx, claims); err != nil {
+		return nil, err
+	}
+	if err = mergeGuest(ctx, s.userRepo, claims.GuestId, user); err != nil {
+		return nil, err
+	}
+	tokens, err := s.tokenService.IssueTokens(ctx, user, client, claims.LoginType)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.LoginMfaActivateResponseData{
+		LoginResponseData:         *tokens,
+		RecoveryCodesResponseData: *codes,
+	}, nil
+}
+
+// required 配置了 required_for_admin 时，持有 admin 角色的用户和超管必须开启二次验证
+func (s *mfaService) required(user *model.User) bool {
+	if !s.requiredForAdmin {
+		return false
+	}
+	if user.UserId == model.AdminUserID {
+		return true
+	}
+	for _, role := range user.Roles {
+		if role.Sid == model.AdminRole {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *mfaService) getTotp(ctx context.Context, userId string) (*model.UserTotp, error) {
+	t, err := s.mfaRepo.GetTotp(ctx, userId)
+	if errors.Is(err, v1.ErrNotFound) {
+		return nil, nil
+	}
+	return t, err
+}
+
+func (s *mfaService) verify(ctx context.Context, t *model.UserTotp, code string, recoveryCode string) error {
+	switch {
+	case code != "":
+		step, ok := totp.Validate(t.Secret, code, time.Now(), 1)
+		if !ok {
+			return v1.ErrMfaCodeInvalid
+		}
+		used, err := s.mfaRepo.UseTotpStep(ctx, t.UserId, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return v1.ErrMfaCodeInvalid
+		}
+		return nil
+	case recoveryCode != "":
+		used, err := s.mfaRepo.UseRecoveryCode(ctx, t.UserId, hashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return v1.ErrMfaCodeInvalid
+		}
+		return nil
+	default:
+		return v1.ErrMfaCodeInvalid
+	}
+}
+
+func (s *mfaService) parsePendingToken(ctx context.Context, raw string) (*model.User, *jwt.MyCustomClaims, error) {
+	claims, err := s.jwt.ParseToken(raw)
+	if err != nil || claims.Scope != jwt.ScopeMfaPending {
+		return nil, nil, v1.ErrUnauthorized
+	}
+	revoked, err := s.jwt.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	if revoked {
+		return nil, nil, v1.ErrUnauthorized
+	}
+	user, err := s.userRepo.GetByID(ctx, claims.UserId)
+	if err != nil {
+		return nil, nil, v1.ErrUnauthorized
+	}
+	return user, claims, nil
+}
+
+// consumePendingToken mfaToken 只能换一次正式 token
+func (s *mfaService) consumePendingToken(ctx context.Context, claims *jwt.MyCustomClaims) error {
+	return s.tokenRepo.RevokeTokenId(ctx, claims.ID, claims.UserId, claims.ExpiresAt.Time)
+}
+
+func (s *mfaService) replaceRecoveryCodes(ctx context.Context, userId string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newRecoveryCode 生成 xxxx-xxxx 格式的恢复码，去掉了容易混淆的字符
+func newRecoveryCode() (string, error) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	b := make([]byte, 8)
+	// rand.Int 在范围内均匀取值，直接对字节取模会偏向前面的字符
+	n := big.NewInt(int64(len(alphabet)))
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[idx.Int64()]
+	}
+	return string(b[:4]) + "-" + string(b[4:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
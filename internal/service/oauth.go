@@ -0,0 +1,407 @@
+package service
+
+import (
+	"context"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/pkg/oauth"
+	"time"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type OAuthService interface {
+	ListProviders(ctx context.Context) *v1.ListOAuthProvidersResponseData
+	// Authorize userId 为空表示登录，非空表示给已登录用户绑定第三方账号
+	Authorize(ctx context.Context, provider string, userId string) (*v1.OAuthAuthorizeResponseData, error)
+	Login(ctx context.Context, provider string, req *v1.OAuthCallbackRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	Link(ctx context.Context, userId string, provider string, req *v1.OAuthCallbackRequest) error
+	// UpgradeGuest 给访客绑定第三方账号，第三方账号对应的用户已存在时合并到该用户
+	UpgradeGuest(ctx context.Context, guestId string, provider string, req *v1.OAuthCallbackRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	ListIdentities(ctx context.Context, userId string) (*v1.ListIdentitiesResponseData, error)
+	Unlink(ctx context.Context, userId string, id uint) error
+}
+
+func NewOAuthService(
+	service *Service,
+	conf *viper.Viper,
+	registry *oauth.Registry,
+	userRepo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	tokenService TokenService,
+	mfaService MfaService,
+	sessionService SessionService,
+) OAuthService {
+	stateTTL := conf.GetDuration("oauth.state_ttl")
+	if stateTTL <= 0 {
+		stateTTL = 10 * time.Minute
+	}
+	linkByEmail := true
+	if conf.IsSet("oauth.link_by_email") {
+		linkByEmail = conf.GetBool("oauth.link_by_email")
+	}
+	return &oauthService{
+		Service:        service,
+		registry:       registry,
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		tokenService:   tokenService,
+		mfaService:     mfaService,
+		sessionService: sessionService,
+		stateTTL:       stateTTL,
+		linkByEmail:    linkByEmail,
+	}
+}
+
+type oauthService struct {
+	*Service
+	registry       *oauth.Registry
+	userRepo       repository.UserRepository
+	identityRepo   repository.IdentityRepository
+	tokenService   TokenService
+	mfaService     MfaService
+	sessionService SessionService
+	stateTTL       time.Duration
+	linkByEmail    bool
+}
+
+func (s *oauthService) ListProviders(ctx context.Context) *v1.ListOAuthProvidersResponseData {
+	data := &v1.ListOAuthProvidersResponseData{List: []v1.OAuthProvider{}}
+	for _, name := range s.registry.Names() {
+		p, _ := s.registry.Get(name)
+		data.List = append(data.List, v1.OAuthProvider{Name: name, Type: p.Config().Type})
+	}
+	return data
+}
+
+func (s *oauthService) Authorize(ctx context.Context, provider string, userId string) (*v1.OAuthAuthorizeResponseData, error) {
+	p, ok := s.registry.Get(provider)
+	if !ok {
+		return nil, v1.ErrNotFound
+	}
+	state, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oauth.NewCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	authUrl, err := p.AuthCodeURL(ctx, state, oauth.CodeChallenge(verifier), nonce)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.identityRepo.CreateState(ctx, &model.OAuthState{
+		StateHash:    hashToken(state),
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		UserId:       userId,
+		ExpiresAt:    time.Now().Add(s.stateTTL),
+	}); err != nil {
+		return nil, err
+	}
+	return &v1.OAuthAuthorizeResponseData{AuthUrl: authUrl}, nil
+}
+
+func (s *oauthService) Login(ctx context.Context, provider string, req *v1.OAuthCallbackRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	p, identity, err := s.exchange(ctx, provider, "", req)
+	if err != nil {
+		if errors.Is(err, v1.ErrOAuthFailed) {
+			s.sessionService.RecordLogin(ctx, &model.LoginLog{
+				IP:        client.IP,
+				UserAgent: client.UserAgent,
+				LoginType: loginType(p.Config()),
+				Result:    model.LoginResultFailed,
+			})
+		}
+		return nil, err
+	}
+
+	var user *model.User
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		user, err = s.resolveUser(ctx, p.Config(), identity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active() {
+		s.sessionService.RecordLogin(ctx, &model.LoginLog{
+			UserId:    user.UserId,
+			Email:     user.Email,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+			LoginType: loginType(p.Config()),
+			Result:    model.LoginResultDisabled,
+		})
+		return nil, v1.ErrUserDisabled
+	}
+
+	challenge, err := s.mfaService.Challenge(ctx, user, loginType(p.Config()), "")
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
+	}
+	return s.tokenService.IssueTokens(ctx, user, client, loginType(p.Config()))
+}
+
+func (s *oauthService) Link(ctx context.Context, userId string, provider string, req *v1.OAuthCallbackRequest) error {
+	p, identity, err := s.exchange(ctx, provider, userId, req)
+	if err != nil {
+		return err
+	}
+	existing, err := s.identityRepo.Get(ctx, provider, identity.Subject)
+	if err == nil {
+		if existing.UserId != userId {
+			return v1.ErrIdentityInUse
+		}
+		return nil
+	}
+	if !errors.Is(err, v1.ErrNotFound) {
+		return err
+	}
+	return s.identityRepo.Create(ctx, newUserIdentity(userId, p.Config().Name, identity))
+}
+
+func (s *oauthService) UpgradeGuest(ctx context.Context, guestId string, provider string, req *v1.OAuthCallbackRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	guest, err := s.userRepo.GetByID(ctx, guestId)
+	if err != nil {
+		return nil, err
+	}
+	if guest.UserType != model.UserTypeGuest {
+		return nil, v1.ErrBadRequest
+	}
+	p, identity, err := s.exchange(ctx, provider, guestId, req)
+	if err != nil {
+		return nil, err
+	}
+	conf := p.Config()
+
+	var target *model.User
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if target, err = s.findUser(ctx, conf, identity); err != nil || target != nil {
+			return err
+		}
+		// 第三方账号未注册，访客直接转为注册用户，UserId 不变
+		guest.UserType = model.UserTypeRegistered
+		if identity.Name != "" {
+			guest.Name = identity.Name
+		}
+		if guest.Image == "" {
+			guest.Image = identity.Picture
+		}
+		if identity.EmailVerified {
+			guest.Email = model.NormalizeEmail(identity.Email)
+			guest.EmailVerifyTime = int(time.Now().Unix())
+		}
+		if err = s.userRepo.Update(ctx, guest); err != nil {
+			return err
+		}
+		return s.linkIdentity(ctx, guestId, conf, identity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if target == nil {
+		if err = s.tokenService.RevokeUserTokens(ctx, guestId); err != nil {
+			return nil, err
+		}
+		return s.tokenService.IssueTokens(ctx, guest, client, loginType(conf))
+	}
+	if !target.Active() {
+		s.sessionService.RecordLogin(ctx, &model.LoginLog{
+			UserId:    target.UserId,
+			Email:     target.Email,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+			LoginType: loginType(conf),
+			Result:    model.LoginResultDisabled,
+		})
+		return nil, v1.ErrUserDisabled
+	}
+	// 目标账号开启了二次验证时，由 LoginMfa 在验证通过后再合并访客
+	challenge, err := s.mfaService.Challenge(ctx, target, loginType(conf), guestId)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
+	}
+	if err = mergeGuest(ctx, s.userRepo, guestId, target); err != nil {
+		return nil, err
+	}
+	return s.tokenService.IssueTokens(ctx, target, client, loginType(conf))
+}
+
+func (s *oauthService) ListIdentities(ctx context.Context, userId string) (*v1.ListIdentitiesResponseData, error) {
+	identities, err := s.identityRepo.ListByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ListIdentitiesResponseData{List: identities}, nil
+}
+
+func (s *oauthService) Unlink(ctx context.Context, userId string, id uint) error {
+	user, err := s.userRepo.GetByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	// 没有邮箱的账号无法通过找回密码登录，至少保留一个第三方账号
+	if user.Email == "" {
+		identities, err := s.identityRepo.ListByUser(ctx, userId)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return v1.ErrBadRequest
+		}
+	}
+	return s.identityRepo.Delete(ctx, userId, id)
+}
+
+// exchange 校验 state 后用授权码换取第三方用户信息，state 必须由同一个用户、同一个 provider 发起
+// provider 存在时即使出错也会返回 provider，用于记录登录日志
+func (s *oauthService) exchange(ctx context.Context, provider string, userId string, req *v1.OAuthCallbackRequest) (oauth.Provider, *oauth.Identity, error) {
+	p, ok := s.registry.Get(provider)
+	if !ok {
+		return nil, nil, v1.ErrNotFound
+	}
+	state, err := s.identityRepo.ConsumeState(ctx, hashToken(req.State))
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return p, nil, v1.ErrOAuthFailed
+		}
+		return p, nil, err
+	}
+	if state.Provider != provider || state.UserId != userId {
+		return p, nil, v1.ErrOAuthFailed
+	}
+	identity, err := p.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("oauth exchange failed", zap.String("provider", provider), zap.Error(err))
+		return p, nil, v1.ErrOAuthFailed
+	}
+	return p, identity, nil
+}
+
+// resolveUser 依次按已绑定的第三方账号、已验证的邮箱查找用户，都找不到时注册新用户
+func (s *oauthService) resolveUser(ctx context.Context, conf oauth.ProviderConfig, identity *oauth.Identity) (*model.User, error) {
+	user, err := s.findUser(ctx, conf, identity)
+	if err != nil || user != nil {
+		return user, err
+	}
+	if user, err = s.createUser(ctx, conf, identity); err != nil {
+		return nil, err
+	}
+	if err = s.linkIdentity(ctx, user.UserId, conf, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// findUser 按已绑定的第三方账号、已验证的邮箱查找用户，按邮箱找到时同时绑定第三方账号，都找不到时返回 nil
+func (s *oauthService) findUser(ctx context.Context, conf oauth.ProviderConfig, identity *oauth.Identity) (*model.User, error) {
+	existing, err := s.identityRepo.Get(ctx, conf.Name, identity.Subject)
+	if err == nil {
+		now := time.Now()
+		existing.Email, existing.Name, existing.Avatar = identity.Email, identity.Name, identity.Picture
+		existing.LastLoginAt = &now
+		if err = s.identityRepo.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+		return s.userRepo.GetByID(ctx, existing.UserId)
+	}
+	if !errors.Is(err, v1.ErrNotFound) {
+		return nil, err
+	}
+
+	if !s.linkByEmail || identity.Email == "" || !identity.EmailVerified {
+		return nil, nil
+	}
+	user, err := s.userRepo.GetByEmail(ctx, identity.Email)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	// 本地账号的邮箱未验证时，无法确认是同一个人，不能自动绑定
+	if user.EmailVerifyTime == 0 {
+		return nil, v1.ErrEmailAlreadyUse
+	}
+	if err = s.linkIdentity(ctx, user.UserId, conf, identity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *oauthService) linkIdentity(ctx context.Context, userId string, conf oauth.ProviderConfig, identity *oauth.Identity) error {
+	now := time.Now()
+	linked := newUserIdentity(userId, conf.Name, identity)
+	linked.LastLoginAt = &now
+	return s.identityRepo.Create(ctx, linked)
+}
+
+func (s *oauthService) createUser(ctx context.Context, conf oauth.ProviderConfig, identity *oauth.Identity) (*model.User, error) {
+	userId, err := s.sid.GenString()
+	if err != nil {
+		return nil, err
+	}
+	// 第三方注册的用户没有密码，需要时可以通过找回密码设置
+	password, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	now := int(time.Now().Unix())
+	user := &model.User{
+		UserId:       userId,
+		Password:     string(hashedPassword),
+		Name:         identity.Name,
+		Image:        identity.Picture,
+		RegisterType: loginType(conf),
+		RegisterTime: now,
+	}
+	if user.Name == "" {
+		user.Name = "new user"
+	}
+	if identity.EmailVerified {
+		user.Email = model.NormalizeEmail(identity.Email)
+		user.EmailVerifyTime = now
+	}
+	if err = s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// loginType 未配置 login_type 时记为通用的第三方登录
+func loginType(conf oauth.ProviderConfig) int {
+	if conf.LoginType == model.LoginTypeGuest {
+		return model.LoginTypeOAuth
+	}
+	return conf.LoginType
+}
+
+func newUserIdentity(userId string, provider string, identity *oauth.Identity) *model.UserIdentity {
+	return &model.UserIdentity{
+		UserId:   userId,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		Name:     identity.Name,
+		Avatar:   identity.Picture,
+	}
+}
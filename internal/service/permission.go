@@ -5,26 +5,42 @@ import (
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
 	"go-nunu/internal/repository"
+
+	"go.uber.org/zap"
 )
 
 type PermissionService interface {
 	GetPermissionList(ctx context.Context, req v1.GetPermissionListRequest) ([]model.Permission, int, error)
 	CreatePermission(ctx context.Context, req v1.CreatePermissionRequest) (*model.Permission, error)
+	// GetPermissionTree 全部权限按 ParentID 组成的树，供权限编辑使用
+	GetPermissionTree(ctx context.Context) (*v1.GetPermissionTreeResponseData, error)
+	// GetMyMenus 用户角色（含继承）授予的目录和菜单树，每个菜单带上用户拥有的按钮 Key
+	GetMyMenus(ctx context.Context, userId string) (*v1.GetMyMenusResponseData, error)
+	// UpdatePermission 修改权限，拥有该权限的角色的 Casbin 策略随之更新，同步失败时恢复原权限
+	UpdatePermission(ctx context.Context, req *v1.UpdatePermissionRequest) (*model.Permission, error)
+	// DeletePermission 删除权限并从所有角色中移除，仍有子权限时返回 ErrPermissionHasChildren，同步失败时恢复权限及角色关联
+	DeletePermission(ctx context.Context, id uint) error
 }
 
 func NewPermissionService(
 	service *Service,
 	permissionRepository repository.PermissionRepository,
+	roleRepository repository.RoleRepository,
+	userRepo repository.UserRepository,
 ) PermissionService {
 	return &permissionService{
 		Service:              service,
 		permissionRepository: permissionRepository,
+		roleRepository:       roleRepository,
+		userRepo:             userRepo,
 	}
 }
 
 type permissionService struct {
 	*Service
 	permissionRepository repository.PermissionRepository
+	roleRepository       repository.RoleRepository
+	userRepo             repository.UserRepository
 }
 
 func (s *permissionService) GetPermissionList(ctx context.Context, req v1.GetPermissionListRequest) ([]model.Permission, int, error) {
@@ -51,3 +67,246 @@ func (s *permissionService) CreatePermission(ctx context.Context, req v1.CreateP
 	}
 	return s.permissionRepository.CreatePermission(ctx, permission)
 }
+
+func (s *permissionService) GetPermissionTree(ctx context.Context) (*v1.GetPermissionTreeResponseData, error) {
+	permissions, err := s.permissionRepository.ListAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*v1.PermissionTreeNode, len(permissions))
+	for _, perm := range permissions {
+		nodes[perm.ID] = &v1.PermissionTreeNode{Permission: perm, Children: []*v1.PermissionTreeNode{}}
+	}
+	data := &v1.GetPermissionTreeResponseData{List: []*v1.PermissionTreeNode{}}
+	// permissions 已按 sort 排序，按顺序挂载即可保证同级有序
+	for _, perm := range permissions {
+		if parent, ok := nodes[uint(perm.ParentID)]; ok && parent.ID != perm.ID {
+			parent.Children = append(parent.Children, nodes[perm.ID])
+		} else {
+			data.List = append(data.List, nodes[perm.ID])
+		}
+	}
+	return data, nil
+}
+
+func (s *permissionService) GetMyMenus(ctx context.Context, userId string) (*v1.GetMyMenusResponseData, error) {
+	permissions, err := s.permissionRepository.ListAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := s.grantedPermissions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	allowed := func(id uint) bool {
+		return granted == nil || granted[id]
+	}
+
+	byId := make(map[uint]*model.Permission, len(permissions))
+	for i := range permissions {
+		byId[permissions[i].ID] = &permissions[i]
+	}
+	// 授予的菜单连同其上级目录一起展示，否则菜单会脱离树
+	visible := make(map[uint]bool)
+	for _, perm := range permissions {
+		if perm.Type == model.PermissionTypeButton || !allowed(perm.ID) {
+			continue
+		}
+		for p := byId[perm.ID]; p != nil && p.Type != model.PermissionTypeButton && !visible[p.ID]; p = byId[uint(p.ParentID)] {
+			visible[p.ID] = true
+		}
+	}
+
+	nodes := make(map[uint]*v1.MenuTreeNode, len(visible))
+	for _, perm := range permissions {
+		if visible[perm.ID] {
+			nodes[perm.ID] = &v1.MenuTreeNode{Permission: perm, Buttons: []string{}, Children: []*v1.MenuTreeNode{}}
+		}
+	}
+	data := &v1.GetMyMenusResponseData{List: []*v1.MenuTreeNode{}}
+	for _, perm := range permissions {
+		if perm.Type == model.PermissionTypeButton {
+			if parent, ok := nodes[uint(perm.ParentID)]; ok && allowed(perm.ID) {
+				parent.Buttons = append(parent.Buttons, perm.Key)
+			}
+			continue
+		}
+		node, ok := nodes[perm.ID]
+		if !ok {
+			continue
+		}
+		if parent, ok := nodes[uint(perm.ParentID)]; ok && parent.ID != perm.ID {
+			parent.Children = append(parent.Children, node)
+		} else {
+			data.List = append(data.List, node)
+		}
+	}
+	return data, nil
+}
+
+func (s *permissionService) UpdatePermission(ctx context.Context, req *v1.UpdatePermissionRequest) (*model.Permission, error) {
+	permission, err := s.permissionRepository.GetPermission(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID != 0 {
+		if err = s.checkParent(ctx, permission.ID, uint(req.ParentID)); err != nil {
+			return nil, err
+		}
+	}
+
+	old := *permission
+
+	permission.Name = req.Name
+	permission.Key = req.Key
+	permission.Path = req.Path
+	permission.Method = req.Method
+	permission.ParentID = req.ParentID
+	permission.Sort = req.Sort
+	permission.Type = req.Type
+	permission.Component = req.Component
+	permission.Api = req.Api
+	if err = s.permissionRepository.UpdatePermission(ctx, permission); err != nil {
+		return nil, err
+	}
+	// Casbin 同步放在数据库写入之后，避免 SQLite 下事务与 adapter 互相锁住；失败时恢复原有权限
+	if err = s.syncPermissionRoles(ctx, permission.ID); err != nil {
+		logger := s.logger.WithContext(ctx)
+		if undoErr := s.permissionRepository.UpdatePermission(ctx, &old); undoErr != nil {
+			logger.Error("undo update permission: UpdatePermission error", zap.Uint("id", old.ID), zap.Error(undoErr))
+		} else if undoErr = s.syncPermissionRoles(ctx, old.ID); undoErr != nil {
+			logger.Error("undo update permission: syncPermissionRoles error", zap.Uint("id", old.ID), zap.Error(undoErr))
+		}
+		return nil, err
+	}
+	return permission, nil
+}
+
+// syncPermissionRoles 重新同步拥有该权限的角色，roles 预加载的是当前的权限
+func (s *permissionService) syncPermissionRoles(ctx context.Context, id uint) error {
+	roles, err := s.permissionRepository.ListPermissionRoles(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err = s.syncRolePolicies(role.Sid, role.Permissions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkParent 父节点必须存在，且不能是自身或自身的后代
+func (s *permissionService) checkParent(ctx context.Context, id uint, parentId uint) error {
+	permissions, err := s.permissionRepository.ListAllPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	byId := make(map[uint]*model.Permission, len(permissions))
+	for i := range permissions {
+		byId[permissions[i].ID] = &permissions[i]
+	}
+	if _, ok := byId[parentId]; !ok {
+		return v1.ErrPermissionParentInvalid
+	}
+	visited := make(map[uint]bool)
+	for p := byId[parentId]; p != nil && !visited[p.ID]; p = byId[uint(p.ParentID)] {
+		if p.ID == id {
+			return v1.ErrPermissionParentInvalid
+		}
+		visited[p.ID] = true
+	}
+	return nil
+}
+
+func (s *permissionService) DeletePermission(ctx context.Context, id uint) error {
+	permission, err := s.permissionRepository.GetPermission(ctx, id)
+	if err != nil {
+		return err
+	}
+	children, err := s.permissionRepository.CountPermissionChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return v1.ErrPermissionHasChildren
+	}
+
+	roles, err := s.permissionRepository.ListPermissionRoles(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.permissionRepository.DeletePermission(ctx, id)
+	}); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		remaining := make([]model.Permission, 0, len(role.Permissions))
+		for _, perm := range role.Permissions {
+			if perm.ID != id {
+				remaining = append(remaining, perm)
+			}
+		}
+		if err = s.syncRolePolicies(role.Sid, remaining); err != nil {
+			s.undoDeletePermission(ctx, permission, roles)
+			return err
+		}
+	}
+	return nil
+}
+
+// undoDeletePermission Casbin 同步失败时恢复已删除的权限及其角色关联，并按原有权限重新同步
+func (s *permissionService) undoDeletePermission(ctx context.Context, permission *model.Permission, roles []model.Role) {
+	logger := s.logger.WithContext(ctx)
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := s.permissionRepository.CreatePermission(ctx, permission); err != nil {
+			return err
+		}
+		for i := range roles {
+			if err := s.roleRepository.ReplacePermissions(ctx, &roles[i], roles[i].Permissions); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		logger.Error("undo delete permission: restore error", zap.Uint("id", permission.ID), zap.Error(err))
+		return
+	}
+	for _, role := range roles {
+		if err := s.syncRolePolicies(role.Sid, role.Permissions); err != nil {
+			logger.Error("undo delete permission: syncRolePolicies error", zap.String("sid", role.Sid), zap.Error(err))
+		}
+	}
+}
+
+// grantedPermissions 用户角色及其祖先角色直接分配的权限 ID，超管返回 nil 表示全部
+func (s *permissionService) grantedPermissions(ctx context.Context, userId string) (map[uint]bool, error) {
+	if userId == model.AdminUserID {
+		return nil, nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	graph := roleGraph(roles)
+
+	granted := make(map[uint]bool)
+	for _, userRole := range user.Roles {
+		role, ok := graph[userRole.ID]
+		if !ok {
+			continue
+		}
+		for _, r := range append([]*model.Role{role}, roleAncestors(graph, role.ID)...) {
+			for _, perm := range r.Permissions {
+				granted[perm.ID] = true
+			}
+		}
+	}
+	return granted, nil
+}
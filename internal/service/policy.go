@@ -0,0 +1,521 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/pkg/log"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/util"
+	"go.uber.org/zap"
+)
+
+// PolicyService 以 roles、role_permissions、role_parents、sys_user_roles 为准检查和修复 casbin_rule
+type PolicyService interface {
+	// DiffPolicies 返回当前的差异，不修改策略
+	DiffPolicies(ctx context.Context) (*v1.PolicyDiff, error)
+	// ReconcilePolicies 计算差异并把 Casbin 改成与关系表一致
+	ReconcilePolicies(ctx context.Context) (*v1.PolicyDiff, error)
+	// BackfillUserRoles 按 sys_user_roles 补齐缺失的 g, user:<uid>, <sid> 规则，可重复执行，返回新增条数
+	BackfillUserRoles(ctx context.Context) (int, error)
+	// SyncApiPermissions 为受 RBAC 保护但没有按钮权限的路由新增权限，并标记 Api 已失效的权限
+	SyncApiPermissions(ctx context.Context, apply bool) (*v1.SyncApiPermissionsData, error)
+	// CheckPermission 模拟 AuthMiddleware 的判断，返回命中的规则、继承链和数据权限
+	CheckPermission(ctx context.Context, req *v1.CheckPermissionRequest) (*v1.CheckPermissionData, error)
+	// WhoCan 反查能访问某个接口的角色和用户
+	WhoCan(ctx context.Context, req *v1.WhoCanRequest) (*v1.WhoCanData, error)
+}
+
+// RouteSource 列出挂载了 AuthMiddleware 的路由，由 router 包实现
+type RouteSource interface {
+	RBACRoutes() []v1.ApiRoute
+}
+
+// 不依赖 *Service，cmd/rbac 和任务进程不需要 jwt、sid 等组件
+func NewPolicyService(
+	logger *log.Logger,
+	casbin *casbin.SyncedCachedEnforcer,
+	roleRepository repository.RoleRepository,
+	userRepository repository.UserRepository,
+	permissionRepository repository.PermissionRepository,
+	routes RouteSource,
+) PolicyService {
+	return &policyService{
+		logger:               logger,
+		casbin:               casbin,
+		roleRepository:       roleRepository,
+		userRepository:       userRepository,
+		permissionRepository: permissionRepository,
+		routes:               routes,
+	}
+}
+
+type policyService struct {
+	logger               *log.Logger
+	casbin               *casbin.SyncedCachedEnforcer
+	roleRepository       repository.RoleRepository
+	userRepository       repository.UserRepository
+	permissionRepository repository.PermissionRepository
+	routes               RouteSource
+}
+
+const (
+	policyTypeP = "p"
+	policyTypeG = "g"
+)
+
+func (s *policyService) DiffPolicies(ctx context.Context) (*v1.PolicyDiff, error) {
+	// 其他实例可能修改过 casbin_rule，先重新加载，保证比较的是表中的数据
+	if err := s.casbin.LoadPolicy(); err != nil {
+		return nil, err
+	}
+	expected, err := s.expectedPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := s.actualPolicies()
+	if err != nil {
+		return nil, err
+	}
+	diff := &v1.PolicyDiff{
+		Missing: [][]string{},
+		Extra:   [][]string{},
+	}
+	for key, rule := range expected {
+		if _, ok := actual[key]; !ok {
+			diff.Missing = append(diff.Missing, rule)
+		}
+	}
+	for key, rule := range actual {
+		if _, ok := expected[key]; !ok {
+			diff.Extra = append(diff.Extra, rule)
+		}
+	}
+	sortPolicies(diff.Missing)
+	sortPolicies(diff.Extra)
+	return diff, nil
+}
+
+func (s *policyService) ReconcilePolicies(ctx context.Context) (*v1.PolicyDiff, error) {
+	diff, err := s.DiffPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(diff.Missing) == 0 && len(diff.Extra) == 0 {
+		return diff, nil
+	}
+
+	missingP, missingG := splitPolicies(diff.Missing)
+	extraP, extraG := splitPolicies(diff.Extra)
+	if len(extraP) > 0 {
+		if _, err = s.casbin.RemovePolicies(extraP); err != nil {
+			return nil, err
+		}
+	}
+	if len(extraG) > 0 {
+		if _, err = s.casbin.RemoveGroupingPolicies(extraG); err != nil {
+			return nil, err
+		}
+	}
+	if len(missingP) > 0 {
+		if _, err = s.casbin.AddPolicies(missingP); err != nil {
+			return nil, err
+		}
+	}
+	if len(missingG) > 0 {
+		if _, err = s.casbin.AddGroupingPolicies(missingG); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.casbin.InvalidateCache(); err != nil {
+		return nil, err
+	}
+	diff.Applied = true
+	s.logger.WithContext(ctx).Warn("casbin policies reconciled",
+		zap.Int("added", len(diff.Missing)), zap.Int("removed", len(diff.Extra)))
+	return diff, nil
+}
+
+func (s *policyService) BackfillUserRoles(ctx context.Context) (int, error) {
+	if err := s.casbin.LoadPolicy(); err != nil {
+		return 0, err
+	}
+	users, err := s.userRepository.ListUserRoles(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var missing [][]string
+	for _, user := range users {
+		for _, role := range user.Roles {
+			if role.Sid == "" {
+				continue
+			}
+			rule := []string{model.UserSubject(user.UserId), role.Sid}
+			ok, err := s.casbin.HasGroupingPolicy(rule)
+			if err != nil {
+				return 0, err
+			}
+			if !ok {
+				missing = append(missing, rule)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+	// 只新增，不删除多余规则，多余规则交给 ReconcilePolicies 处理
+	if _, err = s.casbin.AddGroupingPolicies(missing); err != nil {
+		return 0, err
+	}
+	if err = s.casbin.InvalidateCache(); err != nil {
+		return 0, err
+	}
+	s.logger.WithContext(ctx).Info("casbin user roles backfilled", zap.Int("added", len(missing)))
+	return len(missing), nil
+}
+
+// expectedPolicies 从关系表推导全部规则，与 roleService、userService 写入 Casbin 的规则一致
+func (s *policyService) expectedPolicies(ctx context.Context) (map[string][]string, error) {
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.userRepository.ListUserRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make(map[string][]string)
+	add := func(rule ...string) {
+		rules[policyKey(rule)] = rule
+	}
+	for _, role := range roles {
+		// 没有 Sid 的角色不会写入 Casbin
+		if role.Sid == "" {
+			continue
+		}
+		for _, p := range model.RolePolicies(role.Sid, role.Permissions) {
+			add(append([]string{policyTypeP}, p...)...)
+		}
+		for _, parent := range role.Parents {
+			if parent.Sid != "" {
+				add(policyTypeG, role.Sid, parent.Sid)
+			}
+		}
+	}
+	for _, user := range users {
+		for _, role := range user.Roles {
+			if role.Sid != "" {
+				add(policyTypeG, model.UserSubject(user.UserId), role.Sid)
+			}
+		}
+	}
+	return rules, nil
+}
+
+func (s *policyService) actualPolicies() (map[string][]string, error) {
+	rules := make(map[string][]string)
+	policies, err := s.casbin.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range policies {
+		rule := append([]string{policyTypeP}, p...)
+		rules[policyKey(rule)] = rule
+	}
+	groupings, err := s.casbin.GetGroupingPolicy()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groupings {
+		rule := append([]string{policyTypeG}, g...)
+		rules[policyKey(rule)] = rule
+	}
+	return rules, nil
+}
+
+func (s *policyService) SyncApiPermissions(ctx context.Context, apply bool) (*v1.SyncApiPermissionsData, error) {
+	routes := s.routes.RBACRoutes()
+	permissions, err := s.permissionRepository.ListAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(permissions))
+	var apis []model.Permission
+	for _, perm := range permissions {
+		keys[perm.Key] = true
+		if perm.Type == model.PermissionTypeButton && perm.Api != "" {
+			apis = append(apis, perm)
+		}
+	}
+
+	data := &v1.SyncApiPermissionsData{
+		Created: []v1.ApiRoute{},
+		Stale:   []v1.StalePermission{},
+	}
+	var created []model.Permission
+	for _, route := range routes {
+		// 已被 /v1/user/* 这类通配权限覆盖的路由不再单独建权限
+		if routeCovered(route, apis) {
+			continue
+		}
+		key := routePermissionKey(route, keys)
+		keys[key] = true
+		data.Created = append(data.Created, route)
+		created = append(created, model.Permission{
+			Name:   route.Handler,
+			Key:    key,
+			Type:   model.PermissionTypeButton,
+			Api:    route.Path,
+			Method: route.Method,
+		})
+	}
+
+	var staleIds []uint
+	// 没有 Api 的按钮只用于前端显示，不对应路由
+	for _, perm := range apis {
+		if !routeExists(routes, perm) {
+			staleIds = append(staleIds, perm.ID)
+			data.Stale = append(data.Stale, v1.StalePermission{
+				ID:     perm.ID,
+				Key:    perm.Key,
+				Name:   perm.Name,
+				Api:    perm.Api,
+				Method: perm.Method,
+			})
+		}
+	}
+
+	if !apply {
+		return data, nil
+	}
+	if err = s.permissionRepository.CreatePermissions(ctx, created); err != nil {
+		return nil, err
+	}
+	if err = s.permissionRepository.MarkStale(ctx, staleIds); err != nil {
+		return nil, err
+	}
+	data.Applied = true
+	return data, nil
+}
+
+// routeExists 权限的 Api 可以是 /v1/user/* 这样的通配，与 AuthMiddleware 一样按 keyMatch2 匹配
+func routeExists(routes []v1.ApiRoute, perm model.Permission) bool {
+	for _, route := range routes {
+		if permissionMatches(perm, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeCovered 路由是否已有按钮权限，包括通配的权限
+func routeCovered(route v1.ApiRoute, perms []model.Permission) bool {
+	for _, perm := range perms {
+		if permissionMatches(perm, route) {
+			return true
+		}
+	}
+	return false
+}
+
+func permissionMatches(perm model.Permission, route v1.ApiRoute) bool {
+	return (perm.Method == "*" || perm.Method == route.Method) && util.KeyMatch2(route.Path, perm.Api)
+}
+
+// routePermissionKey 由路由生成权限 Key，如 DELETE /v1/role/:id -> api:role:id:delete
+func routePermissionKey(route v1.ApiRoute, used map[string]bool) string {
+	var parts []string
+	for _, seg := range strings.Split(strings.TrimPrefix(route.Path, "/v1/"), "/") {
+		seg = strings.TrimLeft(seg, ":*")
+		if seg != "" {
+			parts = append(parts, seg)
+		}
+	}
+	base := model.ApiResourcePrefix + strings.Join(append(parts, strings.ToLower(route.Method)), ":")
+	// Key 列为 varchar(50)
+	if len(base) > 46 {
+		base = base[:46]
+	}
+	key := base
+	for i := 2; used[key]; i++ {
+		key = fmt.Sprintf("%s:%d", base, i)
+	}
+	return key
+}
+
+func (s *policyService) CheckPermission(ctx context.Context, req *v1.CheckPermissionRequest) (*v1.CheckPermissionData, error) {
+	if (req.UserId == "") == (req.Role == "") {
+		return nil, v1.ErrBadRequest
+	}
+	data := &v1.CheckPermissionData{
+		Object: model.ApiResourcePrefix + req.Path,
+		Action: strings.ToUpper(req.Method),
+	}
+
+	var err error
+	if req.UserId != "" {
+		if _, err = s.userRepository.GetByID(ctx, req.UserId); err != nil {
+			return nil, err
+		}
+		data.Subject = model.UserSubject(req.UserId)
+		if req.UserId == model.AdminUserID {
+			data.Allowed = true
+			data.AdminBypass = true
+			data.DataScope = model.DataScopeAll
+			return data, nil
+		}
+		if data.DataScope, data.DeptId, err = s.userRepository.GetDataScope(ctx, req.UserId); err != nil {
+			return nil, err
+		}
+	} else {
+		if data.DataScope, err = s.roleDataScope(ctx, req.Role); err != nil {
+			return nil, err
+		}
+		data.Subject = req.Role
+	}
+
+	if data.Allowed, data.Match, err = s.decide(data.Subject, data.Object, data.Action); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *policyService) WhoCan(ctx context.Context, req *v1.WhoCanRequest) (*v1.WhoCanData, error) {
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.WhoCanData{
+		Object: model.ApiResourcePrefix + req.Path,
+		Action: strings.ToUpper(req.Method),
+		Roles:  []v1.WhoCanRole{},
+		Users:  []string{},
+	}
+
+	users := make(map[string]bool)
+	for _, role := range roles {
+		if role.Sid == "" {
+			continue
+		}
+		// 以角色为主体时 g(r.sub, p.sub) 对自身成立，继承的规则同样生效
+		allowed, match, err := s.decide(role.Sid, data.Object, data.Action)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
+		data.Roles = append(data.Roles, v1.WhoCanRole{Sid: role.Sid, Match: *match})
+
+		members, err := s.casbin.GetImplicitUsersForRole(role.Sid)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			if userId, ok := strings.CutPrefix(member, model.UserSubjectPrefix); ok {
+				users[userId] = true
+			}
+		}
+	}
+	for userId := range users {
+		data.Users = append(data.Users, userId)
+	}
+	sort.Strings(data.Users)
+	return data, nil
+}
+
+// decide 与 AuthMiddleware 使用同一个 enforcer，EnforceEx 额外返回命中的规则
+func (s *policyService) decide(sub, obj, act string) (bool, *v1.PolicyMatch, error) {
+	allowed, explain, err := s.casbin.EnforceEx(sub, obj, act)
+	if err != nil || !allowed || len(explain) == 0 {
+		return allowed, nil, err
+	}
+	return true, &v1.PolicyMatch{
+		Policy: explain,
+		Chain:  s.roleChain(sub, explain[0]),
+	}, nil
+}
+
+// roleChain 按 g 规则广度优先查找主体到角色的最短继承链
+func (s *policyService) roleChain(sub, target string) []string {
+	from := map[string]string{}
+	visited := map[string]bool{sub: true}
+	queue := []string{sub}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == target {
+			chain := []string{cur}
+			for cur != sub {
+				cur = from[cur]
+				chain = append([]string{cur}, chain...)
+			}
+			return chain
+		}
+		roles, _ := s.casbin.GetRolesForUser(cur)
+		for _, role := range roles {
+			if !visited[role] {
+				visited[role] = true
+				from[role] = cur
+				queue = append(queue, role)
+			}
+		}
+	}
+	return []string{sub, target}
+}
+
+// roleDataScope 角色自身及继承的角色中范围最大的数据权限，与用户的计算方式一致
+func (s *policyService) roleDataScope(ctx context.Context, sid string) (int, error) {
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return 0, err
+	}
+	scopes := make(map[string]int, len(roles))
+	for _, role := range roles {
+		scopes[role.Sid] = role.DataScope
+	}
+	if _, ok := scopes[sid]; !ok {
+		return 0, v1.ErrNotFound
+	}
+	ancestors, err := s.casbin.GetImplicitRolesForUser(sid)
+	if err != nil {
+		return 0, err
+	}
+	scope := model.DataScopeSelf
+	for _, r := range append(ancestors, sid) {
+		if v := scopes[r]; v > 0 {
+			scope = min(scope, v)
+		}
+	}
+	return scope, nil
+}
+
+func policyKey(rule []string) string {
+	return strings.Join(rule, ", ")
+}
+
+func sortPolicies(rules [][]string) {
+	sort.Slice(rules, func(i, j int) bool {
+		return policyKey(rules[i]) < policyKey(rules[j])
+	})
+}
+
+// splitPolicies 按 ptype 拆分，并去掉 ptype 列
+func splitPolicies(rules [][]string) (p [][]string, g [][]string) {
+	for _, rule := range rules {
+		switch rule[0] {
+		case policyTypeP:
+			p = append(p, rule[1:])
+		case policyTypeG:
+			g = append(g, rule[1:])
+		}
+	}
+	return p, g
+}
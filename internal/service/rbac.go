@@ -0,0 +1,458 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RbacService 以 YAML 文档导出、导入权限树和角色，用于在不同环境之间迁移 RBAC 配置
+type RbacService interface {
+	ExportRbac(ctx context.Context) ([]byte, error)
+	// ImportRbac 按 Permission.Key、Role.Sid 新增或更新，文档中没有的权限和角色保持不变
+	// apply 为 false 时只返回变更，不写入
+	ImportRbac(ctx context.Context, data []byte, apply bool) (*v1.ImportRbacData, error)
+}
+
+func NewRbacService(
+	tm repository.Transaction,
+	permissionRepository repository.PermissionRepository,
+	roleRepository repository.RoleRepository,
+	policyService PolicyService,
+) RbacService {
+	return &rbacService{
+		tm:                   tm,
+		permissionRepository: permissionRepository,
+		roleRepository:       roleRepository,
+		policyService:        policyService,
+	}
+}
+
+type rbacService struct {
+	tm                   repository.Transaction
+	permissionRepository repository.PermissionRepository
+	roleRepository       repository.RoleRepository
+	policyService        PolicyService
+}
+
+func (s *rbacService) ExportRbac(ctx context.Context) ([]byte, error) {
+	permissions, err := s.permissionRepository.ListAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := v1.RbacDocument{
+		Version:     v1.RbacDocumentVersion,
+		Permissions: []v1.RbacPermission{},
+		Roles:       []v1.RbacRole{},
+	}
+	keys := make(map[uint]string, len(permissions))
+	children := make(map[int][]model.Permission)
+	for _, perm := range permissions {
+		keys[perm.ID] = perm.Key
+	}
+	for _, perm := range permissions {
+		parent := perm.ParentID
+		// 父节点不存在时按顶级导出
+		if _, ok := keys[uint(parent)]; !ok {
+			parent = 0
+		}
+		children[parent] = append(children[parent], perm)
+	}
+	// 按树的先序输出，导入时父节点总在前面；permissions 已按 sort、id 排序
+	var walk func(parent int)
+	walk = func(parent int) {
+		for _, perm := range children[parent] {
+			doc.Permissions = append(doc.Permissions, v1.RbacPermission{
+				Key:       perm.Key,
+				Name:      perm.Name,
+				Type:      perm.Type,
+				Parent:    keys[uint(perm.ParentID)],
+				Sort:      perm.Sort,
+				Path:      perm.Path,
+				Component: perm.Component,
+				Api:       perm.Api,
+				Method:    perm.Method,
+			})
+			walk(int(perm.ID))
+		}
+	}
+	walk(0)
+
+	for _, role := range roles {
+		if role.Sid == "" {
+			continue
+		}
+		doc.Roles = append(doc.Roles, v1.RbacRole{
+			Sid:         role.Sid,
+			Name:        role.Name,
+			DataScope:   role.DataScope,
+			Parents:     roleSids(role.Parents),
+			Permissions: permissionKeys(role.Permissions),
+		})
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err = enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *rbacService) ImportRbac(ctx context.Context, data []byte, apply bool) (*v1.ImportRbacData, error) {
+	var doc v1.RbacDocument
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %s", v1.ErrRbacDocumentInvalid, err.Error())
+	}
+	if doc.Version != v1.RbacDocumentVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", v1.ErrRbacDocumentInvalid, doc.Version)
+	}
+
+	permissions, err := s.permissionRepository.ListAllPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := newRbacState(permissions, roles)
+	if err = state.validate(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %s", v1.ErrRbacDocumentInvalid, err.Error())
+	}
+
+	result := &v1.ImportRbacData{Changes: state.diff(&doc)}
+	if !apply || len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	if err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.apply(ctx, state, &doc, result.Changes)
+	}); err != nil {
+		return nil, err
+	}
+	// 关系表写入后由对账统一同步 Casbin，放在事务之外，避免 SQLite 下事务与 adapter 互相锁住
+	if _, err = s.policyService.ReconcilePolicies(ctx); err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+func (s *rbacService) apply(ctx context.Context, state *rbacState, doc *v1.RbacDocument, changes []v1.RbacChange) error {
+	changed := make(map[string]bool, len(changes))
+	for _, c := range changes {
+		changed[c.Kind+":"+c.Key] = true
+	}
+
+	// 1. 权限：先新建缺少的，拿到 ID 后再统一设置父节点
+	for _, p := range doc.Permissions {
+		if _, ok := state.permissions[p.Key]; ok {
+			continue
+		}
+		perm := &model.Permission{Key: p.Key, Name: p.Name, Type: p.Type}
+		if _, err := s.permissionRepository.CreatePermission(ctx, perm); err != nil {
+			return err
+		}
+		state.permissions[p.Key] = perm
+	}
+	for _, p := range doc.Permissions {
+		if !changed["permission:"+p.Key] {
+			continue
+		}
+		perm := state.permissions[p.Key]
+		perm.Name = p.Name
+		perm.Type = p.Type
+		perm.Sort = p.Sort
+		perm.Path = p.Path
+		perm.Component = p.Component
+		perm.Api = p.Api
+		perm.Method = p.Method
+		perm.ParentID = 0
+		if p.Parent != "" {
+			perm.ParentID = int(state.permissions[p.Parent].ID)
+		}
+		if err := s.permissionRepository.UpdatePermission(ctx, perm); err != nil {
+			return err
+		}
+	}
+
+	// 2. 角色：同样先新建，再设置权限和继承关系
+	for _, r := range doc.Roles {
+		if _, ok := state.roles[r.Sid]; ok {
+			continue
+		}
+		role := &model.Role{Sid: r.Sid, Name: r.Name, DataScope: r.DataScope}
+		if _, err := s.roleRepository.CreateRole(ctx, role); err != nil {
+			return err
+		}
+		state.roles[r.Sid] = role
+	}
+	for _, r := range doc.Roles {
+		if !changed["role:"+r.Sid] {
+			continue
+		}
+		role := state.roles[r.Sid]
+		role.Name = r.Name
+		role.DataScope = r.DataScope
+		if _, err := s.roleRepository.UpdateRole(ctx, role); err != nil {
+			return err
+		}
+		perms := make([]model.Permission, 0, len(r.Permissions))
+		for _, key := range r.Permissions {
+			perms = append(perms, *state.permissions[key])
+		}
+		if err := s.roleRepository.ReplacePermissions(ctx, role, perms); err != nil {
+			return err
+		}
+		parents := make([]model.Role, 0, len(r.Parents))
+		for _, sid := range r.Parents {
+			parents = append(parents, *state.roles[sid])
+		}
+		if err := s.roleRepository.ReplaceParents(ctx, role, parents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rbacState 数据库中现有的权限和角色，按 Key、Sid 索引
+type rbacState struct {
+	permissions map[string]*model.Permission
+	parentKeys  map[string]string // 权限 Key -> 父权限 Key
+	roles       map[string]*model.Role
+}
+
+func newRbacState(permissions []model.Permission, roles []model.Role) *rbacState {
+	state := &rbacState{
+		permissions: make(map[string]*model.Permission, len(permissions)),
+		parentKeys:  make(map[string]string, len(permissions)),
+		roles:       make(map[string]*model.Role, len(roles)),
+	}
+	keys := make(map[uint]string, len(permissions))
+	for i := range permissions {
+		state.permissions[permissions[i].Key] = &permissions[i]
+		keys[permissions[i].ID] = permissions[i].Key
+	}
+	for _, perm := range permissions {
+		state.parentKeys[perm.Key] = keys[uint(perm.ParentID)]
+	}
+	for i := range roles {
+		if roles[i].Sid != "" {
+			state.roles[roles[i].Sid] = &roles[i]
+		}
+	}
+	return state
+}
+
+// validate 检查文档内部及与数据库合并后的引用是否完整、是否有环
+func (st *rbacState) validate(doc *v1.RbacDocument) error {
+	permParents := make(map[string][]string, len(st.parentKeys))
+	for key, parent := range st.parentKeys {
+		if parent != "" {
+			permParents[key] = []string{parent}
+		}
+	}
+	docKeys := make(map[string]bool, len(doc.Permissions))
+	for _, p := range doc.Permissions {
+		if p.Key == "" || p.Name == "" {
+			return fmt.Errorf("permission %q: key and name are required", p.Key)
+		}
+		if docKeys[p.Key] {
+			return fmt.Errorf("permission %q: duplicated", p.Key)
+		}
+		docKeys[p.Key] = true
+		if p.Type < model.PermissionTypeDirectory || p.Type > model.PermissionTypeButton {
+			return fmt.Errorf("permission %q: invalid type %d", p.Key, p.Type)
+		}
+		delete(permParents, p.Key)
+		if p.Parent != "" {
+			permParents[p.Key] = []string{p.Parent}
+		}
+	}
+	hasPermission := func(key string) bool {
+		_, ok := st.permissions[key]
+		return ok || docKeys[key]
+	}
+	for _, p := range doc.Permissions {
+		if p.Parent != "" && !hasPermission(p.Parent) {
+			return fmt.Errorf("permission %q: unknown parent %q", p.Key, p.Parent)
+		}
+	}
+	if key, ok := findCycle(permParents); ok {
+		return fmt.Errorf("permission %q: parent forms a cycle", key)
+	}
+
+	roleParents := make(map[string][]string, len(st.roles))
+	for sid, role := range st.roles {
+		roleParents[sid] = roleSids(role.Parents)
+	}
+	docSids := make(map[string]bool, len(doc.Roles))
+	for i := range doc.Roles {
+		r := &doc.Roles[i]
+		if r.Sid == "" || r.Name == "" {
+			return fmt.Errorf("role %q: sid and name are required", r.Sid)
+		}
+		if docSids[r.Sid] {
+			return fmt.Errorf("role %q: duplicated", r.Sid)
+		}
+		docSids[r.Sid] = true
+		if r.DataScope == 0 {
+			r.DataScope = model.DataScopeAll
+		}
+		if r.DataScope < model.DataScopeAll || r.DataScope > model.DataScopeSelf {
+			return fmt.Errorf("role %q: invalid data_scope %d", r.Sid, r.DataScope)
+		}
+		for _, key := range r.Permissions {
+			if !hasPermission(key) {
+				return fmt.Errorf("role %q: unknown permission %q", r.Sid, key)
+			}
+		}
+		roleParents[r.Sid] = r.Parents
+	}
+	for _, r := range doc.Roles {
+		for _, sid := range r.Parents {
+			if _, ok := st.roles[sid]; !ok && !docSids[sid] {
+				return fmt.Errorf("role %q: unknown parent %q", r.Sid, sid)
+			}
+		}
+	}
+	if sid, ok := findCycle(roleParents); ok {
+		return fmt.Errorf("role %q: inheritance forms a cycle", sid)
+	}
+	return nil
+}
+
+// diff 文档相对数据库的变更，权限在前、角色在后，各自按文档顺序
+func (st *rbacState) diff(doc *v1.RbacDocument) []v1.RbacChange {
+	changes := []v1.RbacChange{}
+	for _, p := range doc.Permissions {
+		perm, ok := st.permissions[p.Key]
+		if !ok {
+			changes = append(changes, v1.RbacChange{Kind: "permission", Key: p.Key, Action: "create"})
+			continue
+		}
+		var fields []string
+		check := func(field string, equal bool) {
+			if !equal {
+				fields = append(fields, field)
+			}
+		}
+		check("name", perm.Name == p.Name)
+		check("type", perm.Type == p.Type)
+		check("parent", st.parentKeys[p.Key] == p.Parent)
+		check("sort", perm.Sort == p.Sort)
+		check("path", perm.Path == p.Path)
+		check("component", perm.Component == p.Component)
+		check("api", perm.Api == p.Api)
+		check("method", perm.Method == p.Method)
+		if len(fields) > 0 {
+			changes = append(changes, v1.RbacChange{Kind: "permission", Key: p.Key, Action: "update", Fields: fields})
+		}
+	}
+	for _, r := range doc.Roles {
+		role, ok := st.roles[r.Sid]
+		if !ok {
+			changes = append(changes, v1.RbacChange{Kind: "role", Key: r.Sid, Action: "create"})
+			continue
+		}
+		var fields []string
+		if role.Name != r.Name {
+			fields = append(fields, "name")
+		}
+		if role.DataScope != r.DataScope {
+			fields = append(fields, "data_scope")
+		}
+		if !sameSet(permissionKeys(role.Permissions), r.Permissions) {
+			fields = append(fields, "permissions")
+		}
+		if !sameSet(roleSids(role.Parents), r.Parents) {
+			fields = append(fields, "parents")
+		}
+		if len(fields) > 0 {
+			changes = append(changes, v1.RbacChange{Kind: "role", Key: r.Sid, Action: "update", Fields: fields})
+		}
+	}
+	return changes
+}
+
+// findCycle 在 节点 -> 父节点 的图中查找环，返回环上的一个节点
+func findCycle(parents map[string][]string) (string, bool) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(parents))
+	var visit func(node string) bool
+	visit = func(node string) bool {
+		switch state[node] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[node] = visiting
+		for _, parent := range parents[node] {
+			if visit(parent) {
+				return true
+			}
+		}
+		state[node] = done
+		return false
+	}
+	nodes := make([]string, 0, len(parents))
+	for node := range parents {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if visit(node) {
+			return node, true
+		}
+	}
+	return "", false
+}
+
+func permissionKeys(permissions []model.Permission) []string {
+	keys := make([]string, 0, len(permissions))
+	for _, perm := range permissions {
+		keys = append(keys, perm.Key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func roleSids(roles []model.Role) []string {
+	sids := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role.Sid != "" {
+			sids = append(sids, role.Sid)
+		}
+	}
+	sort.Strings(sids)
+	return sids
+}
+
+func sameSet(a, b []string) bool {
+	a = slices.Compact(slices.Sorted(slices.Values(a)))
+	b = slices.Compact(slices.Sorted(slices.Values(b)))
+	return slices.Equal(a, b)
+}
@@ -5,22 +5,33 @@ import (
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
 	"go-nunu/internal/repository"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
-	"gorm.io/gorm"
+	"github.com/duke-git/lancet/v2/slice"
+	"go.uber.org/zap"
 )
 
 type RoleService interface {
 	GetRole(ctx context.Context, id int64) (*model.Role, error)
-	CreateRole(ctx context.Context, req v1.CreateRoleRequest) (*model.Role, error)
+	CreateRole(ctx context.Context, userId string, req v1.CreateRoleRequest) (*model.Role, error)
 	GetRoleList(ctx context.Context, req v1.GetRoleListRequest) ([]model.Role, int, error)
 	UpdateRolePermissions(ctx context.Context, roleId int64, permissionIds []uint) error
+	// UpdateRoleParents 设置角色继承的父角色，形成环时返回 ErrRoleCycle
+	UpdateRoleParents(ctx context.Context, roleId int64, parentIds []uint) error
+	// GetRolePermissions 返回角色的有效权限，区分直接分配和继承获得
+	GetRolePermissions(ctx context.Context, roleId int64) (*v1.GetRolePermissionsResponseData, error)
+	UpdateRoleDataScope(ctx context.Context, req *v1.UpdateRoleDataScopeRequest) error
+	// UpdateRole 修改角色名称和标识，标识变化时同步改写 Casbin 规则
+	UpdateRole(ctx context.Context, req *v1.UpdateRoleRequest) (*model.Role, error)
+	// DeleteRole 删除角色，仍分配给用户或被其他角色继承时返回 ErrRoleInUse
+	DeleteRole(ctx context.Context, id int64) error
 }
 
 func NewRoleService(
 	service *Service,
 	roleRepository repository.RoleRepository,
-	casbin *casbin.CachedEnforcer,
+	casbin *casbin.SyncedCachedEnforcer,
 ) RoleService {
 	return &roleService{
 		Service:        service,
@@ -32,32 +43,114 @@ func NewRoleService(
 type roleService struct {
 	*Service
 	roleRepository repository.RoleRepository
-	Casbin         *casbin.CachedEnforcer
+	Casbin         *casbin.SyncedCachedEnforcer
 }
 
 func (s *roleService) GetRole(ctx context.Context, id int64) (*model.Role, error) {
 	return s.roleRepository.GetRole(ctx, id)
 }
 
-func (s *roleService) CreateRole(ctx context.Context, req v1.CreateRoleRequest) (*model.Role, error) {
+func (s *roleService) CreateRole(ctx context.Context, userId string, req v1.CreateRoleRequest) (*model.Role, error) {
+	// 没有 Sid 的角色无法写入 Casbin，分配给用户也不会生效
+	if strings.TrimSpace(req.Key) == "" {
+		return nil, v1.ErrRoleKeyRequired
+	}
+	if err := s.checkSidAvailable(ctx, req.Key); err != nil {
+		return nil, err
+	}
 	role := &model.Role{
-		Name: req.Name,
-		Sid:  req.Key,
+		Name:      req.Name,
+		Sid:       req.Key,
+		DataScope: req.DataScope,
+		CreatedBy: userId,
 		// Status: req.Status,
 	}
-	role, err := s.roleRepository.CreateRole(ctx, role)
+	if role.DataScope == 0 {
+		role.DataScope = model.DataScopeAll
+	}
+	var permissions []model.Permission
+	if len(req.PermissionIds) > 0 {
+		if err := s.DB(ctx).Find(&permissions, req.PermissionIds).Error; err != nil {
+			return nil, err
+		}
+	}
+	// 新角色没有子角色，继承关系不会成环，只需检查父角色是否存在
+	parents, err := s.roleRepository.GetRolesByIds(ctx, req.ParentIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(parents) != len(slice.Unique(req.ParentIds)) {
+		return nil, v1.ErrNotFound
+	}
+	if err = checkRoleSids(parents...); err != nil {
+		return nil, err
+	}
+
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := s.roleRepository.CreateRole(ctx, role); err != nil {
+			return err
+		}
+		if err := s.roleRepository.ReplacePermissions(ctx, role, permissions); err != nil {
+			return err
+		}
+		if len(parents) > 0 {
+			return s.roleRepository.ReplaceParents(ctx, role, parents)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	err = s.UpdateRolePermissions(
-		ctx,
-		int64(role.ID),
-		req.PermissionIds,
-	)
+	// Casbin 写入放在事务提交之后，避免 SQLite 下事务与 adapter 互相锁住；失败时撤销角色
+	if err = s.syncRolePolicies(role.Sid, permissions); err == nil {
+		err = s.syncRoleParents(role.Sid, parents)
+	}
 	if err != nil {
+		s.undoCreateRole(ctx, role)
 		return nil, err
 	}
-	return role, err
+	return role, nil
+}
+
+// undoCreateRole 删除已写入的 Casbin 规则和角色，避免留下与 Casbin 不一致的角色
+func (s *roleService) undoCreateRole(ctx context.Context, role *model.Role) {
+	logger := s.logger.WithContext(ctx)
+	if _, err := s.Casbin.RemoveFilteredPolicy(0, role.Sid); err != nil {
+		logger.Error("undo create role: RemoveFilteredPolicy error", zap.String("sid", role.Sid), zap.Error(err))
+	}
+	if _, err := s.Casbin.RemoveFilteredGroupingPolicy(0, role.Sid); err != nil {
+		logger.Error("undo create role: RemoveFilteredGroupingPolicy error", zap.String("sid", role.Sid), zap.Error(err))
+	}
+	if err := s.Casbin.InvalidateCache(); err != nil {
+		logger.Error("undo create role: InvalidateCache error", zap.Error(err))
+	}
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.roleRepository.DeleteRole(ctx, role)
+	}); err != nil {
+		logger.Error("undo create role: DeleteRole error", zap.String("sid", role.Sid), zap.Error(err))
+	}
+}
+
+// checkRoleSids 历史数据中可能存在没有 Sid 的角色，不能据此写入 Casbin 规则
+func checkRoleSids(roles ...model.Role) error {
+	for _, role := range roles {
+		if role.Sid == "" {
+			return v1.ErrRoleKeyRequired
+		}
+	}
+	return nil
+}
+
+// checkSidAvailable Sid 是 Casbin 中的角色名，不能与其他角色重复
+func (s *roleService) checkSidAvailable(ctx context.Context, sid string) error {
+	roles, err := s.roleRepository.GetRolesBySids(ctx, []string{sid})
+	if err != nil {
+		return err
+	}
+	if len(roles) > 0 {
+		return v1.ErrRoleSidExists
+	}
+	return nil
 }
 
 func (s *roleService) GetRoleList(ctx context.Context, req v1.GetRoleListRequest) ([]model.Role, int, error) {
@@ -73,61 +166,302 @@ func (s *roleService) GetRoleList(ctx context.Context, req v1.GetRoleListRequest
 }
 
 func (s *roleService) UpdateRolePermissions(ctx context.Context, roleID int64, permissionIDs []uint) error {
-	var role model.Role
-
 	// 1. 查找角色
-	if err := s.DB(ctx).First(&role, roleID).Error; err != nil {
+	role, err := s.roleRepository.GetRole(ctx, roleID)
+	if err != nil {
 		return err
 	}
+	if err = checkRoleSids(*role); err != nil {
+		return err
+	}
+	oldPermissions := append([]model.Permission(nil), role.Permissions...)
 
 	// 2. 查询完整的权限对象（包含 Path 和 Method）
 	var permissions []model.Permission
-	if err := s.DB(ctx).Find(&permissions, permissionIDs).Error; err != nil {
+	if len(permissionIDs) > 0 {
+		if err = s.DB(ctx).Find(&permissions, permissionIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	// 3. 更新数据库关联表
+	if err = s.roleRepository.ReplacePermissions(ctx, role, permissions); err != nil {
 		return err
 	}
-	if len(permissionIDs) == 0 {
-		permissions = nil
+	// 4. 同步 Casbin 策略，放在数据库写入之后，避免 SQLite 下事务与 adapter 互相锁住；失败时恢复原有权限
+	if err = s.syncRolePolicies(role.Sid, permissions); err != nil {
+		logger := s.logger.WithContext(ctx)
+		if undoErr := s.roleRepository.ReplacePermissions(ctx, role, oldPermissions); undoErr != nil {
+			logger.Error("undo update role permissions: ReplacePermissions error", zap.String("sid", role.Sid), zap.Error(undoErr))
+		} else if undoErr = s.syncRolePolicies(role.Sid, oldPermissions); undoErr != nil {
+			logger.Error("undo update role permissions: syncRolePolicies error", zap.String("sid", role.Sid), zap.Error(undoErr))
+		}
+		return err
 	}
+	return nil
+}
 
-	err := s.DB(ctx).Transaction(func(tx *gorm.DB) error {
-		// 3. 更新数据库关联表
-		if err := tx.Model(&role).Association("Permissions").Replace(&permissions); err != nil {
-			return err
+func (s *roleService) UpdateRoleParents(ctx context.Context, roleId int64, parentIds []uint) error {
+	role, err := s.roleRepository.GetRole(ctx, roleId)
+	if err != nil {
+		return err
+	}
+	parents, err := s.roleRepository.GetRolesByIds(ctx, parentIds)
+	if err != nil {
+		return err
+	}
+	if len(parents) != len(slice.Unique(parentIds)) {
+		return v1.ErrNotFound
+	}
+	if err = checkRoleSids(append(parents, *role)...); err != nil {
+		return err
+	}
+	oldParents := append([]model.Role(nil), role.Parents...)
+
+	// 用新的父角色替换后检查能否从父角色走回自身
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return err
+	}
+	graph := roleGraph(roles)
+	proposed := *graph[role.ID]
+	proposed.Parents = parents
+	graph[role.ID] = &proposed
+	for _, ancestor := range roleAncestors(graph, role.ID) {
+		if ancestor.ID == role.ID {
+			return v1.ErrRoleCycle
 		}
+	}
 
-		// 4. 同步 Casbin 策略（关键步骤）
-		sub := role.Sid
+	if err = s.roleRepository.ReplaceParents(ctx, role, parents); err != nil {
+		return err
+	}
+	// 与 UpdateRolePermissions 相同，Casbin 写入失败时恢复原有继承关系
+	if err = s.syncRoleParents(role.Sid, parents); err != nil {
+		logger := s.logger.WithContext(ctx)
+		if undoErr := s.roleRepository.ReplaceParents(ctx, role, oldParents); undoErr != nil {
+			logger.Error("undo update role parents: ReplaceParents error", zap.String("sid", role.Sid), zap.Error(undoErr))
+		} else if undoErr = s.syncRoleParents(role.Sid, oldParents); undoErr != nil {
+			logger.Error("undo update role parents: syncRoleParents error", zap.String("sid", role.Sid), zap.Error(undoErr))
+		}
+		return err
+	}
+	return nil
+}
 
-		// 4.1 清除该角色的旧策略
-		if _, err := s.Casbin.RemoveFilteredPolicy(0, sub); err != nil {
+// syncRoleParents 把角色继承关系同步为 Casbin g 规则: g, <子角色 Sid>, <父角色 Sid>
+func (s *roleService) syncRoleParents(sid string, parents []model.Role) error {
+	if _, err := s.Casbin.DeleteRolesForUser(sid); err != nil {
+		return err
+	}
+	if len(parents) > 0 {
+		parentSids := make([]string, 0, len(parents))
+		for _, parent := range parents {
+			parentSids = append(parentSids, parent.Sid)
+		}
+		if _, err := s.Casbin.AddRolesForUser(sid, parentSids); err != nil {
 			return err
 		}
+	}
+	return s.Casbin.InvalidateCache()
+}
 
-		// 4.2 添加新策略
-		var rules [][]string
-		for _, perm := range permissions {
-			if perm.Path == "" || perm.Method == "" {
-				continue
-			}
-			// 对应 Casbin 规则: p, role_key, path, method
-			switch perm.Type {
-			case model.PermissionTypeDirectory:
-				rules = append(rules, []string{sub, model.DirectoryResourcePrefix + perm.Api, perm.Method})
-			case model.PermissionTypeMenu:
-				rules = append(rules, []string{sub, model.MenuResourcePrefix + perm.Api, perm.Method})
-			case model.PermissionTypeButton:
-				rules = append(rules, []string{sub, model.ApiResourcePrefix + perm.Api, perm.Method})
+func (s *roleService) GetRolePermissions(ctx context.Context, roleId int64) (*v1.GetRolePermissionsResponseData, error) {
+	roles, err := s.roleRepository.ListRoleGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	graph := roleGraph(roles)
+	role, ok := graph[uint(roleId)]
+	if !ok {
+		return nil, v1.ErrNotFound
+	}
+
+	data := &v1.GetRolePermissionsResponseData{
+		Role:      *role,
+		Ancestors: []string{},
+		List:      []v1.EffectivePermission{},
+	}
+	index := make(map[uint]int)
+	for _, perm := range role.Permissions {
+		index[perm.ID] = len(data.List)
+		data.List = append(data.List, v1.EffectivePermission{
+			Permission:    perm,
+			Source:        v1.PermissionSourceDirect,
+			InheritedFrom: []string{},
+		})
+	}
+	for _, ancestor := range roleAncestors(graph, role.ID) {
+		data.Ancestors = append(data.Ancestors, ancestor.Sid)
+		for _, perm := range ancestor.Permissions {
+			i, ok := index[perm.ID]
+			if !ok {
+				i = len(data.List)
+				index[perm.ID] = i
+				data.List = append(data.List, v1.EffectivePermission{
+					Permission:    perm,
+					Source:        v1.PermissionSourceInherited,
+					InheritedFrom: []string{},
+				})
 			}
+			data.List[i].InheritedFrom = append(data.List[i].InheritedFrom, ancestor.Sid)
 		}
+	}
+	return data, nil
+}
+
+func (s *roleService) UpdateRoleDataScope(ctx context.Context, req *v1.UpdateRoleDataScopeRequest) error {
+	role, err := s.roleRepository.GetRole(ctx, int64(req.ID))
+	if err != nil {
+		return err
+	}
+	role.DataScope = req.DataScope
+	_, err = s.roleRepository.UpdateRole(ctx, role)
+	return err
+}
 
-		if len(rules) > 0 {
-			if _, err := s.Casbin.AddPolicies(rules); err != nil {
-				return err
+func (s *roleService) UpdateRole(ctx context.Context, req *v1.UpdateRoleRequest) (*model.Role, error) {
+	role, err := s.roleRepository.GetRole(ctx, int64(req.ID))
+	if err != nil {
+		return nil, err
+	}
+	oldSid := role.Sid
+	if req.Name != "" {
+		role.Name = req.Name
+	}
+	if req.Key != "" && req.Key != oldSid {
+		if err = s.checkSidAvailable(ctx, req.Key); err != nil {
+			return nil, err
+		}
+		role.Sid = req.Key
+	}
+	if role, err = s.roleRepository.UpdateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	if role.Sid != oldSid {
+		if err = s.renameRoleSubject(oldSid, role.Sid); err != nil {
+			// 改回旧 Sid，已经改名的规则由 PolicyService 对账修复
+			role.Sid = oldSid
+			if _, undoErr := s.roleRepository.UpdateRole(ctx, role); undoErr != nil {
+				s.logger.WithContext(ctx).Error("undo rename role error", zap.String("sid", oldSid), zap.Error(undoErr))
 			}
+			return nil, err
 		}
+	}
+	return role, nil
+}
+
+// renameRoleSubject 把 Casbin 中引用旧 Sid 的 p 规则和 g 规则（角色作为子角色或被用户、角色继承）改为新 Sid
+func (s *roleService) renameRoleSubject(oldSid string, newSid string) error {
+	policies, err := s.Casbin.GetFilteredPolicy(0, oldSid)
+	if err != nil {
+		return err
+	}
+	asChild, err := s.Casbin.GetFilteredGroupingPolicy(0, oldSid)
+	if err != nil {
+		return err
+	}
+	asParent, err := s.Casbin.GetFilteredGroupingPolicy(1, oldSid)
+	if err != nil {
+		return err
+	}
 
+	rename := func(rules [][]string, field int) [][]string {
+		renamed := make([][]string, 0, len(rules))
+		for _, rule := range rules {
+			rule = append([]string(nil), rule...)
+			rule[field] = newSid
+			renamed = append(renamed, rule)
+		}
+		return renamed
+	}
+	if _, err = s.Casbin.RemoveFilteredPolicy(0, oldSid); err != nil {
+		return err
+	}
+	if _, err = s.Casbin.RemoveFilteredGroupingPolicy(0, oldSid); err != nil {
+		return err
+	}
+	if _, err = s.Casbin.RemoveFilteredGroupingPolicy(1, oldSid); err != nil {
+		return err
+	}
+	if len(policies) > 0 {
+		if _, err = s.Casbin.AddPolicies(rename(policies, 0)); err != nil {
+			return err
+		}
+	}
+	if grouping := append(rename(asChild, 0), rename(asParent, 1)...); len(grouping) > 0 {
+		if _, err = s.Casbin.AddGroupingPolicies(grouping); err != nil {
+			return err
+		}
+	}
+	return s.Casbin.InvalidateCache()
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, id int64) error {
+	role, err := s.roleRepository.GetRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	users, err := s.roleRepository.CountRoleUsers(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+	children, err := s.roleRepository.CountRoleChildren(ctx, role.ID)
+	if err != nil {
+		return err
+	}
+	if users > 0 || children > 0 {
+		return v1.ErrRoleInUse
+	}
+
+	if err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.roleRepository.DeleteRole(ctx, role)
+	}); err != nil {
+		return err
+	}
+	// 没有 Sid 的角色不会写入 Casbin，空字段的过滤条件会被 Casbin 拒绝
+	if role.Sid == "" {
 		return nil
-	})
+	}
+	if _, err = s.Casbin.RemoveFilteredPolicy(0, role.Sid); err != nil {
+		return err
+	}
+	if _, err = s.Casbin.RemoveFilteredGroupingPolicy(0, role.Sid); err != nil {
+		return err
+	}
+	return s.Casbin.InvalidateCache()
+}
 
-	return err
+func roleGraph(roles []model.Role) map[uint]*model.Role {
+	graph := make(map[uint]*model.Role, len(roles))
+	for i := range roles {
+		graph[roles[i].ID] = &roles[i]
+	}
+	return graph
+}
+
+// roleAncestors 按广度优先返回全部祖先角色，由近到远，每个角色只出现一次
+// 存在环时角色自身也会出现在结果中
+func roleAncestors(graph map[uint]*model.Role, id uint) []*model.Role {
+	var ancestors []*model.Role
+	visited := make(map[uint]bool)
+	queue := []uint{id}
+	for len(queue) > 0 {
+		current, ok := graph[queue[0]]
+		queue = queue[1:]
+		if !ok {
+			continue
+		}
+		for _, parent := range current.Parents {
+			if visited[parent.ID] {
+				continue
+			}
+			visited[parent.ID] = true
+			if p, ok := graph[parent.ID]; ok {
+				ancestors = append(ancestors, p)
+			}
+			queue = append(queue, parent.ID)
+		}
+	}
+	return ancestors
 }
@@ -2,17 +2,20 @@ package service
 
 import (
 	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
 	"go-nunu/internal/repository"
 	"go-nunu/pkg/jwt"
 	"go-nunu/pkg/log"
 	"go-nunu/pkg/sid"
+	"slices"
 
 	"github.com/casbin/casbin/v2"
 	"gorm.io/gorm"
 )
 
 type Service struct {
-	Casbin *casbin.CachedEnforcer
+	Casbin *casbin.SyncedCachedEnforcer
 	db     *gorm.DB
 	logger *log.Logger
 	sid    *sid.Sid
@@ -26,8 +29,10 @@ func NewService(
 	logger *log.Logger,
 	sid *sid.Sid,
 	jwt *jwt.JWT,
+	casbin *casbin.SyncedCachedEnforcer,
 ) *Service {
 	return &Service{
+		Casbin: casbin,
 		db:     db, // <-- 必须将 DB 实例赋值给 db 字段
 		logger: logger,
 		sid:    sid,
@@ -41,3 +46,62 @@ func (s *Service) DB(ctx context.Context) *gorm.DB {
 	// 返回一个绑定了上下文的 DB 实例
 	return s.db.WithContext(ctx)
 }
+
+// unassignableRoles 返回操作人不能分配的角色 Sid
+// 只能分配自己拥有的角色及其继承的角色，超管不受限制
+func (s *Service) unassignableRoles(operatorId string, sids []string) ([]string, error) {
+	if operatorId == model.AdminUserID || len(sids) == 0 {
+		return nil, nil
+	}
+	held, err := s.Casbin.GetImplicitRolesForUser(model.UserSubject(operatorId))
+	if err != nil {
+		return nil, err
+	}
+	var denied []string
+	for _, sid := range sids {
+		if !slices.Contains(held, sid) {
+			denied = append(denied, sid)
+		}
+	}
+	return denied, nil
+}
+
+// checkAssignableRoles 分配或收回操作人不能分配的角色时返回 ErrForbidden
+func (s *Service) checkAssignableRoles(operatorId string, sids []string) error {
+	denied, err := s.unassignableRoles(operatorId, sids)
+	if err != nil {
+		return err
+	}
+	if len(denied) > 0 {
+		return v1.ErrForbidden
+	}
+	return nil
+}
+
+// syncUserRoles 把用户的角色同步为 Casbin g 规则，AuthMiddleware 据此解析用户拥有的全部角色
+func (s *Service) syncUserRoles(userId string, roleSids []string) error {
+	sub := model.UserSubject(userId)
+	if _, err := s.Casbin.DeleteRolesForUser(sub); err != nil {
+		return err
+	}
+	if len(roleSids) > 0 {
+		if _, err := s.Casbin.AddRolesForUser(sub, roleSids); err != nil {
+			return err
+		}
+	}
+	return s.Casbin.InvalidateCache()
+}
+
+// syncRolePolicies 把角色的权限同步为 Casbin p 规则: p, <Role.Sid>, <obj>, <method>
+func (s *Service) syncRolePolicies(sid string, permissions []model.Permission) error {
+	if _, err := s.Casbin.RemoveFilteredPolicy(0, sid); err != nil {
+		return err
+	}
+	if rules := model.RolePolicies(sid, permissions); len(rules) > 0 {
+		if _, err := s.Casbin.AddPolicies(rules); err != nil {
+			return err
+		}
+	}
+	// 缓存按请求 (user, obj, act) 记录，策略变化后需要整体失效
+	return s.Casbin.InvalidateCache()
+}
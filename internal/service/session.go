@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+type SessionService interface {
+	// RecordLogin 记录失败的登录，成功的登录由 TokenService.IssueTokens 记录
+	RecordLogin(ctx context.Context, entry *model.LoginLog)
+	ListSessions(ctx context.Context, userId string, currentTokenId string) (*v1.ListSessionsResponseData, error)
+	RevokeSession(ctx context.Context, userId string, sessionId uint) error
+	RevokeAllSessions(ctx context.Context, userId string) error
+	GetLoginLogList(ctx context.Context, req *v1.GetLoginLogListRequest) (*v1.GetLoginLogListResponseData, error)
+}
+
+func NewSessionService(
+	service *Service,
+	sessionRepo repository.SessionRepository,
+	tokenService TokenService,
+) SessionService {
+	return &sessionService{
+		Service:      service,
+		sessionRepo:  sessionRepo,
+		tokenService: tokenService,
+	}
+}
+
+type sessionService struct {
+	*Service
+	sessionRepo  repository.SessionRepository
+	tokenService TokenService
+}
+
+// RecordLogin 登录日志写入失败不影响登录结果，只记录错误
+func (s *sessionService) RecordLogin(ctx context.Context, entry *model.LoginLog) {
+	if err := s.sessionRepo.CreateLoginLog(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).Error("sessionRepo.CreateLoginLog error", zap.Error(err))
+	}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userId string, currentTokenId string) (*v1.ListSessionsResponseData, error) {
+	sessions, err := s.sessionRepo.ListActiveSessions(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.ListSessionsResponseData{List: sessions}
+	for _, session := range sessions {
+		if currentTokenId != "" && session.TokenId == currentTokenId {
+			data.CurrentSessionId = session.ID
+		}
+	}
+	return data, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userId string, sessionId uint) error {
+	session, err := s.sessionRepo.GetSession(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if session.UserId != userId {
+		return v1.ErrNotFound
+	}
+	return s.tokenService.RevokeFamily(ctx, session.FamilyId)
+}
+
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userId string) error {
+	return s.tokenService.RevokeUserTokens(ctx, userId)
+}
+
+func (s *sessionService) GetLoginLogList(ctx context.Context, req *v1.GetLoginLogListRequest) (*v1.GetLoginLogListResponseData, error) {
+	logs, total, err := s.sessionRepo.GetLoginLogList(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetLoginLogListResponseData{List: logs}
+	data.Total = total
+	return data, nil
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/pkg/jwt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type TokenService interface {
+	IssueTokens(ctx context.Context, user *model.User, client *model.ClientInfo, loginType int) (*v1.LoginResponseData, error)
+	RefreshToken(ctx context.Context, req *v1.RefreshTokenRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	Logout(ctx context.Context, claims *jwt.MyCustomClaims, req *v1.LogoutRequest) error
+	RevokeFamily(ctx context.Context, familyId string) error
+	RevokeUserTokens(ctx context.Context, userId string) error
+}
+
+func NewTokenService(
+	service *Service,
+	conf *viper.Viper,
+	tokenRepo repository.TokenRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+) TokenService {
+	accessTTL := conf.GetDuration("security.jwt.access_ttl")
+	if accessTTL <= 0 {
+		accessTTL = 15 * time.Minute
+	}
+	refreshTTL := conf.GetDuration("security.jwt.refresh_ttl")
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
+	return &tokenService{
+		Service:     service,
+		tokenRepo:   tokenRepo,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+type tokenService struct {
+	*Service
+	tokenRepo   repository.TokenRepository
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+// IssueTokens 登录成功后签发 access token，开启一个新的刷新令牌 family 和对应的会话，并记录登录日志
+func (s *tokenService) IssueTokens(ctx context.Context, user *model.User, client *model.ClientInfo, loginType int) (*v1.LoginResponseData, error) {
+	if !user.Active() {
+		return nil, v1.ErrUserDisabled
+	}
+	familyId := uuid.NewString()
+	var data *v1.LoginResponseData
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		var (
+			tokenId string
+			err     error
+		)
+		data, tokenId, err = s.issue(ctx, user, familyId)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err = s.sessionRepo.CreateSession(ctx, &model.Session{
+			UserId:       user.UserId,
+			TokenId:      tokenId,
+			FamilyId:     familyId,
+			IP:           client.IP,
+			UserAgent:    client.UserAgent,
+			LoginType:    loginType,
+			LastActiveAt: now,
+			ExpiresAt:    now.Add(s.refreshTTL),
+		}); err != nil {
+			return err
+		}
+		if err = s.userRepo.UpdateLastLogin(ctx, user.UserId, client.IP, loginType); err != nil {
+			return err
+		}
+		return s.sessionRepo.CreateLoginLog(ctx, &model.LoginLog{
+			UserId:    user.UserId,
+			Email:     user.Email,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+			LoginType: loginType,
+			Result:    model.LoginResultSuccess,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *tokenService) RefreshToken(ctx context.Context, req *v1.RefreshTokenRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	token, err := s.tokenRepo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken))
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return nil, v1.ErrUnauthorized
+		}
+		return nil, err
+	}
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		return nil, s.revokeReusedFamily(ctx, token)
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, v1.ErrUnauthorized
+	}
+	// 标记旧令牌、签发新令牌和更新会话要么全部生效，要么全部回滚
+	var (
+		data   *v1.LoginResponseData
+		reused bool
+	)
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		ok, err := s.tokenRepo.MarkRefreshTokenUsed(ctx, token.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// 并发请求抢先轮换了同一个刷新令牌
+			reused = true
+			return nil
+		}
+		user, err := s.userRepo.GetByID(ctx, token.UserId)
+		if err != nil || !user.Active() {
+			return v1.ErrUnauthorized
+		}
+		var tokenId string
+		data, tokenId, err = s.issue(ctx, user, token.FamilyId)
+		if err != nil {
+			return err
+		}
+		return s.sessionRepo.RotateSession(ctx, token.FamilyId, tokenId, time.Now().Add(s.refreshTTL), client)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, s.revokeReusedFamily(ctx, token)
+	}
+	return data, nil
+}
+
+func (s *tokenService) Logout(ctx context.Context, claims *jwt.MyCustomClaims, req *v1.LogoutRequest) error {
+	var (
+		token *model.RefreshToken
+		err   error
+	)
+	if req.RefreshToken != "" {
+		token, err = s.tokenRepo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken))
+	} else {
+		token, err = s.tokenRepo.GetRefreshTokenByAccessTokenId(ctx, claims.ID)
+	}
+	if err != nil && !errors.Is(err, v1.ErrNotFound) {
+		return err
+	}
+	if token != nil && token.UserId == claims.UserId {
+		if err = s.RevokeFamily(ctx, token.FamilyId); err != nil {
+			return err
+		}
+	}
+	return s.tokenRepo.RevokeTokenId(ctx, claims.ID, claims.UserId, claims.ExpiresAt.Time)
+}
+
+// RevokeFamily 吊销一个会话
+func (s *tokenService) RevokeFamily(ctx context.Context, familyId string) error {
+	return s.tokenRepo.RevokeFamily(ctx, familyId, time.Now().Add(s.accessTTL))
+}
+
+func (s *tokenService) RevokeUserTokens(ctx context.Context, userId string) error {
+	return s.tokenRepo.RevokeUserTokens(ctx, userId, time.Now().Add(s.accessTTL))
+}
+
+// issue 签发 access token 和刷新令牌，返回 access token 的 ID
+func (s *tokenService) issue(ctx context.Context, user *model.User, familyId string) (*v1.LoginResponseData, string, error) {
+	now := time.Now()
+	tokenId := uuid.NewString()
+	// 访客只拿到受限 token，升级为注册用户后刷新即得到正式 token
+	scope := ""
+	if user.UserType == model.UserTypeGuest {
+		scope = jwt.ScopeGuest
+	}
+	accessToken, err := s.jwt.GenScopedToken(user, scope, tokenId, now.Add(s.accessTTL))
+	if err != nil {
+		return nil, "", err
+	}
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	if err = s.tokenRepo.CreateRefreshToken(ctx, &model.RefreshToken{
+		UserId:        user.UserId,
+		FamilyId:      familyId,
+		TokenHash:     hashToken(refreshToken),
+		AccessTokenId: tokenId,
+		ExpiresAt:     now.Add(s.refreshTTL),
+	}); err != nil {
+		return nil, "", err
+	}
+	return &v1.LoginResponseData{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, tokenId, nil
+}
+
+func (s *tokenService) revokeReusedFamily(ctx context.Context, token *model.RefreshToken) error {
+	s.logger.WithContext(ctx).Warn("refresh token reuse detected, revoking family",
+		zap.String("user_id", token.UserId),
+		zap.String("family_id", token.FamilyId),
+	)
+	if err := s.RevokeFamily(ctx, token.FamilyId); err != nil {
+		return err
+	}
+	return v1.ErrUnauthorized
+}
+
+// newOpaqueToken 生成随机令牌，服务端只保存 hashToken 之后的值
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
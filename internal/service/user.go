@@ -2,40 +2,89 @@ package service
 
 import (
 	"context"
+	"errors"
 	v1 "go-nunu/api/v1"
 	"go-nunu/internal/model"
 	"go-nunu/internal/repository"
+	"go-nunu/pkg/lockout"
 	"time"
 
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
 type UserService interface {
 	Register(ctx context.Context, req *v1.RegisterRequest) error
-	Login(ctx context.Context, req *v1.LoginRequest) (string, error)
+	Login(ctx context.Context, req *v1.LoginRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	// GuestLogin 按 udid 登录访客，不存在时自动创建，返回只能访问访客接口的 token
+	GuestLogin(ctx context.Context, req *v1.GuestLoginRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	// UpgradeGuest 给访客绑定邮箱和密码，邮箱已注册时合并到该账号
+	UpgradeGuest(ctx context.Context, guestId string, req *v1.UpgradeGuestRequest, client *model.ClientInfo) (*v1.LoginResponseData, error)
+	UnlockUser(ctx context.Context, req *v1.UnlockUserRequest) error
 	GetProfile(ctx context.Context, userId string) (*v1.GetProfileResponseData, error)
-	GetUserList(ctx context.Context) (*v1.GetUserListResponseData, error)
+	GetUserList(ctx context.Context, req *v1.GetUserListRequest) (*v1.GetUserListResponseData, error)
 	UpdateProfile(ctx context.Context, userId string, req *v1.UpdateProfileRequest) error
-	UpdateUser(ctx context.Context, req *v1.UpdateUserRequest) error
+
+	// 以下为管理员操作，operatorId 为操作人，不能对自己和超管执行禁用、注销、恢复、删除
+	// 分配角色时只能分配操作人自己拥有或继承的角色
+	UpdateUser(ctx context.Context, operatorId string, req *v1.UpdateUserRequest) error
+	CreateUser(ctx context.Context, operatorId string, req *v1.CreateUserRequest) (*v1.CreateUserResponseData, error)
+	DisableUser(ctx context.Context, operatorId string, userId string) error
+	EnableUser(ctx context.Context, userId string) error
+	// DeactivateUser 注销用户：软删除并吊销全部 token，可以通过 RestoreUser 恢复
+	DeactivateUser(ctx context.Context, operatorId string, userId string) error
+	GetDeletedUserList(ctx context.Context, req *v1.GetDeletedUserListRequest) (*v1.GetUserListResponseData, error)
+	RestoreUser(ctx context.Context, operatorId string, userId string) error
+	// PurgeUser 永久删除已注销的用户
+	PurgeUser(ctx context.Context, operatorId string, userId string) error
 }
 
 func NewUserService(
 	service *Service,
+	conf *viper.Viper,
 	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	tokenService TokenService,
+	accountService AccountService,
+	mfaService MfaService,
+	sessionService SessionService,
+	avatarService AvatarService,
+	loginGuard *lockout.Guard,
 ) UserService {
 	return &userService{
-		userRepo: userRepo,
-		Service:  service,
+		userRepo:             userRepo,
+		roleRepo:             roleRepo,
+		tokenService:         tokenService,
+		accountService:       accountService,
+		mfaService:           mfaService,
+		sessionService:       sessionService,
+		avatarService:        avatarService,
+		loginGuard:           loginGuard,
+		requireEmailVerified: conf.GetBool("security.account.require_email_verified"),
+		Service:              service,
 	}
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo             repository.UserRepository
+	roleRepo             repository.RoleRepository
+	tokenService         TokenService
+	accountService       AccountService
+	mfaService           MfaService
+	sessionService       SessionService
+	avatarService        AvatarService
+	loginGuard           *lockout.Guard
+	requireEmailVerified bool
 	*Service
 }
 
 func (s *userService) Register(ctx context.Context, req *v1.RegisterRequest) error {
+	req.Email = model.NormalizeEmail(req.Email)
 	// check username
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
@@ -66,25 +115,230 @@ func (s *userService) Register(ctx context.Context, req *v1.RegisterRequest) err
 		}
 		return nil
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	s.sendVerifyEmail(ctx, user)
+	return nil
+}
+
+func (s *userService) Login(ctx context.Context, req *v1.LoginRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	return s.login(ctx, req, client, "")
 }
 
-func (s *userService) Login(ctx context.Context, req *v1.LoginRequest) (string, error) {
+// login guestId 不为空时，登录成功后把该访客合并到登录的账号；需要二次验证时由 LoginMfa 在验证通过后合并
+func (s *userService) login(ctx context.Context, req *v1.LoginRequest, client *model.ClientInfo, guestId string) (*v1.LoginResponseData, error) {
+	entry := &model.LoginLog{
+		Email:     req.Email,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		LoginType: model.LoginTypeEmail,
+	}
+	lockedFor, err := s.loginGuard.Check(ctx, req.Email, client.IP)
+	if err != nil {
+		return nil, err
+	}
+	if lockedFor > 0 {
+		s.logger.WithContext(ctx).Warn("login locked",
+			zap.String("email", req.Email),
+			zap.String("ip", client.IP),
+			zap.Duration("locked_for", lockedFor),
+		)
+		entry.Result = model.LoginResultLocked
+		s.sessionService.RecordLogin(ctx, entry)
+		return nil, v1.ErrUnauthorized
+	}
+
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
-	if err != nil || user == nil {
-		return "", v1.ErrUnauthorized
+	if err != nil {
+		return nil, err
+	}
+	// 邮箱不存在时也做一次 bcrypt 比较，避免通过响应时间判断邮箱是否注册
+	hashedPassword := dummyPasswordHash
+	if user != nil {
+		hashedPassword = []byte(user.Password)
+		entry.UserId = user.UserId
+	}
+	if err = bcrypt.CompareHashAndPassword(hashedPassword, []byte(req.Password)); err != nil || user == nil {
+		entry.Result = model.LoginResultFailed
+		s.sessionService.RecordLogin(ctx, entry)
+		if err = s.loginGuard.Fail(ctx, req.Email, client.IP); err != nil {
+			return nil, err
+		}
+		return nil, v1.ErrUnauthorized
+	}
+	if !user.Active() {
+		entry.Result = model.LoginResultDisabled
+		s.sessionService.RecordLogin(ctx, entry)
+		return nil, v1.ErrUserDisabled
+	}
+	if s.requireEmailVerified && user.EmailVerifyTime == 0 {
+		entry.Result = model.LoginResultEmailNotVerified
+		s.sessionService.RecordLogin(ctx, entry)
+		return nil, v1.ErrEmailNotVerified
+	}
+
+	// 开启了二次验证时先返回 mfaToken，校验通过后再签发正式 token
+	challenge, err := s.mfaService.Challenge(ctx, user, model.LoginTypeEmail, guestId)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
+	}
+	// 需要二次验证时由 LoginMfa 在验证通过后清除失败记录
+	if err = s.loginGuard.Succeed(ctx, req.Email); err != nil {
+		return nil, err
+	}
+	if err = mergeGuest(ctx, s.userRepo, guestId, user); err != nil {
+		return nil, err
+	}
+	return s.tokenService.IssueTokens(ctx, user, client, model.LoginTypeEmail)
+}
+
+func (s *userService) GuestLogin(ctx context.Context, req *v1.GuestLoginRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	user, err := s.userRepo.GetGuestByUdid(ctx, req.Udid)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		if user, err = s.createGuest(ctx, req.Udid, client); err != nil {
+			return nil, err
+		}
+	}
+	if !user.Active() {
+		s.sessionService.RecordLogin(ctx, &model.LoginLog{
+			UserId:    user.UserId,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+			LoginType: model.LoginTypeGuest,
+			Result:    model.LoginResultDisabled,
+		})
+		return nil, v1.ErrUserDisabled
+	}
+	return s.tokenService.IssueTokens(ctx, user, client, model.LoginTypeGuest)
+}
+
+func (s *userService) createGuest(ctx context.Context, udid string, client *model.ClientInfo) (*model.User, error) {
+	userId, err := s.sid.GenString()
+	if err != nil {
+		return nil, err
+	}
+	// 访客没有密码，升级时再设置
+	password, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.User{
+		UserId:       userId,
+		Password:     string(hashedPassword),
+		Name:         "guest",
+		Udid:         udid,
+		UserType:     model.UserTypeGuest,
+		Status:       model.UserStatusNormal,
+		RegisterIP:   client.IP,
+		RegisterTime: int(time.Now().Unix()),
+		RegisterType: model.LoginTypeGuest,
+	}
+	if err = s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *userService) UpgradeGuest(ctx context.Context, guestId string, req *v1.UpgradeGuestRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	guest, err := s.userRepo.GetByID(ctx, guestId)
+	if err != nil {
+		return nil, err
+	}
+	if guest.UserType != model.UserTypeGuest {
+		return nil, v1.ErrBadRequest
+	}
+	req.Email = model.NormalizeEmail(req.Email)
+	existing, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	if existing != nil {
+		// 邮箱已注册，按正常登录校验密码（锁定、禁用、邮箱验证、二次验证），全部通过后才把访客合并到该账号
+		return s.login(ctx, &v1.LoginRequest{Email: req.Email, Password: req.Password}, client, guestId)
+	}
+
+	// 邮箱未注册，访客直接转为注册用户，UserId 不变
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	guest.Email = req.Email
+	guest.Password = string(hashedPassword)
+	guest.UserType = model.UserTypeRegistered
+	if err = s.userRepo.Update(ctx, guest); err != nil {
+		return nil, err
+	}
+	if err = s.tokenService.RevokeUserTokens(ctx, guestId); err != nil {
+		return nil, err
+	}
+	s.sendVerifyEmail(ctx, guest)
+	if s.requireEmailVerified {
+		return nil, v1.ErrEmailNotVerified
+	}
+	return s.tokenService.IssueTokens(ctx, guest, client, model.LoginTypeEmail)
+}
+
+// mergeGuest 把访客合并到 target，guestId 为空时不处理
+// 访客已被其他请求合并或已升级时跳过，不影响本次登录
+func mergeGuest(ctx context.Context, userRepo repository.UserRepository, guestId string, target *model.User) error {
+	if guestId == "" {
+		return nil
+	}
+	guest, err := userRepo.GetByID(ctx, guestId)
+	if errors.Is(err, v1.ErrNotFound) {
+		return nil
 	}
-	token, err := s.jwt.GenToken(user, time.Now().Add(time.Hour*24*90))
 	if err != nil {
-		return "", err
+		return err
+	}
+	if guest.UserType != model.UserTypeGuest {
+		return nil
 	}
+	mergeGuestProfile(target, guest)
+	return userRepo.MergeGuest(ctx, guestId, target)
+}
+
+// mergeGuestProfile 目标账号没有填写的资料用访客的补上
+func mergeGuestProfile(target *model.User, guest *model.User) {
+	if target.Image == "" {
+		target.Image, target.AvatarKeys = guest.Image, guest.AvatarKeys
+	}
+	if target.Gender == 0 {
+		target.Gender = guest.Gender
+	}
+	if target.CountryID == 0 {
+		target.CountryID = guest.CountryID
+	}
+	if target.Udid == "" {
+		target.Udid = guest.Udid
+	}
+}
 
-	return token, nil
+func (s *userService) UnlockUser(ctx context.Context, req *v1.UnlockUserRequest) error {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return v1.ErrNotFound
+	}
+	// 只能解锁数据权限范围内的用户
+	if _, err = s.userRepo.GetScopedByID(ctx, user.UserId); err != nil {
+		return err
+	}
+	return s.loginGuard.Unlock(ctx, req.Email)
 }
 
 func (s *userService) GetProfile(ctx context.Context, userId string) (*v1.GetProfileResponseData, error) {
@@ -94,27 +348,22 @@ func (s *userService) GetProfile(ctx context.Context, userId string) (*v1.GetPro
 	}
 
 	return &v1.GetProfileResponseData{
-		UserId: user.UserId,
-		Name:   user.Name,
-		Email:  user.Email,
-		Image:  user.Image,
+		UserId:  user.UserId,
+		Name:    user.Name,
+		Email:   user.Email,
+		Image:   user.Image,
+		Avatars: s.avatarService.AvatarURLs(user.AvatarKeys),
 	}, nil
 }
 
-func (s *userService) GetUserList(ctx context.Context) (*v1.GetUserListResponseData, error) {
-	user, err := s.userRepo.GetUserList(ctx)
+func (s *userService) GetUserList(ctx context.Context, req *v1.GetUserListRequest) (*v1.GetUserListResponseData, error) {
+	users, count, err := s.userRepo.GetUserList(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	// count, err1 := s.userRepo.GetUserCount(ctx)
-	// if err1 != nil {
-	// 	return nil, err1
-	// }
-
-	return &v1.GetUserListResponseData{
-		List: *user,
-		// Total: count,
-	}, nil
+	data := &v1.GetUserListResponseData{List: users}
+	data.Total = count
+	return data, nil
 }
 
 func (s *userService) UpdateProfile(ctx context.Context, userId string, req *v1.UpdateProfileRequest) error {
@@ -123,40 +372,108 @@ func (s *userService) UpdateProfile(ctx context.Context, userId string, req *v1.
 		return err
 	}
 
-	if req.Email != "" {
-		user.Email = req.Email
+	emailChanged, err := s.changeEmail(ctx, user, req.Email)
+	if err != nil {
+		return err
 	}
 	if req.Name != "" {
 		user.Name = req.Name
 	}
-	user.Image = req.Image
+	oldAvatar, err := s.setAvatar(ctx, user, req.Image)
+	if err != nil {
+		return err
+	}
 
 	if err = s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
+	s.avatarService.DeleteAvatar(ctx, oldAvatar)
+	if emailChanged {
+		s.sendVerifyEmail(ctx, user)
+	}
 
 	return nil
 }
 
-func (s *userService) UpdateUser(ctx context.Context, req *v1.UpdateUserRequest) error {
-	user, err := s.userRepo.GetByID(ctx, req.UserId)
+// changeEmail 新邮箱不能被其他用户占用，否则可以借 OAuth 按邮箱关联接管对方账号
+// 修改后需要重新验证，返回邮箱是否发生变化
+func (s *userService) changeEmail(ctx context.Context, user *model.User, email string) (bool, error) {
+	email = model.NormalizeEmail(email)
+	if email == "" || email == model.NormalizeEmail(user.Email) {
+		return false, nil
+	}
+	existing, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil && existing.UserId != user.UserId {
+		return false, v1.ErrEmailAlreadyUse
+	}
+	user.Email = email
+	user.EmailVerifyTime = 0
+	return true, nil
+}
+
+// sendVerifyEmail 验证邮件发送失败不影响当前操作，用户可以重新发送
+func (s *userService) sendVerifyEmail(ctx context.Context, user *model.User) {
+	if err := s.accountService.SendVerifyEmail(ctx, user); err != nil {
+		s.logger.WithContext(ctx).Error("accountService.SendVerifyEmail error", zap.Error(err))
+	}
+}
+
+// setAvatar 头像地址变化时处理新上传的图片，返回需要删除的旧头像
+// 客户端原样提交当前的地址表示不修改，提交空字符串表示清除
+func (s *userService) setAvatar(ctx context.Context, user *model.User, image string) (model.AvatarKeys, error) {
+	if image == user.Image {
+		return nil, nil
+	}
+	oldAvatar := user.AvatarKeys
+	if image == "" {
+		user.Image, user.AvatarKeys = "", nil
+		return oldAvatar, nil
+	}
+	keys, err := s.avatarService.ProcessAvatar(ctx, user.UserId, image)
+	if err != nil {
+		return nil, err
+	}
+	user.Image, user.AvatarKeys = s.avatarService.AvatarURL(keys), keys
+	return oldAvatar, nil
+}
+
+func (s *userService) UpdateUser(ctx context.Context, operatorId string, req *v1.UpdateUserRequest) error {
+	user, err := s.userRepo.GetScopedByID(ctx, req.UserId)
 	if err != nil {
 		return err
 	}
 
-	var roles []model.Role
-	for _, id := range req.RoleIds {
-		roles = append(roles, model.Role{Model: gorm.Model{ID: uint(id)}})
+	roleIds := slice.Unique(req.RoleIds)
+	roles, err := s.roleRepo.GetRolesByIds(ctx, roleIds)
+	if err != nil {
+		return err
+	}
+	if len(roles) != len(roleIds) {
+		return v1.ErrBadRequest
+	}
+	// 新增和收回的角色都要在操作人可分配的范围内，不能借此降级更高权限的管理员
+	if err = s.checkAssignableRoles(operatorId, changedRoleSids(user.Roles, roles)); err != nil {
+		return err
 	}
 
-	if req.Email != "" {
-		user.Email = req.Email
+	emailChanged, err := s.changeEmail(ctx, user, req.Email)
+	if err != nil {
+		return err
 	}
 	if req.Name != "" {
 		user.Name = req.Name
 	}
+	var oldAvatar model.AvatarKeys
 	if req.Image != "" {
-		user.Image = req.Image
+		if oldAvatar, err = s.setAvatar(ctx, user, req.Image); err != nil {
+			return err
+		}
+	}
+	if req.DeptId != nil {
+		user.DeptId = *req.DeptId
 	}
 	err = s.DB(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Model(&user).Association("Roles").Replace(&roles); err != nil {
@@ -168,5 +485,200 @@ func (s *userService) UpdateUser(ctx context.Context, req *v1.UpdateUserRequest)
 		// 4. 返回 nil 提交事务
 		return nil
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	// 同步 Casbin g 规则，角色变更立即生效；放在事务提交之后，避免 SQLite 下与事务互相锁住
+	if err = s.syncUserRoles(user.UserId, roleSids(roles)); err != nil {
+		return err
+	}
+	s.avatarService.DeleteAvatar(ctx, oldAvatar)
+	if emailChanged {
+		s.sendVerifyEmail(ctx, user)
+	}
+	return nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, operatorId string, req *v1.CreateUserRequest) (*v1.CreateUserResponseData, error) {
+	req.Email = model.NormalizeEmail(req.Email)
+	existing, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, v1.ErrEmailAlreadyUse
+	}
+	roleIds := slice.Unique(req.RoleIds)
+	roles, err := s.roleRepo.GetRolesByIds(ctx, roleIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(roleIds) {
+		return nil, v1.ErrBadRequest
+	}
+	if err = s.checkAssignableRoles(operatorId, roleSids(roles)); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := s.sid.GenString()
+	if err != nil {
+		return nil, err
+	}
+	name := req.Name
+	if name == "" {
+		name = "new user"
+	}
+	now := int(time.Now().Unix())
+	user := &model.User{
+		UserId:          userId,
+		Email:           req.Email,
+		Password:        string(hashedPassword),
+		Name:            name,
+		UserType:        model.UserTypeRegistered,
+		Status:          model.UserStatusNormal,
+		RegisterTime:    now,
+		RegisterType:    model.LoginTypeEmail,
+		EmailVerifyTime: now, // 邮箱由管理员填写，不再发送验证邮件
+		DeptId:          req.DeptId,
+		CreatedBy:       operatorId,
+		Roles:           roles,
+	}
+	if err = s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	// Casbin 写入放在用户创建之后，避免 SQLite 下与事务互相锁住
+	if err = s.syncUserRoles(userId, roleSids(roles)); err != nil {
+		return nil, err
+	}
+	return &v1.CreateUserResponseData{UserId: userId}, nil
+}
+
+func (s *userService) DisableUser(ctx context.Context, operatorId string, userId string) error {
+	if err := checkManageable(operatorId, userId); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetScopedByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Status == model.UserStatusDisabled {
+		return nil
+	}
+	if err = s.userRepo.UpdateStatus(ctx, userId, model.UserStatusDisabled); err != nil {
+		return err
+	}
+	// 状态变化后 StrictAuth 已经会拒绝旧 token，这里再吊销会话，启用后需要重新登录
+	return s.tokenService.RevokeUserTokens(ctx, userId)
+}
+
+func (s *userService) EnableUser(ctx context.Context, userId string) error {
+	user, err := s.userRepo.GetScopedByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Status != model.UserStatusDisabled {
+		return nil
+	}
+	return s.userRepo.UpdateStatus(ctx, userId, model.UserStatusNormal)
+}
+
+func (s *userService) DeactivateUser(ctx context.Context, operatorId string, userId string) error {
+	if err := checkManageable(operatorId, userId); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.GetScopedByID(ctx, userId); err != nil {
+		return err
+	}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateStatus(ctx, userId, model.UserStatusDeactivated); err != nil {
+			return err
+		}
+		return s.userRepo.Delete(ctx, userId)
+	})
+	if err != nil {
+		return err
+	}
+	if err = s.tokenService.RevokeUserTokens(ctx, userId); err != nil {
+		return err
+	}
+	// 角色关联保留用于恢复，Casbin 中只保留未删除用户的 g 规则
+	return s.syncUserRoles(userId, nil)
+}
+
+func (s *userService) GetDeletedUserList(ctx context.Context, req *v1.GetDeletedUserListRequest) (*v1.GetUserListResponseData, error) {
+	users, count, err := s.userRepo.GetDeletedUserList(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data := &v1.GetUserListResponseData{List: users}
+	data.Total = count
+	return data, nil
+}
+
+func (s *userService) RestoreUser(ctx context.Context, operatorId string, userId string) error {
+	if err := checkManageable(operatorId, userId); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetDeletedByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	// 注销期间邮箱可能已被重新注册；空邮箱会匹配到访客，不需要检查
+	if user.Email != "" {
+		existing, err := s.userRepo.GetByEmail(ctx, user.Email)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return v1.ErrEmailAlreadyUse
+		}
+	}
+	if err = s.userRepo.Restore(ctx, userId); err != nil {
+		return err
+	}
+	return s.syncUserRoles(userId, roleSids(user.Roles))
+}
+
+func (s *userService) PurgeUser(ctx context.Context, operatorId string, userId string) error {
+	if err := checkManageable(operatorId, userId); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetDeletedByID(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if err = s.userRepo.Purge(ctx, userId); err != nil {
+		return err
+	}
+	s.avatarService.DeleteAvatar(ctx, user.AvatarKeys)
+	return s.syncUserRoles(userId, nil)
+}
+
+// changedRoleSids 修改前后角色的差集，即新增和收回的角色
+func changedRoleSids(before []model.Role, after []model.Role) []string {
+	a, b := roleSids(before), roleSids(after)
+	var changed []string
+	for _, sid := range a {
+		if !slice.Contain(b, sid) {
+			changed = append(changed, sid)
+		}
+	}
+	for _, sid := range b {
+		if !slice.Contain(a, sid) {
+			changed = append(changed, sid)
+		}
+	}
+	return changed
+}
+
+// checkManageable 管理员不能禁用、注销、恢复、删除自己和超管
+func checkManageable(operatorId string, userId string) error {
+	if userId == operatorId || userId == model.AdminUserID {
+		return v1.ErrForbidden
+	}
+	return nil
 }
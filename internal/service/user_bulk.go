@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"io"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	UserFileCSV  = "csv"
+	UserFileXLSX = "xlsx"
+
+	// maxImportRows 单个文件最多导入的用户数
+	maxImportRows   = 5000
+	exportBatchSize = 500
+)
+
+// UserBulkService 以 CSV、XLSX 批量导入、导出用户
+type UserBulkService interface {
+	// ImportUsers 先校验全部行，有错误时返回 ErrImportFileInvalid 和逐行的错误，否则在一个事务中创建全部用户
+	ImportUsers(ctx context.Context, operatorId string, format string, r io.Reader, req *v1.ImportUsersRequest) (*v1.ImportUsersData, error)
+	// ExportUsers 按列表的过滤条件分批写出用户，不会一次加载全部数据
+	ExportUsers(ctx context.Context, req *v1.ExportUsersRequest, w io.Writer) error
+}
+
+func NewUserBulkService(
+	service *Service,
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	accountService AccountService,
+) UserBulkService {
+	return &userBulkService{
+		Service:        service,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		accountService: accountService,
+	}
+}
+
+type userBulkService struct {
+	*Service
+	userRepo       repository.UserRepository
+	roleRepo       repository.RoleRepository
+	accountService AccountService
+}
+
+// importRow 通过校验、待创建的一行
+type importRow struct {
+	user  *model.User
+	roles []string
+}
+
+func (s *userBulkService) ImportUsers(ctx context.Context, operatorId string, format string, r io.Reader, req *v1.ImportUsersRequest) (*v1.ImportUsersData, error) {
+	data := &v1.ImportUsersData{Errors: []v1.ImportUserError{}}
+	fileError := func(reason string) (*v1.ImportUsersData, error) {
+		data.Errors = append(data.Errors, v1.ImportUserError{Reason: reason})
+		return data, v1.ErrImportFileInvalid
+	}
+
+	records, err := readUserFile(format, r)
+	if err != nil {
+		return fileError(err.Error())
+	}
+	if len(records) == 0 {
+		return fileError("the file is empty")
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["email"]; !ok {
+		return fileError("the header row has no email column")
+	}
+	cell := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	// 第一遍：逐行检查格式，收集邮箱和角色，再一次性到数据库中核对
+	var (
+		rows    []importRow
+		rowNums []int
+		seen    = make(map[string]int)
+		sids    = make(map[string]bool)
+	)
+	rowError := func(row int, email string, reason string) {
+		data.Errors = append(data.Errors, v1.ImportUserError{Row: row, Email: email, Reason: reason})
+	}
+	for i, record := range records[1:] {
+		row := i + 2
+		if isBlankRecord(record) {
+			continue
+		}
+		data.Total++
+		if data.Total > maxImportRows {
+			return fileError(fmt.Sprintf("the file has more than %d rows", maxImportRows))
+		}
+
+		email := model.NormalizeEmail(cell(record, "email"))
+		name := cell(record, "name")
+		switch {
+		case email == "":
+			rowError(row, email, "email is required")
+			continue
+		case !validEmail(email):
+			rowError(row, email, "email is invalid")
+			continue
+		case len(name) > 255:
+			rowError(row, email, "name is longer than 255 characters")
+			continue
+		}
+		if first, ok := seen[email]; ok {
+			rowError(row, email, fmt.Sprintf("email duplicates row %d", first))
+			continue
+		}
+		seen[email] = row
+
+		roles := splitRoles(cell(record, "roles"))
+		for _, sid := range roles {
+			sids[sid] = true
+		}
+		if name == "" {
+			name = "new user"
+		}
+		rows = append(rows, importRow{
+			user:  &model.User{Email: email, Name: name},
+			roles: roles,
+		})
+		rowNums = append(rowNums, row)
+	}
+
+	emails := make([]string, 0, len(rows))
+	for _, row := range rows {
+		emails = append(emails, row.user.Email)
+	}
+	existing, err := s.userRepo.GetExistingEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(existing))
+	for _, email := range existing {
+		used[email] = true
+	}
+	roleList, err := s.roleRepo.GetRolesBySids(ctx, mapKeys(sids))
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]model.Role, len(roleList))
+	for _, role := range roleList {
+		roles[role.Sid] = role
+	}
+	// 与 CreateUser 相同，只能分配操作人自己拥有或继承的角色
+	unassignable, err := s.unassignableRoles(operatorId, mapKeys(sids))
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make([]importRow, 0, len(rows))
+	for i, row := range rows {
+		if used[row.user.Email] {
+			rowError(rowNums[i], row.user.Email, "email is already in use")
+			continue
+		}
+		var unknown, denied []string
+		for _, sid := range row.roles {
+			role, ok := roles[sid]
+			if !ok {
+				unknown = append(unknown, sid)
+				continue
+			}
+			if slices.Contains(unassignable, sid) {
+				denied = append(denied, sid)
+				continue
+			}
+			row.user.Roles = append(row.user.Roles, role)
+		}
+		if len(unknown) > 0 {
+			rowError(rowNums[i], row.user.Email, "unknown roles: "+strings.Join(unknown, ", "))
+			continue
+		}
+		if len(denied) > 0 {
+			rowError(rowNums[i], row.user.Email, "roles not assignable: "+strings.Join(denied, ", "))
+			continue
+		}
+		valid = append(valid, row)
+	}
+	if len(data.Errors) > 0 {
+		sortImportErrors(data.Errors)
+		return data, v1.ErrImportFileInvalid
+	}
+	data.Created = len(valid)
+	if req.DryRun || len(valid) == 0 {
+		return data, nil
+	}
+
+	now := int(time.Now().Unix())
+	for _, row := range valid {
+		userId, err := s.sid.GenString()
+		if err != nil {
+			return nil, err
+		}
+		// 随机密码不会告知任何人，用户通过设置密码的链接或找回密码登录；
+		// 密码本身不可猜测，用最低的 cost 避免几千行导入时逐个 bcrypt 过慢
+		password, err := newOpaqueToken()
+		if err != nil {
+			return nil, err
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			return nil, err
+		}
+		row.user.UserId = userId
+		row.user.Password = string(hashedPassword)
+		row.user.UserType = model.UserTypeRegistered
+		row.user.Status = model.UserStatusNormal
+		row.user.RegisterTime = now
+		row.user.RegisterType = model.LoginTypeEmail
+		row.user.EmailVerifyTime = now
+		row.user.CreatedBy = operatorId
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		for _, row := range valid {
+			if err := s.userRepo.Create(ctx, row.user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	data.Applied = true
+
+	// Casbin 在事务之外一次性写入全部 g 规则
+	var rules [][]string
+	for _, row := range valid {
+		for _, sid := range roleSids(row.user.Roles) {
+			rules = append(rules, []string{model.UserSubject(row.user.UserId), sid})
+		}
+	}
+	if len(rules) > 0 {
+		if _, err = s.Casbin.AddGroupingPolicies(rules); err != nil {
+			return nil, err
+		}
+		if err = s.Casbin.InvalidateCache(); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.SendEmail {
+		for _, row := range valid {
+			// 发送失败只记录日志，管理员可以让用户走找回密码
+			if err = s.accountService.SendSetPasswordEmail(ctx, row.user); err != nil {
+				s.logger.WithContext(ctx).Error("accountService.SendSetPasswordEmail error", zap.String("user_id", row.user.UserId), zap.Error(err))
+				continue
+			}
+			data.EmailsSent++
+		}
+	}
+	return data, nil
+}
+
+// userExportHeader 导出的列，email、name、roles 三列可以直接再导入
+var userExportHeader = []string{"user_id", "email", "name", "roles", "status", "user_type", "dept_id", "registered_at", "last_login_at"}
+
+func (s *userBulkService) ExportUsers(ctx context.Context, req *v1.ExportUsersRequest, w io.Writer) error {
+	writer, err := newUserFileWriter(req.Format, w)
+	if err != nil {
+		return err
+	}
+	if err = s.writeUsers(ctx, req, writer); err != nil {
+		writer.Abort()
+		return err
+	}
+	return writer.Close()
+}
+
+func (s *userBulkService) writeUsers(ctx context.Context, req *v1.ExportUsersRequest, writer userFileWriter) error {
+	if err := writer.Write(userExportHeader); err != nil {
+		return err
+	}
+	return s.userRepo.EachUser(ctx, &req.GetUserListRequest, exportBatchSize, func(users []model.User) error {
+		for _, user := range users {
+			lastLogin := ""
+			if user.LastLoginTime > 0 {
+				lastLogin = time.Unix(int64(user.LastLoginTime), 0).Format(time.RFC3339)
+			}
+			if err := writer.Write([]string{
+				user.UserId,
+				user.Email,
+				user.Name,
+				strings.Join(roleSids(user.Roles), ","),
+				strconv.Itoa(user.Status),
+				strconv.Itoa(user.UserType),
+				strconv.FormatUint(uint64(user.DeptId), 10),
+				user.CreatedAt.Format(time.RFC3339),
+				lastLogin,
+			}); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+}
+
+// readUserFile 读出全部行，XLSX 只读第一个工作表
+func readUserFile(format string, r io.Reader) ([][]string, error) {
+	switch format {
+	case UserFileCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read csv: %w", err)
+		}
+		return records, nil
+	case UserFileXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read xlsx: %w", err)
+		}
+		defer f.Close()
+		records, err := f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("cannot read xlsx: %w", err)
+		}
+		return records, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type userFileWriter interface {
+	Write(record []string) error
+	// Flush 把已写入的行发送给客户端，XLSX 只能在 Close 时整体写出
+	Flush() error
+	// Close 写出剩余内容并释放资源
+	Close() error
+	// Abort 导出中途失败时只释放资源，不再写出
+	Abort()
+}
+
+func newUserFileWriter(format string, w io.Writer) (userFileWriter, error) {
+	switch format {
+	case "", UserFileCSV:
+		// 带 BOM，Excel 打开时才能正确识别 UTF-8
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+		return &csvFileWriter{w: csv.NewWriter(w)}, nil
+	case UserFileXLSX:
+		f := excelize.NewFile()
+		sw, err := f.NewStreamWriter(f.GetSheetName(0))
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxFileWriter{f: f, sw: sw, out: w}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type csvFileWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvFileWriter) Write(record []string) error {
+	cells := make([]string, len(record))
+	for i, v := range record {
+		cells[i] = escapeFormula(v)
+	}
+	return c.w.Write(cells)
+}
+
+func (c *csvFileWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvFileWriter) Close() error {
+	return c.Flush()
+}
+
+func (c *csvFileWriter) Abort() {}
+
+// xlsxFileWriter 行由 StreamWriter 逐行编码，超过 excelize.StreamChunkSize 后转存到临时文件，内存占用与导出行数无关
+type xlsxFileWriter struct {
+	f   *excelize.File
+	sw  *excelize.StreamWriter
+	out io.Writer
+	row int
+}
+
+func (x *xlsxFileWriter) Write(record []string) error {
+	x.row++
+	cells := make([]interface{}, len(record))
+	for i, v := range record {
+		cells[i] = v
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sw.SetRow(cell, cells)
+}
+
+func (x *xlsxFileWriter) Flush() error {
+	return nil
+}
+
+func (x *xlsxFileWriter) Close() error {
+	defer x.Abort()
+	if err := x.sw.Flush(); err != nil {
+		return err
+	}
+	return x.f.Write(x.out)
+}
+
+// Abort 删除 StreamWriter 的临时文件
+func (x *xlsxFileWriter) Abort() {
+	_ = x.f.Close()
+}
+
+// escapeFormula 以 = + - @ 开头的单元格会被表格软件当作公式执行，前面加单引号
+func escapeFormula(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// splitRoles 角色 Sid 之间可以用逗号、分号或空格分隔
+func splitRoles(v string) []string {
+	fields := strings.FieldsFunc(v, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '|'
+	})
+	roles := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, sid := range fields {
+		if !seen[sid] {
+			seen[sid] = true
+			roles = append(roles, sid)
+		}
+	}
+	return roles
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func mapKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func sortImportErrors(errs []v1.ImportUserError) {
+	slices.SortStableFunc(errs, func(a, b v1.ImportUserError) int {
+		return a.Row - b.Row
+	})
+}
@@ -0,0 +1,44 @@
+package task
+
+import (
+	"context"
+	"go-nunu/internal/service"
+
+	"go.uber.org/zap"
+)
+
+type PolicyTask interface {
+	// ReconcilePolicies 检查 casbin_rule 是否与关系表一致，apply 为 false 时只记录差异
+	ReconcilePolicies(ctx context.Context, apply bool) error
+}
+
+func NewPolicyTask(
+	task *Task,
+	policyService service.PolicyService,
+) PolicyTask {
+	return &policyTask{
+		policyService: policyService,
+		Task:          task,
+	}
+}
+
+type policyTask struct {
+	policyService service.PolicyService
+	*Task
+}
+
+func (t policyTask) ReconcilePolicies(ctx context.Context, apply bool) error {
+	if !apply {
+		diff, err := t.policyService.DiffPolicies(ctx)
+		if err != nil {
+			return err
+		}
+		if len(diff.Missing) > 0 || len(diff.Extra) > 0 {
+			t.logger.Warn("casbin policy drift detected",
+				zap.Any("missing", diff.Missing), zap.Any("extra", diff.Extra))
+		}
+		return nil
+	}
+	_, err := t.policyService.ReconcilePolicies(ctx)
+	return err
+}
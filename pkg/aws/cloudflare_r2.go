@@ -1,14 +1,18 @@
 package aws
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/google/uuid"
 	"github.com/spf13/viper"
 )
@@ -19,6 +23,9 @@ const (
 	UploadJobConfigIcon       = 3 // 职业配置图标
 )
 
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("object not found")
+
 var (
 	r2Instance *CloudflareR2
 	r2Once     sync.Once
@@ -72,7 +79,9 @@ type CloudflareR2 struct {
 }
 
 // 生成上传用的预签名url
-func (r *CloudflareR2) UploadPresignedUrl(fileExt string, uploadScene int) (string, string, error) {
+// fileSize 大于 0 时签名包含 Content-Length，上传的文件大小必须与之一致
+// userId 为上传者，个人信息图片放在 user_profile/<userId>/ 下，使用时据此校验归属
+func (r *CloudflareR2) UploadPresignedUrl(userId string, fileExt string, uploadScene int, fileSize int64) (string, string, error) {
 	// 文件扩展名并转换为小写
 	fileExt = strings.ToLower(fileExt)
 	// 根据扩展名设置 Content-Type
@@ -93,7 +102,10 @@ func (r *CloudflareR2) UploadPresignedUrl(fileExt string, uploadScene int) (stri
 
 	switch uploadScene {
 	case UploadSceneUserProfile:
-		objectKey = "user_profile/" + objectKey
+		if userId == "" || strings.ContainsAny(userId, "/.") {
+			return "", "", fmt.Errorf("无效的上传用户: %q", userId)
+		}
+		objectKey = "user_profile/" + userId + "/" + objectKey
 	case UploadSceneActivityBanner:
 		objectKey = "activity_banner/" + objectKey
 	case UploadJobConfigIcon:
@@ -102,12 +114,16 @@ func (r *CloudflareR2) UploadPresignedUrl(fileExt string, uploadScene int) (stri
 		return "", "", fmt.Errorf("不允许的上传场景: %d", uploadScene)
 	}
 
-	// 通过预签名客户端发起请求，获取上传文件的预签名Url的信息
-	req, err := r.r2PresignClient.PresignPutObject(context.TODO(), &s3.PutObjectInput{
+	input := &s3.PutObjectInput{
 		Bucket:      aws.String(r.bucketName),
 		Key:         aws.String(objectKey),
 		ContentType: aws.String(contentType),
-	}, func(opts *s3.PresignOptions) {
+	}
+	if fileSize > 0 {
+		input.ContentLength = aws.Int64(fileSize)
+	}
+	// 通过预签名客户端发起请求，获取上传文件的预签名Url的信息
+	req, err := r.r2PresignClient.PresignPutObject(context.TODO(), input, func(opts *s3.PresignOptions) {
 		// 链接的有效时间设置成5分钟
 		opts.Expires = 5 * time.Minute
 	})
@@ -118,3 +134,70 @@ func (r *CloudflareR2) UploadPresignedUrl(fileExt string, uploadScene int) (stri
 	// 返回预签名url
 	return req.URL, r.fileUrl + objectKey, nil
 }
+
+// HeadObject 返回对象大小，对象不存在时返回 ErrObjectNotFound
+func (r *CloudflareR2) HeadObject(ctx context.Context, key string) (int64, error) {
+	output, err := r.r2Client.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(r.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return 0, ErrObjectNotFound
+		}
+		return 0, err
+	}
+	return aws.ToInt64(output.ContentLength), nil
+}
+
+// GetObject 读取对象内容，调用方负责关闭
+func (r *CloudflareR2) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := r.r2Client.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (r *CloudflareR2) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	_, err := r.r2Client.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(r.bucketName),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func (r *CloudflareR2) DeleteObjects(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	objects := make([]types.ObjectIdentifier, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+	}
+	_, err := r.r2Client.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(r.bucketName),
+		Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+	})
+	return err
+}
+
+// ObjectURL 对象的公开访问地址
+func (r *CloudflareR2) ObjectURL(key string) string {
+	return r.fileUrl + key
+}
+
+// ObjectKey 从 ObjectURL 生成的地址中取出 key，不带前缀时按 key 处理
+func (r *CloudflareR2) ObjectKey(url string) string {
+	return strings.TrimPrefix(url, r.fileUrl)
+}
@@ -7,12 +7,14 @@ import (
 
 	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"gorm.io/gorm"
 )
 
 // NewEnforcer 初始化 Casbin Enforcer
-func NewEnforcer(db *gorm.DB) *casbin.CachedEnforcer {
+// watcher 不为空时，本实例修改策略后通知其他实例，收到其他实例的通知后重新加载策略并清空缓存
+func NewEnforcer(db *gorm.DB, watcher persist.Watcher) *casbin.SyncedCachedEnforcer {
 	a, err := gormadapter.NewAdapterByDB(db)
 	if err != nil {
 		fmt.Println("创建 Casbin 适配器失败:", err)
@@ -24,7 +26,7 @@ func NewEnforcer(db *gorm.DB) *casbin.CachedEnforcer {
 		fmt.Println("创建 NewModelFromFile 失败:", err)
 		return nil
 	}
-	enforcer, err := casbin.NewCachedEnforcer(m, a)
+	enforcer, err := casbin.NewSyncedCachedEnforcer(m, a)
 	if err != nil {
 		fmt.Println("创建 Casbin Enforcer 失败:", err)
 		return nil
@@ -37,5 +39,26 @@ func NewEnforcer(db *gorm.DB) *casbin.CachedEnforcer {
 		fmt.Println("加载 Casbin 策略失败:", err)
 		return nil
 	}
+	if watcher != nil {
+		if err := enforcer.SetWatcher(watcher); err != nil {
+			fmt.Println("设置 Casbin Watcher 失败:", err)
+			return nil
+		}
+		// SetWatcher 默认的回调调用的是内嵌 Enforcer 的 LoadPolicy，不加锁也不会清空缓存
+		// 回调在 watcher 的 goroutine 中执行，与请求中的 Enforce 并发，因此使用 SyncedCachedEnforcer
+		if err := watcher.SetUpdateCallback(func(string) {
+			if err := enforcer.LoadPolicy(); err != nil {
+				fmt.Println("重新加载 Casbin 策略失败:", err)
+				return
+			}
+			// LoadPolicy 先清缓存再替换策略，期间按旧策略算出的结果可能又被缓存
+			if err := enforcer.InvalidateCache(); err != nil {
+				fmt.Println("清空 Casbin 缓存失败:", err)
+			}
+		}); err != nil {
+			fmt.Println("设置 Casbin Watcher 失败:", err)
+			return nil
+		}
+	}
 	return enforcer
 }
@@ -0,0 +1,139 @@
+package casbinPkg
+
+import (
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// policyVersion 策略版本号，只有一行，每次策略变化加一
+type policyVersion struct {
+	ID        uint  `gorm:"primarykey"`
+	Version   int64 `gorm:"not null;default:0"`
+	UpdatedAt time.Time
+}
+
+func (policyVersion) TableName() string {
+	return "casbin_policy_version"
+}
+
+// DBWatcher 没有 Redis 时的兜底方案：修改策略时递增版本号，其他实例定时轮询，版本变化后重新加载
+type DBWatcher struct {
+	db       *gorm.DB
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{} // 轮询协程退出后关闭
+	once     sync.Once
+
+	mu       sync.Mutex
+	version  int64 // 本实例已经加载的版本
+	callback func(string)
+}
+
+func NewDBWatcher(db *gorm.DB, interval time.Duration) (*DBWatcher, error) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if err := db.AutoMigrate(&policyVersion{}); err != nil {
+		return nil, err
+	}
+	row := policyVersion{ID: 1}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return nil, err
+	}
+	version, err := readPolicyVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	w := &DBWatcher{
+		// 轮询很频繁，不打印 SQL
+		db:       db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Silent)}),
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		version:  version,
+	}
+	go w.poll()
+	return w, nil
+}
+
+func (w *DBWatcher) poll() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			// stop 和 ticker 同时就绪时 select 随机选择，关闭后不再检查
+			select {
+			case <-w.stop:
+				return
+			default:
+			}
+			w.check()
+		}
+	}
+}
+
+func (w *DBWatcher) check() {
+	version, err := readPolicyVersion(w.db)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	changed := version != w.version
+	w.version = version
+	callback := w.callback
+	w.mu.Unlock()
+	if changed && callback != nil {
+		callback("")
+	}
+}
+
+func (w *DBWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+// Update 递增版本号，并记下新版本，避免本实例重复加载自己的修改
+func (w *DBWatcher) Update() error {
+	if err := w.db.Model(&policyVersion{}).Where("id = ?", 1).
+		UpdateColumns(map[string]interface{}{
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+	version, err := readPolicyVersion(w.db)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	// 两次轮询之间其他实例也修改过时，版本会跳过不止一次，留给下一次轮询处理
+	if version == w.version+1 {
+		w.version = version
+	}
+	w.mu.Unlock()
+	return nil
+}
+
+// Close 停止轮询，等待正在执行的检查结束，返回后不会再调用回调
+func (w *DBWatcher) Close() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func readPolicyVersion(db *gorm.DB) (int64, error) {
+	var row policyVersion
+	if err := db.Select("version").Where("id = ?", 1).Take(&row).Error; err != nil {
+		return 0, err
+	}
+	return row.Version, nil
+}
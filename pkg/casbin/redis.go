@@ -0,0 +1,75 @@
+package casbinPkg
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisWatcher 通过 Redis pub/sub 通知其他实例重新加载策略
+// 消息内容为发送方的实例 ID，收到自己发出的消息时忽略
+type RedisWatcher struct {
+	rdb      *redis.Client
+	channel  string
+	instance string
+	pubsub   *redis.PubSub
+
+	mu       sync.RWMutex
+	callback func(string)
+}
+
+func NewRedisWatcher(rdb *redis.Client, channel string) (*RedisWatcher, error) {
+	ctx := context.Background()
+	pubsub := rdb.Subscribe(ctx, channel)
+	// 等待订阅确认，保证返回后不会漏掉其他实例的通知
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	w := &RedisWatcher{
+		rdb:      rdb,
+		channel:  channel,
+		instance: newInstanceId(),
+		pubsub:   pubsub,
+	}
+	go w.listen()
+	return w, nil
+}
+
+func (w *RedisWatcher) listen() {
+	for msg := range w.pubsub.Channel() {
+		if msg.Payload == w.instance {
+			continue
+		}
+		w.mu.RLock()
+		callback := w.callback
+		w.mu.RUnlock()
+		if callback != nil {
+			callback(msg.Payload)
+		}
+	}
+}
+
+func (w *RedisWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+func (w *RedisWatcher) Update() error {
+	return w.rdb.Publish(context.Background(), w.channel, w.instance).Err()
+}
+
+func (w *RedisWatcher) Close() {
+	_ = w.pubsub.Close()
+}
+
+func newInstanceId() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
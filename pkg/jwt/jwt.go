@@ -1,8 +1,11 @@
 package jwt
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"go-nunu/internal/model"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,45 +14,111 @@ import (
 )
 
 type JWT struct {
-	key []byte
+	key    []byte // legacy HS256 secret, only used when no asymmetric keys are configured
+	keys   map[string]*signingKey
+	active *signingKey
+	store  RevocationStore
 }
 
+// ScopeMfaPending 密码校验通过、尚未完成二次验证的临时 token，只能用来换取正式 token
+const ScopeMfaPending = "mfa_pending"
+
+// ScopeApiKey 由 API key 认证的请求，claims 不是来自 token，只在服务端内部使用
+const ScopeApiKey = "api_key"
+
+// ScopeGuest 访客 token，只能访问允许访客的接口，升级为注册用户后重新签发正式 token
+const ScopeGuest = "guest"
+
 type MyCustomClaims struct {
 	UserId string
-	Role   string
+	Roles  []string // 签发时的角色 Sid，仅供展示；鉴权以 Casbin 中的 g 规则为准
+	Scope  string   `json:",omitempty"` // 为空表示正式的 access token
+	// LoginType 仅 mfa_pending token 使用，记录第一步的登录方式，完成二次验证后写入会话
+	LoginType int `json:",omitempty"`
+	// GuestId 仅 mfa_pending token 使用，访客升级到已有账号时记录访客，完成二次验证后再合并
+	GuestId string `json:",omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJwt(conf *viper.Viper) *JWT {
-	return &JWT{key: []byte(conf.GetString("security.jwt.key"))}
+type claimsKey struct{}
+
+// WithClaims 把当前请求的 claims 放入 context，service / repository 通过 ClaimsFromContext 读取
+func WithClaims(ctx context.Context, claims *MyCustomClaims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func ClaimsFromContext(ctx context.Context) (*MyCustomClaims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*MyCustomClaims)
+	return claims, ok && claims != nil
+}
+
+// RevocationStore 记录服务端已吊销的 token ID，StrictAuth 通过它拒绝被吊销的 token
+type RevocationStore interface {
+	IsRevoked(ctx context.Context, claims *MyCustomClaims) (bool, error)
+}
+
+func NewJwt(conf *viper.Viper, store RevocationStore) *JWT {
+	keys, active, err := loadSigningKeys(conf)
+	if err != nil {
+		panic(err)
+	}
+	return &JWT{
+		key:    []byte(conf.GetString("security.jwt.key")),
+		keys:   keys,
+		active: active,
+		store:  store,
+	}
+}
+
+func (j *JWT) GenToken(user *model.User, tokenId string, expiresAt time.Time) (string, error) {
+	return j.GenScopedToken(user, "", tokenId, expiresAt)
 }
 
-func (j *JWT) GenToken(user *model.User, expiresAt time.Time) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, MyCustomClaims{
+// GenScopedToken 签发受限用途的 token，StrictAuth 会拒绝 scope 不被允许的 token
+func (j *JWT) GenScopedToken(user *model.User, scope string, tokenId string, expiresAt time.Time) (string, error) {
+	claims := newClaims(user, tokenId, expiresAt)
+	claims.Scope = scope
+	return j.sign(claims)
+}
+
+// GenMfaPendingToken 签发登录第二步使用的 mfa_pending token
+func (j *JWT) GenMfaPendingToken(user *model.User, loginType int, guestId string, tokenId string, expiresAt time.Time) (string, error) {
+	claims := newClaims(user, tokenId, expiresAt)
+	claims.Scope = ScopeMfaPending
+	claims.LoginType = loginType
+	claims.GuestId = guestId
+	return j.sign(claims)
+}
+
+func newClaims(user *model.User, tokenId string, expiresAt time.Time) MyCustomClaims {
+	roles := make([]string, 0, len(user.Roles))
+	for _, role := range user.Roles {
+		roles = append(roles, role.Sid)
+	}
+	return MyCustomClaims{
 		UserId: user.UserId,
-		Role: func() string {
-			if len(user.Roles) > 0 {
-				return user.Roles[0].Sid
-			}
-			return ""
-		}(), // Assume the first role is the primary one
+		Roles:  roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "",
 			Subject:   "",
-			ID:        "",
+			ID:        tokenId,
 			Audience:  []string{},
 		},
-	})
+	}
+}
 
-	// Sign and get the complete encoded token as a string using the key
-	tokenString, err := token.SignedString(j.key)
-	if err != nil {
-		return "", err
+func (j *JWT) sign(claims MyCustomClaims) (string, error) {
+	if j.active == nil {
+		// Sign and get the complete encoded token as a string using the key
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.key)
 	}
-	return tokenString, nil
+
+	token := jwt.NewWithClaims(j.active.method, claims)
+	token.Header["kid"] = j.active.kid
+	return token.SignedString(j.active.private)
 }
 
 func (j *JWT) ParseToken(tokenString string) (*MyCustomClaims, error) {
@@ -57,9 +126,7 @@ func (j *JWT) ParseToken(tokenString string) (*MyCustomClaims, error) {
 	if strings.TrimSpace(tokenString) == "" {
 		return nil, errors.New("token is empty")
 	}
-	token, err := jwt.ParseWithClaims(tokenString, &MyCustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return j.key, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &MyCustomClaims{}, j.keyFunc)
 	if err != nil {
 		return nil, err
 	}
@@ -69,3 +136,55 @@ func (j *JWT) ParseToken(tokenString string) (*MyCustomClaims, error) {
 		return nil, err
 	}
 }
+
+// keyFunc picks the verification key by the token's kid header.
+// Any configured key verifies, so tokens signed before a rotation stay valid until they expire.
+func (j *JWT) keyFunc(token *jwt.Token) (interface{}, error) {
+	if j.active == nil {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return j.key, nil
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for kid %q", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public verification keys, active key first.
+func (j *JWT) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if j.active == nil {
+		return set
+	}
+	set.Keys = append(set.Keys, j.active.jwk())
+	kids := make([]string, 0, len(j.keys))
+	for kid := range j.keys {
+		if kid != j.active.kid {
+			kids = append(kids, kid)
+		}
+	}
+	sort.Strings(kids)
+	for _, kid := range kids {
+		set.Keys = append(set.Keys, j.keys[kid].jwk())
+	}
+	return set
+}
+
+// IsRevoked reports whether the token has been revoked server-side.
+// Tokens without an ID were issued before revocation existed and cannot be tracked, so they are rejected.
+func (j *JWT) IsRevoked(ctx context.Context, claims *MyCustomClaims) (bool, error) {
+	if claims.ID == "" {
+		return true, nil
+	}
+	if j.store == nil {
+		return false, nil
+	}
+	return j.store.IsRevoked(ctx, claims)
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+)
+
+// KeyConfig 对应 security.jwt.keys 下的一项
+// 只配置公钥的 key 仅用于验签，密钥轮换时把旧 key 留在这里直到旧 token 全部过期
+// 私钥通过 *_env 指定的环境变量或 *_file 指定的文件提供，不要把 PEM 写进提交的配置
+type KeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Alg            string `mapstructure:"alg"` // RS256 or EdDSA
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyEnv  string `mapstructure:"private_key_env"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyEnv   string `mapstructure:"public_key_env"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+}
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey // nil for verify-only keys
+	public  crypto.PublicKey
+}
+
+// JWK is a single public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func loadSigningKeys(conf *viper.Viper) (map[string]*signingKey, *signingKey, error) {
+	var configs []KeyConfig
+	if err := conf.UnmarshalKey("security.jwt.keys", &configs); err != nil {
+		return nil, nil, err
+	}
+	keys := make(map[string]*signingKey, len(configs))
+	for _, c := range configs {
+		key, err := parseKeyConfig(c)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwt key %q: %w", c.Kid, err)
+		}
+		if _, ok := keys[key.kid]; ok {
+			return nil, nil, fmt.Errorf("jwt key %q: duplicate kid", c.Kid)
+		}
+		keys[key.kid] = key
+	}
+	if len(keys) == 0 {
+		return keys, nil, nil
+	}
+
+	activeKid := conf.GetString("security.jwt.active_kid")
+	active, ok := keys[activeKid]
+	if !ok {
+		return nil, nil, fmt.Errorf("jwt active_kid %q is not configured", activeKid)
+	}
+	if active.private == nil {
+		return nil, nil, fmt.Errorf("jwt active key %q has no private key", activeKid)
+	}
+	return keys, active, nil
+}
+
+func parseKeyConfig(c KeyConfig) (*signingKey, error) {
+	if c.Kid == "" {
+		return nil, fmt.Errorf("kid is required")
+	}
+	privatePEM, err := readPEM(c.PrivateKey, c.PrivateKeyEnv, c.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	publicPEM, err := readPEM(c.PublicKey, c.PublicKeyEnv, c.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if privatePEM == nil && publicPEM == nil {
+		return nil, fmt.Errorf("either a private or a public key is required")
+	}
+
+	key := &signingKey{kid: c.Kid}
+	switch c.Alg {
+	case "RS256":
+		key.method = jwt.SigningMethodRS256
+		if privatePEM != nil {
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+			key.private, key.public = private, &private.PublicKey
+		} else if key.public, err = jwt.ParseRSAPublicKeyFromPEM(publicPEM); err != nil {
+			return nil, err
+		}
+	case "EdDSA":
+		key.method = jwt.SigningMethodEdDSA
+		if privatePEM != nil {
+			private, err := jwt.ParseEdPrivateKeyFromPEM(privatePEM)
+			if err != nil {
+				return nil, err
+			}
+			key.private, key.public = private, private.(ed25519.PrivateKey).Public()
+		} else if key.public, err = jwt.ParseEdPublicKeyFromPEM(publicPEM); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported alg %q", c.Alg)
+	}
+	return key, nil
+}
+
+func readPEM(inline string, env string, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if env != "" {
+		// 配置了环境变量却没有设置时报错，避免静默退化为只验签的 key
+		value := os.Getenv(env)
+		if value == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", env)
+		}
+		return []byte(value), nil
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+func (k *signingKey) jwk() JWK {
+	jwk := JWK{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
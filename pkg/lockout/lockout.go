@@ -0,0 +1,121 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Store 保存失败次数和锁定状态，单实例可以用内存，多实例部署需要 Redis
+type Store interface {
+	// Incr 失败次数加一并返回新值，计数在第一次失败后的 window 内有效
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Lock 锁定 key 一段时间
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockedFor 返回剩余的锁定时间，未锁定时返回 0
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Reset 清除失败次数和锁定状态
+	Reset(ctx context.Context, key string) error
+}
+
+type Config struct {
+	MaxAccountFailures int64         // 同一账号连续失败多少次后锁定
+	MaxIPFailures      int64         // 同一 IP 失败多少次后锁定，用来限制撞库
+	Window             time.Duration // 失败计数的统计窗口
+	BaseLockout        time.Duration // 第一次锁定的时长，之后每多失败一次翻倍
+	MaxLockout         time.Duration
+}
+
+// Guard 按账号和 IP 分别统计登录失败次数，超过阈值后按指数退避锁定
+type Guard struct {
+	store Store
+	conf  Config
+}
+
+func NewGuard(conf *viper.Viper, store Store) *Guard {
+	c := Config{
+		MaxAccountFailures: conf.GetInt64("security.login_protection.max_account_failures"),
+		MaxIPFailures:      conf.GetInt64("security.login_protection.max_ip_failures"),
+		Window:             conf.GetDuration("security.login_protection.window"),
+		BaseLockout:        conf.GetDuration("security.login_protection.base_lockout"),
+		MaxLockout:         conf.GetDuration("security.login_protection.max_lockout"),
+	}
+	if c.MaxAccountFailures <= 0 {
+		c.MaxAccountFailures = 5
+	}
+	if c.MaxIPFailures <= 0 {
+		c.MaxIPFailures = 50
+	}
+	if c.Window <= 0 {
+		c.Window = 15 * time.Minute
+	}
+	if c.BaseLockout <= 0 {
+		c.BaseLockout = time.Minute
+	}
+	if c.MaxLockout <= 0 {
+		c.MaxLockout = time.Hour
+	}
+	return &Guard{store: store, conf: c}
+}
+
+// Check 返回账号或 IP 中较长的剩余锁定时间，0 表示允许尝试
+func (g *Guard) Check(ctx context.Context, account string, ip string) (time.Duration, error) {
+	accountLock, err := g.store.LockedFor(ctx, accountKey(account))
+	if err != nil {
+		return 0, err
+	}
+	ipLock, err := g.store.LockedFor(ctx, ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	return max(accountLock, ipLock), nil
+}
+
+// Fail 记录一次失败，达到阈值后锁定
+func (g *Guard) Fail(ctx context.Context, account string, ip string) error {
+	if err := g.fail(ctx, accountKey(account), g.conf.MaxAccountFailures); err != nil {
+		return err
+	}
+	return g.fail(ctx, ipKey(ip), g.conf.MaxIPFailures)
+}
+
+// Succeed 登录成功后清除账号的失败记录
+// IP 计数不清除，否则攻击者可以用自己的账号穿插登录来重置计数
+func (g *Guard) Succeed(ctx context.Context, account string) error {
+	return g.store.Reset(ctx, accountKey(account))
+}
+
+// Unlock 管理员手动解锁账号
+func (g *Guard) Unlock(ctx context.Context, account string) error {
+	return g.store.Reset(ctx, accountKey(account))
+}
+
+func (g *Guard) fail(ctx context.Context, key string, threshold int64) error {
+	count, err := g.store.Incr(ctx, key, g.conf.Window)
+	if err != nil {
+		return err
+	}
+	if count < threshold {
+		return nil
+	}
+	return g.store.Lock(ctx, key, g.lockoutFor(count-threshold))
+}
+
+// lockoutFor 超过阈值后第 n 次失败（从 0 开始）的锁定时长
+func (g *Guard) lockoutFor(n int64) time.Duration {
+	d := g.conf.BaseLockout
+	for i := int64(0); i < n && d < g.conf.MaxLockout; i++ {
+		d *= 2
+	}
+	return min(d, g.conf.MaxLockout)
+}
+
+func accountKey(account string) string {
+	return "login:account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
@@ -0,0 +1,84 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	count       int64
+	windowEnd   time.Time
+	lockedUntil time.Time
+}
+
+// MemoryStore 进程内存储，只适用于单实例部署
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	if now.After(e.windowEnd) {
+		e.count, e.windowEnd = 0, now.Add(window)
+	}
+	e.count++
+	return e.count, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	e.lockedUntil = time.Now().Add(d)
+	return nil
+}
+
+func (s *MemoryStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	return max(time.Until(e.lockedUntil), 0), nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// sweep 每分钟最多清理一次过期的记录，避免内存无限增长
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if now.After(e.windowEnd) && now.After(e.lockedUntil) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore 多实例共享失败计数
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+// incrScript 只在第一次失败时设置过期时间，窗口从第一次失败开始计算
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.rdb, []string{key + ":count"}, window.Milliseconds()).Int64()
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	return s.rdb.Set(ctx, key+":lock", 1, d).Err()
+}
+
+func (s *RedisStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.rdb.PTTL(ctx, key+":lock").Result()
+	if err != nil {
+		return 0, err
+	}
+	// key 不存在时 PTTL 返回负数
+	return max(ttl, 0), nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, key+":count", key+":lock").Err()
+}
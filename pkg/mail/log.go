@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"go-nunu/pkg/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// logSender 本地开发用，不真正发信
+// 配置了 dir 时把邮件写成 .eml 文件，方便点开里面的链接
+type logSender struct {
+	dir    string
+	logger *log.Logger
+}
+
+func NewLogSender(dir string, logger *log.Logger) Sender {
+	return &logSender{dir: dir, logger: logger}
+}
+
+func (s *logSender) Send(ctx context.Context, msg *Message) error {
+	s.logger.WithContext(ctx).Info("mail",
+		zap.Strings("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	if s.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405.000000"), strings.Join(msg.To, "_"))
+	return os.WriteFile(filepath.Join(s.dir, name), buildMessage("noreply@localhost", msg), 0o644)
+}
@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"go-nunu/pkg/log"
+
+	"github.com/spf13/viper"
+)
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string // text/plain
+}
+
+// Sender 邮件发送接口，根据 mail.driver 选择实现
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewSender
+// mail.driver: smtp 通过 SMTP 服务器发送; log 只写日志/文件，用于本地开发
+func NewSender(conf *viper.Viper, logger *log.Logger) Sender {
+	switch driver := conf.GetString("mail.driver"); driver {
+	case "smtp":
+		return NewSMTPSender(SMTPConfig{
+			Host:     conf.GetString("mail.smtp.host"),
+			Port:     conf.GetInt("mail.smtp.port"),
+			Username: conf.GetString("mail.smtp.username"),
+			Password: conf.GetString("mail.smtp.password"),
+			From:     conf.GetString("mail.from"),
+		})
+	case "log", "":
+		return NewLogSender(conf.GetString("mail.log_dir"), logger)
+	default:
+		panic(fmt.Sprintf("unknown mail driver: %s", driver))
+	}
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type smtpSender struct {
+	conf SMTPConfig
+}
+
+func NewSMTPSender(conf SMTPConfig) Sender {
+	return &smtpSender{conf: conf}
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg *Message) error {
+	addr := net.JoinHostPort(s.conf.Host, strconv.Itoa(s.conf.Port))
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	// 465 端口是隐式 TLS，其余端口在服务端支持时走 STARTTLS
+	if s.conf.Port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: s.conf.Host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && s.conf.Port != 465 {
+		if err = c.StartTLS(&tls.Config{ServerName: s.conf.Host}); err != nil {
+			return err
+		}
+	}
+	if s.conf.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", s.conf.Username, s.conf.Password, s.conf.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(s.conf.From); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err = c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(buildMessage(s.conf.From, msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func buildMessage(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,99 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	githubAuthURL  = "https://github.com/login/oauth/authorize"
+	githubTokenURL = "https://github.com/login/oauth/access_token"
+	githubAPIURL   = "https://api.github.com"
+)
+
+// githubProvider GitHub 不支持 OIDC，用户信息和邮箱分别来自 /user 和 /user/emails
+type githubProvider struct {
+	conf   ProviderConfig
+	client *http.Client
+}
+
+func newGithubProvider(c ProviderConfig, client *http.Client) *githubProvider {
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"read:user", "user:email"}
+	}
+	if c.AuthURL == "" {
+		c.AuthURL = githubAuthURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = githubTokenURL
+	}
+	if c.UserInfoURL == "" {
+		c.UserInfoURL = githubAPIURL + "/user"
+	}
+	return &githubProvider{conf: c, client: client}
+}
+
+func (p *githubProvider) Config() ProviderConfig {
+	return p.conf
+}
+
+func (p *githubProvider) AuthCodeURL(ctx context.Context, state string, codeChallenge string, nonce string) (string, error) {
+	return buildURL(p.conf.AuthURL, url.Values{
+		"client_id":             {p.conf.ClientId},
+		"redirect_uri":          {p.conf.RedirectURL},
+		"scope":                 {strings.Join(p.conf.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, error) {
+	token, err := exchangeCode(ctx, p.client, p.conf, p.conf.TokenURL, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		Id        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarUrl string `json:"avatar_url"`
+	}
+	if err = getJSON(ctx, p.client, p.conf.UserInfoURL, token.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.Id == 0 {
+		return nil, errors.New("github user has no id")
+	}
+	identity := &Identity{
+		Subject: strconv.FormatInt(user.Id, 10),
+		Name:    user.Name,
+		Picture: user.AvatarUrl,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+
+	// /user 里的 email 是公开邮箱，未必验证过，以 /user/emails 中的主邮箱为准
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	emailsURL := strings.TrimSuffix(p.conf.UserInfoURL, "/user") + "/user/emails"
+	if err = getJSON(ctx, p.client, emailsURL, token.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email, identity.EmailVerified = e.Email, e.Verified
+			break
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,192 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ProviderConfig 对应 oauth.providers 下的一项
+type ProviderConfig struct {
+	Name         string   `mapstructure:"name"` // 路由中使用的名称，例如 /oauth/google/authorize
+	Type         string   `mapstructure:"type"` // oidc, github, wechat
+	ClientId     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"` // 前端回调页，由前端把 code/state 提交给 /oauth/:provider/callback
+	Scopes       []string `mapstructure:"scopes"`
+	Issuer       string   `mapstructure:"issuer"` // oidc: 配置后通过 discovery 获取各个端点
+	AuthURL      string   `mapstructure:"auth_url"`
+	TokenURL     string   `mapstructure:"token_url"`
+	UserInfoURL  string   `mapstructure:"userinfo_url"`
+	LoginType    int      `mapstructure:"login_type"` // 写入 users.register_type / last_login_type
+	UnionId      bool     `mapstructure:"union_id"`   // wechat: 以 unionid 作为 Subject，应用需已绑定到开放平台
+}
+
+// Identity 第三方账号信息，Subject 在同一个 provider 内唯一
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+type Provider interface {
+	Config() ProviderConfig
+	// AuthCodeURL 生成授权地址，codeChallenge 为 PKCE S256 challenge，nonce 仅 OIDC 使用
+	AuthCodeURL(ctx context.Context, state string, codeChallenge string, nonce string) (string, error)
+	// Exchange 用授权码换取用户信息
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, error)
+}
+
+type Registry struct {
+	providers map[string]Provider
+	names     []string
+}
+
+func NewRegistry(conf *viper.Viper) *Registry {
+	var configs []ProviderConfig
+	if err := conf.UnmarshalKey("oauth.providers", &configs); err != nil {
+		panic(err)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	r := &Registry{providers: make(map[string]Provider, len(configs))}
+	for _, c := range configs {
+		if c.Name == "" {
+			panic("oauth provider name is required")
+		}
+		if _, ok := r.providers[c.Name]; ok {
+			panic(fmt.Sprintf("oauth provider %q: duplicate name", c.Name))
+		}
+		var p Provider
+		switch c.Type {
+		case "oidc":
+			p = newOIDCProvider(c, client)
+		case "github":
+			p = newGithubProvider(c, client)
+		case "wechat":
+			p = newWechatProvider(c, client)
+		default:
+			panic(fmt.Sprintf("oauth provider %q: unknown type %q", c.Name, c.Type))
+		}
+		r.providers[c.Name] = p
+		r.names = append(r.names, c.Name)
+	}
+	return r
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names 按配置顺序返回全部 provider
+func (r *Registry) Names() []string {
+	return r.names
+}
+
+// NewCodeVerifier 生成 PKCE code_verifier (RFC 7636)
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeCode 标准的 authorization_code 换 token，client 凭证放在表单里 (client_secret_post)
+func exchangeCode(ctx context.Context, client *http.Client, c ProviderConfig, tokenURL string, code string, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.RedirectURL},
+		"client_id":     {c.ClientId},
+		"client_secret": {c.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err = doJSON(client, req, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("oauth token error: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("oauth token response has no access_token")
+	}
+	return &token, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, rawURL string, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	return doJSON(client, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	// token 端点出错时返回 400 和 JSON 错误体，交给调用方解析 error 字段
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusBadRequest {
+		return fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Redacted(), resp.StatusCode)
+	}
+	if err = json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+func buildURL(base string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + params.Encode()
+}
@@ -0,0 +1,284 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval 遇到未知 kid 时重新拉取公钥的最小间隔
+const jwksRefreshInterval = time.Minute
+
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// oidcProvider 支持任意 OIDC issuer；不配置 issuer 时按普通 OAuth2 处理，用户信息全部来自 userinfo_url
+type oidcProvider struct {
+	conf   ProviderConfig
+	client *http.Client
+
+	mu     sync.Mutex
+	meta   *oidcMetadata
+	keys   map[string]crypto.PublicKey
+	keysAt time.Time
+}
+
+func newOIDCProvider(c ProviderConfig, client *http.Client) *oidcProvider {
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &oidcProvider{conf: c, client: client}
+}
+
+func (p *oidcProvider) Config() ProviderConfig {
+	return p.conf
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state string, codeChallenge string, nonce string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.conf.ClientId},
+		"redirect_uri":          {p.conf.RedirectURL},
+		"scope":                 {strings.Join(p.conf.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	if meta.JwksURI != "" {
+		params.Set("nonce", nonce)
+	}
+	return buildURL(meta.AuthorizationEndpoint, params), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := exchangeCode(ctx, p.client, p.conf, meta.TokenEndpoint, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{}
+	if token.IDToken != "" && meta.JwksURI != "" {
+		claims, err := p.verifyIDToken(ctx, meta, token.IDToken, nonce)
+		if err != nil {
+			return nil, err
+		}
+		fillIdentity(identity, claims)
+	}
+	// id_token 里不一定带 email，缺少时再查 userinfo
+	if meta.UserinfoEndpoint != "" && (identity.Subject == "" || identity.Email == "") {
+		var claims map[string]interface{}
+		if err = getJSON(ctx, p.client, meta.UserinfoEndpoint, token.AccessToken, &claims); err != nil {
+			return nil, err
+		}
+		if identity.Subject != "" && stringClaim(claims, "sub") != identity.Subject {
+			return nil, errors.New("oidc userinfo sub does not match id_token")
+		}
+		fillIdentity(identity, claims)
+	}
+	if identity.Subject == "" {
+		return nil, errors.New("oidc provider returned no subject")
+	}
+	return identity, nil
+}
+
+func (p *oidcProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+	meta := &oidcMetadata{}
+	if p.conf.Issuer != "" {
+		discoveryURL := strings.TrimSuffix(p.conf.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := getJSON(ctx, p.client, discoveryURL, "", meta); err != nil {
+			return nil, err
+		}
+		if meta.Issuer != p.conf.Issuer {
+			return nil, fmt.Errorf("oidc issuer mismatch: got %q, want %q", meta.Issuer, p.conf.Issuer)
+		}
+	}
+	// 显式配置的端点优先于 discovery
+	if p.conf.AuthURL != "" {
+		meta.AuthorizationEndpoint = p.conf.AuthURL
+	}
+	if p.conf.TokenURL != "" {
+		meta.TokenEndpoint = p.conf.TokenURL
+	}
+	if p.conf.UserInfoURL != "" {
+		meta.UserinfoEndpoint = p.conf.UserInfoURL
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" {
+		return nil, fmt.Errorf("oauth provider %q: authorization and token endpoints are required", p.conf.Name)
+	}
+	p.meta = meta
+	return meta, nil
+}
+
+func (p *oidcProvider) verifyIDToken(ctx context.Context, meta *oidcMetadata, raw string, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, meta, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.conf.ClientId),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if nonce != "" && stringClaim(claims, "nonce") != nonce {
+		return nil, errors.New("oidc id_token nonce mismatch")
+	}
+	return claims, nil
+}
+
+func (p *oidcProvider) publicKey(ctx context.Context, meta *oidcMetadata, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("oidc unknown kid %q", kid)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, p.client, meta.JwksURI, "", &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAKey(k.N, k.E)
+		case "EC":
+			key, err = parseECKey(k.Crv, k.X, k.Y)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("oidc jwk %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	p.keys, p.keysAt = keys, time.Now()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("oidc unknown kid %q", kid)
+	}
+	return key, nil
+}
+
+func parseRSAKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(new(big.Int).SetBytes(eb).Int64())}, nil
+}
+
+func parseECKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	xb, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, err
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, err
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xb), Y: new(big.Int).SetBytes(yb)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return key, nil
+}
+
+// fillIdentity 用标准 OIDC claims 补全缺失的字段
+func fillIdentity(identity *Identity, claims map[string]interface{}) {
+	if identity.Subject == "" {
+		identity.Subject = stringClaim(claims, "sub")
+	}
+	if identity.Email == "" {
+		identity.Email = stringClaim(claims, "email")
+		// 有的 provider 把 email_verified 返回成字符串
+		switch v := claims["email_verified"].(type) {
+		case bool:
+			identity.EmailVerified = v
+		case string:
+			identity.EmailVerified = v == "true"
+		}
+	}
+	if identity.Name == "" {
+		identity.Name = stringClaim(claims, "name")
+	}
+	if identity.Picture == "" {
+		identity.Picture = stringClaim(claims, "picture")
+	}
+}
+
+func stringClaim(claims map[string]interface{}, key string) string {
+	v, _ := claims[key].(string)
+	return v
+}
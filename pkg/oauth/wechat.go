@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	wechatAuthURL     = "https://open.weixin.qq.com/connect/qrconnect"
+	wechatTokenURL    = "https://api.weixin.qq.com/sns/oauth2/access_token"
+	wechatUserInfoURL = "https://api.weixin.qq.com/sns/userinfo"
+)
+
+// wechatProvider 微信网站应用扫码登录；服务号网页授权把 auth_url 改成
+// https://open.weixin.qq.com/connect/oauth2/authorize 并使用 snsapi_userinfo scope
+// 微信不支持 PKCE，也不返回邮箱
+// Subject 只取一种：配置 union_id 时为 unionid，同一主体下多个应用共用账号；否则为 appid:openid
+type wechatProvider struct {
+	conf   ProviderConfig
+	client *http.Client
+}
+
+func newWechatProvider(c ProviderConfig, client *http.Client) *wechatProvider {
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"snsapi_login"}
+	}
+	if c.AuthURL == "" {
+		c.AuthURL = wechatAuthURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = wechatTokenURL
+	}
+	if c.UserInfoURL == "" {
+		c.UserInfoURL = wechatUserInfoURL
+	}
+	return &wechatProvider{conf: c, client: client}
+}
+
+func (p *wechatProvider) Config() ProviderConfig {
+	return p.conf
+}
+
+func (p *wechatProvider) AuthCodeURL(ctx context.Context, state string, codeChallenge string, nonce string) (string, error) {
+	return buildURL(p.conf.AuthURL, url.Values{
+		"appid":         {p.conf.ClientId},
+		"redirect_uri":  {p.conf.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {strings.Join(p.conf.Scopes, ",")},
+		"state":         {state},
+	}) + "#wechat_redirect", nil
+}
+
+type wechatError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (e wechatError) err() error {
+	if e.ErrCode == 0 {
+		return nil
+	}
+	return fmt.Errorf("wechat error %d: %s", e.ErrCode, e.ErrMsg)
+}
+
+func (p *wechatProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*Identity, error) {
+	var token struct {
+		wechatError
+		AccessToken string `json:"access_token"`
+		OpenId      string `json:"openid"`
+		UnionId     string `json:"unionid"`
+		Scope       string `json:"scope"`
+	}
+	tokenURL := buildURL(p.conf.TokenURL, url.Values{
+		"appid":      {p.conf.ClientId},
+		"secret":     {p.conf.ClientSecret},
+		"code":       {code},
+		"grant_type": {"authorization_code"},
+	})
+	if err := getJSON(ctx, p.client, tokenURL, "", &token); err != nil {
+		return nil, err
+	}
+	if err := token.err(); err != nil {
+		return nil, err
+	}
+	if token.OpenId == "" {
+		return nil, errors.New("wechat token response has no openid")
+	}
+
+	// snsapi_base 不能调用 userinfo，unionid 只能来自 token 响应
+	if token.Scope == "snsapi_base" {
+		return p.identity(token.OpenId, token.UnionId)
+	}
+
+	var user struct {
+		wechatError
+		Nickname   string `json:"nickname"`
+		HeadImgUrl string `json:"headimgurl"`
+		UnionId    string `json:"unionid"`
+	}
+	userURL := buildURL(p.conf.UserInfoURL, url.Values{
+		"access_token": {token.AccessToken},
+		"openid":       {token.OpenId},
+	})
+	if err := getJSON(ctx, p.client, userURL, "", &user); err != nil {
+		return nil, err
+	}
+	if err := user.err(); err != nil {
+		return nil, err
+	}
+	unionId := token.UnionId
+	if unionId == "" {
+		unionId = user.UnionId
+	}
+	identity, err := p.identity(token.OpenId, unionId)
+	if err != nil {
+		return nil, err
+	}
+	identity.Name, identity.Picture = user.Nickname, user.HeadImgUrl
+	return identity, nil
+}
+
+// identity 不在 unionid 和 openid 之间回退，否则同一个微信用户可能对应两个 Subject
+func (p *wechatProvider) identity(openId string, unionId string) (*Identity, error) {
+	if !p.conf.UnionId {
+		return &Identity{Subject: p.conf.ClientId + ":" + openId}, nil
+	}
+	if unionId == "" {
+		return nil, errors.New("wechat response has no unionid")
+	}
+	return &Identity{Subject: unionId}, nil
+}
@@ -0,0 +1,57 @@
+package sign
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// MemoryStore 进程内的 LRU，只适用于单实例部署
+// 容量满时淘汰最早的 nonce，容量应大于 2*skew 内的请求量，否则被淘汰的 nonce 可以重放
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // 按写入时间排列，front 最新
+	entries map[string]*list.Element
+}
+
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = 100000
+	}
+	return &MemoryStore{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+
+	if el, ok := s.entries[key]; ok {
+		if now.Before(el.Value.(*memoryEntry).expiresAt) {
+			return false, nil
+		}
+		s.remove(el)
+	}
+	// 先清理队尾已过期的记录，再按容量淘汰
+	for el := s.order.Back(); el != nil && (s.order.Len() >= s.size || now.After(el.Value.(*memoryEntry).expiresAt)); el = s.order.Back() {
+		s.remove(el)
+	}
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, expiresAt: now.Add(ttl)})
+	return true, nil
+}
+
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
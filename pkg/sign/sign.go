@@ -0,0 +1,150 @@
+package sign
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/cryptor"
+	"github.com/spf13/viper"
+)
+
+// ErrInvalid 请求本身不合法，其余错误来自 NonceStore
+var ErrInvalid = errors.New("sign: invalid request")
+
+var (
+	ErrMissingHeader = fmt.Errorf("%w: missing header", ErrInvalid)
+	ErrUnknownApp    = fmt.Errorf("%w: unknown app key", ErrInvalid)
+	ErrBadTimestamp  = fmt.Errorf("%w: timestamp out of window", ErrInvalid)
+	ErrBadSign       = fmt.Errorf("%w: signature mismatch", ErrInvalid)
+	ErrReplayed      = fmt.Errorf("%w: nonce already used", ErrInvalid)
+)
+
+// NonceStore 记录已使用的 nonce，单实例可以用内存，多实例部署需要 Redis
+type NonceStore interface {
+	// Claim 首次使用返回 true，ttl 内重复使用返回 false
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// Headers 客户端签名用到的请求头
+type Headers struct {
+	AppKey     string // 只配置了一个 app 时可以省略
+	Timestamp  string // unix 秒或毫秒
+	Nonce      string
+	AppVersion string
+	Sign       string
+}
+
+// Verifier 校验请求签名: 时间戳在 skew 窗口内、签名匹配、nonce 未被使用过
+type Verifier struct {
+	store NonceStore
+	apps  map[string]string // app_key -> app_security
+	skew  time.Duration
+}
+
+func NewVerifier(conf *viper.Viper, store NonceStore) *Verifier {
+	var apps []struct {
+		AppKey      string `mapstructure:"app_key"`
+		AppSecurity string `mapstructure:"app_security"`
+	}
+	if err := conf.UnmarshalKey("security.api_sign.apps", &apps); err != nil {
+		panic(fmt.Sprintf("invalid security.api_sign.apps: %v", err))
+	}
+	v := &Verifier{
+		store: store,
+		apps:  make(map[string]string, len(apps)+1),
+		skew:  conf.GetDuration("security.api_sign.skew"),
+	}
+	for _, app := range apps {
+		if app.AppKey == "" || app.AppSecurity == "" {
+			panic("security.api_sign.apps: app_key and app_security are required")
+		}
+		v.apps[app.AppKey] = app.AppSecurity
+	}
+	// 兼容旧配置的单个 app_key/app_security
+	if key := conf.GetString("security.api_sign.app_key"); key != "" {
+		v.apps[key] = conf.GetString("security.api_sign.app_security")
+	}
+	if v.skew <= 0 {
+		v.skew = 5 * time.Minute
+	}
+	return v
+}
+
+func (v *Verifier) Verify(ctx context.Context, h Headers) error {
+	if h.Timestamp == "" || h.Nonce == "" || h.AppVersion == "" || h.Sign == "" {
+		return ErrMissingHeader
+	}
+	appKey, secret, err := v.app(h.AppKey)
+	if err != nil {
+		return err
+	}
+
+	ts, err := strconv.ParseInt(h.Timestamp, 10, 64)
+	if err != nil {
+		return ErrBadTimestamp
+	}
+	if ts > 1e12 {
+		ts /= 1000
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > v.skew || d < -v.skew {
+		return ErrBadTimestamp
+	}
+
+	expected := Sign(appKey, secret, h.Timestamp, h.Nonce, h.AppVersion)
+	if subtle.ConstantTimeCompare([]byte(h.Sign), []byte(expected)) != 1 {
+		return ErrBadSign
+	}
+
+	// 签名通过后才记录 nonce，避免未签名的请求占用 nonce
+	// 超过 skew 的请求会被时间戳拒绝，nonce 只需保留前后两个窗口
+	ok, err := v.store.Claim(ctx, "sign:nonce:"+appKey+":"+h.Nonce, 2*v.skew)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrReplayed
+	}
+	return nil
+}
+
+func (v *Verifier) app(appKey string) (string, string, error) {
+	if appKey == "" && len(v.apps) == 1 {
+		for key, secret := range v.apps {
+			return key, secret, nil
+		}
+	}
+	secret, ok := v.apps[appKey]
+	if !ok {
+		return "", "", ErrUnknownApp
+	}
+	return appKey, secret, nil
+}
+
+// Sign 按参数名（不区分大小写）排序后拼接 key+value，末尾加上 app_security，取 MD5 大写
+func Sign(appKey, appSecurity, timestamp, nonce, appVersion string) string {
+	data := map[string]string{
+		"AppKey":     appKey,
+		"Timestamp":  timestamp,
+		"Nonce":      nonce,
+		"AppVersion": appVersion,
+	}
+
+	var keys []string
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
+
+	var str string
+	for _, k := range keys {
+		str += k + data[k]
+	}
+	str += appSecurity
+	return strings.ToUpper(cryptor.Md5String(str))
+}
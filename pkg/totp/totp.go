@@ -0,0 +1,86 @@
+// Package totp implements RFC 6238 time-based one-time passwords (SHA1, 6 digits, 30s step),
+// compatible with Google Authenticator and similar apps.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	Digits = 6
+	Period = 30 // seconds
+)
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random 160-bit secret in base32, as recommended by RFC 4226.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// URI builds the otpauth:// URI that authenticator apps scan as a QR code.
+func URI(issuer string, account string, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digits))
+	q.Set("period", fmt.Sprint(Period))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// Step returns the time step counter for t.
+func Step(t time.Time) int64 {
+	return t.Unix() / Period
+}
+
+// Code computes the one-time password for the given step.
+func Code(secret string, step int64) (string, error) {
+	key, err := b32.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000), nil
+}
+
+// Validate checks code against the steps around t, allowing skew steps of clock drift either way.
+// It returns the matched step so callers can reject reuse of the same code.
+func Validate(secret string, code string, t time.Time, skew int) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	current := Step(t)
+	for i := -skew; i <= skew; i++ {
+		expected, err := Code(secret, current+int64(i))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + int64(i), true
+		}
+	}
+	return 0, false
+}
@@ -6,9 +6,9 @@ package mock_repository
 
 import (
 	context "context"
-	reflect "reflect"
-
+	v1 "go-nunu/api/v1"
 	model "go-nunu/internal/model"
+	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -50,6 +50,34 @@ func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
 }
 
+// Delete mocks base method.
+func (m *MockUserRepository) Delete(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUserRepositoryMockRecorder) Delete(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUserRepository)(nil).Delete), ctx, userId)
+}
+
+// EachUser mocks base method.
+func (m *MockUserRepository) EachUser(ctx context.Context, req *v1.GetUserListRequest, batchSize int, fn func([]model.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachUser", ctx, req, batchSize, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachUser indicates an expected call of EachUser.
+func (mr *MockUserRepositoryMockRecorder) EachUser(ctx, req, batchSize, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachUser", reflect.TypeOf((*MockUserRepository)(nil).EachUser), ctx, req, batchSize, fn)
+}
+
 // GetByEmail mocks base method.
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +108,171 @@ func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
 }
 
+// GetDataScope mocks base method.
+func (m *MockUserRepository) GetDataScope(ctx context.Context, userId string) (int, uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDataScope", ctx, userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(uint)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDataScope indicates an expected call of GetDataScope.
+func (mr *MockUserRepositoryMockRecorder) GetDataScope(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDataScope", reflect.TypeOf((*MockUserRepository)(nil).GetDataScope), ctx, userId)
+}
+
+// GetDeletedByID mocks base method.
+func (m *MockUserRepository) GetDeletedByID(ctx context.Context, userId string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedByID", ctx, userId)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedByID indicates an expected call of GetDeletedByID.
+func (mr *MockUserRepositoryMockRecorder) GetDeletedByID(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedByID", reflect.TypeOf((*MockUserRepository)(nil).GetDeletedByID), ctx, userId)
+}
+
+// GetDeletedUserList mocks base method.
+func (m *MockUserRepository) GetDeletedUserList(ctx context.Context, req *v1.GetDeletedUserListRequest) ([]model.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserList", ctx, req)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetDeletedUserList indicates an expected call of GetDeletedUserList.
+func (mr *MockUserRepositoryMockRecorder) GetDeletedUserList(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserList", reflect.TypeOf((*MockUserRepository)(nil).GetDeletedUserList), ctx, req)
+}
+
+// GetExistingEmails mocks base method.
+func (m *MockUserRepository) GetExistingEmails(ctx context.Context, emails []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExistingEmails", ctx, emails)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExistingEmails indicates an expected call of GetExistingEmails.
+func (mr *MockUserRepositoryMockRecorder) GetExistingEmails(ctx, emails interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExistingEmails", reflect.TypeOf((*MockUserRepository)(nil).GetExistingEmails), ctx, emails)
+}
+
+// GetGuestByUdid mocks base method.
+func (m *MockUserRepository) GetGuestByUdid(ctx context.Context, udid string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGuestByUdid", ctx, udid)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGuestByUdid indicates an expected call of GetGuestByUdid.
+func (mr *MockUserRepositoryMockRecorder) GetGuestByUdid(ctx, udid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGuestByUdid", reflect.TypeOf((*MockUserRepository)(nil).GetGuestByUdid), ctx, udid)
+}
+
+// GetScopedByID mocks base method.
+func (m *MockUserRepository) GetScopedByID(ctx context.Context, id string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScopedByID", ctx, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScopedByID indicates an expected call of GetScopedByID.
+func (mr *MockUserRepositoryMockRecorder) GetScopedByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScopedByID", reflect.TypeOf((*MockUserRepository)(nil).GetScopedByID), ctx, id)
+}
+
+// GetUserList mocks base method.
+func (m *MockUserRepository) GetUserList(ctx context.Context, req *v1.GetUserListRequest) ([]model.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserList", ctx, req)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserList indicates an expected call of GetUserList.
+func (mr *MockUserRepositoryMockRecorder) GetUserList(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserList", reflect.TypeOf((*MockUserRepository)(nil).GetUserList), ctx, req)
+}
+
+// ListUserRoles mocks base method.
+func (m *MockUserRepository) ListUserRoles(ctx context.Context) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserRoles", ctx)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserRoles indicates an expected call of ListUserRoles.
+func (mr *MockUserRepositoryMockRecorder) ListUserRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserRoles", reflect.TypeOf((*MockUserRepository)(nil).ListUserRoles), ctx)
+}
+
+// MergeGuest mocks base method.
+func (m *MockUserRepository) MergeGuest(ctx context.Context, guestId string, target *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeGuest", ctx, guestId, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeGuest indicates an expected call of MergeGuest.
+func (mr *MockUserRepositoryMockRecorder) MergeGuest(ctx, guestId, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeGuest", reflect.TypeOf((*MockUserRepository)(nil).MergeGuest), ctx, guestId, target)
+}
+
+// Purge mocks base method.
+func (m *MockUserRepository) Purge(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockUserRepositoryMockRecorder) Purge(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockUserRepository)(nil).Purge), ctx, userId)
+}
+
+// Restore mocks base method.
+func (m *MockUserRepository) Restore(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUserRepositoryMockRecorder) Restore(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUserRepository)(nil).Restore), ctx, userId)
+}
+
 // Update mocks base method.
 func (m *MockUserRepository) Update(ctx context.Context, user *model.User) error {
 	m.ctrl.T.Helper()
@@ -93,3 +286,31 @@ func (mr *MockUserRepositoryMockRecorder) Update(ctx, user interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUserRepository)(nil).Update), ctx, user)
 }
+
+// UpdateLastLogin mocks base method.
+func (m *MockUserRepository) UpdateLastLogin(ctx context.Context, userId, ip string, loginType int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastLogin", ctx, userId, ip, loginType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastLogin indicates an expected call of UpdateLastLogin.
+func (mr *MockUserRepositoryMockRecorder) UpdateLastLogin(ctx, userId, ip, loginType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastLogin", reflect.TypeOf((*MockUserRepository)(nil).UpdateLastLogin), ctx, userId, ip, loginType)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockUserRepository) UpdateStatus(ctx context.Context, userId string, status int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, userId, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockUserRepositoryMockRecorder) UpdateStatus(ctx, userId, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockUserRepository)(nil).UpdateStatus), ctx, userId, status)
+}
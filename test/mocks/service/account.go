@@ -0,0 +1,121 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/account.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	v1 "go-nunu/api/v1"
+	model "go-nunu/internal/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAccountService is a mock of AccountService interface.
+type MockAccountService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountServiceMockRecorder
+}
+
+// MockAccountServiceMockRecorder is the mock recorder for MockAccountService.
+type MockAccountServiceMockRecorder struct {
+	mock *MockAccountService
+}
+
+// NewMockAccountService creates a new mock instance.
+func NewMockAccountService(ctrl *gomock.Controller) *MockAccountService {
+	mock := &MockAccountService{ctrl: ctrl}
+	mock.recorder = &MockAccountServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountService) EXPECT() *MockAccountServiceMockRecorder {
+	return m.recorder
+}
+
+// ForgotPassword mocks base method.
+func (m *MockAccountService) ForgotPassword(ctx context.Context, req *v1.ForgotPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForgotPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockAccountServiceMockRecorder) ForgotPassword(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockAccountService)(nil).ForgotPassword), ctx, req)
+}
+
+// ResendVerifyEmail mocks base method.
+func (m *MockAccountService) ResendVerifyEmail(ctx context.Context, req *v1.ResendVerifyEmailRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerifyEmail", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerifyEmail indicates an expected call of ResendVerifyEmail.
+func (mr *MockAccountServiceMockRecorder) ResendVerifyEmail(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerifyEmail", reflect.TypeOf((*MockAccountService)(nil).ResendVerifyEmail), ctx, req)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAccountService) ResetPassword(ctx context.Context, req *v1.ResetPasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAccountServiceMockRecorder) ResetPassword(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAccountService)(nil).ResetPassword), ctx, req)
+}
+
+// SendSetPasswordEmail mocks base method.
+func (m *MockAccountService) SendSetPasswordEmail(ctx context.Context, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendSetPasswordEmail", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendSetPasswordEmail indicates an expected call of SendSetPasswordEmail.
+func (mr *MockAccountServiceMockRecorder) SendSetPasswordEmail(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSetPasswordEmail", reflect.TypeOf((*MockAccountService)(nil).SendSetPasswordEmail), ctx, user)
+}
+
+// SendVerifyEmail mocks base method.
+func (m *MockAccountService) SendVerifyEmail(ctx context.Context, user *model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerifyEmail", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerifyEmail indicates an expected call of SendVerifyEmail.
+func (mr *MockAccountServiceMockRecorder) SendVerifyEmail(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerifyEmail", reflect.TypeOf((*MockAccountService)(nil).SendVerifyEmail), ctx, user)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockAccountService) VerifyEmail(ctx context.Context, req *v1.VerifyEmailRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockAccountServiceMockRecorder) VerifyEmail(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockAccountService)(nil).VerifyEmail), ctx, req)
+}
@@ -0,0 +1,206 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/avatar.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	model "go-nunu/internal/model"
+	io "io"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockObjectStorage is a mock of ObjectStorage interface.
+type MockObjectStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockObjectStorageMockRecorder
+}
+
+// MockObjectStorageMockRecorder is the mock recorder for MockObjectStorage.
+type MockObjectStorageMockRecorder struct {
+	mock *MockObjectStorage
+}
+
+// NewMockObjectStorage creates a new mock instance.
+func NewMockObjectStorage(ctrl *gomock.Controller) *MockObjectStorage {
+	mock := &MockObjectStorage{ctrl: ctrl}
+	mock.recorder = &MockObjectStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockObjectStorage) EXPECT() *MockObjectStorageMockRecorder {
+	return m.recorder
+}
+
+// DeleteObjects mocks base method.
+func (m *MockObjectStorage) DeleteObjects(ctx context.Context, keys ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteObjects", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteObjects indicates an expected call of DeleteObjects.
+func (mr *MockObjectStorageMockRecorder) DeleteObjects(ctx interface{}, keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObjects", reflect.TypeOf((*MockObjectStorage)(nil).DeleteObjects), varargs...)
+}
+
+// GetObject mocks base method.
+func (m *MockObjectStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetObject", ctx, key)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetObject indicates an expected call of GetObject.
+func (mr *MockObjectStorageMockRecorder) GetObject(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetObject", reflect.TypeOf((*MockObjectStorage)(nil).GetObject), ctx, key)
+}
+
+// HeadObject mocks base method.
+func (m *MockObjectStorage) HeadObject(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeadObject", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HeadObject indicates an expected call of HeadObject.
+func (mr *MockObjectStorageMockRecorder) HeadObject(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeadObject", reflect.TypeOf((*MockObjectStorage)(nil).HeadObject), ctx, key)
+}
+
+// ObjectKey mocks base method.
+func (m *MockObjectStorage) ObjectKey(url string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ObjectKey", url)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ObjectKey indicates an expected call of ObjectKey.
+func (mr *MockObjectStorageMockRecorder) ObjectKey(url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObjectKey", reflect.TypeOf((*MockObjectStorage)(nil).ObjectKey), url)
+}
+
+// ObjectURL mocks base method.
+func (m *MockObjectStorage) ObjectURL(key string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ObjectURL", key)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ObjectURL indicates an expected call of ObjectURL.
+func (mr *MockObjectStorageMockRecorder) ObjectURL(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObjectURL", reflect.TypeOf((*MockObjectStorage)(nil).ObjectURL), key)
+}
+
+// PutObject mocks base method.
+func (m *MockObjectStorage) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutObject", ctx, key, body, contentType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutObject indicates an expected call of PutObject.
+func (mr *MockObjectStorageMockRecorder) PutObject(ctx, key, body, contentType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutObject", reflect.TypeOf((*MockObjectStorage)(nil).PutObject), ctx, key, body, contentType)
+}
+
+// MockAvatarService is a mock of AvatarService interface.
+type MockAvatarService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAvatarServiceMockRecorder
+}
+
+// MockAvatarServiceMockRecorder is the mock recorder for MockAvatarService.
+type MockAvatarServiceMockRecorder struct {
+	mock *MockAvatarService
+}
+
+// NewMockAvatarService creates a new mock instance.
+func NewMockAvatarService(ctrl *gomock.Controller) *MockAvatarService {
+	mock := &MockAvatarService{ctrl: ctrl}
+	mock.recorder = &MockAvatarServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAvatarService) EXPECT() *MockAvatarServiceMockRecorder {
+	return m.recorder
+}
+
+// AvatarURL mocks base method.
+func (m *MockAvatarService) AvatarURL(keys model.AvatarKeys) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AvatarURL", keys)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// AvatarURL indicates an expected call of AvatarURL.
+func (mr *MockAvatarServiceMockRecorder) AvatarURL(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvatarURL", reflect.TypeOf((*MockAvatarService)(nil).AvatarURL), keys)
+}
+
+// AvatarURLs mocks base method.
+func (m *MockAvatarService) AvatarURLs(keys model.AvatarKeys) map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AvatarURLs", keys)
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// AvatarURLs indicates an expected call of AvatarURLs.
+func (mr *MockAvatarServiceMockRecorder) AvatarURLs(keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvatarURLs", reflect.TypeOf((*MockAvatarService)(nil).AvatarURLs), keys)
+}
+
+// DeleteAvatar mocks base method.
+func (m *MockAvatarService) DeleteAvatar(ctx context.Context, keys model.AvatarKeys) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteAvatar", ctx, keys)
+}
+
+// DeleteAvatar indicates an expected call of DeleteAvatar.
+func (mr *MockAvatarServiceMockRecorder) DeleteAvatar(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAvatar", reflect.TypeOf((*MockAvatarService)(nil).DeleteAvatar), ctx, keys)
+}
+
+// ProcessAvatar mocks base method.
+func (m *MockAvatarService) ProcessAvatar(ctx context.Context, userId, avatar string) (model.AvatarKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessAvatar", ctx, userId, avatar)
+	ret0, _ := ret[0].(model.AvatarKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessAvatar indicates an expected call of ProcessAvatar.
+func (mr *MockAvatarServiceMockRecorder) ProcessAvatar(ctx, userId, avatar interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessAvatar", reflect.TypeOf((*MockAvatarService)(nil).ProcessAvatar), ctx, userId, avatar)
+}
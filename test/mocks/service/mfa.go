@@ -0,0 +1,171 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/mfa.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	v1 "go-nunu/api/v1"
+	model "go-nunu/internal/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMfaService is a mock of MfaService interface.
+type MockMfaService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMfaServiceMockRecorder
+}
+
+// MockMfaServiceMockRecorder is the mock recorder for MockMfaService.
+type MockMfaServiceMockRecorder struct {
+	mock *MockMfaService
+}
+
+// NewMockMfaService creates a new mock instance.
+func NewMockMfaService(ctrl *gomock.Controller) *MockMfaService {
+	mock := &MockMfaService{ctrl: ctrl}
+	mock.recorder = &MockMfaServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMfaService) EXPECT() *MockMfaServiceMockRecorder {
+	return m.recorder
+}
+
+// ActivateTotp mocks base method.
+func (m *MockMfaService) ActivateTotp(ctx context.Context, userId, code string) (*v1.RecoveryCodesResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateTotp", ctx, userId, code)
+	ret0, _ := ret[0].(*v1.RecoveryCodesResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateTotp indicates an expected call of ActivateTotp.
+func (mr *MockMfaServiceMockRecorder) ActivateTotp(ctx, userId, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateTotp", reflect.TypeOf((*MockMfaService)(nil).ActivateTotp), ctx, userId, code)
+}
+
+// Challenge mocks base method.
+func (m *MockMfaService) Challenge(ctx context.Context, user *model.User, loginType int, guestId string) (*v1.LoginResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Challenge", ctx, user, loginType, guestId)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Challenge indicates an expected call of Challenge.
+func (mr *MockMfaServiceMockRecorder) Challenge(ctx, user, loginType, guestId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Challenge", reflect.TypeOf((*MockMfaService)(nil).Challenge), ctx, user, loginType, guestId)
+}
+
+// DisableTotp mocks base method.
+func (m *MockMfaService) DisableTotp(ctx context.Context, userId string, req *v1.MfaCodeRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTotp", ctx, userId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTotp indicates an expected call of DisableTotp.
+func (mr *MockMfaServiceMockRecorder) DisableTotp(ctx, userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTotp", reflect.TypeOf((*MockMfaService)(nil).DisableTotp), ctx, userId, req)
+}
+
+// EnrollTotp mocks base method.
+func (m *MockMfaService) EnrollTotp(ctx context.Context, userId string) (*v1.EnrollTotpResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTotp", ctx, userId)
+	ret0, _ := ret[0].(*v1.EnrollTotpResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTotp indicates an expected call of EnrollTotp.
+func (mr *MockMfaServiceMockRecorder) EnrollTotp(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTotp", reflect.TypeOf((*MockMfaService)(nil).EnrollTotp), ctx, userId)
+}
+
+// GetStatus mocks base method.
+func (m *MockMfaService) GetStatus(ctx context.Context, userId string) (*v1.GetMfaStatusResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatus", ctx, userId)
+	ret0, _ := ret[0].(*v1.GetMfaStatusResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatus indicates an expected call of GetStatus.
+func (mr *MockMfaServiceMockRecorder) GetStatus(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatus", reflect.TypeOf((*MockMfaService)(nil).GetStatus), ctx, userId)
+}
+
+// LoginMfa mocks base method.
+func (m *MockMfaService) LoginMfa(ctx context.Context, req *v1.LoginMfaRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMfa", ctx, req, client)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMfa indicates an expected call of LoginMfa.
+func (mr *MockMfaServiceMockRecorder) LoginMfa(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMfa", reflect.TypeOf((*MockMfaService)(nil).LoginMfa), ctx, req, client)
+}
+
+// LoginMfaActivate mocks base method.
+func (m *MockMfaService) LoginMfaActivate(ctx context.Context, req *v1.LoginMfaActivateRequest, client *model.ClientInfo) (*v1.LoginMfaActivateResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMfaActivate", ctx, req, client)
+	ret0, _ := ret[0].(*v1.LoginMfaActivateResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMfaActivate indicates an expected call of LoginMfaActivate.
+func (mr *MockMfaServiceMockRecorder) LoginMfaActivate(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMfaActivate", reflect.TypeOf((*MockMfaService)(nil).LoginMfaActivate), ctx, req, client)
+}
+
+// LoginMfaEnroll mocks base method.
+func (m *MockMfaService) LoginMfaEnroll(ctx context.Context, req *v1.LoginMfaEnrollRequest) (*v1.EnrollTotpResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMfaEnroll", ctx, req)
+	ret0, _ := ret[0].(*v1.EnrollTotpResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMfaEnroll indicates an expected call of LoginMfaEnroll.
+func (mr *MockMfaServiceMockRecorder) LoginMfaEnroll(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMfaEnroll", reflect.TypeOf((*MockMfaService)(nil).LoginMfaEnroll), ctx, req)
+}
+
+// RegenerateRecoveryCodes mocks base method.
+func (m *MockMfaService) RegenerateRecoveryCodes(ctx context.Context, userId string, req *v1.MfaCodeRequest) (*v1.RecoveryCodesResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateRecoveryCodes", ctx, userId, req)
+	ret0, _ := ret[0].(*v1.RecoveryCodesResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateRecoveryCodes indicates an expected call of RegenerateRecoveryCodes.
+func (mr *MockMfaServiceMockRecorder) RegenerateRecoveryCodes(ctx, userId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateRecoveryCodes", reflect.TypeOf((*MockMfaService)(nil).RegenerateRecoveryCodes), ctx, userId, req)
+}
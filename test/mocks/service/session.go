@@ -0,0 +1,107 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/session.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	v1 "go-nunu/api/v1"
+	model "go-nunu/internal/model"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSessionService is a mock of SessionService interface.
+type MockSessionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionServiceMockRecorder
+}
+
+// MockSessionServiceMockRecorder is the mock recorder for MockSessionService.
+type MockSessionServiceMockRecorder struct {
+	mock *MockSessionService
+}
+
+// NewMockSessionService creates a new mock instance.
+func NewMockSessionService(ctrl *gomock.Controller) *MockSessionService {
+	mock := &MockSessionService{ctrl: ctrl}
+	mock.recorder = &MockSessionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionService) EXPECT() *MockSessionServiceMockRecorder {
+	return m.recorder
+}
+
+// GetLoginLogList mocks base method.
+func (m *MockSessionService) GetLoginLogList(ctx context.Context, req *v1.GetLoginLogListRequest) (*v1.GetLoginLogListResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginLogList", ctx, req)
+	ret0, _ := ret[0].(*v1.GetLoginLogListResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginLogList indicates an expected call of GetLoginLogList.
+func (mr *MockSessionServiceMockRecorder) GetLoginLogList(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLogList", reflect.TypeOf((*MockSessionService)(nil).GetLoginLogList), ctx, req)
+}
+
+// ListSessions mocks base method.
+func (m *MockSessionService) ListSessions(ctx context.Context, userId, currentTokenId string) (*v1.ListSessionsResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx, userId, currentTokenId)
+	ret0, _ := ret[0].(*v1.ListSessionsResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockSessionServiceMockRecorder) ListSessions(ctx, userId, currentTokenId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockSessionService)(nil).ListSessions), ctx, userId, currentTokenId)
+}
+
+// RecordLogin mocks base method.
+func (m *MockSessionService) RecordLogin(ctx context.Context, entry *model.LoginLog) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordLogin", ctx, entry)
+}
+
+// RecordLogin indicates an expected call of RecordLogin.
+func (mr *MockSessionServiceMockRecorder) RecordLogin(ctx, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLogin", reflect.TypeOf((*MockSessionService)(nil).RecordLogin), ctx, entry)
+}
+
+// RevokeAllSessions mocks base method.
+func (m *MockSessionService) RevokeAllSessions(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAllSessions", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAllSessions indicates an expected call of RevokeAllSessions.
+func (mr *MockSessionServiceMockRecorder) RevokeAllSessions(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAllSessions", reflect.TypeOf((*MockSessionService)(nil).RevokeAllSessions), ctx, userId)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionService) RevokeSession(ctx context.Context, userId string, sessionId uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, userId, sessionId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionServiceMockRecorder) RevokeSession(ctx, userId, sessionId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionService)(nil).RevokeSession), ctx, userId, sessionId)
+}
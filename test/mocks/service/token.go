@@ -0,0 +1,110 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/token.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	v1 "go-nunu/api/v1"
+	model "go-nunu/internal/model"
+	jwt "go-nunu/pkg/jwt"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTokenService is a mock of TokenService interface.
+type MockTokenService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenServiceMockRecorder
+}
+
+// MockTokenServiceMockRecorder is the mock recorder for MockTokenService.
+type MockTokenServiceMockRecorder struct {
+	mock *MockTokenService
+}
+
+// NewMockTokenService creates a new mock instance.
+func NewMockTokenService(ctrl *gomock.Controller) *MockTokenService {
+	mock := &MockTokenService{ctrl: ctrl}
+	mock.recorder = &MockTokenServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTokenService) EXPECT() *MockTokenServiceMockRecorder {
+	return m.recorder
+}
+
+// IssueTokens mocks base method.
+func (m *MockTokenService) IssueTokens(ctx context.Context, user *model.User, client *model.ClientInfo, loginType int) (*v1.LoginResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueTokens", ctx, user, client, loginType)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueTokens indicates an expected call of IssueTokens.
+func (mr *MockTokenServiceMockRecorder) IssueTokens(ctx, user, client, loginType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueTokens", reflect.TypeOf((*MockTokenService)(nil).IssueTokens), ctx, user, client, loginType)
+}
+
+// Logout mocks base method.
+func (m *MockTokenService) Logout(ctx context.Context, claims *jwt.MyCustomClaims, req *v1.LogoutRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, claims, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockTokenServiceMockRecorder) Logout(ctx, claims, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockTokenService)(nil).Logout), ctx, claims, req)
+}
+
+// RefreshToken mocks base method.
+func (m *MockTokenService) RefreshToken(ctx context.Context, req *v1.RefreshTokenRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, req, client)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockTokenServiceMockRecorder) RefreshToken(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockTokenService)(nil).RefreshToken), ctx, req, client)
+}
+
+// RevokeFamily mocks base method.
+func (m *MockTokenService) RevokeFamily(ctx context.Context, familyId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFamily", ctx, familyId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFamily indicates an expected call of RevokeFamily.
+func (mr *MockTokenServiceMockRecorder) RevokeFamily(ctx, familyId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFamily", reflect.TypeOf((*MockTokenService)(nil).RevokeFamily), ctx, familyId)
+}
+
+// RevokeUserTokens mocks base method.
+func (m *MockTokenService) RevokeUserTokens(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserTokens", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserTokens indicates an expected call of RevokeUserTokens.
+func (mr *MockTokenServiceMockRecorder) RevokeUserTokens(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserTokens", reflect.TypeOf((*MockTokenService)(nil).RevokeUserTokens), ctx, userId)
+}
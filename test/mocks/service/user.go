@@ -6,9 +6,9 @@ package mock_service
 
 import (
 	context "context"
-	reflect "reflect"
-
 	v1 "go-nunu/api/v1"
+	model "go-nunu/internal/model"
+	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -36,6 +36,78 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
+// CreateUser mocks base method.
+func (m *MockUserService) CreateUser(ctx context.Context, operatorId string, req *v1.CreateUserRequest) (*v1.CreateUserResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, operatorId, req)
+	ret0, _ := ret[0].(*v1.CreateUserResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserServiceMockRecorder) CreateUser(ctx, operatorId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserService)(nil).CreateUser), ctx, operatorId, req)
+}
+
+// DeactivateUser mocks base method.
+func (m *MockUserService) DeactivateUser(ctx context.Context, operatorId, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivateUser", ctx, operatorId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivateUser indicates an expected call of DeactivateUser.
+func (mr *MockUserServiceMockRecorder) DeactivateUser(ctx, operatorId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateUser", reflect.TypeOf((*MockUserService)(nil).DeactivateUser), ctx, operatorId, userId)
+}
+
+// DisableUser mocks base method.
+func (m *MockUserService) DisableUser(ctx context.Context, operatorId, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableUser", ctx, operatorId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableUser indicates an expected call of DisableUser.
+func (mr *MockUserServiceMockRecorder) DisableUser(ctx, operatorId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableUser", reflect.TypeOf((*MockUserService)(nil).DisableUser), ctx, operatorId, userId)
+}
+
+// EnableUser mocks base method.
+func (m *MockUserService) EnableUser(ctx context.Context, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUser", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUser indicates an expected call of EnableUser.
+func (mr *MockUserServiceMockRecorder) EnableUser(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUser", reflect.TypeOf((*MockUserService)(nil).EnableUser), ctx, userId)
+}
+
+// GetDeletedUserList mocks base method.
+func (m *MockUserService) GetDeletedUserList(ctx context.Context, req *v1.GetDeletedUserListRequest) (*v1.GetUserListResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserList", ctx, req)
+	ret0, _ := ret[0].(*v1.GetUserListResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserList indicates an expected call of GetDeletedUserList.
+func (mr *MockUserServiceMockRecorder) GetDeletedUserList(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserList", reflect.TypeOf((*MockUserService)(nil).GetDeletedUserList), ctx, req)
+}
+
 // GetProfile mocks base method.
 func (m *MockUserService) GetProfile(ctx context.Context, userId string) (*v1.GetProfileResponseData, error) {
 	m.ctrl.T.Helper()
@@ -51,19 +123,63 @@ func (mr *MockUserServiceMockRecorder) GetProfile(ctx, userId interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockUserService)(nil).GetProfile), ctx, userId)
 }
 
+// GetUserList mocks base method.
+func (m *MockUserService) GetUserList(ctx context.Context, req *v1.GetUserListRequest) (*v1.GetUserListResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserList", ctx, req)
+	ret0, _ := ret[0].(*v1.GetUserListResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserList indicates an expected call of GetUserList.
+func (mr *MockUserServiceMockRecorder) GetUserList(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserList", reflect.TypeOf((*MockUserService)(nil).GetUserList), ctx, req)
+}
+
+// GuestLogin mocks base method.
+func (m *MockUserService) GuestLogin(ctx context.Context, req *v1.GuestLoginRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GuestLogin", ctx, req, client)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GuestLogin indicates an expected call of GuestLogin.
+func (mr *MockUserServiceMockRecorder) GuestLogin(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GuestLogin", reflect.TypeOf((*MockUserService)(nil).GuestLogin), ctx, req, client)
+}
+
 // Login mocks base method.
-func (m *MockUserService) Login(ctx context.Context, req *v1.LoginRequest) (string, error) {
+func (m *MockUserService) Login(ctx context.Context, req *v1.LoginRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", ctx, req)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Login", ctx, req, client)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockUserServiceMockRecorder) Login(ctx, req interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) Login(ctx, req, client interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), ctx, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUserService)(nil).Login), ctx, req, client)
+}
+
+// PurgeUser mocks base method.
+func (m *MockUserService) PurgeUser(ctx context.Context, operatorId, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUser", ctx, operatorId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeUser indicates an expected call of PurgeUser.
+func (mr *MockUserServiceMockRecorder) PurgeUser(ctx, operatorId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUser", reflect.TypeOf((*MockUserService)(nil).PurgeUser), ctx, operatorId, userId)
 }
 
 // Register mocks base method.
@@ -80,6 +196,34 @@ func (mr *MockUserServiceMockRecorder) Register(ctx, req interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUserService)(nil).Register), ctx, req)
 }
 
+// RestoreUser mocks base method.
+func (m *MockUserService) RestoreUser(ctx context.Context, operatorId, userId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", ctx, operatorId, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserServiceMockRecorder) RestoreUser(ctx, operatorId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserService)(nil).RestoreUser), ctx, operatorId, userId)
+}
+
+// UnlockUser mocks base method.
+func (m *MockUserService) UnlockUser(ctx context.Context, req *v1.UnlockUserRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockUserServiceMockRecorder) UnlockUser(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockUserService)(nil).UnlockUser), ctx, req)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockUserService) UpdateProfile(ctx context.Context, userId string, req *v1.UpdateProfileRequest) error {
 	m.ctrl.T.Helper()
@@ -93,3 +237,32 @@ func (mr *MockUserServiceMockRecorder) UpdateProfile(ctx, userId, req interface{
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserService)(nil).UpdateProfile), ctx, userId, req)
 }
+
+// UpdateUser mocks base method.
+func (m *MockUserService) UpdateUser(ctx context.Context, operatorId string, req *v1.UpdateUserRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, operatorId, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserServiceMockRecorder) UpdateUser(ctx, operatorId, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserService)(nil).UpdateUser), ctx, operatorId, req)
+}
+
+// UpgradeGuest mocks base method.
+func (m *MockUserService) UpgradeGuest(ctx context.Context, guestId string, req *v1.UpgradeGuestRequest, client *model.ClientInfo) (*v1.LoginResponseData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpgradeGuest", ctx, guestId, req, client)
+	ret0, _ := ret[0].(*v1.LoginResponseData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpgradeGuest indicates an expected call of UpgradeGuest.
+func (mr *MockUserServiceMockRecorder) UpgradeGuest(ctx, guestId, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpgradeGuest", reflect.TypeOf((*MockUserService)(nil).UpgradeGuest), ctx, guestId, req, client)
+}
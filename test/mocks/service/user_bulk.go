@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/user_bulk.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	v1 "go-nunu/api/v1"
+	io "io"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUserBulkService is a mock of UserBulkService interface.
+type MockUserBulkService struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserBulkServiceMockRecorder
+}
+
+// MockUserBulkServiceMockRecorder is the mock recorder for MockUserBulkService.
+type MockUserBulkServiceMockRecorder struct {
+	mock *MockUserBulkService
+}
+
+// NewMockUserBulkService creates a new mock instance.
+func NewMockUserBulkService(ctrl *gomock.Controller) *MockUserBulkService {
+	mock := &MockUserBulkService{ctrl: ctrl}
+	mock.recorder = &MockUserBulkServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserBulkService) EXPECT() *MockUserBulkServiceMockRecorder {
+	return m.recorder
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserBulkService) ExportUsers(ctx context.Context, req *v1.ExportUsersRequest, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", ctx, req, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserBulkServiceMockRecorder) ExportUsers(ctx, req, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserBulkService)(nil).ExportUsers), ctx, req, w)
+}
+
+// ImportUsers mocks base method.
+func (m *MockUserBulkService) ImportUsers(ctx context.Context, operatorId, format string, r io.Reader, req *v1.ImportUsersRequest) (*v1.ImportUsersData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", ctx, operatorId, format, r, req)
+	ret0, _ := ret[0].(*v1.ImportUsersData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockUserBulkServiceMockRecorder) ImportUsers(ctx, operatorId, format, r, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockUserBulkService)(nil).ImportUsers), ctx, operatorId, format, r, req)
+}
+
+// MockuserFileWriter is a mock of userFileWriter interface.
+type MockuserFileWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockuserFileWriterMockRecorder
+}
+
+// MockuserFileWriterMockRecorder is the mock recorder for MockuserFileWriter.
+type MockuserFileWriterMockRecorder struct {
+	mock *MockuserFileWriter
+}
+
+// NewMockuserFileWriter creates a new mock instance.
+func NewMockuserFileWriter(ctrl *gomock.Controller) *MockuserFileWriter {
+	mock := &MockuserFileWriter{ctrl: ctrl}
+	mock.recorder = &MockuserFileWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockuserFileWriter) EXPECT() *MockuserFileWriterMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockuserFileWriter) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockuserFileWriterMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockuserFileWriter)(nil).Close))
+}
+
+// Flush mocks base method.
+func (m *MockuserFileWriter) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockuserFileWriterMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockuserFileWriter)(nil).Flush))
+}
+
+// Write mocks base method.
+func (m *MockuserFileWriter) Write(record []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Write", record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Write indicates an expected call of Write.
+func (mr *MockuserFileWriterMockRecorder) Write(record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockuserFileWriter)(nil).Write), record)
+}
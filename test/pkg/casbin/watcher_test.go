@@ -0,0 +1,180 @@
+package casbin_test
+
+import (
+	casbinPkg "go-nunu/pkg/casbin"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+const pollInterval = 20 * time.Millisecond
+
+// projectRoot NewEnforcer 按工作目录读取 config/model.conf
+var projectRoot, _ = filepath.Abs("../../..")
+
+// openDB 两个实例共享同一个 SQLite 文件，模拟共用一个数据库的多实例部署
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "casbin.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormLogger.Default.LogMode(gormLogger.Silent)})
+	require.NoError(t, err)
+	return db
+}
+
+func newEnforcer(t *testing.T, db *gorm.DB, watcher persist.Watcher) *casbin.SyncedCachedEnforcer {
+	t.Helper()
+	t.Chdir(projectRoot)
+	e := casbinPkg.NewEnforcer(db, watcher)
+	require.NotNil(t, e)
+	return e
+}
+
+func newDBWatcher(t *testing.T, db *gorm.DB) *casbinPkg.DBWatcher {
+	t.Helper()
+	w, err := casbinPkg.NewDBWatcher(db, pollInterval)
+	require.NoError(t, err)
+	t.Cleanup(w.Close)
+	return w
+}
+
+// fakeWatcher 记录 NewEnforcer 注册的回调，由测试手动触发
+type fakeWatcher struct {
+	mu       sync.Mutex
+	callback func(string)
+	updates  int
+}
+
+func (w *fakeWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+func (w *fakeWatcher) Update() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.updates++
+	return nil
+}
+
+func (w *fakeWatcher) Close() {}
+
+func (w *fakeWatcher) notify() {
+	w.mu.Lock()
+	callback := w.callback
+	w.mu.Unlock()
+	callback("other-instance")
+}
+
+func TestNewEnforcer_ReloadClearsCache(t *testing.T) {
+	db := openDB(t)
+	local, remote := &fakeWatcher{}, &fakeWatcher{}
+	e := newEnforcer(t, db, local)
+	other := newEnforcer(t, db, remote)
+
+	// 缓存中先记下拒绝的结果
+	ok, err := e.Enforce("viewer", "api:/v1/thing", "GET")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = other.AddPolicy("viewer", "api:/v1/thing", "GET")
+	require.NoError(t, err)
+	assert.Equal(t, 1, remote.updates)
+
+	local.notify()
+	ok, err = e.Enforce("viewer", "api:/v1/thing", "GET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestDBWatcher_Propagation(t *testing.T) {
+	tests := []struct {
+		name string
+		// change 在 a 上修改策略
+		change func(t *testing.T, a *casbin.SyncedCachedEnforcer)
+		want   bool
+	}{
+		{
+			name: "added policy",
+			change: func(t *testing.T, a *casbin.SyncedCachedEnforcer) {
+				_, err := a.AddPolicy("viewer", "api:/v1/thing", "GET")
+				require.NoError(t, err)
+			},
+			want: true,
+		},
+		{
+			name: "added grouping",
+			change: func(t *testing.T, a *casbin.SyncedCachedEnforcer) {
+				_, err := a.AddPolicy("editor", "api:/v1/thing", "GET")
+				require.NoError(t, err)
+				_, err = a.AddGroupingPolicy("viewer", "editor")
+				require.NoError(t, err)
+			},
+			want: true,
+		},
+		{
+			name: "removed policy",
+			change: func(t *testing.T, a *casbin.SyncedCachedEnforcer) {
+				_, err := a.AddPolicy("viewer", "api:/v1/thing", "GET")
+				require.NoError(t, err)
+				_, err = a.RemovePolicy("viewer", "api:/v1/thing", "GET")
+				require.NoError(t, err)
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openDB(t)
+			a := newEnforcer(t, db, newDBWatcher(t, db))
+			b := newEnforcer(t, db, newDBWatcher(t, db))
+			ok, err := b.Enforce("viewer", "api:/v1/thing", "GET")
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			tt.change(t, a)
+			assert.Eventually(t, func() bool {
+				ok, err := b.Enforce("viewer", "api:/v1/thing", "GET")
+				return err == nil && ok == tt.want
+			}, time.Second, pollInterval)
+			if !tt.want {
+				// 结果本来就是拒绝，多等几轮确认 b 没有停在中间状态
+				time.Sleep(5 * pollInterval)
+				ok, err = b.Enforce("viewer", "api:/v1/thing", "GET")
+				require.NoError(t, err)
+				assert.False(t, ok)
+			}
+		})
+	}
+}
+
+func TestDBWatcher_IgnoresOwnUpdate(t *testing.T) {
+	db := openDB(t)
+	a, b := newDBWatcher(t, db), newDBWatcher(t, db)
+	var aCalls, bCalls atomic.Int32
+	require.NoError(t, a.SetUpdateCallback(func(string) { aCalls.Add(1) }))
+	require.NoError(t, b.SetUpdateCallback(func(string) { bCalls.Add(1) }))
+
+	require.NoError(t, a.Update())
+	assert.Eventually(t, func() bool { return bCalls.Load() == 1 }, time.Second, pollInterval)
+	time.Sleep(5 * pollInterval)
+	assert.Zero(t, aCalls.Load())
+	assert.EqualValues(t, 1, bCalls.Load())
+
+	// 关闭后不再轮询
+	b.Close()
+	require.NoError(t, a.Update())
+	time.Sleep(5 * pollInterval)
+	assert.EqualValues(t, 1, bCalls.Load())
+}
@@ -0,0 +1,303 @@
+package jwt_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/jwt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwtv5 "github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKey 一把测试用密钥的私钥和公钥 PEM
+type testKey struct {
+	kid     string
+	alg     string
+	private string
+	public  string
+}
+
+func newEd25519Key(t *testing.T, kid string) testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return newTestKey(t, kid, "EdDSA", priv, pub)
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return newTestKey(t, kid, "RS256", priv, &priv.PublicKey)
+}
+
+func newTestKey(t *testing.T, kid string, alg string, priv any, pub any) testKey {
+	t.Helper()
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return testKey{
+		kid:     kid,
+		alg:     alg,
+		private: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		public:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+	}
+}
+
+func newConf(activeKid string, keys ...map[string]any) *viper.Viper {
+	conf := viper.New()
+	conf.Set("security.jwt.key", "hs256-secret")
+	conf.Set("security.jwt.active_kid", activeKid)
+	list := make([]any, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, key)
+	}
+	conf.Set("security.jwt.keys", list)
+	return conf
+}
+
+func signing(key testKey) map[string]any {
+	return map[string]any{"kid": key.kid, "alg": key.alg, "private_key": key.private}
+}
+
+func verifyOnly(key testKey) map[string]any {
+	return map[string]any{"kid": key.kid, "alg": key.alg, "public_key": key.public}
+}
+
+func genToken(t *testing.T, j *jwt.JWT) string {
+	t.Helper()
+	token, err := j.GenToken(&model.User{UserId: "u1"}, "token-id", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return token
+}
+
+// headerKid 读取 token 头部的 kid，不验签
+func headerKid(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwtv5.NewParser().ParseUnverified(token, &jwt.MyCustomClaims{})
+	require.NoError(t, err)
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestJWT_KidSelection(t *testing.T) {
+	ed1, ed2, rsa1 := newEd25519Key(t, "ed-1"), newEd25519Key(t, "ed-2"), newRSAKey(t, "rsa-1")
+	tests := []struct {
+		name      string
+		conf      *viper.Viper
+		wantKid   string
+		wantAlg   string
+		wantPanic bool
+	}{
+		{
+			name:    "eddsa active key",
+			conf:    newConf("ed-2", signing(ed1), signing(ed2)),
+			wantKid: "ed-2",
+			wantAlg: "EdDSA",
+		},
+		{
+			name:    "rsa active key",
+			conf:    newConf("rsa-1", signing(ed1), signing(rsa1)),
+			wantKid: "rsa-1",
+			wantAlg: "RS256",
+		},
+		{
+			name:    "no keys falls back to hs256 without kid",
+			conf:    newConf(""),
+			wantAlg: "HS256",
+		},
+		{
+			name:      "active kid not configured",
+			conf:      newConf("missing", signing(ed1)),
+			wantPanic: true,
+		},
+		{
+			name:      "active key is verify only",
+			conf:      newConf("ed-1", verifyOnly(ed1)),
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantPanic {
+				assert.Panics(t, func() { jwt.NewJwt(tt.conf, nil) })
+				return
+			}
+			j := jwt.NewJwt(tt.conf, nil)
+			token := genToken(t, j)
+			assert.Equal(t, tt.wantKid, headerKid(t, token))
+			parsed, _, err := jwtv5.NewParser().ParseUnverified(token, &jwt.MyCustomClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, parsed.Method.Alg())
+
+			claims, err := j.ParseToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "u1", claims.UserId)
+		})
+	}
+}
+
+func TestJWT_Rotation(t *testing.T) {
+	oldKey, newKey, unknown := newEd25519Key(t, "key-1"), newRSAKey(t, "key-2"), newEd25519Key(t, "key-1")
+	before := jwt.NewJwt(newConf("key-1", signing(oldKey)), nil)
+	oldToken := genToken(t, before)
+
+	tests := []struct {
+		name    string
+		conf    *viper.Viper
+		token   func(t *testing.T, j *jwt.JWT) string
+		wantErr bool
+	}{
+		{
+			name:  "old kid kept as verify-only still verifies",
+			conf:  newConf("key-2", signing(newKey), verifyOnly(oldKey)),
+			token: func(t *testing.T, j *jwt.JWT) string { return oldToken },
+		},
+		{
+			name: "new tokens use the new kid",
+			conf: newConf("key-2", signing(newKey), verifyOnly(oldKey)),
+			token: func(t *testing.T, j *jwt.JWT) string {
+				token := genToken(t, j)
+				assert.Equal(t, "key-2", headerKid(t, token))
+				return token
+			},
+		},
+		{
+			name:    "old kid removed after rotation",
+			conf:    newConf("key-2", signing(newKey)),
+			token:   func(t *testing.T, j *jwt.JWT) string { return oldToken },
+			wantErr: true,
+		},
+		{
+			name: "same kid signed by another key",
+			conf: newConf("key-2", signing(newKey), verifyOnly(oldKey)),
+			token: func(t *testing.T, j *jwt.JWT) string {
+				return genToken(t, jwt.NewJwt(newConf("key-1", signing(unknown)), nil))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := jwt.NewJwt(tt.conf, nil)
+			claims, err := j.ParseToken(tt.token(t, j))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "u1", claims.UserId)
+		})
+	}
+}
+
+func TestJWT_JWKS(t *testing.T) {
+	a, b, c := newEd25519Key(t, "a"), newRSAKey(t, "b"), newEd25519Key(t, "c")
+	tests := []struct {
+		name     string
+		conf     *viper.Viper
+		wantKids []string
+	}{
+		{
+			name:     "active key first, others sorted by kid",
+			conf:     newConf("b", signing(c), verifyOnly(a), signing(b)),
+			wantKids: []string{"b", "a", "c"},
+		},
+		{
+			name:     "active key already first in sort order",
+			conf:     newConf("a", signing(c), signing(a), verifyOnly(b)),
+			wantKids: []string{"a", "b", "c"},
+		},
+		{
+			name:     "hs256 publishes no keys",
+			conf:     newConf(""),
+			wantKids: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := jwt.NewJwt(tt.conf, nil).JWKS()
+			kids := make([]string, 0, len(set.Keys))
+			for _, key := range set.Keys {
+				kids = append(kids, key.Kid)
+				assert.Equal(t, "sig", key.Use)
+				switch key.Alg {
+				case "RS256":
+					assert.Equal(t, "RSA", key.Kty)
+					assert.NotEmpty(t, key.N)
+					assert.NotEmpty(t, key.E)
+				case "EdDSA":
+					assert.Equal(t, "OKP", key.Kty)
+					assert.Equal(t, "Ed25519", key.Crv)
+					assert.NotEmpty(t, key.X)
+				default:
+					t.Fatalf("unexpected alg %s", key.Alg)
+				}
+			}
+			assert.Equal(t, tt.wantKids, kids)
+		})
+	}
+}
+
+func TestJWT_KeySources(t *testing.T) {
+	key := newEd25519Key(t, "ed-1")
+	file := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(file, []byte(key.private), 0o600))
+	t.Setenv("JWT_TEST_PRIVATE_KEY", key.private)
+
+	tests := []struct {
+		name      string
+		key       map[string]any
+		wantPanic bool
+	}{
+		{
+			name: "private key from env",
+			key:  map[string]any{"kid": "ed-1", "alg": "EdDSA", "private_key_env": "JWT_TEST_PRIVATE_KEY"},
+		},
+		{
+			name: "private key from file",
+			key:  map[string]any{"kid": "ed-1", "alg": "EdDSA", "private_key_file": file},
+		},
+		{
+			name:      "env not set",
+			key:       map[string]any{"kid": "ed-1", "alg": "EdDSA", "private_key_env": "JWT_TEST_UNSET_KEY"},
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := newConf("ed-1", tt.key)
+			if tt.wantPanic {
+				assert.Panics(t, func() { jwt.NewJwt(conf, nil) })
+				return
+			}
+			j := jwt.NewJwt(conf, nil)
+			token := genToken(t, j)
+			assert.Equal(t, "ed-1", headerKid(t, token))
+			// 与内联配置同一把私钥签发的 token 互相可验
+			inline := jwt.NewJwt(newConf("ed-1", signing(key)), nil)
+			_, err := inline.ParseToken(token)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestJWT_CommittedConfigHasNoPrivateKey(t *testing.T) {
+	for _, name := range []string{"local.yml", "prod.yml"} {
+		data, err := os.ReadFile(filepath.Join("../../../config", name))
+		require.NoError(t, err)
+		assert.False(t, strings.Contains(string(data), "BEGIN PRIVATE KEY"), name)
+	}
+}
@@ -0,0 +1,114 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-nunu/pkg/oauth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWechatServer 模拟微信 token 和 userinfo 接口
+func newWechatServer(t *testing.T, token map[string]any, user map[string]any) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(token)
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if user == nil {
+			t.Error("userinfo should not be called")
+		}
+		_ = json.NewEncoder(w).Encode(user)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newWechatProvider(t *testing.T, srv *httptest.Server, unionId bool) oauth.Provider {
+	t.Helper()
+	conf := viper.New()
+	conf.Set("oauth.providers", []any{map[string]any{
+		"name":         "wechat",
+		"type":         "wechat",
+		"client_id":    "wx-app",
+		"token_url":    srv.URL + "/token",
+		"userinfo_url": srv.URL + "/userinfo",
+		"union_id":     unionId,
+	}})
+	p, ok := oauth.NewRegistry(conf).Get("wechat")
+	require.True(t, ok)
+	return p
+}
+
+func TestWechatProvider_Exchange(t *testing.T) {
+	tests := []struct {
+		name        string
+		unionId     bool
+		token       map[string]any
+		user        map[string]any
+		wantSubject string
+		wantErr     bool
+	}{
+		{
+			name:        "openid keyed by appid",
+			token:       map[string]any{"access_token": "at", "openid": "open-1", "scope": "snsapi_login"},
+			user:        map[string]any{"nickname": "wx"},
+			wantSubject: "wx-app:open-1",
+		},
+		{
+			name:        "unionid ignored unless configured",
+			token:       map[string]any{"access_token": "at", "openid": "open-1", "unionid": "union-1", "scope": "snsapi_login"},
+			user:        map[string]any{"nickname": "wx", "unionid": "union-1"},
+			wantSubject: "wx-app:open-1",
+		},
+		{
+			name:        "unionid from userinfo",
+			unionId:     true,
+			token:       map[string]any{"access_token": "at", "openid": "open-1", "scope": "snsapi_login"},
+			user:        map[string]any{"nickname": "wx", "unionid": "union-1"},
+			wantSubject: "union-1",
+		},
+		{
+			name:        "snsapi_base uses unionid from token",
+			unionId:     true,
+			token:       map[string]any{"access_token": "at", "openid": "open-1", "unionid": "union-1", "scope": "snsapi_base"},
+			wantSubject: "union-1",
+		},
+		{
+			name:    "unionid configured but missing",
+			unionId: true,
+			token:   map[string]any{"access_token": "at", "openid": "open-1", "scope": "snsapi_login"},
+			user:    map[string]any{"nickname": "wx"},
+			wantErr: true,
+		},
+		{
+			name:    "no openid and no unionid",
+			token:   map[string]any{"access_token": "at", "scope": "snsapi_login"},
+			wantErr: true,
+		},
+		{
+			name:    "wechat error code",
+			token:   map[string]any{"errcode": 40029, "errmsg": "invalid code"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newWechatProvider(t, newWechatServer(t, tt.token, tt.user), tt.unionId)
+			identity, err := p.Exchange(context.Background(), "code", "", "")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSubject, identity.Subject)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go-nunu/internal/handler"
 	"go-nunu/internal/middleware"
+	"go-nunu/internal/model"
 	"go-nunu/pkg/config"
 	jwt2 "go-nunu/pkg/jwt"
 	"go-nunu/pkg/log"
@@ -44,7 +45,7 @@ func TestMain(m *testing.M) {
 	logger = log.NewLog(conf)
 	hdl = handler.NewHandler(logger)
 
-	jwt = jwt2.NewJwt(conf)
+	jwt = jwt2.NewJwt(conf, nil)
 	gin.SetMode(gin.TestMode)
 	router = gin.Default()
 	router.Use(
@@ -68,7 +69,7 @@ func performRequest(r http.Handler, method, path string, body *bytes.Buffer) *ht
 }
 
 func genToken(t *testing.T) string {
-	token, err := jwt.GenToken(userId, time.Now().Add(time.Hour*24*90))
+	token, err := jwt.GenToken(&model.User{UserId: userId}, "test-token", time.Now().Add(time.Hour*24*90))
 	if err != nil {
 		t.Error(err)
 		return token
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/handler"
+	"go-nunu/test/mocks/service"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestUserBulkHandler_ExportUsers(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		wantStatus  int
+		wantType    string
+		wantExports bool
+	}{
+		{name: "default csv", wantStatus: http.StatusOK, wantType: "text/csv; charset=utf-8", wantExports: true},
+		{name: "xlsx", format: "xlsx", wantStatus: http.StatusOK, wantType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", wantExports: true},
+		{name: "unknown format", format: "pdf", wantStatus: http.StatusBadRequest, wantType: "application/json; charset=utf-8"},
+		{name: "upper case", format: "CSV", wantStatus: http.StatusBadRequest, wantType: "application/json; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockBulkService := mock_service.NewMockUserBulkService(ctrl)
+			if tt.wantExports {
+				mockBulkService.EXPECT().ExportUsers(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, req *v1.ExportUsersRequest, w io.Writer) error {
+						_, err := io.WriteString(w, "user_id,email\n")
+						return err
+					})
+			}
+
+			bulkHandler := handler.NewUserBulkHandler(hdl, mockBulkService)
+			path := "/user/export/" + tt.name
+			router.GET(path, bulkHandler.ExportUsers)
+
+			req := newHttpExcept(t, router).GET(path)
+			if tt.format != "" {
+				req = req.WithQuery("format", tt.format)
+			}
+			resp := req.Expect().Status(tt.wantStatus)
+			resp.Header("Content-Type").IsEqual(tt.wantType)
+			if !tt.wantExports {
+				resp.Header("Content-Disposition").IsEmpty()
+				resp.JSON().Object().Value("code").IsEqual(400)
+			}
+		})
+	}
+}
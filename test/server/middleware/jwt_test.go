@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-nunu/internal/middleware"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// service / repository 拿到的是 *gin.Context，claims 需要通过请求的 context 传下去
+func TestStrictAuth_ClaimsInContext(t *testing.T) {
+	conf := viper.New()
+	conf.Set("security.jwt.key", "test-secret")
+	j := jwt.NewJwt(conf, nil)
+	token, err := j.GenToken(&model.User{UserId: "u1"}, "token-id", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		fallback bool
+		want     string
+	}{
+		{name: "with context fallback", fallback: true, want: "u1"},
+		{name: "without context fallback", fallback: false, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.ContextWithFallback = tt.fallback
+			var got string
+			r.GET("/me", middleware.StrictAuth(j, &log.Logger{Logger: zap.NewNop()}), func(ctx *gin.Context) {
+				if claims, ok := jwt.ClaimsFromContext(ctx); ok {
+					got = claims.UserId
+				}
+				ctx.Status(http.StatusOK)
+			})
+
+			resp := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(resp, req)
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
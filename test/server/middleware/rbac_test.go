@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-nunu/internal/middleware"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/log"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func newEnforcer(t *testing.T) *casbin.SyncedCachedEnforcer {
+	e, err := casbin.NewSyncedCachedEnforcer("../../../config/model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := [][]string{
+		{"dev", "api:/v1/user/:id", "GET"},
+		{"dev", "api:/v1/role/*", "GET"},
+		{"ops", "api:/v1/user/:id/sessions", "*"},
+	}
+	if _, err = e.AddPolicies(rules); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.AddRoleForUser(model.UserSubject("dev-uid"), "dev"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = e.AddRoleForUser(model.UserSubject("ops-uid"), "ops"); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func newRouter(e *casbin.SyncedCachedEnforcer, userId string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(ctx *gin.Context) {
+		ctx.Set("claims", &jwt.MyCustomClaims{UserId: userId})
+	}, middleware.AuthMiddleware(e, &log.Logger{Logger: zap.NewNop()}))
+	ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+	r.GET("/v1/user/:id", ok)
+	r.PUT("/v1/user/:id", ok)
+	r.GET("/v1/user/:id/sessions", ok)
+	r.DELETE("/v1/user/:id/sessions", ok)
+	r.GET("/v1/role/list", ok)
+	r.GET("/v1/role/:id/permissions", ok)
+	r.POST("/v1/role/list", ok)
+	r.GET("/v1/permission/list", ok)
+	return r
+}
+
+func TestAuthMiddleware_PathPatterns(t *testing.T) {
+	e := newEnforcer(t)
+	tests := []struct {
+		name   string
+		userId string
+		method string
+		path   string
+		want   int
+	}{
+		{"param matches one segment", "dev-uid", http.MethodGet, "/v1/user/42", http.StatusOK},
+		{"param does not match deeper path", "dev-uid", http.MethodGet, "/v1/user/42/sessions", http.StatusForbidden},
+		{"param does not grant other methods", "dev-uid", http.MethodPut, "/v1/user/42", http.StatusForbidden},
+		{"wildcard matches direct child", "dev-uid", http.MethodGet, "/v1/role/list", http.StatusOK},
+		{"wildcard matches nested path", "dev-uid", http.MethodGet, "/v1/role/7/permissions", http.StatusOK},
+		{"wildcard does not grant other methods", "dev-uid", http.MethodPost, "/v1/role/list", http.StatusForbidden},
+		{"unrelated path is denied", "dev-uid", http.MethodGet, "/v1/permission/list", http.StatusForbidden},
+		{"method wildcard allows GET", "ops-uid", http.MethodGet, "/v1/user/42/sessions", http.StatusOK},
+		{"method wildcard allows DELETE", "ops-uid", http.MethodDelete, "/v1/user/42/sessions", http.StatusOK},
+		{"method wildcard keeps the path check", "ops-uid", http.MethodGet, "/v1/user/42", http.StatusForbidden},
+		{"user without roles is denied", "nobody", http.MethodGet, "/v1/user/42", http.StatusForbidden},
+		{"super admin bypasses policies", model.AdminUserID, http.MethodGet, "/v1/permission/list", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			newRouter(e, tt.userId).ServeHTTP(resp, req)
+			assert.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_ApiKeyScopes(t *testing.T) {
+	e := newEnforcer(t)
+	tests := []struct {
+		name   string
+		userId string
+		scopes string
+		method string
+		path   string
+		want   int
+	}{
+		// 在 scope 内且用户有权限
+		{"in scope and allowed by role", "dev-uid", "GET /v1/role/*", http.MethodGet, "/v1/role/list", http.StatusOK},
+		// 用户有权限但不在 key 的 scope 内
+		{"allowed by role but out of scope", "dev-uid", "GET /v1/role/*", http.MethodGet, "/v1/user/42", http.StatusForbidden},
+		// scope 不能扩大用户自身的权限
+		{"in scope but not allowed by role", "dev-uid", "* /v1/*", http.MethodPut, "/v1/user/42", http.StatusForbidden},
+		{"scope method mismatch", "dev-uid", "POST /v1/user/:id", http.MethodGet, "/v1/user/42", http.StatusForbidden},
+		{"scope method wildcard", "dev-uid", "* /v1/user/:id", http.MethodGet, "/v1/user/42", http.StatusOK},
+		{"scope param matches one segment", "ops-uid", "* /v1/user/:id", http.MethodGet, "/v1/user/42/sessions", http.StatusForbidden},
+		{"any of several scopes", "ops-uid", "GET /v1/role/*, DELETE /v1/user/:id/sessions", http.MethodDelete, "/v1/user/42/sessions", http.StatusOK},
+		{"malformed scope is ignored", "dev-uid", "/v1/user/:id", http.MethodGet, "/v1/user/42", http.StatusForbidden},
+		{"empty scopes deny everything", "dev-uid", "", http.MethodGet, "/v1/user/42", http.StatusForbidden},
+		// 超管不受 casbin 策略限制，但 key 的 scope 仍然生效
+		{"super admin key in scope", model.AdminUserID, "GET /v1/permission/*", http.MethodGet, "/v1/permission/list", http.StatusOK},
+		{"super admin key out of scope", model.AdminUserID, "GET /v1/role/*", http.MethodGet, "/v1/permission/list", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &model.ApiKey{UserId: tt.userId, Scopes: tt.scopes}
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(func(ctx *gin.Context) {
+				ctx.Set("claims", &jwt.MyCustomClaims{UserId: key.UserId, Scope: jwt.ScopeApiKey})
+				ctx.Set("apiKey", key)
+			}, middleware.AuthMiddleware(e, &log.Logger{Logger: zap.NewNop()}))
+			ok := func(ctx *gin.Context) { ctx.Status(http.StatusOK) }
+			r.GET("/v1/role/list", ok)
+			r.GET("/v1/user/:id", ok)
+			r.PUT("/v1/user/:id", ok)
+			r.GET("/v1/user/:id/sessions", ok)
+			r.DELETE("/v1/user/:id/sessions", ok)
+			r.GET("/v1/permission/list", ok)
+
+			resp := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			r.ServeHTTP(resp, req)
+			assert.Equal(t, tt.want, resp.Code)
+		})
+	}
+}
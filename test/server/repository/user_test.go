@@ -6,9 +6,10 @@ import (
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"go-nunu/internal/model"
 	"go-nunu/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -32,7 +33,7 @@ func setupRepository(t *testing.T) (repository.UserRepository, sqlmock.Sqlmock)
 
 	//rdb, _ := redismock.NewClientMock()
 
-	repo := repository.NewRepository(logger, db)
+	repo := repository.NewRepository(logger, db, nil)
 	userRepo := repository.NewUserRepository(repo)
 
 	return userRepo, mock
@@ -43,18 +44,15 @@ func TestUserRepository_Create(t *testing.T) {
 
 	ctx := context.Background()
 	user := &model.User{
-		Id:        1,
+		BaseModel: model.BaseModel{ID: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()},
 		UserId:    "123",
-		Nickname:  "Test",
+		Name:      "Test",
 		Password:  "password",
 		Email:     "test@example.com",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
 	}
 
 	mock.ExpectBegin()
 	mock.ExpectExec("INSERT INTO `users`").
-		WithArgs(user.UserId, user.Nickname, user.Password, user.Email, user.CreatedAt, user.UpdatedAt, user.DeletedAt, user.Id).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
@@ -69,13 +67,11 @@ func TestUserRepository_Update(t *testing.T) {
 
 	ctx := context.Background()
 	user := &model.User{
-		Id:        1,
+		BaseModel: model.BaseModel{ID: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()},
 		UserId:    "123",
-		Nickname:  "Test",
+		Name:      "Test",
 		Password:  "password",
 		Email:     "test@example.com",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
 	}
 
 	mock.ExpectBegin()
@@ -94,9 +90,11 @@ func TestUserRepository_GetById(t *testing.T) {
 	ctx := context.Background()
 	userId := "123"
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "username", "nickname", "password", "email", "created_at", "updated_at"}).
-		AddRow(1, "123", "test", "Test", "password", "test@example.com", time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "password", "email", "created_at", "updated_at"}).
+		AddRow(1, "123", "Test", "password", "test@example.com", time.Now(), time.Now())
 	mock.ExpectQuery("SELECT \\* FROM `users`").WillReturnRows(rows)
+	// Preload("Roles")
+	mock.ExpectQuery("SELECT \\* FROM `sys_user_roles`").WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}))
 
 	user, err := userRepo.GetByID(ctx, userId)
 	assert.NoError(t, err)
@@ -112,9 +110,11 @@ func TestUserRepository_GetByUsername(t *testing.T) {
 	ctx := context.Background()
 	email := "test@example.com"
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "username", "nickname", "password", "email", "created_at", "updated_at"}).
-		AddRow(1, "123", "test", "Test", "password", "test@example.com", time.Now(), time.Now())
+	rows := sqlmock.NewRows([]string{"id", "user_id", "name", "password", "email", "created_at", "updated_at"}).
+		AddRow(1, "123", "Test", "password", "test@example.com", time.Now(), time.Now())
 	mock.ExpectQuery("SELECT \\* FROM `users`").WillReturnRows(rows)
+	// Preload("Roles")
+	mock.ExpectQuery("SELECT \\* FROM `sys_user_roles`").WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}))
 
 	user, err := userRepo.GetByEmail(ctx, email)
 	assert.NoError(t, err)
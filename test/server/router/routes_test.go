@@ -0,0 +1,83 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/middleware"
+	"go-nunu/internal/router"
+	"go-nunu/pkg/log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestRBACRoutes(t *testing.T) {
+	routes := router.NewRouteSource(viper.New(), &log.Logger{Logger: zap.NewNop()}).RBACRoutes()
+	has := func(method, path string) bool {
+		for _, route := range routes {
+			if route.Method == method && route.Path == path {
+				return true
+			}
+		}
+		return false
+	}
+
+	// 挂载了 AuthMiddleware 的路由
+	assert.True(t, has("POST", "/v1/user/list"))
+	assert.True(t, has("DELETE", "/v1/role/:id"))
+	assert.True(t, has("GET", "/v1/permission/tree"))
+	assert.Contains(t, routes, v1.ApiRoute{Method: "PUT", Path: "/v1/role/info", Handler: "UpdateRole"})
+
+	// 公开接口和只需要登录的接口不参与 RBAC
+	assert.False(t, has("POST", "/v1/login"))
+	assert.False(t, has("POST", "/v1/app/login"))
+	assert.False(t, has("GET", "/v1/api-keys"))
+	assert.False(t, has("GET", "/v1/menus/mine"))
+	assert.False(t, has("GET", "/swagger/*any"))
+}
+
+func TestRouteTable_Use(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	table := router.NewRouteTable()
+	engine := gin.New()
+	v1Group := engine.Group("/v1")
+
+	var served int
+	handler := func(ctx *gin.Context) {
+		served++
+		ctx.Status(http.StatusNoContent)
+	}
+	v1Group.GET("/open", handler)
+	// 没有 AuthMiddleware 的组只登记不记录
+	table.Use(v1Group.Group("/menus"), gin.Recovery()).GET("/mine", handler)
+	guarded := table.Use(v1Group.Group("/thing"), middleware.AuthMiddleware(nil, nil))
+	guarded.GET("/:id", handler)
+	guarded.Match([]string{http.MethodPut, http.MethodDelete}, "/", handler)
+	// 在单个路由上挂载也能识别
+	table.Use(v1Group.Group("/report")).POST("/export", middleware.AuthMiddleware(nil, nil), handler)
+
+	assert.Equal(t, []v1.ApiRoute{
+		{Method: http.MethodPost, Path: "/v1/report/export", Handler: "func1"},
+		{Method: http.MethodDelete, Path: "/v1/thing/", Handler: "func1"},
+		{Method: http.MethodPut, Path: "/v1/thing/", Handler: "func1"},
+		{Method: http.MethodGet, Path: "/v1/thing/:id", Handler: "func1"},
+	}, table.RBACRoutes())
+	// 记录时不执行任何中间件和 handler
+	assert.Zero(t, served)
+
+	// 记录的路由照常注册到 engine
+	for _, path := range []string{"/v1/open", "/v1/menus/mine"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNoContent, w.Code, path)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/thing/1", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 2, served)
+}
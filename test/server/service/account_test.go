@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name string
+		// token 返回用于重置的令牌，可在其中先消费一次
+		token   func(t *testing.T, env *testEnv, raw string) string
+		wantErr error
+	}{
+		{
+			name:  "valid link",
+			token: func(t *testing.T, env *testEnv, raw string) string { return raw },
+		},
+		{
+			name: "link used twice",
+			token: func(t *testing.T, env *testEnv, raw string) string {
+				require.NoError(t, env.accountService.ResetPassword(context.Background(), &v1.ResetPasswordRequest{Token: raw, Password: "first-password"}))
+				return raw
+			},
+			wantErr: v1.ErrInvalidLink,
+		},
+		{
+			name:    "unknown link",
+			token:   func(t *testing.T, env *testEnv, raw string) string { return "not-a-token" },
+			wantErr: v1.ErrInvalidLink,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			user := env.createUser(t, model.User{Email: "reset@example.com"})
+			session, err := env.tokenService.IssueTokens(ctx, user, testClient(), model.LoginTypeEmail)
+			require.NoError(t, err)
+
+			require.NoError(t, env.accountService.ForgotPassword(ctx, &v1.ForgotPasswordRequest{Email: user.Email}))
+			require.Equal(t, 1, env.mail.sentTo(user.Email))
+
+			err = env.accountService.ResetPassword(ctx, &v1.ResetPasswordRequest{
+				Token:    tt.token(t, env, env.mail.lastToken(t)),
+				Password: "new-password",
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			updated, err := env.userRepo.GetByID(ctx, user.UserId)
+			require.NoError(t, err)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(updated.Password), []byte("new-password")))
+			// 重置密码后旧会话全部失效
+			_, err = env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: session.RefreshToken}, testClient())
+			assert.ErrorIs(t, err, v1.ErrUnauthorized)
+		})
+	}
+}
+
+func TestAccountService_ForgotPassword_UnknownEmail(t *testing.T) {
+	env := newTestEnv(t)
+
+	// 邮箱不存在也返回成功，且不发信
+	require.NoError(t, env.accountService.ForgotPassword(context.Background(), &v1.ForgotPasswordRequest{Email: "nobody@example.com"}))
+	assert.Equal(t, 0, env.mail.sentTo("nobody@example.com"))
+}
+
+func TestAccountService_VerifyEmail(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, model.User{Email: "verify@example.com"})
+
+	require.NoError(t, env.accountService.SendVerifyEmail(ctx, user))
+	require.NoError(t, env.accountService.VerifyEmail(ctx, &v1.VerifyEmailRequest{Token: env.mail.lastToken(t)}))
+
+	updated, err := env.userRepo.GetByID(ctx, user.UserId)
+	require.NoError(t, err)
+	assert.NotZero(t, updated.EmailVerifyTime)
+	// 已验证的邮箱不再发验证邮件
+	require.NoError(t, env.accountService.SendVerifyEmail(ctx, updated))
+	assert.Equal(t, 1, env.mail.sentTo(user.Email))
+}
+
+// lastToken 取最后一封邮件中链接携带的 token
+func (r *mailRecorder) lastToken(t *testing.T) string {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	require.NotEmpty(t, r.sent)
+	body := r.sent[len(r.sent)-1].Body
+	i := strings.Index(body, "?token=")
+	require.GreaterOrEqual(t, i, 0)
+	raw := body[i+len("?token="):]
+	if j := strings.IndexAny(raw, "\n "); j >= 0 {
+		raw = raw[:j]
+	}
+	token, err := url.QueryUnescape(raw)
+	require.NoError(t, err)
+	return token
+}
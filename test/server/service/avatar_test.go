@@ -0,0 +1,238 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	avatarRed  = color.NRGBA{R: 255, A: 255}
+	avatarBlue = color.NRGBA{B: 255, A: 255}
+)
+
+// twoColorImage 左半边红色、右半边蓝色
+func twoColorImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, avatarRed)
+			} else {
+				img.Set(x, y, avatarBlue)
+			}
+		}
+	}
+	return img
+}
+
+// jpegWithOrientation 编码为 jpeg，orientation 大于 0 时在 SOI 之后插入只有 Orientation 标签的 EXIF
+func jpegWithOrientation(t *testing.T, img image.Image, orientation int, order binary.ByteOrder) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	data := buf.Bytes()
+	if orientation == 0 {
+		return data
+	}
+
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.BigEndian {
+		copy(tiff, "MM")
+	} else {
+		copy(tiff, "II")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], 0x0112) // Orientation
+	order.PutUint16(tiff[12:], 3)      // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], uint16(orientation))
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func (env *testEnv) storedImage(t *testing.T, key string) image.Image {
+	t.Helper()
+	body, err := env.storage.GetObject(context.Background(), key)
+	require.NoError(t, err)
+	defer body.Close()
+	img, _, err := image.Decode(body)
+	require.NoError(t, err)
+	return img
+}
+
+// isColor 允许 jpeg 压缩带来的误差
+func isColor(c color.Color, want color.NRGBA) bool {
+	r, g, b, _ := c.RGBA()
+	near := func(v uint32, w uint8) bool {
+		d := int(v>>8) - int(w)
+		return d > -40 && d < 40
+	}
+	return near(r, want.R) && near(g, want.G) && near(b, want.B)
+}
+
+func TestAvatarService_ProcessAvatar_Orientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation int
+		order       binary.ByteOrder
+		wantW       int
+		wantH       int
+		// 摆正后左上角、右下角的颜色
+		wantTopLeft     color.NRGBA
+		wantBottomRight color.NRGBA
+	}{
+		{name: "no exif", wantW: 40, wantH: 20, wantTopLeft: avatarRed, wantBottomRight: avatarBlue},
+		{name: "normal", orientation: 1, order: binary.LittleEndian, wantW: 40, wantH: 20, wantTopLeft: avatarRed, wantBottomRight: avatarBlue},
+		{name: "mirrored", orientation: 2, order: binary.LittleEndian, wantW: 40, wantH: 20, wantTopLeft: avatarBlue, wantBottomRight: avatarRed},
+		{name: "rotated 180", orientation: 3, order: binary.BigEndian, wantW: 40, wantH: 20, wantTopLeft: avatarBlue, wantBottomRight: avatarRed},
+		{name: "rotated 90 cw", orientation: 6, order: binary.LittleEndian, wantW: 20, wantH: 40, wantTopLeft: avatarRed, wantBottomRight: avatarBlue},
+		{name: "rotated 90 ccw", orientation: 8, order: binary.BigEndian, wantW: 20, wantH: 40, wantTopLeft: avatarBlue, wantBottomRight: avatarRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			upload := "user_profile/user-1/photo.jpg"
+			require.NoError(t, env.storage.PutObject(ctx, upload, jpegWithOrientation(t, twoColorImage(40, 20), tt.orientation, tt.order), "image/jpeg"))
+
+			keys, err := env.avatarService.ProcessAvatar(ctx, "user-1", env.storage.ObjectURL(upload))
+			require.NoError(t, err)
+
+			img := env.storedImage(t, keys[model.AvatarOriginal])
+			b := img.Bounds()
+			assert.Equal(t, tt.wantW, b.Dx())
+			assert.Equal(t, tt.wantH, b.Dy())
+			assert.True(t, isColor(img.At(b.Min.X+2, b.Min.Y+2), tt.wantTopLeft), "top left")
+			assert.True(t, isColor(img.At(b.Max.X-3, b.Max.Y-3), tt.wantBottomRight), "bottom right")
+
+			// 重新编码后不再带有 EXIF，原文件已删除
+			body, err := env.storage.GetObject(ctx, keys[model.AvatarOriginal])
+			require.NoError(t, err)
+			var stored bytes.Buffer
+			_, err = stored.ReadFrom(body)
+			require.NoError(t, err)
+			assert.NotContains(t, stored.String(), "Exif\x00\x00")
+			assert.False(t, env.storage.has(upload))
+		})
+	}
+}
+
+func TestAvatarService_ProcessAvatar_Thumbnails(t *testing.T) {
+	// 配置中 sizes 为 [64, 128, 512]，小图不放大
+	tests := []struct {
+		name      string
+		width     int
+		height    int
+		png       bool
+		wantSizes map[string]int
+		wantExt   string
+	}{
+		{
+			name: "landscape jpeg", width: 600, height: 300,
+			wantSizes: map[string]int{"64": 64, "128": 128, "512": 300},
+			wantExt:   ".jpg",
+		},
+		{
+			name: "small png", width: 100, height: 120, png: true,
+			wantSizes: map[string]int{"64": 64, "128": 100, "512": 100},
+			wantExt:   ".png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			var buf bytes.Buffer
+			if tt.png {
+				require.NoError(t, png.Encode(&buf, twoColorImage(tt.width, tt.height)))
+			} else {
+				require.NoError(t, jpeg.Encode(&buf, twoColorImage(tt.width, tt.height), nil))
+			}
+			require.NoError(t, env.storage.PutObject(ctx, "user_profile/user-1/photo", buf.Bytes(), ""))
+
+			keys, err := env.avatarService.ProcessAvatar(ctx, "user-1", "user_profile/user-1/photo")
+			require.NoError(t, err)
+			assert.Len(t, keys, len(tt.wantSizes)+1)
+
+			original := env.storedImage(t, keys[model.AvatarOriginal])
+			assert.Equal(t, image.Rect(0, 0, tt.width, tt.height), original.Bounds())
+			for name, side := range tt.wantSizes {
+				key, ok := keys[name]
+				require.True(t, ok, name)
+				assert.True(t, strings.HasPrefix(key, "avatar/user-1/"), key)
+				assert.True(t, strings.HasSuffix(key, tt.wantExt), key)
+				img := env.storedImage(t, key)
+				assert.Equal(t, image.Rect(0, 0, side, side), img.Bounds(), name)
+			}
+		})
+	}
+}
+
+func TestAvatarService_ProcessAvatar_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		avatar string
+		// data 为空时不上传文件
+		data []byte
+	}{
+		{name: "outside the upload prefix", avatar: "avatar/other/photo.jpg", data: []byte("x")},
+		{name: "path traversal", avatar: "user_profile/user-1/../user-2/photo.jpg", data: []byte("x")},
+		{name: "uploaded by another user", avatar: "user_profile/user-2/photo.jpg", data: avatarJPEG()},
+		{name: "user id prefix only", avatar: "user_profile/user-10/photo.jpg", data: avatarJPEG()},
+		{name: "outside the user directory", avatar: "user_profile/photo.jpg", data: avatarJPEG()},
+		{name: "nested directory", avatar: "user_profile/user-1/a/photo.jpg", data: avatarJPEG()},
+		{name: "not uploaded", avatar: "user_profile/user-1/missing.jpg"},
+		{name: "not an image", avatar: "user_profile/user-1/file.txt", data: []byte("hello")},
+		{name: "too many pixels", avatar: "user_profile/user-1/wide.png", data: func() []byte {
+			var buf bytes.Buffer
+			_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4097, 1)))
+			return buf.Bytes()
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			if tt.data != nil {
+				require.NoError(t, env.storage.PutObject(ctx, tt.avatar, tt.data, ""))
+			}
+
+			_, err := env.avatarService.ProcessAvatar(ctx, "user-1", tt.avatar)
+			assert.ErrorIs(t, err, v1.ErrAvatarInvalid)
+			// 失败时不写入任何处理后的文件
+			env.storage.mu.Lock()
+			defer env.storage.mu.Unlock()
+			for key := range env.storage.objects {
+				assert.False(t, strings.HasPrefix(key, "avatar/user-1/"), key)
+			}
+		})
+	}
+}
+
+// avatarJPEG 可以正常处理的图片，用于确认被拒绝是因为 key 而不是内容
+func avatarJPEG() []byte {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 8)), nil)
+	return buf.Bytes()
+}
@@ -0,0 +1,145 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/pkg/jwt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 本部门数据权限的管理员只能操作本部门的用户，其他部门的用户按不存在处理
+func TestUserService_DataScope_TwoDepartments(t *testing.T) {
+	const ownDept, otherDept = uint(1), uint(2)
+	tests := []struct {
+		name string
+		// deleted 目标用户需要先注销
+		deleted bool
+		op      func(env *testEnv, ctx context.Context, operator, target *model.User) error
+	}{
+		{
+			name: "update",
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.UpdateUser(ctx, operator.UserId, &v1.UpdateUserRequest{UserId: target.UserId, Name: "renamed"})
+			},
+		},
+		{
+			name: "unlock",
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.UnlockUser(ctx, &v1.UnlockUserRequest{Email: target.Email})
+			},
+		},
+		{
+			name: "disable",
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.DisableUser(ctx, operator.UserId, target.UserId)
+			},
+		},
+		{
+			name: "enable",
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.EnableUser(ctx, target.UserId)
+			},
+		},
+		{
+			name: "deactivate",
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.DeactivateUser(ctx, operator.UserId, target.UserId)
+			},
+		},
+		{
+			name:    "restore",
+			deleted: true,
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.RestoreUser(ctx, operator.UserId, target.UserId)
+			},
+		},
+		{
+			name:    "purge",
+			deleted: true,
+			op: func(env *testEnv, ctx context.Context, operator, target *model.User) error {
+				return env.userService.PurgeUser(ctx, operator.UserId, target.UserId)
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, dept := range []uint{ownDept, otherDept} {
+			name := tt.name + "/own department"
+			var wantErr error
+			if dept == otherDept {
+				name = tt.name + "/other department"
+				wantErr = v1.ErrNotFound
+			}
+			t.Run(name, func(t *testing.T) {
+				env := newTestEnv(t)
+				role := env.createRole(t, "dept-admin", model.DataScopeDept)
+				operator := env.createUser(t, model.User{Email: "operator@example.com", DeptId: ownDept})
+				require.NoError(t, env.userService.UpdateUser(asUser(model.AdminUserID), model.AdminUserID, &v1.UpdateUserRequest{
+					UserId:  operator.UserId,
+					RoleIds: []uint{role.ID},
+				}))
+				target := env.createUser(t, model.User{Email: "target@example.com", DeptId: dept})
+				if tt.deleted {
+					// 超管不受数据权限限制
+					require.NoError(t, env.userService.DeactivateUser(asUser(model.AdminUserID), model.AdminUserID, target.UserId))
+				}
+
+				err := tt.op(env, asUser(operator.UserId), operator, target)
+				assert.ErrorIs(t, err, wantErr)
+			})
+		}
+	}
+}
+
+func TestUserService_GetUserList_DataScope(t *testing.T) {
+	env := newTestEnv(t)
+	role := env.createRole(t, "dept-admin", model.DataScopeDept)
+	operator := env.createUser(t, model.User{Email: "operator@example.com", DeptId: 1})
+	require.NoError(t, env.userService.UpdateUser(asUser(model.AdminUserID), model.AdminUserID, &v1.UpdateUserRequest{UserId: operator.UserId, RoleIds: []uint{role.ID}}))
+	env.createUser(t, model.User{Email: "same@example.com", DeptId: 1})
+	env.createUser(t, model.User{Email: "other@example.com", DeptId: 2})
+
+	data, err := env.userService.GetUserList(asUser(operator.UserId), &v1.GetUserListRequest{})
+	require.NoError(t, err)
+	var emails []string
+	for _, u := range data.List {
+		emails = append(emails, u.Email)
+	}
+	assert.ElementsMatch(t, []string{"operator@example.com", "same@example.com"}, emails)
+	assert.Equal(t, 2, data.Total)
+}
+
+// 没有 claims 的查询直接拒绝，不退化为不过滤
+func TestUserRepository_DataScope_FailClosed(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantErr   error
+		wantTotal int
+	}{
+		{name: "no claims", ctx: context.Background(), wantErr: v1.ErrForbidden},
+		{name: "untyped claims key", ctx: context.WithValue(context.Background(), "claims", &jwt.MyCustomClaims{UserId: model.AdminUserID}), wantErr: v1.ErrForbidden},
+		{name: "explicitly unscoped", ctx: repository.WithoutDataScope(context.Background()), wantTotal: 2},
+		{name: "super admin", ctx: asUser(model.AdminUserID), wantTotal: 2},
+		{name: "user without roles sees only self", ctx: asUser("member-uid"), wantTotal: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.createUser(t, model.User{UserId: "member-uid", Email: "member@example.com"})
+			env.createUser(t, model.User{Email: "other@example.com"})
+
+			_, total, err := env.userRepo.GetUserList(tt.ctx, &v1.GetUserListRequest{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+}
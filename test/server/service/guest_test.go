@@ -0,0 +1,177 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// guestLogin 以设备标识登录访客，返回访客和访客 token
+func (env *testEnv) guestLogin(t *testing.T, udid string) (*model.User, *v1.LoginResponseData) {
+	t.Helper()
+	data, err := env.userService.GuestLogin(context.Background(), &v1.GuestLoginRequest{Udid: udid}, testClient())
+	require.NoError(t, err)
+	claims, err := env.jwt.ParseToken(data.AccessToken)
+	require.NoError(t, err)
+	guest, err := env.userRepo.GetByID(context.Background(), claims.UserId)
+	require.NoError(t, err)
+	require.Equal(t, model.UserTypeGuest, guest.UserType)
+	return guest, data
+}
+
+func (env *testEnv) userExists(t *testing.T, userId string) bool {
+	t.Helper()
+	var count int64
+	require.NoError(t, env.db.Unscoped().Model(&model.User{}).Where("user_id = ?", userId).Count(&count).Error)
+	return count > 0
+}
+
+func TestUserService_UpgradeGuest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  v1.UpgradeGuestRequest
+		// wantUser 签发的 token 属于哪个用户：guest 或 existing
+		wantUser  string
+		wantErr   error
+		wantMerge bool
+	}{
+		{
+			name:     "new email converts the guest",
+			req:      v1.UpgradeGuestRequest{Email: "New@Example.com", Password: "secret"},
+			wantUser: "guest",
+		},
+		{
+			name:      "existing account merges the guest",
+			req:       v1.UpgradeGuestRequest{Email: "member@example.com", Password: "password"},
+			wantUser:  "existing",
+			wantMerge: true,
+		},
+		{
+			name:      "existing account matched ignoring case",
+			req:       v1.UpgradeGuestRequest{Email: "Member@Example.com", Password: "password"},
+			wantUser:  "existing",
+			wantMerge: true,
+		},
+		{
+			name:    "existing account with wrong password",
+			req:     v1.UpgradeGuestRequest{Email: "member@example.com", Password: "wrong"},
+			wantErr: v1.ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			existing := env.createUser(t, model.User{Email: "member@example.com"})
+			guest, guestTokens := env.guestLogin(t, "device-1")
+			require.NoError(t, env.db.Model(&model.User{}).Where("user_id = ?", guest.UserId).Update("gender", 2).Error)
+
+			data, err := env.userService.UpgradeGuest(ctx, guest.UserId, &tt.req, testClient())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, env.userExists(t, guest.UserId))
+				assert.False(t, env.accessTokenRevoked(t, guestTokens.AccessToken))
+				return
+			}
+			require.NoError(t, err)
+			claims, err := env.jwt.ParseToken(data.AccessToken)
+			require.NoError(t, err)
+			// 访客 token 不能继续使用
+			assert.True(t, env.accessTokenRevoked(t, guestTokens.AccessToken))
+
+			if tt.wantUser == "guest" {
+				assert.Equal(t, guest.UserId, claims.UserId)
+				got, err := env.userRepo.GetByID(ctx, guest.UserId)
+				require.NoError(t, err)
+				assert.Equal(t, model.UserTypeRegistered, got.UserType)
+				assert.Equal(t, "new@example.com", got.Email)
+				return
+			}
+			assert.Equal(t, existing.UserId, claims.UserId)
+			assert.Equal(t, !tt.wantMerge, env.userExists(t, guest.UserId))
+			got, err := env.userRepo.GetByID(ctx, existing.UserId)
+			require.NoError(t, err)
+			assert.Equal(t, "device-1", got.Udid)
+			assert.Equal(t, 2, got.Gender)
+		})
+	}
+}
+
+// 目标账号开启了二次验证时，只有二次验证通过后才合并访客
+func TestUserService_UpgradeGuest_Mfa(t *testing.T) {
+	tests := []struct {
+		name string
+		// complete 提交二次验证，返回是否成功
+		complete  func(t *testing.T, env *testEnv, secret, mfaToken string) bool
+		wantMerge bool
+	}{
+		{
+			name: "not completed",
+			complete: func(t *testing.T, env *testEnv, secret, mfaToken string) bool {
+				return false
+			},
+		},
+		{
+			name: "wrong code",
+			complete: func(t *testing.T, env *testEnv, secret, mfaToken string) bool {
+				_, err := env.mfaService.LoginMfa(context.Background(), &v1.LoginMfaRequest{MfaToken: mfaToken, Code: "000000"}, testClient())
+				assert.ErrorIs(t, err, v1.ErrMfaCodeInvalid)
+				return false
+			},
+		},
+		{
+			name: "correct code",
+			complete: func(t *testing.T, env *testEnv, secret, mfaToken string) bool {
+				code, err := totp.Code(secret, totp.Step(time.Now())+1)
+				require.NoError(t, err)
+				data, err := env.mfaService.LoginMfa(context.Background(), &v1.LoginMfaRequest{MfaToken: mfaToken, Code: code}, testClient())
+				require.NoError(t, err)
+				assert.NotEmpty(t, data.AccessToken)
+				return true
+			},
+			wantMerge: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			existing := env.createUser(t, model.User{Email: "mfa@example.com"})
+			secret, _ := env.enableTotp(t, existing.UserId)
+			guest, guestTokens := env.guestLogin(t, "device-1")
+
+			data, err := env.userService.UpgradeGuest(ctx, guest.UserId, &v1.UpgradeGuestRequest{Email: "mfa@example.com", Password: "password"}, testClient())
+			require.NoError(t, err)
+			require.True(t, data.MfaRequired)
+			assert.Empty(t, data.AccessToken)
+			// 只通过了密码校验，访客保持不变
+			assert.True(t, env.userExists(t, guest.UserId))
+			assert.False(t, env.accessTokenRevoked(t, guestTokens.AccessToken))
+
+			tt.complete(t, env, secret, data.MfaToken)
+
+			assert.Equal(t, !tt.wantMerge, env.userExists(t, guest.UserId))
+			got, err := env.userRepo.GetByID(ctx, existing.UserId)
+			require.NoError(t, err)
+			if tt.wantMerge {
+				assert.Equal(t, "device-1", got.Udid)
+			} else {
+				assert.Empty(t, got.Udid)
+			}
+		})
+	}
+}
+
+func TestUserService_UpgradeGuest_NotGuest(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, model.User{Email: "member@example.com"})
+
+	_, err := env.userService.UpgradeGuest(context.Background(), user.UserId, &v1.UpgradeGuestRequest{Email: "other@example.com", Password: "password"}, testClient())
+	assert.ErrorIs(t, err, v1.ErrBadRequest)
+}
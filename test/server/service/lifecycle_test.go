@@ -0,0 +1,255 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_Lifecycle(t *testing.T) {
+	const operator = "operator-uid"
+	var (
+		disable = func(env *testEnv, userId string) error {
+			return env.userService.DisableUser(asUser(model.AdminUserID), operator, userId)
+		}
+		enable = func(env *testEnv, userId string) error {
+			return env.userService.EnableUser(asUser(model.AdminUserID), userId)
+		}
+		deactivate = func(env *testEnv, userId string) error {
+			return env.userService.DeactivateUser(asUser(model.AdminUserID), operator, userId)
+		}
+		restore = func(env *testEnv, userId string) error {
+			return env.userService.RestoreUser(asUser(model.AdminUserID), operator, userId)
+		}
+		purge = func(env *testEnv, userId string) error {
+			return env.userService.PurgeUser(asUser(model.AdminUserID), operator, userId)
+		}
+	)
+	tests := []struct {
+		name        string
+		steps       []func(env *testEnv, userId string) error
+		wantStatus  int  // 0 表示记录已被永久删除
+		wantDeleted bool // 软删除
+		wantRevoked bool // 之前签发的令牌是否失效
+		wantRole    bool // Casbin 中是否保留 g 规则
+		wantLogin   error
+	}{
+		{
+			name:        "disable",
+			steps:       []func(env *testEnv, userId string) error{disable},
+			wantStatus:  model.UserStatusDisabled,
+			wantRevoked: true,
+			wantRole:    true,
+			wantLogin:   v1.ErrUserDisabled,
+		},
+		{
+			name:        "disable twice",
+			steps:       []func(env *testEnv, userId string) error{disable, disable},
+			wantStatus:  model.UserStatusDisabled,
+			wantRevoked: true,
+			wantRole:    true,
+			wantLogin:   v1.ErrUserDisabled,
+		},
+		{
+			// 启用后需要重新登录，旧令牌不会恢复
+			name:        "disable then enable",
+			steps:       []func(env *testEnv, userId string) error{disable, enable},
+			wantStatus:  model.UserStatusNormal,
+			wantRevoked: true,
+			wantRole:    true,
+		},
+		{
+			name:       "enable normal user",
+			steps:      []func(env *testEnv, userId string) error{enable},
+			wantStatus: model.UserStatusNormal,
+			wantRole:   true,
+		},
+		{
+			name:        "deactivate",
+			steps:       []func(env *testEnv, userId string) error{deactivate},
+			wantStatus:  model.UserStatusDeactivated,
+			wantDeleted: true,
+			wantRevoked: true,
+			wantLogin:   v1.ErrUnauthorized,
+		},
+		{
+			name:        "deactivate disabled user",
+			steps:       []func(env *testEnv, userId string) error{disable, deactivate},
+			wantStatus:  model.UserStatusDeactivated,
+			wantDeleted: true,
+			wantRevoked: true,
+			wantLogin:   v1.ErrUnauthorized,
+		},
+		{
+			name:        "deactivate then restore",
+			steps:       []func(env *testEnv, userId string) error{deactivate, restore},
+			wantStatus:  model.UserStatusNormal,
+			wantRevoked: true,
+			wantRole:    true,
+		},
+		{
+			name:        "deactivate then purge",
+			steps:       []func(env *testEnv, userId string) error{deactivate, purge},
+			wantRevoked: true,
+			wantLogin:   v1.ErrUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			role := env.createRole(t, "member", model.DataScopeAll)
+			user := env.createUser(t, model.User{Email: "member@example.com", EmailVerifyTime: 1})
+			require.NoError(t, env.db.Model(user).Association("Roles").Append(role))
+			_, err := env.casbin.AddRoleForUser(model.UserSubject(user.UserId), role.Sid)
+			require.NoError(t, err)
+			tokens, err := env.tokenService.IssueTokens(ctx, user, testClient(), model.LoginTypeEmail)
+			require.NoError(t, err)
+
+			for _, step := range tt.steps {
+				require.NoError(t, step(env, user.UserId))
+			}
+
+			var got model.User
+			err = env.db.Unscoped().Where("user_id = ?", user.UserId).First(&got).Error
+			if tt.wantStatus == 0 {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantStatus, got.Status)
+				assert.Equal(t, tt.wantDeleted, got.DeletedAt.Valid)
+			}
+
+			assert.Equal(t, tt.wantRevoked, env.accessTokenRevoked(t, tokens.AccessToken))
+			_, err = env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, testClient())
+			if tt.wantRevoked {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			hasRole, err := env.casbin.HasGroupingPolicy(model.UserSubject(user.UserId), role.Sid)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRole, hasRole)
+
+			_, err = env.userService.Login(ctx, &v1.LoginRequest{Email: user.Email, Password: "password"}, testClient())
+			if tt.wantLogin != nil {
+				assert.ErrorIs(t, err, tt.wantLogin)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// 不能禁用、注销、恢复、删除自己和超管
+func TestUserService_Lifecycle_Unmanageable(t *testing.T) {
+	const operator = "operator-uid"
+	ops := []struct {
+		name    string
+		deleted bool // 操作对象为已注销的用户
+		op      func(env *testEnv, userId string) error
+	}{
+		{
+			name: "disable",
+			op: func(env *testEnv, userId string) error {
+				return env.userService.DisableUser(asUser(operator), operator, userId)
+			},
+		},
+		{
+			name: "deactivate",
+			op: func(env *testEnv, userId string) error {
+				return env.userService.DeactivateUser(asUser(operator), operator, userId)
+			},
+		},
+		{
+			name:    "restore",
+			deleted: true,
+			op: func(env *testEnv, userId string) error {
+				return env.userService.RestoreUser(asUser(operator), operator, userId)
+			},
+		},
+		{
+			name:    "purge",
+			deleted: true,
+			op: func(env *testEnv, userId string) error {
+				return env.userService.PurgeUser(asUser(operator), operator, userId)
+			},
+		},
+	}
+	for _, tt := range ops {
+		for _, target := range []string{operator, model.AdminUserID} {
+			t.Run(tt.name+" "+target, func(t *testing.T) {
+				env := newTestEnv(t)
+				user := env.createUser(t, model.User{UserId: target, Email: target + "@example.com"})
+				if tt.deleted {
+					require.NoError(t, env.db.Delete(user).Error)
+				}
+
+				assert.ErrorIs(t, tt.op(env, target), v1.ErrForbidden)
+
+				var got model.User
+				require.NoError(t, env.db.Unscoped().Where("user_id = ?", user.UserId).First(&got).Error)
+				assert.Equal(t, model.UserStatusNormal, got.Status)
+				assert.Equal(t, tt.deleted, got.DeletedAt.Valid)
+			})
+		}
+	}
+}
+
+func TestUserService_RestoreUser_Email(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		// setup 在用户注销期间创建的其他用户
+		setup   func(t *testing.T, env *testEnv)
+		wantErr error
+	}{
+		{
+			name:  "email still free",
+			email: "restore@example.com",
+			setup: func(t *testing.T, env *testEnv) {},
+		},
+		{
+			name:  "email registered again",
+			email: "restore@example.com",
+			setup: func(t *testing.T, env *testEnv) {
+				env.createUser(t, model.User{Email: "Restore@Example.com"})
+			},
+			wantErr: v1.ErrEmailAlreadyUse,
+		},
+		{
+			// 访客的邮箱为空，不能被当成邮箱冲突
+			name:  "empty email with guests",
+			email: "",
+			setup: func(t *testing.T, env *testEnv) {
+				env.createUser(t, model.User{UserType: model.UserTypeGuest, Udid: "device-1"})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := asUser(model.AdminUserID)
+			user := env.createUser(t, model.User{Email: tt.email})
+			require.NoError(t, env.userService.DeactivateUser(ctx, model.AdminUserID, user.UserId))
+			tt.setup(t, env)
+
+			err := env.userService.RestoreUser(ctx, model.AdminUserID, user.UserId)
+			var got model.User
+			require.NoError(t, env.db.Unscoped().Where("user_id = ?", user.UserId).First(&got).Error)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, got.DeletedAt.Valid)
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, got.DeletedAt.Valid)
+			assert.Equal(t, model.UserStatusNormal, got.Status)
+		})
+	}
+}
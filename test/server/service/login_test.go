@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_Login_Lockout(t *testing.T) {
+	// 配置中 max_account_failures 为 5，max_ip_failures 为 50
+	const maxFailures = 5
+	tests := []struct {
+		name     string
+		failures int
+		// after 在失败之后、正确密码登录之前执行
+		after   func(t *testing.T, env *testEnv)
+		client  *model.ClientInfo
+		wantErr error
+	}{
+		{
+			name:     "below threshold",
+			failures: maxFailures - 1,
+		},
+		{
+			name:     "locked at threshold",
+			failures: maxFailures,
+			wantErr:  v1.ErrUnauthorized,
+		},
+		{
+			name:     "lock is per account, not per client",
+			failures: maxFailures,
+			client:   &model.ClientInfo{IP: "10.0.0.9", UserAgent: "go-test"},
+			wantErr:  v1.ErrUnauthorized,
+		},
+		{
+			name:     "unlocked by admin",
+			failures: maxFailures,
+			after: func(t *testing.T, env *testEnv) {
+				require.NoError(t, env.userService.UnlockUser(asUser(model.AdminUserID), &v1.UnlockUserRequest{Email: "lock@example.com"}))
+			},
+		},
+		{
+			// 邮箱不区分大小写
+			name:     "unlocked by admin with different case",
+			failures: maxFailures,
+			after: func(t *testing.T, env *testEnv) {
+				require.NoError(t, env.userService.UnlockUser(asUser(model.AdminUserID), &v1.UnlockUserRequest{Email: "Lock@Example.com"}))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			env.createUser(t, model.User{Email: "lock@example.com"})
+
+			for i := 0; i < tt.failures; i++ {
+				_, err := env.userService.Login(ctx, &v1.LoginRequest{Email: "lock@example.com", Password: "wrong"}, testClient())
+				require.ErrorIs(t, err, v1.ErrUnauthorized)
+			}
+			if tt.after != nil {
+				tt.after(t, env)
+			}
+			client := tt.client
+			if client == nil {
+				client = testClient()
+			}
+			data, err := env.userService.Login(ctx, &v1.LoginRequest{Email: "lock@example.com", Password: "password"}, client)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, data.AccessToken)
+		})
+	}
+}
+
+func TestUserService_Login_SuccessResetsFailures(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.createUser(t, model.User{Email: "lock@example.com"})
+	fail := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := env.userService.Login(ctx, &v1.LoginRequest{Email: "lock@example.com", Password: "wrong"}, testClient())
+			require.ErrorIs(t, err, v1.ErrUnauthorized)
+		}
+	}
+
+	fail(4)
+	_, err := env.userService.Login(ctx, &v1.LoginRequest{Email: "lock@example.com", Password: "password"}, testClient())
+	require.NoError(t, err)
+	fail(4)
+	_, err = env.userService.Login(ctx, &v1.LoginRequest{Email: "lock@example.com", Password: "password"}, testClient())
+	assert.NoError(t, err)
+}
+
+func TestUserService_Login_UnknownEmailCountsAgainstIP(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.createUser(t, model.User{Email: "victim@example.com"})
+	attacker := &model.ClientInfo{IP: "10.0.0.66", UserAgent: "go-test"}
+
+	// 每个邮箱只试一次，账号计数不会达到阈值，IP 计数达到 50 后锁定
+	for i := 0; i < 50; i++ {
+		_, err := env.userService.Login(ctx, &v1.LoginRequest{Email: string(rune('a'+i%26)) + "@spray.example.com", Password: "wrong"}, attacker)
+		require.ErrorIs(t, err, v1.ErrUnauthorized)
+	}
+	_, err := env.userService.Login(ctx, &v1.LoginRequest{Email: "victim@example.com", Password: "password"}, attacker)
+	assert.ErrorIs(t, err, v1.ErrUnauthorized)
+	// 其他 IP 不受影响
+	_, err = env.userService.Login(ctx, &v1.LoginRequest{Email: "victim@example.com", Password: "password"}, testClient())
+	assert.NoError(t, err)
+}
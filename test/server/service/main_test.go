@@ -0,0 +1,315 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/internal/service"
+	"go-nunu/pkg/aws"
+	"go-nunu/pkg/config"
+	"go-nunu/pkg/jwt"
+	"go-nunu/pkg/lockout"
+	"go-nunu/pkg/log"
+	"go-nunu/pkg/mail"
+	"go-nunu/pkg/sid"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	casbinModel "github.com/casbin/casbin/v2/model"
+	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/glebarez/sqlite"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+)
+
+var (
+	conf   *viper.Viper
+	logger *log.Logger
+	j      *jwt.JWT
+	sf     *sid.Sid
+)
+
+func TestMain(m *testing.M) {
+	fmt.Println("begin")
+
+	err := os.Setenv("APP_CONF", "../../../config/local.yml")
+	if err != nil {
+		panic(err)
+	}
+
+	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	flag.Parse()
+	conf = config.NewConfig(*envConf)
+
+	// modify log directory
+	logPath := filepath.Join("../../../", conf.GetString("log.log_file_name"))
+	conf.Set("log.log_file_name", logPath)
+
+	logger = log.NewLog(conf)
+	j = jwt.NewJwt(conf, nil)
+	sf = sid.NewSid()
+
+	code := m.Run()
+	fmt.Println("test end")
+
+	os.Exit(code)
+}
+
+// testEnv 以 SQLite 文件库和真实的 repository、Casbin 组装服务，用于验证跨多张表的行为
+type testEnv struct {
+	db       *gorm.DB
+	casbin   *casbin.SyncedCachedEnforcer
+	tm       repository.Transaction
+	jwt      *jwt.JWT
+	guard    *lockout.Guard
+	mail     *mailRecorder
+	storage  *memoryStorage
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+
+	tokenService   service.TokenService
+	sessionService service.SessionService
+	accountService service.AccountService
+	mfaService     service.MfaService
+	avatarService  service.AvatarService
+	userService    service.UserService
+	roleService    service.RoleService
+	bulkService    service.UserBulkService
+	policyService  service.PolicyService
+	rbacService    service.RbacService
+	permService    service.PermissionService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormLogger.Default.LogMode(gormLogger.Silent), TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	if err = db.AutoMigrate(
+		&model.User{},
+		&model.Role{},
+		&model.Permission{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.VerificationToken{},
+		&model.UserTotp{},
+		&model.MfaRecoveryCode{},
+		&model.UserIdentity{},
+		&model.OAuthState{},
+		&model.LoginLog{},
+		&model.Session{},
+		&model.ApiKey{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err = repository.CreateUserEmailIndex(db); err != nil {
+		t.Fatal(err)
+	}
+
+	adapter, err := gormadapter.NewAdapterByDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := casbinModel.NewModelFromFile("../../../config/model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := casbin.NewSyncedCachedEnforcer(m, adapter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &testEnv{
+		db:      db,
+		casbin:  e,
+		mail:    &mailRecorder{},
+		storage: newMemoryStorage(),
+		guard:   lockout.NewGuard(conf, lockout.NewMemoryStore()),
+	}
+	repo := repository.NewRepository(logger, db, e)
+	env.tm = repository.NewTransaction(repo)
+	env.userRepo = repository.NewUserRepository(repo)
+	env.roleRepo = repository.NewRoleRepository(repo)
+	permissionRepo := repository.NewPermissionRepository(repo)
+	tokenRepo := repository.NewTokenRepository(repo)
+	sessionRepo := repository.NewSessionRepository(repo)
+	mfaRepo := repository.NewMfaRepository(repo)
+	verificationRepo := repository.NewVerificationTokenRepository(repo)
+
+	env.jwt = jwt.NewJwt(conf, tokenRepo)
+	srv := service.NewService(db, env.tm, logger, sf, env.jwt, e)
+	env.tokenService = service.NewTokenService(srv, conf, tokenRepo, env.userRepo, sessionRepo)
+	env.sessionService = service.NewSessionService(srv, sessionRepo, env.tokenService)
+	env.accountService = service.NewAccountService(srv, conf, env.userRepo, verificationRepo, env.tokenService, env.mail)
+	env.mfaService = service.NewMfaService(srv, conf, env.userRepo, mfaRepo, tokenRepo, env.tokenService, env.sessionService, env.guard)
+	env.avatarService = service.NewAvatarService(srv, conf, env.storage)
+	env.userService = service.NewUserService(srv, conf, env.userRepo, env.roleRepo, env.tokenService, env.accountService,
+		env.mfaService, env.sessionService, env.avatarService, env.guard)
+	env.roleService = service.NewRoleService(srv, env.roleRepo, e)
+	env.bulkService = service.NewUserBulkService(srv, env.userRepo, env.roleRepo, env.accountService)
+	env.policyService = service.NewPolicyService(logger, e, env.roleRepo, env.userRepo, permissionRepo, staticRoutes{})
+	env.rbacService = service.NewRbacService(env.tm, permissionRepo, env.roleRepo, env.policyService)
+	env.permService = service.NewPermissionService(srv, permissionRepo, env.roleRepo, env.userRepo)
+	return env
+}
+
+// createUser 直接写库创建用户，密码为 password
+func (env *testEnv) createUser(t *testing.T, user model.User) *model.User {
+	t.Helper()
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UserId == "" {
+		if user.UserId, err = sf.GenString(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if user.Status == 0 {
+		user.Status = model.UserStatusNormal
+	}
+	if user.UserType == 0 {
+		user.UserType = model.UserTypeRegistered
+	}
+	user.Password = string(hashed)
+	if err = env.db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &user
+}
+
+// createRole 创建角色并通过 roleService 写入 Casbin
+func (env *testEnv) createRole(t *testing.T, sid string, dataScope int, permissionIds ...uint) *model.Role {
+	t.Helper()
+	role, err := env.roleService.CreateRole(context.Background(), model.AdminUserID, v1.CreateRoleRequest{
+		Name:          sid,
+		Key:           sid,
+		DataScope:     dataScope,
+		PermissionIds: permissionIds,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return role
+}
+
+// asUser 模拟 StrictAuth 写入的 claims，DataScope 据此过滤
+func asUser(userId string) context.Context {
+	return jwt.WithClaims(context.Background(), &jwt.MyCustomClaims{UserId: userId})
+}
+
+func testClient() *model.ClientInfo {
+	return &model.ClientInfo{IP: "127.0.0.1", UserAgent: "go-test"}
+}
+
+type staticRoutes []v1.ApiRoute
+
+func (r staticRoutes) RBACRoutes() []v1.ApiRoute {
+	return r
+}
+
+// mailRecorder 记录发送的邮件，不真正发送
+type mailRecorder struct {
+	mu   sync.Mutex
+	sent []*mail.Message
+}
+
+func (r *mailRecorder) Send(ctx context.Context, msg *mail.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (r *mailRecorder) sentTo(email string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, msg := range r.sent {
+		for _, to := range msg.To {
+			if to == email {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// memoryStorage 内存中的 service.ObjectStorage
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: map[string][]byte{}}
+}
+
+func (s *memoryStorage) HeadObject(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return 0, aws.ErrObjectNotFound
+	}
+	return int64(len(data)), nil
+}
+
+func (s *memoryStorage) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, aws.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryStorage) PutObject(ctx context.Context, key string, body []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = body
+	return nil
+}
+
+func (s *memoryStorage) DeleteObjects(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.objects, key)
+	}
+	return nil
+}
+
+func (s *memoryStorage) ObjectURL(key string) string {
+	return "https://files.example.com/" + key
+}
+
+func (s *memoryStorage) ObjectKey(url string) string {
+	return strings.TrimPrefix(url, "https://files.example.com/")
+}
+
+func (s *memoryStorage) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.objects[key]
+	return ok
+}
@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/pkg/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTotp 为用户开启 TOTP，返回密钥和恢复码
+func (env *testEnv) enableTotp(t *testing.T, userId string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	enroll, err := env.mfaService.EnrollTotp(ctx, userId)
+	require.NoError(t, err)
+	code, err := totp.Code(enroll.Secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	codes, err := env.mfaService.ActivateTotp(ctx, userId, code)
+	require.NoError(t, err)
+	for _, c := range codes.RecoveryCodes {
+		assert.Regexp(t, `^[a-z2-9]{4}-[a-z2-9]{4}$`, c)
+	}
+	return enroll.Secret, codes.RecoveryCodes
+}
+
+func TestMfaService_LoginMfa(t *testing.T) {
+	tests := []struct {
+		name string
+		// req 根据密钥和恢复码构造二次验证请求
+		req     func(t *testing.T, secret string, recoveryCodes []string) v1.LoginMfaRequest
+		wantErr error
+	}{
+		{
+			name: "totp code",
+			req: func(t *testing.T, secret string, recoveryCodes []string) v1.LoginMfaRequest {
+				// 激活时用过当前步长，取下一个
+				code, err := totp.Code(secret, totp.Step(time.Now())+1)
+				require.NoError(t, err)
+				return v1.LoginMfaRequest{Code: code}
+			},
+		},
+		{
+			name: "code reused from activation",
+			req: func(t *testing.T, secret string, recoveryCodes []string) v1.LoginMfaRequest {
+				code, err := totp.Code(secret, totp.Step(time.Now()))
+				require.NoError(t, err)
+				return v1.LoginMfaRequest{Code: code}
+			},
+			wantErr: v1.ErrMfaCodeInvalid,
+		},
+		{
+			name: "recovery code",
+			req: func(t *testing.T, secret string, recoveryCodes []string) v1.LoginMfaRequest {
+				return v1.LoginMfaRequest{RecoveryCode: recoveryCodes[0]}
+			},
+		},
+		{
+			name: "wrong code",
+			req: func(t *testing.T, secret string, recoveryCodes []string) v1.LoginMfaRequest {
+				return v1.LoginMfaRequest{Code: "000000"}
+			},
+			wantErr: v1.ErrMfaCodeInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			user := env.createUser(t, model.User{Email: "mfa@example.com"})
+			secret, recoveryCodes := env.enableTotp(t, user.UserId)
+
+			req := tt.req(t, secret, recoveryCodes)
+			req.MfaToken = env.loginForMfaToken(t)
+			data, err := env.mfaService.LoginMfa(ctx, &req, testClient())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, data.AccessToken)
+
+			// mfaToken 和恢复码都只能使用一次
+			_, err = env.mfaService.LoginMfa(ctx, &req, testClient())
+			assert.ErrorIs(t, err, v1.ErrUnauthorized)
+			if req.RecoveryCode != "" {
+				req.MfaToken = env.loginForMfaToken(t)
+				_, err = env.mfaService.LoginMfa(ctx, &req, testClient())
+				assert.ErrorIs(t, err, v1.ErrMfaCodeInvalid)
+			}
+		})
+	}
+}
+
+func TestMfaService_LoginMfa_Lockout(t *testing.T) {
+	// 配置中 max_account_failures 为 5
+	const maxFailures = 5
+	tests := []struct {
+		name string
+		// before 在最后一次错误验证码前执行，返回用于提交验证码的 mfaToken
+		before func(t *testing.T, env *testEnv, mfaToken string) string
+	}{
+		{
+			name: "same mfa token",
+			before: func(t *testing.T, env *testEnv, mfaToken string) string {
+				return mfaToken
+			},
+		},
+		{
+			name: "password resent for a new mfa token",
+			before: func(t *testing.T, env *testEnv, mfaToken string) string {
+				// 密码正确不会清除验证码的失败次数
+				return env.loginForMfaToken(t)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			user := env.createUser(t, model.User{Email: "mfa@example.com"})
+			secret, _ := env.enableTotp(t, user.UserId)
+			mfaToken := env.loginForMfaToken(t)
+
+			for i := 0; i < maxFailures-1; i++ {
+				_, err := env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: "000000"}, testClient())
+				require.ErrorIs(t, err, v1.ErrMfaCodeInvalid)
+			}
+			mfaToken = tt.before(t, env, mfaToken)
+			_, err := env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: "000000"}, testClient())
+			assert.ErrorIs(t, err, v1.ErrUnauthorized)
+
+			// 锁定后 mfaToken 已作废，正确的验证码也无法登录
+			code, err := totp.Code(secret, totp.Step(time.Now())+1)
+			require.NoError(t, err)
+			_, err = env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: code}, testClient())
+			assert.ErrorIs(t, err, v1.ErrUnauthorized)
+			// 也不能重新提交密码获取新的 mfaToken
+			_, err = env.userService.Login(ctx, &v1.LoginRequest{Email: user.Email, Password: "password"}, testClient())
+			assert.ErrorIs(t, err, v1.ErrUnauthorized)
+		})
+	}
+}
+
+func TestMfaService_LoginMfa_SuccessResetsFailures(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, model.User{Email: "mfa@example.com"})
+	secret, _ := env.enableTotp(t, user.UserId)
+
+	mfaToken := env.loginForMfaToken(t)
+	for i := 0; i < 3; i++ {
+		_, err := env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: "000000"}, testClient())
+		require.ErrorIs(t, err, v1.ErrMfaCodeInvalid)
+	}
+	code, err := totp.Code(secret, totp.Step(time.Now())+1)
+	require.NoError(t, err)
+	data, err := env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: code}, testClient())
+	require.NoError(t, err)
+	assert.NotEmpty(t, data.AccessToken)
+
+	// mfaToken 只能使用一次
+	_, err = env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: code}, testClient())
+	assert.ErrorIs(t, err, v1.ErrUnauthorized)
+
+	// 计数已清除，再错 4 次仍未锁定
+	mfaToken = env.loginForMfaToken(t)
+	for i := 0; i < 4; i++ {
+		_, err = env.mfaService.LoginMfa(ctx, &v1.LoginMfaRequest{MfaToken: mfaToken, Code: "000000"}, testClient())
+		require.ErrorIs(t, err, v1.ErrMfaCodeInvalid)
+	}
+}
+
+func (env *testEnv) loginForMfaToken(t *testing.T) string {
+	t.Helper()
+	data, err := env.userService.Login(context.Background(), &v1.LoginRequest{Email: "mfa@example.com", Password: "password"}, testClient())
+	require.NoError(t, err)
+	require.True(t, data.MfaRequired)
+	return data.MfaToken
+}
@@ -0,0 +1,250 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/internal/service"
+	"go-nunu/pkg/oauth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGithub 模拟 GitHub 授权服务器，授权码与发起授权时的 code_challenge 绑定
+type fakeGithub struct {
+	*httptest.Server
+	mu         sync.Mutex
+	challenges map[string]string
+	// email 为 /user/emails 返回的主邮箱
+	email    string
+	verified bool
+}
+
+func newFakeGithub(t *testing.T) *fakeGithub {
+	t.Helper()
+	f := &fakeGithub{challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		challenge, ok := f.challenges[r.FormValue("code")]
+		f.mu.Unlock()
+		if !ok || oauth.CodeChallenge(r.FormValue("code_verifier")) != challenge {
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "bearer"})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": 42, "login": "octocat"})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{{"email": f.email, "primary": true, "verified": f.verified}})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// approve 模拟用户在授权页同意，返回授权码和 state
+func (f *fakeGithub) approve(t *testing.T, authUrl string, code string) string {
+	t.Helper()
+	u, err := url.Parse(authUrl)
+	require.NoError(t, err)
+	f.mu.Lock()
+	f.challenges[code] = u.Query().Get("code_challenge")
+	f.mu.Unlock()
+	return u.Query().Get("state")
+}
+
+// newOAuthService 配置两个指向 fakeGithub 的 provider：github 和 github2
+func (env *testEnv) newOAuthService(t *testing.T, f *fakeGithub, linkByEmail bool) service.OAuthService {
+	t.Helper()
+	c := viper.New()
+	c.Set("oauth.link_by_email", linkByEmail)
+	var providers []any
+	for _, name := range []string{"github", "github2"} {
+		providers = append(providers, map[string]any{
+			"name":         name,
+			"type":         "github",
+			"client_id":    "client",
+			"token_url":    f.URL + "/token",
+			"userinfo_url": f.URL + "/user",
+		})
+	}
+	c.Set("oauth.providers", providers)
+	repo := repository.NewRepository(logger, env.db, env.casbin)
+	srv := service.NewService(env.db, env.tm, logger, sf, env.jwt, env.casbin)
+	return service.NewOAuthService(srv, c, oauth.NewRegistry(c), env.userRepo, repository.NewIdentityRepository(repo),
+		env.tokenService, env.mfaService, env.sessionService)
+}
+
+func TestOAuthService_State(t *testing.T) {
+	tests := []struct {
+		name string
+		// callback 发起授权后用不匹配的参数回调
+		callback func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error
+	}{
+		{
+			name: "unknown state",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				authorize(t, s, f, "github", "", "code-1")
+				_, err := s.Login(context.Background(), "github", &v1.OAuthCallbackRequest{Code: "code-1", State: "forged"}, testClient())
+				return err
+			},
+		},
+		{
+			name: "replayed state",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				req := authorize(t, s, f, "github", "", "code-1")
+				_, err := s.Login(context.Background(), "github", req, testClient())
+				require.NoError(t, err)
+				_, err = s.Login(context.Background(), "github", req, testClient())
+				return err
+			},
+		},
+		{
+			name: "expired state",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				req := authorize(t, s, f, "github", "", "code-1")
+				require.NoError(t, env.db.Model(&model.OAuthState{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute)).Error)
+				_, err := s.Login(context.Background(), "github", req, testClient())
+				return err
+			},
+		},
+		{
+			name: "state issued for another provider",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				req := authorize(t, s, f, "github2", "", "code-1")
+				_, err := s.Login(context.Background(), "github", req, testClient())
+				return err
+			},
+		},
+		{
+			name: "link state used to log in",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				user := env.createUser(t, model.User{Email: "member@example.com"})
+				req := authorize(t, s, f, "github", user.UserId, "code-1")
+				_, err := s.Login(context.Background(), "github", req, testClient())
+				return err
+			},
+		},
+		{
+			name: "login state used to link another user",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				user := env.createUser(t, model.User{Email: "member@example.com"})
+				req := authorize(t, s, f, "github", "", "code-1")
+				return s.Link(context.Background(), user.UserId, "github", req)
+			},
+		},
+		{
+			name: "code issued for another verifier",
+			callback: func(t *testing.T, env *testEnv, s service.OAuthService, f *fakeGithub) error {
+				// 攻击者用自己发起授权得到的 code 配合受害者的 state
+				authorize(t, s, f, "github", "", "attacker-code")
+				victim := authorize(t, s, f, "github", "", "victim-code")
+				victim.Code = "attacker-code"
+				_, err := s.Login(context.Background(), "github", victim, testClient())
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			f := newFakeGithub(t)
+			s := env.newOAuthService(t, f, true)
+
+			err := tt.callback(t, env, s, f)
+			assert.ErrorIs(t, err, v1.ErrOAuthFailed)
+		})
+	}
+}
+
+func TestOAuthService_LinkByEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		linkByEmail   bool
+		local         model.User // 已有的本地账号
+		email         string     // provider 返回的主邮箱
+		verified      bool
+		wantErr       error
+		wantLocalUser bool // 是否登录到已有账号
+	}{
+		{
+			name:          "verified email links the verified local account",
+			linkByEmail:   true,
+			local:         model.User{Email: "member@example.com", EmailVerifyTime: 1},
+			email:         "Member@Example.com",
+			verified:      true,
+			wantLocalUser: true,
+		},
+		{
+			name:        "local email not verified",
+			linkByEmail: true,
+			local:       model.User{Email: "member@example.com"},
+			email:       "member@example.com",
+			verified:    true,
+			wantErr:     v1.ErrEmailAlreadyUse,
+		},
+		{
+			name:        "provider email not verified creates a user without email",
+			linkByEmail: true,
+			local:       model.User{Email: "member@example.com", EmailVerifyTime: 1},
+			email:       "member@example.com",
+		},
+		{
+			name:     "link by email disabled",
+			local:    model.User{Email: "member@example.com", EmailVerifyTime: 1},
+			email:    "member@example.com",
+			verified: true,
+			wantErr:  v1.ErrEmailAlreadyUse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			f := newFakeGithub(t)
+			f.email, f.verified = tt.email, tt.verified
+			s := env.newOAuthService(t, f, tt.linkByEmail)
+			local := env.createUser(t, tt.local)
+
+			data, err := s.Login(ctx, "github", authorize(t, s, f, "github", "", "code-1"), testClient())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			claims, err := env.jwt.ParseToken(data.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLocalUser, claims.UserId == local.UserId)
+
+			identities, err := s.ListIdentities(ctx, claims.UserId)
+			require.NoError(t, err)
+			assert.Len(t, identities.List, 1)
+			if !tt.wantLocalUser {
+				user, err := env.userRepo.GetByID(ctx, claims.UserId)
+				require.NoError(t, err)
+				assert.Empty(t, user.Email)
+			}
+		})
+	}
+}
+
+// authorize 发起授权并同意，返回回调参数
+func authorize(t *testing.T, s service.OAuthService, f *fakeGithub, provider string, userId string, code string) *v1.OAuthCallbackRequest {
+	t.Helper()
+	data, err := s.Authorize(context.Background(), provider, userId)
+	require.NoError(t, err)
+	return &v1.OAuthCallbackRequest{Code: code, State: f.approve(t, data.AuthUrl, code)}
+}
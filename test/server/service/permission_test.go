@@ -0,0 +1,179 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// seedMenus 写入一棵菜单树，report 的 sort 比 system 小，orphan 的上级不存在
+func (env *testEnv) seedMenus(t *testing.T) map[string]uint {
+	t.Helper()
+	perms := []model.Permission{
+		{Model: gorm.Model{ID: 1}, Name: "system", Key: "system", Type: model.PermissionTypeDirectory, Sort: 2},
+		{Model: gorm.Model{ID: 2}, Name: "users", Key: "system:user", Type: model.PermissionTypeMenu, ParentID: 1, Sort: 2},
+		{Model: gorm.Model{ID: 3}, Name: "roles", Key: "system:role", Type: model.PermissionTypeMenu, ParentID: 1, Sort: 1},
+		{Model: gorm.Model{ID: 4}, Name: "create", Key: "user:create", Type: model.PermissionTypeButton, ParentID: 2, Api: "/v1/user", Method: "POST"},
+		{Model: gorm.Model{ID: 5}, Name: "delete", Key: "user:delete", Type: model.PermissionTypeButton, ParentID: 2, Api: "/v1/user/:id", Method: "DELETE"},
+		{Model: gorm.Model{ID: 6}, Name: "report", Key: "report", Type: model.PermissionTypeDirectory, Sort: 1},
+		{Model: gorm.Model{ID: 7}, Name: "daily", Key: "report:daily", Type: model.PermissionTypeMenu, ParentID: 6},
+		{Model: gorm.Model{ID: 8}, Name: "orphan", Key: "orphan", Type: model.PermissionTypeMenu, ParentID: 99, Sort: 3},
+		{Model: gorm.Model{ID: 9}, Name: "lost", Key: "orphan:button", Type: model.PermissionTypeButton, ParentID: 99, Api: "/v1/lost", Method: "GET"},
+	}
+	require.NoError(t, env.db.Create(&perms).Error)
+	ids := make(map[string]uint, len(perms))
+	for _, perm := range perms {
+		ids[perm.Key] = perm.ID
+	}
+	return ids
+}
+
+// menuOutline 把菜单树压成一行便于比较：名称[按钮]{子节点}
+func menuOutline(nodes []*v1.MenuTreeNode) string {
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		part := node.Name
+		if len(node.Buttons) > 0 {
+			part += "[" + strings.Join(node.Buttons, " ") + "]"
+		}
+		if len(node.Children) > 0 {
+			part += "{" + menuOutline(node.Children) + "}"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestPermissionService_GetMyMenus(t *testing.T) {
+	tests := []struct {
+		name string
+		// user 返回要查询的用户
+		user func(t *testing.T, env *testEnv, ids map[string]uint) string
+		want string
+	}{
+		{
+			name: "admin sees the whole tree ordered by sort",
+			user: func(t *testing.T, env *testEnv, ids map[string]uint) string {
+				return model.AdminUserID
+			},
+			want: "report{daily},system{roles,users[user:create user:delete]},orphan",
+		},
+		{
+			name: "granted menu brings its directory and granted buttons",
+			user: func(t *testing.T, env *testEnv, ids map[string]uint) string {
+				role := env.createRole(t, "editor", model.DataScopeAll, ids["system:user"], ids["user:create"])
+				return env.createUser(t, model.User{Email: "editor@example.com", Roles: []model.Role{*role}}).UserId
+			},
+			want: "system{users[user:create]}",
+		},
+		{
+			name: "menus inherited from a parent role",
+			user: func(t *testing.T, env *testEnv, ids map[string]uint) string {
+				viewer := env.createRole(t, "viewer", model.DataScopeAll, ids["report:daily"])
+				editor := env.createRole(t, "editor", model.DataScopeAll, ids["system:role"])
+				require.NoError(t, env.roleService.UpdateRoleParents(context.Background(), int64(editor.ID), []uint{viewer.ID}))
+				return env.createUser(t, model.User{Email: "editor@example.com", Roles: []model.Role{*editor}}).UserId
+			},
+			want: "report{daily},system{roles}",
+		},
+		{
+			name: "menu whose parent is missing becomes a root",
+			user: func(t *testing.T, env *testEnv, ids map[string]uint) string {
+				role := env.createRole(t, "lost", model.DataScopeAll, ids["orphan"], ids["orphan:button"])
+				return env.createUser(t, model.User{Email: "lost@example.com", Roles: []model.Role{*role}}).UserId
+			},
+			want: "orphan",
+		},
+		{
+			name: "button without its menu shows nothing",
+			user: func(t *testing.T, env *testEnv, ids map[string]uint) string {
+				role := env.createRole(t, "deleter", model.DataScopeAll, ids["user:delete"])
+				return env.createUser(t, model.User{Email: "deleter@example.com", Roles: []model.Role{*role}}).UserId
+			},
+			want: "",
+		},
+		{
+			name: "user without roles",
+			user: func(t *testing.T, env *testEnv, ids map[string]uint) string {
+				return env.createUser(t, model.User{Email: "nobody@example.com"}).UserId
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ids := env.seedMenus(t)
+			userId := tt.user(t, env, ids)
+
+			data, err := env.permService.GetMyMenus(asUser(userId), userId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, menuOutline(data.List))
+		})
+	}
+}
+
+func TestPermissionService_CasbinPropagation(t *testing.T) {
+	update := func(env *testEnv, ctx context.Context, perm *model.Permission) error {
+		_, err := env.permService.UpdatePermission(ctx, &v1.UpdatePermissionRequest{
+			ID: perm.ID, Name: perm.Name, Key: perm.Key, Type: perm.Type, Api: "/v1/thing/renamed", Method: "GET",
+		})
+		return err
+	}
+	remove := func(env *testEnv, ctx context.Context, perm *model.Permission) error {
+		return env.permService.DeletePermission(ctx, perm.ID)
+	}
+	tests := []struct {
+		name   string
+		run    func(env *testEnv, ctx context.Context, perm *model.Permission) error
+		broken bool // casbin_rule 不可写，Casbin 同步失败
+		// 操作后角色应拥有的接口，nil 表示没有
+		wantApis []string
+	}{
+		{name: "update moves the policy", run: update, wantApis: []string{"/v1/thing/renamed"}},
+		{name: "delete removes the policy", run: remove},
+		{name: "failed update keeps the permission", run: update, broken: true, wantApis: []string{"/v1/thing/list"}},
+		{name: "failed delete restores the permission", run: remove, broken: true, wantApis: []string{"/v1/thing/list"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			perm := model.Permission{Name: "list", Key: "api:thing:list", Type: model.PermissionTypeButton, Api: "/v1/thing/list", Method: "GET"}
+			require.NoError(t, env.db.Create(&perm).Error)
+			viewer := env.createRole(t, "viewer", model.DataScopeAll, perm.ID)
+			if tt.broken {
+				require.NoError(t, env.db.Migrator().RenameTable("casbin_rule", "casbin_rule_bak"))
+			}
+
+			err := tt.run(env, ctx, &perm)
+			if tt.broken {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			role, err := env.roleService.GetRole(ctx, int64(viewer.ID))
+			require.NoError(t, err)
+			var apis []string
+			for _, p := range role.Permissions {
+				apis = append(apis, p.Api)
+			}
+			assert.Equal(t, tt.wantApis, apis)
+			if !tt.broken {
+				for _, api := range []string{"/v1/thing/list", "/v1/thing/renamed"} {
+					has, err := env.casbin.HasPolicy("viewer", model.ApiResourcePrefix+api, "GET")
+					require.NoError(t, err)
+					assert.Equal(t, slices.Contains(tt.wantApis, api), has, api)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package service_test
+
+import (
+	"context"
+	"go-nunu/internal/model"
+	"go-nunu/internal/repository"
+	"go-nunu/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyService_BackfillUserRoles(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	role := env.createRole(t, "auditor", model.DataScopeAll)
+
+	// 绕过 userService 直接写关系表，模拟升级前只有 sys_user_roles 的数据
+	var users []*model.User
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		user := env.createUser(t, model.User{Email: email})
+		require.NoError(t, env.db.Model(user).Association("Roles").Append(role))
+		users = append(users, user)
+	}
+
+	added, err := env.policyService.BackfillUserRoles(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(users), added)
+	for _, user := range users {
+		ok, err := env.casbin.HasGroupingPolicy(model.UserSubject(user.UserId), role.Sid)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	// 再次执行不重复写入
+	added, err = env.policyService.BackfillUserRoles(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, added)
+
+	diff, err := env.policyService.DiffPolicies(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Missing)
+}
+
+func TestPolicyService_SyncApiPermissions(t *testing.T) {
+	routes := staticRoutes{
+		{Method: "GET", Path: "/v1/user/:id", Handler: "GetUser"},
+		{Method: "POST", Path: "/v1/user/list", Handler: "GetUserList"},
+		{Method: "DELETE", Path: "/v1/role/:id", Handler: "DeleteRole"},
+		{Method: "GET", Path: "/v1/report", Handler: "GetReport"},
+	}
+	tests := []struct {
+		name        string
+		permissions []model.Permission
+		wantCreated []string
+		wantStale   []string
+	}{
+		{
+			name:        "no permissions",
+			wantCreated: []string{"GET /v1/user/:id", "POST /v1/user/list", "DELETE /v1/role/:id", "GET /v1/report"},
+		},
+		{
+			name: "exact match",
+			permissions: []model.Permission{
+				{Key: "api:role:delete", Api: "/v1/role/:id", Method: "DELETE"},
+			},
+			wantCreated: []string{"GET /v1/user/:id", "POST /v1/user/list", "GET /v1/report"},
+		},
+		{
+			name: "covered by keyMatch2 patterns",
+			permissions: []model.Permission{
+				{Key: "api:user:all", Api: "/v1/user/*", Method: "*"},
+				{Key: "api:role:any", Api: "/v1/role/:rid", Method: "DELETE"},
+			},
+			wantCreated: []string{"GET /v1/report"},
+		},
+		{
+			name: "method must match",
+			permissions: []model.Permission{
+				{Key: "api:user:get", Api: "/v1/user/*", Method: "GET"},
+			},
+			wantCreated: []string{"POST /v1/user/list", "DELETE /v1/role/:id", "GET /v1/report"},
+		},
+		{
+			name: "stale permission",
+			permissions: []model.Permission{
+				{Key: "api:user:all", Api: "/v1/user/*", Method: "*"},
+				{Key: "api:role:all", Api: "/v1/role/*", Method: "*"},
+				{Key: "api:report", Api: "/v1/report", Method: "GET"},
+				{Key: "api:legacy", Api: "/v1/legacy/*", Method: "GET"},
+			},
+			wantStale: []string{"api:legacy"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			permissionRepo := repository.NewPermissionRepository(repository.NewRepository(logger, env.db, env.casbin))
+			for _, perm := range tt.permissions {
+				perm.Name = perm.Key
+				perm.Type = model.PermissionTypeButton
+				_, err := permissionRepo.CreatePermission(ctx, &perm)
+				require.NoError(t, err)
+			}
+			policyService := service.NewPolicyService(logger, env.casbin, env.roleRepo, env.userRepo, permissionRepo, routes)
+
+			data, err := policyService.SyncApiPermissions(ctx, true)
+			require.NoError(t, err)
+			var created, stale []string
+			for _, route := range data.Created {
+				created = append(created, route.Method+" "+route.Path)
+			}
+			for _, perm := range data.Stale {
+				stale = append(stale, perm.Key)
+			}
+			assert.ElementsMatch(t, tt.wantCreated, created)
+			assert.ElementsMatch(t, tt.wantStale, stale)
+
+			// 写入后再次同步没有新增
+			data, err = policyService.SyncApiPermissions(ctx, false)
+			require.NoError(t, err)
+			assert.Empty(t, data.Created)
+		})
+	}
+}
+
+func TestPolicyService_DiffAndReconcile(t *testing.T) {
+	// policyFixture 关系表与 Casbin 一致的初始数据：editor 继承 viewer，用户拥有 editor
+	type policyFixture struct {
+		obj    string
+		user   *model.User
+		viewer *model.Role
+		editor *model.Role
+	}
+	tests := []struct {
+		name string
+		// drift 制造关系表与 casbin_rule 的差异
+		drift       func(t *testing.T, env *testEnv, f *policyFixture)
+		wantMissing func(f *policyFixture) [][]string
+		wantExtra   func(f *policyFixture) [][]string
+	}{
+		{
+			name:  "in sync",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {},
+		},
+		{
+			name: "permission policy missing",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {
+				_, err := env.casbin.RemovePolicy("viewer", f.obj, "GET")
+				require.NoError(t, err)
+			},
+			wantMissing: func(f *policyFixture) [][]string { return [][]string{{"p", "viewer", f.obj, "GET"}} },
+		},
+		{
+			name: "stray policy",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {
+				_, err := env.casbin.AddPolicy("editor", f.obj, "DELETE")
+				require.NoError(t, err)
+			},
+			wantExtra: func(f *policyFixture) [][]string { return [][]string{{"p", "editor", f.obj, "DELETE"}} },
+		},
+		{
+			name: "role inheritance missing",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {
+				_, err := env.casbin.RemoveGroupingPolicy("editor", "viewer")
+				require.NoError(t, err)
+			},
+			wantMissing: func(f *policyFixture) [][]string { return [][]string{{"g", "editor", "viewer"}} },
+		},
+		{
+			name: "user role missing and stale",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {
+				require.NoError(t, env.db.Model(f.user).Association("Roles").Replace(f.viewer))
+			},
+			wantMissing: func(f *policyFixture) [][]string {
+				return [][]string{{"g", model.UserSubject(f.user.UserId), "viewer"}}
+			},
+			wantExtra: func(f *policyFixture) [][]string {
+				return [][]string{{"g", model.UserSubject(f.user.UserId), "editor"}}
+			},
+		},
+		{
+			name: "rules changed by another instance",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {
+				// 直接改表，不经过本进程的 enforcer
+				require.NoError(t, env.db.Exec("DELETE FROM casbin_rule WHERE ptype = 'p'").Error)
+			},
+			wantMissing: func(f *policyFixture) [][]string { return [][]string{{"p", "viewer", f.obj, "GET"}} },
+		},
+		{
+			name: "role without sid is ignored",
+			drift: func(t *testing.T, env *testEnv, f *policyFixture) {
+				legacy := model.Role{Name: "legacy", Permissions: []model.Permission{{Name: "legacy", Key: "api:legacy", Type: model.PermissionTypeButton, Api: "/v1/legacy", Method: "GET"}}}
+				require.NoError(t, env.db.Create(&legacy).Error)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			perm := model.Permission{Name: "list", Key: "api:thing:list", Type: model.PermissionTypeButton, Api: "/v1/thing/list", Method: "GET"}
+			require.NoError(t, env.db.Create(&perm).Error)
+			f := &policyFixture{obj: perm.PolicyObject()}
+			f.viewer = env.createRole(t, "viewer", model.DataScopeAll, perm.ID)
+			f.editor = env.createRole(t, "editor", model.DataScopeAll)
+			require.NoError(t, env.roleService.UpdateRoleParents(ctx, int64(f.editor.ID), []uint{f.viewer.ID}))
+			f.user = env.createUser(t, model.User{Email: "drift@example.com", Roles: []model.Role{*f.editor}})
+			_, err := env.policyService.BackfillUserRoles(ctx)
+			require.NoError(t, err)
+
+			tt.drift(t, env, f)
+			wantMissing, wantExtra := [][]string{}, [][]string{}
+			if tt.wantMissing != nil {
+				wantMissing = tt.wantMissing(f)
+			}
+			if tt.wantExtra != nil {
+				wantExtra = tt.wantExtra(f)
+			}
+
+			diff, err := env.policyService.DiffPolicies(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, wantMissing, diff.Missing)
+			assert.Equal(t, wantExtra, diff.Extra)
+			assert.False(t, diff.Applied)
+
+			diff, err = env.policyService.ReconcilePolicies(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, wantMissing, diff.Missing)
+			assert.Equal(t, wantExtra, diff.Extra)
+			assert.Equal(t, len(wantMissing)+len(wantExtra) > 0, diff.Applied)
+
+			// 修复后再次比较没有差异，且结果已写入 casbin_rule
+			diff, err = env.policyService.DiffPolicies(ctx)
+			require.NoError(t, err)
+			assert.Empty(t, diff.Missing)
+			assert.Empty(t, diff.Extra)
+			ok, err := env.casbin.Enforce(model.UserSubject(f.user.UserId), f.obj, "GET")
+			require.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
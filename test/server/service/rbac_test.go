@@ -0,0 +1,201 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rbacBaseDoc = `
+version: 1
+permissions:
+  - key: dir:report
+    name: 报表
+    type: 1
+  - key: api:report:list
+    name: 报表列表
+    type: 3
+    parent: dir:report
+    api: /v1/report
+    method: GET
+  - key: api:report:export
+    name: 导出报表
+    type: 3
+    parent: dir:report
+    api: /v1/report/export
+    method: GET
+roles:
+  - sid: viewer
+    name: 只读
+    data_scope: 3
+    permissions: [api:report:list]
+  - sid: auditor
+    name: 审计
+    parents: [viewer]
+    permissions: [api:report:export]
+`
+
+func TestRbacService_ImportRbac_Diff(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []v1.RbacChange
+	}{
+		{
+			name: "unchanged",
+			doc:  rbacBaseDoc,
+			want: []v1.RbacChange{},
+		},
+		{
+			name: "new permission and role",
+			doc: `
+version: 1
+permissions:
+  - key: api:report:delete
+    name: 删除报表
+    type: 3
+    parent: dir:report
+    api: /v1/report/:id
+    method: DELETE
+roles:
+  - sid: editor
+    name: 编辑
+    permissions: [api:report:delete]
+`,
+			want: []v1.RbacChange{
+				{Kind: "permission", Key: "api:report:delete", Action: "create"},
+				{Kind: "role", Key: "editor", Action: "create"},
+			},
+		},
+		{
+			name: "updated fields",
+			doc: `
+version: 1
+permissions:
+  - key: api:report:list
+    name: 报表查询
+    type: 3
+    api: /v1/report/*
+    method: GET
+roles:
+  - sid: auditor
+    name: 审计
+    data_scope: 2
+    permissions: [api:report:export, api:report:list]
+`,
+			want: []v1.RbacChange{
+				{Kind: "permission", Key: "api:report:list", Action: "update", Fields: []string{"name", "parent", "api"}},
+				{Kind: "role", Key: "auditor", Action: "update", Fields: []string{"data_scope", "permissions", "parents"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			_, err := env.rbacService.ImportRbac(ctx, []byte(rbacBaseDoc), true)
+			require.NoError(t, err)
+			before, err := env.rbacService.ExportRbac(ctx)
+			require.NoError(t, err)
+
+			data, err := env.rbacService.ImportRbac(ctx, []byte(tt.doc), false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, data.Changes)
+			assert.False(t, data.Applied)
+
+			// 只预览时不修改数据库
+			after, err := env.rbacService.ExportRbac(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, string(before), string(after))
+		})
+	}
+}
+
+func TestRbacService_ImportRbac_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{name: "unsupported version", doc: "version: 2\n"},
+		{name: "unknown field", doc: "version: 1\nusers: []\n"},
+		{name: "unknown parent permission", doc: `
+version: 1
+permissions:
+  - {key: api:a, name: a, type: 3, parent: dir:missing}
+`},
+		{name: "permission parent cycle", doc: `
+version: 1
+permissions:
+  - {key: dir:a, name: a, type: 1, parent: dir:b}
+  - {key: dir:b, name: b, type: 1, parent: dir:a}
+`},
+		{name: "unknown role permission", doc: `
+version: 1
+roles:
+  - {sid: r, name: r, permissions: [api:missing]}
+`},
+		{name: "role inheritance cycle with existing role", doc: `
+version: 1
+roles:
+  - {sid: viewer, name: 只读, data_scope: 3, parents: [auditor], permissions: [api:report:list]}
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			_, err := env.rbacService.ImportRbac(ctx, []byte(rbacBaseDoc), true)
+			require.NoError(t, err)
+
+			_, err = env.rbacService.ImportRbac(ctx, []byte(tt.doc), true)
+			assert.ErrorIs(t, err, v1.ErrRbacDocumentInvalid)
+		})
+	}
+}
+
+func TestRbacService_ImportRbac_Apply(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	data, err := env.rbacService.ImportRbac(ctx, []byte(rbacBaseDoc), true)
+	require.NoError(t, err)
+	assert.True(t, data.Applied)
+	assert.Len(t, data.Changes, 5)
+
+	var export model.Permission
+	require.NoError(t, env.db.Where("`key` = ?", "api:report:export").First(&export).Error)
+	var dir model.Permission
+	require.NoError(t, env.db.Where("`key` = ?", "dir:report").First(&dir).Error)
+	assert.Equal(t, int(dir.ID), export.ParentID)
+
+	// 权限和继承关系同步到 Casbin
+	user := env.createUser(t, model.User{Email: "auditor@example.com"})
+	_, err = env.casbin.AddRoleForUser(model.UserSubject(user.UserId), "auditor")
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		obj  string
+		want bool
+	}{
+		{obj: "/v1/report/export", want: true},
+		{obj: "/v1/report", want: true}, // 继承自 viewer
+		{obj: "/v1/user/list", want: false},
+	} {
+		ok, err := env.casbin.Enforce(model.UserSubject(user.UserId), model.ApiResourcePrefix+tc.obj, "GET")
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, ok, tc.obj)
+	}
+
+	// 再次导入同一文档没有变更
+	data, err = env.rbacService.ImportRbac(ctx, []byte(rbacBaseDoc), true)
+	require.NoError(t, err)
+	assert.Empty(t, data.Changes)
+	assert.False(t, data.Applied)
+
+	diff, err := env.policyService.DiffPolicies(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Missing)
+}
@@ -0,0 +1,268 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleService_UpdateRoleParents(t *testing.T) {
+	tests := []struct {
+		name string
+		// edges 依次设置的继承关系，最后一条是被测的修改
+		edges   [][2]string
+		wantErr error
+	}{
+		{name: "chain", edges: [][2]string{{"a", "b"}, {"b", "c"}}},
+		{name: "inherit itself", edges: [][2]string{{"a", "a"}}, wantErr: v1.ErrRoleCycle},
+		{name: "direct cycle", edges: [][2]string{{"a", "b"}, {"b", "a"}}, wantErr: v1.ErrRoleCycle},
+		{name: "indirect cycle", edges: [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}}, wantErr: v1.ErrRoleCycle},
+		{name: "diamond", edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			roles := map[string]*model.Role{}
+			for _, sid := range []string{"a", "b", "c", "d"} {
+				roles[sid] = env.createRole(t, sid, model.DataScopeAll)
+			}
+			parents := map[string][]uint{}
+			var err error
+			for i, edge := range tt.edges {
+				child, parent := edge[0], edge[1]
+				parents[child] = append(parents[child], roles[parent].ID)
+				err = env.roleService.UpdateRoleParents(ctx, int64(roles[child].ID), parents[child])
+				if i < len(tt.edges)-1 {
+					require.NoError(t, err)
+				}
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			// 被拒绝的修改不能写入 Casbin
+			last := tt.edges[len(tt.edges)-1]
+			has, err := env.casbin.HasGroupingPolicy(last[0], last[1])
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantErr == nil, has)
+		})
+	}
+}
+
+func TestRoleService_GetRolePermissions_Inherited(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	perms := []model.Permission{
+		{Name: "list", Key: "api:thing:list", Type: model.PermissionTypeButton, Api: "/v1/thing/list", Method: "GET"},
+		{Name: "create", Key: "api:thing:create", Type: model.PermissionTypeButton, Api: "/v1/thing", Method: "POST"},
+	}
+	require.NoError(t, env.db.Create(&perms).Error)
+	viewer := env.createRole(t, "viewer", model.DataScopeAll, perms[0].ID)
+	editor := env.createRole(t, "editor", model.DataScopeAll, perms[1].ID)
+	require.NoError(t, env.roleService.UpdateRoleParents(ctx, int64(editor.ID), []uint{viewer.ID}))
+
+	data, err := env.roleService.GetRolePermissions(ctx, int64(editor.ID))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"viewer"}, data.Ancestors)
+	sources := map[string]string{}
+	for _, p := range data.List {
+		sources[p.Key] = p.Source
+	}
+	assert.Equal(t, map[string]string{
+		"api:thing:create": v1.PermissionSourceDirect,
+		"api:thing:list":   v1.PermissionSourceInherited,
+	}, sources)
+
+	// 继承的规则在 Casbin 中同样生效
+	user := env.createUser(t, model.User{Email: "editor@example.com"})
+	_, err = env.casbin.AddRoleForUser(model.UserSubject(user.UserId), editor.Sid)
+	require.NoError(t, err)
+	ok, err := env.casbin.Enforce(model.UserSubject(user.UserId), model.ApiResourcePrefix+"/v1/thing/list", "GET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRoleService_CreateRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       v1.CreateRoleRequest
+		wantErr   error
+		wantRules [][]string
+	}{
+		{
+			name:      "with parent",
+			req:       v1.CreateRoleRequest{Name: "editor", Key: "editor"},
+			wantRules: [][]string{{"editor", "viewer"}},
+		},
+		{
+			name:    "duplicate key",
+			req:     v1.CreateRoleRequest{Name: "viewer 2", Key: "viewer"},
+			wantErr: v1.ErrRoleSidExists,
+		},
+		{
+			name:    "missing parent",
+			req:     v1.CreateRoleRequest{Name: "editor", Key: "editor", ParentIds: []uint{999}},
+			wantErr: v1.ErrNotFound,
+		},
+		{
+			name:    "empty key",
+			req:     v1.CreateRoleRequest{Name: "editor", Key: " "},
+			wantErr: v1.ErrRoleKeyRequired,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			viewer := env.createRole(t, "viewer", model.DataScopeAll)
+			if tt.req.ParentIds == nil {
+				tt.req.ParentIds = []uint{viewer.ID}
+			}
+
+			_, err := env.roleService.CreateRole(ctx, model.AdminUserID, tt.req)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			// 失败时不留下角色
+			var count int64
+			require.NoError(t, env.db.Model(&model.Role{}).Count(&count).Error)
+			if tt.wantErr != nil {
+				assert.EqualValues(t, 1, count)
+			} else {
+				assert.EqualValues(t, 2, count)
+			}
+			if tt.wantRules != nil {
+				rules, err := env.casbin.GetFilteredGroupingPolicy(0, tt.req.Key)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, tt.wantRules, rules)
+			}
+		})
+	}
+}
+
+func TestRoleService_RequiresKey(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(env *testEnv, ctx context.Context, legacy *model.Role, viewer *model.Role) error
+	}{
+		{
+			name: "update permissions of a role without key",
+			run: func(env *testEnv, ctx context.Context, legacy *model.Role, viewer *model.Role) error {
+				return env.roleService.UpdateRolePermissions(ctx, int64(legacy.ID), nil)
+			},
+		},
+		{
+			name: "update parents of a role without key",
+			run: func(env *testEnv, ctx context.Context, legacy *model.Role, viewer *model.Role) error {
+				return env.roleService.UpdateRoleParents(ctx, int64(legacy.ID), []uint{viewer.ID})
+			},
+		},
+		{
+			name: "inherit a role without key",
+			run: func(env *testEnv, ctx context.Context, legacy *model.Role, viewer *model.Role) error {
+				return env.roleService.UpdateRoleParents(ctx, int64(viewer.ID), []uint{legacy.ID})
+			},
+		},
+		{
+			name: "create a role inheriting a role without key",
+			run: func(env *testEnv, ctx context.Context, legacy *model.Role, viewer *model.Role) error {
+				_, err := env.roleService.CreateRole(ctx, model.AdminUserID, v1.CreateRoleRequest{Name: "editor", Key: "editor", ParentIds: []uint{legacy.ID}})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			viewer := env.createRole(t, "viewer", model.DataScopeAll)
+			// 历史数据中没有 Sid 的角色
+			legacy := &model.Role{Name: "legacy", DataScope: model.DataScopeAll}
+			require.NoError(t, env.db.Create(legacy).Error)
+
+			err := tt.run(env, context.Background(), legacy, viewer)
+			assert.ErrorIs(t, err, v1.ErrRoleKeyRequired)
+			rules, err := env.casbin.GetGroupingPolicy()
+			require.NoError(t, err)
+			assert.Empty(t, rules)
+		})
+	}
+}
+
+func TestRoleService_CasbinFailureRestoresRelations(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(env *testEnv, ctx context.Context, editor *model.Role) error
+	}{
+		{
+			name: "update permissions",
+			run: func(env *testEnv, ctx context.Context, editor *model.Role) error {
+				return env.roleService.UpdateRolePermissions(ctx, int64(editor.ID), nil)
+			},
+		},
+		{
+			name: "update parents",
+			run: func(env *testEnv, ctx context.Context, editor *model.Role) error {
+				return env.roleService.UpdateRoleParents(ctx, int64(editor.ID), nil)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			perm := model.Permission{Name: "list", Key: "api:thing:list", Type: model.PermissionTypeButton, Api: "/v1/thing/list", Method: "GET"}
+			require.NoError(t, env.db.Create(&perm).Error)
+			viewer := env.createRole(t, "viewer", model.DataScopeAll)
+			editor := env.createRole(t, "editor", model.DataScopeAll, perm.ID)
+			require.NoError(t, env.roleService.UpdateRoleParents(ctx, int64(editor.ID), []uint{viewer.ID}))
+			// casbin_rule 不可写时 Casbin 同步失败
+			require.NoError(t, env.db.Migrator().RenameTable("casbin_rule", "casbin_rule_bak"))
+
+			err := tt.run(env, ctx, editor)
+			assert.Error(t, err)
+
+			role, err := env.roleService.GetRole(ctx, int64(editor.ID))
+			require.NoError(t, err)
+			require.Len(t, role.Permissions, 1)
+			assert.Equal(t, perm.ID, role.Permissions[0].ID)
+			require.Len(t, role.Parents, 1)
+			assert.Equal(t, viewer.ID, role.Parents[0].ID)
+		})
+	}
+}
+
+func TestRoleService_UpdateRole_Rename(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+		wantSid string
+	}{
+		{name: "rename", key: "author", wantSid: "author"},
+		{name: "same key", key: "editor", wantSid: "editor"},
+		{name: "collides with another role", key: "viewer", wantErr: v1.ErrRoleSidExists, wantSid: "editor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			perm := model.Permission{Name: "list", Key: "api:thing:list", Type: model.PermissionTypeButton, Api: "/v1/thing/list", Method: "GET"}
+			require.NoError(t, env.db.Create(&perm).Error)
+			env.createRole(t, "viewer", model.DataScopeAll)
+			editor := env.createRole(t, "editor", model.DataScopeAll, perm.ID)
+
+			_, err := env.roleService.UpdateRole(ctx, &v1.UpdateRoleRequest{ID: int(editor.ID), Key: tt.key})
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			role, err := env.roleService.GetRole(ctx, int64(editor.ID))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSid, role.Sid)
+			// p 规则跟随 Sid
+			ok, err := env.casbin.HasPolicy(tt.wantSid, model.ApiResourcePrefix+"/v1/thing/list", "GET")
+			require.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionService_Revoke(t *testing.T) {
+	tests := []struct {
+		name string
+		// revoke 由 current 所属用户发起，撤销 other 会话
+		revoke  func(t *testing.T, env *testEnv, owner *model.User, other uint) error
+		wantErr error
+		// 各会话撤销后是否失效
+		wantCurrentRevoked bool
+		wantOtherRevoked   bool
+	}{
+		{
+			name: "revoke another own session",
+			revoke: func(t *testing.T, env *testEnv, owner *model.User, other uint) error {
+				return env.sessionService.RevokeSession(context.Background(), owner.UserId, other)
+			},
+			wantOtherRevoked: true,
+		},
+		{
+			name: "session of another user",
+			revoke: func(t *testing.T, env *testEnv, owner *model.User, other uint) error {
+				intruder := env.createUser(t, model.User{Email: "intruder@example.com"})
+				return env.sessionService.RevokeSession(context.Background(), intruder.UserId, other)
+			},
+			wantErr: v1.ErrNotFound,
+		},
+		{
+			name: "revoke all sessions",
+			revoke: func(t *testing.T, env *testEnv, owner *model.User, other uint) error {
+				return env.sessionService.RevokeAllSessions(context.Background(), owner.UserId)
+			},
+			wantCurrentRevoked: true,
+			wantOtherRevoked:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			user := env.createUser(t, model.User{Email: "session@example.com"})
+			current, err := env.tokenService.IssueTokens(ctx, user, testClient(), model.LoginTypeEmail)
+			require.NoError(t, err)
+			other, err := env.tokenService.IssueTokens(ctx, user, &model.ClientInfo{IP: "10.0.0.2", UserAgent: "other-device"}, model.LoginTypeEmail)
+			require.NoError(t, err)
+
+			claims, err := env.jwt.ParseToken(current.AccessToken)
+			require.NoError(t, err)
+			sessions, err := env.sessionService.ListSessions(ctx, user.UserId, claims.ID)
+			require.NoError(t, err)
+			require.Len(t, sessions.List, 2)
+			var otherId uint
+			for _, session := range sessions.List {
+				if session.ID != sessions.CurrentSessionId {
+					otherId = session.ID
+				}
+			}
+			require.NotZero(t, sessions.CurrentSessionId)
+			require.NotZero(t, otherId)
+
+			err = tt.revoke(t, env, user, otherId)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCurrentRevoked, env.accessTokenRevoked(t, current.AccessToken))
+			assert.Equal(t, tt.wantOtherRevoked, env.accessTokenRevoked(t, other.AccessToken))
+			// 被撤销的会话不能再刷新
+			_, err = env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: other.RefreshToken}, testClient())
+			if tt.wantOtherRevoked {
+				assert.ErrorIs(t, err, v1.ErrUnauthorized)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
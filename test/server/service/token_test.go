@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenService_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// run 返回最后一次刷新的结果，以及之后应当失效的 access token
+		run       func(t *testing.T, env *testEnv, first *v1.LoginResponseData) (*v1.LoginResponseData, []string, error)
+		wantErr   error
+		wantAlive bool // 最后一次刷新得到的令牌是否仍然可用
+	}{
+		{
+			name: "rotation",
+			run: func(t *testing.T, env *testEnv, first *v1.LoginResponseData) (*v1.LoginResponseData, []string, error) {
+				data, err := env.tokenService.RefreshToken(context.Background(), &v1.RefreshTokenRequest{RefreshToken: first.RefreshToken}, testClient())
+				return data, nil, err
+			},
+			wantAlive: true,
+		},
+		{
+			name: "reuse of a rotated token revokes the family",
+			run: func(t *testing.T, env *testEnv, first *v1.LoginResponseData) (*v1.LoginResponseData, []string, error) {
+				ctx := context.Background()
+				second, err := env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: first.RefreshToken}, testClient())
+				require.NoError(t, err)
+				// 旧令牌被重放
+				_, err = env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: first.RefreshToken}, testClient())
+				assert.ErrorIs(t, err, v1.ErrUnauthorized)
+				// 合法持有者的新令牌也随 family 一起失效
+				_, err = env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: second.RefreshToken}, testClient())
+				return nil, []string{first.AccessToken, second.AccessToken}, err
+			},
+			wantErr: v1.ErrUnauthorized,
+		},
+		{
+			name: "unknown token",
+			run: func(t *testing.T, env *testEnv, first *v1.LoginResponseData) (*v1.LoginResponseData, []string, error) {
+				_, err := env.tokenService.RefreshToken(context.Background(), &v1.RefreshTokenRequest{RefreshToken: "not-a-token"}, testClient())
+				return nil, nil, err
+			},
+			wantErr: v1.ErrUnauthorized,
+		},
+		{
+			name: "failed rotation leaves the token unused",
+			run: func(t *testing.T, env *testEnv, first *v1.LoginResponseData) (*v1.LoginResponseData, []string, error) {
+				ctx := context.Background()
+				setStatus := func(status int) {
+					require.NoError(t, env.db.Model(&model.User{}).Where("email = ?", "token@example.com").Update("status", status).Error)
+				}
+				setStatus(model.UserStatusDisabled)
+				_, err := env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: first.RefreshToken}, testClient())
+				require.ErrorIs(t, err, v1.ErrUnauthorized)
+				// 事务回滚后令牌未被标记为已使用，不会被当作重放吊销整个 family
+				setStatus(model.UserStatusNormal)
+				data, err := env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: first.RefreshToken}, testClient())
+				return data, nil, err
+			},
+			wantAlive: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.createUser(t, model.User{Email: "token@example.com"})
+			first, err := env.tokenService.IssueTokens(context.Background(), user, testClient(), model.LoginTypeEmail)
+			require.NoError(t, err)
+
+			data, revoked, err := tt.run(t, env, first)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, token := range revoked {
+				assert.True(t, env.accessTokenRevoked(t, token))
+			}
+			if tt.wantAlive {
+				assert.NotEqual(t, first.RefreshToken, data.RefreshToken)
+				assert.False(t, env.accessTokenRevoked(t, data.AccessToken))
+			}
+		})
+	}
+}
+
+func TestTokenService_Logout(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	user := env.createUser(t, model.User{Email: "logout@example.com"})
+	data, err := env.tokenService.IssueTokens(ctx, user, testClient(), model.LoginTypeEmail)
+	require.NoError(t, err)
+	claims, err := env.jwt.ParseToken(data.AccessToken)
+	require.NoError(t, err)
+
+	require.NoError(t, env.tokenService.Logout(ctx, claims, &v1.LogoutRequest{}))
+
+	assert.True(t, env.accessTokenRevoked(t, data.AccessToken))
+	_, err = env.tokenService.RefreshToken(ctx, &v1.RefreshTokenRequest{RefreshToken: data.RefreshToken}, testClient())
+	assert.ErrorIs(t, err, v1.ErrUnauthorized)
+}
+
+// accessTokenRevoked 与 StrictAuth 的判断一致
+func (env *testEnv) accessTokenRevoked(t *testing.T, token string) bool {
+	t.Helper()
+	claims, err := env.jwt.ParseToken(token)
+	require.NoError(t, err)
+	revoked, err := env.jwt.IsRevoked(context.Background(), claims)
+	require.NoError(t, err)
+	return revoked
+}
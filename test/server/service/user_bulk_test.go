@@ -0,0 +1,238 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	v1 "go-nunu/api/v1"
+	"go-nunu/internal/model"
+	"go-nunu/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+func TestUserBulkService_ImportUsers_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		csv        string
+		wantErrors []v1.ImportUserError
+	}{
+		{
+			name:       "empty file",
+			csv:        "",
+			wantErrors: []v1.ImportUserError{{Reason: "the file is empty"}},
+		},
+		{
+			name:       "no email column",
+			csv:        "name,roles\nalice,\n",
+			wantErrors: []v1.ImportUserError{{Reason: "the header row has no email column"}},
+		},
+		{
+			name: "missing and invalid email",
+			csv:  "email,name\n,alice\nnot-an-email,bob\n",
+			wantErrors: []v1.ImportUserError{
+				{Row: 2, Reason: "email is required"},
+				{Row: 3, Email: "not-an-email", Reason: "email is invalid"},
+			},
+		},
+		{
+			name: "name too long",
+			csv:  "email,name\nlong@example.com," + strings.Repeat("x", 256) + "\n",
+			wantErrors: []v1.ImportUserError{
+				{Row: 2, Email: "long@example.com", Reason: "name is longer than 255 characters"},
+			},
+		},
+		{
+			name: "duplicated rows ignore case",
+			csv:  "email\nalice@example.com\nAlice@Example.com\n",
+			wantErrors: []v1.ImportUserError{
+				{Row: 3, Email: "alice@example.com", Reason: "email duplicates row 2"},
+			},
+		},
+		{
+			name: "email already in use ignores case",
+			csv:  "email\nEXISTING@example.com\n",
+			wantErrors: []v1.ImportUserError{
+				{Row: 2, Email: "existing@example.com", Reason: "email is already in use"},
+			},
+		},
+		{
+			name: "unknown roles",
+			csv:  "email,roles\nbob@example.com,\"member,ghost\"\n",
+			wantErrors: []v1.ImportUserError{
+				{Row: 2, Email: "bob@example.com", Reason: "unknown roles: ghost"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			env.createRole(t, "member", model.DataScopeAll)
+			env.createUser(t, model.User{Email: "existing@example.com"})
+
+			data, err := env.bulkService.ImportUsers(ctx, model.AdminUserID, service.UserFileCSV, strings.NewReader(tt.csv), &v1.ImportUsersRequest{})
+			assert.ErrorIs(t, err, v1.ErrImportFileInvalid)
+			assert.Equal(t, tt.wantErrors, data.Errors)
+			assert.False(t, data.Applied)
+
+			// 有任何错误时整个文件都不导入
+			var count int64
+			require.NoError(t, env.db.Model(&model.User{}).Count(&count).Error)
+			assert.Equal(t, int64(1), count)
+		})
+	}
+}
+
+// 非超管导入时只能分配自己拥有或继承的角色
+func TestUserBulkService_ImportUsers_RoleAssignment(t *testing.T) {
+	env := newTestEnv(t)
+	editor := env.createRole(t, "editor", model.DataScopeAll)
+	env.createRole(t, "root", model.DataScopeAll)
+	operator := env.createUser(t, model.User{Email: "operator@example.com"})
+	require.NoError(t, env.userService.UpdateUser(asUser(model.AdminUserID), model.AdminUserID, &v1.UpdateUserRequest{
+		UserId:  operator.UserId,
+		RoleIds: []uint{editor.ID},
+	}))
+
+	csv := "email,roles\nalice@example.com,editor\nbob@example.com,\"editor,root\"\n"
+	data, err := env.bulkService.ImportUsers(asUser(operator.UserId), operator.UserId, service.UserFileCSV, strings.NewReader(csv), &v1.ImportUsersRequest{})
+	assert.ErrorIs(t, err, v1.ErrImportFileInvalid)
+	assert.Equal(t, []v1.ImportUserError{
+		{Row: 3, Email: "bob@example.com", Reason: "roles not assignable: root"},
+	}, data.Errors)
+	assert.False(t, data.Applied)
+}
+
+func TestUserBulkService_ImportUsers(t *testing.T) {
+	const csv = "email,name,roles\n Alice@Example.com ,alice,member\nbob@example.com,,\n"
+	tests := []struct {
+		name      string
+		req       v1.ImportUsersRequest
+		wantUsers int
+	}{
+		{name: "dry run", req: v1.ImportUsersRequest{DryRun: true}},
+		{name: "apply", req: v1.ImportUsersRequest{SendEmail: true}, wantUsers: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			env.createRole(t, "member", model.DataScopeAll)
+
+			data, err := env.bulkService.ImportUsers(ctx, model.AdminUserID, service.UserFileCSV, strings.NewReader(csv), &tt.req)
+			require.NoError(t, err)
+			assert.Equal(t, 2, data.Total)
+			assert.Equal(t, 2, data.Created)
+			assert.Equal(t, !tt.req.DryRun, data.Applied)
+
+			var users []model.User
+			require.NoError(t, env.db.Order("email").Find(&users).Error)
+			require.Len(t, users, tt.wantUsers)
+			if tt.wantUsers == 0 {
+				assert.Zero(t, env.mail.sentTo("alice@example.com"))
+				return
+			}
+			assert.Equal(t, "alice@example.com", users[0].Email)
+			assert.Equal(t, "new user", users[1].Name)
+			ok, err := env.casbin.HasGroupingPolicy(model.UserSubject(users[0].UserId), "member")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, 2, data.EmailsSent)
+			assert.Equal(t, 1, env.mail.sentTo("alice@example.com"))
+		})
+	}
+}
+
+// 写入中途失败时整个文件回滚，不写 Casbin，也不发送邮件
+func TestUserBulkService_ImportUsers_Rollback(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.createRole(t, "member", model.DataScopeAll)
+	require.NoError(t, env.db.Callback().Create().Before("gorm:create").Register("test:fail_import", func(db *gorm.DB) {
+		if user, ok := db.Statement.Dest.(*model.User); ok && user.Email == "bob@example.com" {
+			db.AddError(errors.New("insert failed"))
+		}
+	}))
+
+	csv := "email,roles\nalice@example.com,member\nbob@example.com,member\n"
+	_, err := env.bulkService.ImportUsers(ctx, model.AdminUserID, service.UserFileCSV, strings.NewReader(csv), &v1.ImportUsersRequest{SendEmail: true})
+	require.Error(t, err)
+
+	var count int64
+	require.NoError(t, env.db.Model(&model.User{}).Count(&count).Error)
+	assert.Zero(t, count)
+	users, err := env.casbin.GetUsersForRole("member")
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Zero(t, env.mail.sentTo("alice@example.com"))
+}
+
+func TestUserBulkService_ExportUsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		// read 解析导出的文件，formula 为 name 列是否被当成公式
+		read func(t *testing.T, data []byte) (rows [][]string, formula bool)
+	}{
+		{
+			name:   "csv",
+			format: service.UserFileCSV,
+			read: func(t *testing.T, data []byte) ([][]string, bool) {
+				rows, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff")))).ReadAll()
+				require.NoError(t, err)
+				return rows, false
+			},
+		},
+		{
+			name:   "xlsx",
+			format: service.UserFileXLSX,
+			read: func(t *testing.T, data []byte) ([][]string, bool) {
+				f, err := excelize.OpenReader(bytes.NewReader(data))
+				require.NoError(t, err)
+				defer f.Close()
+				sheet := f.GetSheetName(0)
+				rows, err := f.GetRows(sheet)
+				require.NoError(t, err)
+				formula, err := f.GetCellFormula(sheet, "C2")
+				require.NoError(t, err)
+				return rows, formula != ""
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			// 超过一批，覆盖分批写入
+			const total = 501
+			first := env.createUser(t, model.User{UserId: "u000", Email: "u000@example.com", Name: "=1+1"})
+			for i := 1; i < total; i++ {
+				env.createUser(t, model.User{UserId: fmt.Sprintf("u%03d", i), Email: fmt.Sprintf("u%03d@example.com", i)})
+			}
+
+			var out bytes.Buffer
+			req := &v1.ExportUsersRequest{Format: tt.format}
+			req.SortBy, req.SortOrder = "id", "asc"
+			require.NoError(t, env.bulkService.ExportUsers(asUser(model.AdminUserID), req, &out))
+
+			rows, formula := tt.read(t, out.Bytes())
+			require.Len(t, rows, total+1)
+			assert.Equal(t, []string{"user_id", "email", "name", "roles", "status", "user_type", "dept_id", "registered_at", "last_login_at"}, rows[0])
+			assert.Equal(t, first.UserId, rows[1][0])
+			assert.False(t, formula)
+			if tt.format == service.UserFileCSV {
+				assert.Equal(t, "'=1+1", rows[1][2])
+			} else {
+				assert.Equal(t, "=1+1", rows[1][2])
+			}
+			assert.Equal(t, "u500", rows[total][0])
+		})
+	}
+}
@@ -3,61 +3,53 @@ package service_test
 import (
 	"context"
 	"errors"
-	"flag"
-	"fmt"
 	v1 "go-nunu/api/v1"
-	"go-nunu/pkg/jwt"
+	"go-nunu/internal/model"
+	"go-nunu/internal/service"
+	"go-nunu/pkg/lockout"
 	"go-nunu/test/mocks/repository"
-	"os"
+	mock_service "go-nunu/test/mocks/service"
 	"testing"
 
-	"go-nunu/internal/model"
-	"go-nunu/internal/service"
-	"go-nunu/pkg/config"
-	"go-nunu/pkg/log"
-	"go-nunu/pkg/sid"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var (
-	logger *log.Logger
-	j      *jwt.JWT
-	sf     *sid.Sid
-)
-
-func TestMain(m *testing.M) {
-	fmt.Println("begin")
+// userServiceMocks UserService 依赖的其他服务全部用 mock 替代
+type userServiceMocks struct {
+	userRepo *mock_repository.MockUserRepository
+	tm       *mock_repository.MockTransaction
+	token    *mock_service.MockTokenService
+	account  *mock_service.MockAccountService
+	mfa      *mock_service.MockMfaService
+	session  *mock_service.MockSessionService
+	avatar   *mock_service.MockAvatarService
+}
 
-	err := os.Setenv("APP_CONF", "../../../config/local.yml")
-	if err != nil {
-		panic(err)
+func newMockUserService(ctrl *gomock.Controller) (service.UserService, *userServiceMocks) {
+	m := &userServiceMocks{
+		userRepo: mock_repository.NewMockUserRepository(ctrl),
+		tm:       mock_repository.NewMockTransaction(ctrl),
+		token:    mock_service.NewMockTokenService(ctrl),
+		account:  mock_service.NewMockAccountService(ctrl),
+		mfa:      mock_service.NewMockMfaService(ctrl),
+		session:  mock_service.NewMockSessionService(ctrl),
+		avatar:   mock_service.NewMockAvatarService(ctrl),
 	}
-
-	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
-	flag.Parse()
-	conf := config.NewConfig(*envConf)
-
-	logger = log.NewLog(conf)
-	j = jwt.NewJwt(conf)
-	sf = sid.NewSid()
-
-	code := m.Run()
-	fmt.Println("test end")
-
-	os.Exit(code)
+	srv := service.NewService(nil, m.tm, logger, sf, j, nil)
+	userService := service.NewUserService(srv, conf, m.userRepo, nil, m.token, m.account, m.mfa, m.session, m.avatar,
+		lockout.NewGuard(conf, lockout.NewMemoryStore()))
+	return userService, m
 }
 
 func TestUserService_Register(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo, mockTm := m.userRepo, m.tm
 
 	ctx := context.Background()
 	req := &v1.RegisterRequest{
@@ -67,6 +59,7 @@ func TestUserService_Register(t *testing.T) {
 
 	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, nil)
 	mockTm.EXPECT().Transaction(ctx, gomock.Any()).Return(nil)
+	m.account.EXPECT().SendVerifyEmail(ctx, gomock.Any()).Return(nil)
 
 	err := userService.Register(ctx, req)
 
@@ -77,10 +70,8 @@ func TestUserService_Register_UsernameExists(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
 
 	ctx := context.Background()
 	req := &v1.RegisterRequest{
@@ -99,10 +90,8 @@ func TestUserService_Login(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
 
 	ctx := context.Background()
 	req := &v1.LoginRequest{
@@ -114,24 +103,29 @@ func TestUserService_Login(t *testing.T) {
 		t.Error("failed to hash password")
 	}
 
-	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(&model.User{
+	user := &model.User{
+		UserId:   "123",
+		Email:    req.Email,
 		Password: string(hashedPassword),
-	}, nil)
+		Status:   model.UserStatusNormal,
+	}
+	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(user, nil)
+	m.mfa.EXPECT().Challenge(ctx, user, model.LoginTypeEmail, "").Return(nil, nil)
+	m.token.EXPECT().IssueTokens(ctx, user, gomock.Any(), model.LoginTypeEmail).
+		Return(&v1.LoginResponseData{AccessToken: "access", RefreshToken: "refresh"}, nil)
 
-	token, err := userService.Login(ctx, req)
+	token, err := userService.Login(ctx, req, testClient())
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotEmpty(t, token.AccessToken)
 }
 
 func TestUserService_Login_UserNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
 
 	ctx := context.Background()
 	req := &v1.LoginRequest{
@@ -141,7 +135,7 @@ func TestUserService_Login_UserNotFound(t *testing.T) {
 
 	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, errors.New("user not found"))
 
-	_, err := userService.Login(ctx, req)
+	_, err := userService.Login(ctx, req, testClient())
 
 	assert.Error(t, err)
 }
@@ -150,10 +144,8 @@ func TestUserService_GetProfile(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
 
 	ctx := context.Background()
 	userId := "123"
@@ -162,6 +154,7 @@ func TestUserService_GetProfile(t *testing.T) {
 		UserId: userId,
 		Email:  "test@example.com",
 	}, nil)
+	m.avatar.EXPECT().AvatarURLs(gomock.Any()).Return(map[string]string{})
 
 	user, err := userService.GetProfile(ctx, userId)
 
@@ -173,43 +166,71 @@ func TestUserService_UpdateProfile(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
 
 	ctx := context.Background()
 	userId := "123"
 	req := &v1.UpdateProfileRequest{
-		Nickname: "testuser",
-		Email:    "test@example.com",
+		Name:  "testuser",
+		Email: "test@example.com",
 	}
 
 	mockUserRepo.EXPECT().GetByID(ctx, userId).Return(&model.User{
 		UserId: userId,
 		Email:  "old@example.com",
 	}, nil)
-	mockUserRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, nil)
+	mockUserRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, user *model.User) error {
+		assert.Equal(t, req.Email, user.Email)
+		assert.Zero(t, user.EmailVerifyTime)
+		return nil
+	})
+	m.avatar.EXPECT().DeleteAvatar(ctx, gomock.Any())
+	m.account.EXPECT().SendVerifyEmail(ctx, gomock.Any()).Return(nil)
 
 	err := userService.UpdateProfile(ctx, userId, req)
 
 	assert.NoError(t, err)
 }
 
+func TestUserService_UpdateProfile_EmailTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
+
+	ctx := context.Background()
+	userId := "123"
+	req := &v1.UpdateProfileRequest{
+		Email: "taken@example.com",
+	}
+
+	mockUserRepo.EXPECT().GetByID(ctx, userId).Return(&model.User{
+		UserId:          userId,
+		Email:           "old@example.com",
+		EmailVerifyTime: 1,
+	}, nil)
+	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(&model.User{UserId: "456", Email: req.Email}, nil)
+
+	err := userService.UpdateProfile(ctx, userId, req)
+
+	assert.ErrorIs(t, err, v1.ErrEmailAlreadyUse)
+}
+
 func TestUserService_UpdateProfile_UserNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService, m := newMockUserService(ctrl)
+	mockUserRepo := m.userRepo
 
 	ctx := context.Background()
 	userId := "123"
 	req := &v1.UpdateProfileRequest{
-		Nickname: "testuser",
-		Email:    "test@example.com",
+		Name:  "testuser",
+		Email: "test@example.com",
 	}
 
 	mockUserRepo.EXPECT().GetByID(ctx, userId).Return(nil, errors.New("user not found"))
@@ -218,3 +239,281 @@ func TestUserService_UpdateProfile_UserNotFound(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestUserService_UpdateUser_Email(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		wantErr  error
+		wantMail bool
+	}{
+		{name: "unchanged", email: "member@example.com"},
+		{name: "changed", email: "new@example.com", wantMail: true},
+		{name: "taken by another user", email: "other@example.com", wantErr: v1.ErrEmailAlreadyUse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := asUser(model.AdminUserID)
+			user := env.createUser(t, model.User{Email: "member@example.com", EmailVerifyTime: 1})
+			env.createUser(t, model.User{Email: "other@example.com", EmailVerifyTime: 1})
+
+			err := env.userService.UpdateUser(ctx, model.AdminUserID, &v1.UpdateUserRequest{UserId: user.UserId, Email: tt.email})
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			got, err := env.userRepo.GetByID(ctx, user.UserId)
+			assert.NoError(t, err)
+			if tt.wantErr != nil {
+				assert.Equal(t, "member@example.com", got.Email)
+				return
+			}
+			assert.Equal(t, tt.email, got.Email)
+			// 新邮箱需要重新验证
+			assert.Equal(t, tt.wantMail, got.EmailVerifyTime == 0)
+			assert.Equal(t, tt.wantMail, env.mail.sentTo(tt.email) == 1)
+		})
+	}
+}
+
+// TestUserRepository_EmailUniqueIndex 绕过服务层检查直接写库，由唯一索引拦截
+// 非超管只能分配自己拥有或继承的角色，也不能收回其他角色
+func TestUserService_RoleAssignment(t *testing.T) {
+	tests := []struct {
+		name string
+		// operatorIsAdmin 为 false 时操作人拥有 editor，editor 继承 viewer
+		operatorIsAdmin bool
+		run             func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error
+		wantErr         error
+	}{
+		{
+			name: "create with a held role",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				_, err := env.userService.CreateUser(ctx, operatorId, &v1.CreateUserRequest{Email: "new@example.com", Password: "password", RoleIds: []uint{roles["editor"].ID}})
+				return err
+			},
+		},
+		{
+			name: "create with an inherited role",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				_, err := env.userService.CreateUser(ctx, operatorId, &v1.CreateUserRequest{Email: "new@example.com", Password: "password", RoleIds: []uint{roles["viewer"].ID}})
+				return err
+			},
+		},
+		{
+			name: "create with a role the operator does not hold",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				_, err := env.userService.CreateUser(ctx, operatorId, &v1.CreateUserRequest{Email: "new@example.com", Password: "password", RoleIds: []uint{roles["root"].ID}})
+				return err
+			},
+			wantErr: v1.ErrForbidden,
+		},
+		{
+			name: "update to a role the operator does not hold",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				target := env.createUser(t, model.User{Email: "target@example.com", CreatedBy: operatorId})
+				return env.userService.UpdateUser(ctx, operatorId, &v1.UpdateUserRequest{UserId: target.UserId, RoleIds: []uint{roles["root"].ID}})
+			},
+			wantErr: v1.ErrForbidden,
+		},
+		{
+			name: "revoke a role the operator does not hold",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				target := env.createUser(t, model.User{Email: "target@example.com", CreatedBy: operatorId, Roles: []model.Role{*roles["root"]}})
+				return env.userService.UpdateUser(ctx, operatorId, &v1.UpdateUserRequest{UserId: target.UserId, RoleIds: []uint{roles["viewer"].ID}})
+			},
+			wantErr: v1.ErrForbidden,
+		},
+		{
+			name: "keep a role the operator does not hold",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				target := env.createUser(t, model.User{Email: "target@example.com", CreatedBy: operatorId, Roles: []model.Role{*roles["root"]}})
+				return env.userService.UpdateUser(ctx, operatorId, &v1.UpdateUserRequest{UserId: target.UserId, Name: "renamed", RoleIds: []uint{roles["root"].ID}})
+			},
+		},
+		{
+			name: "unknown role",
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				target := env.createUser(t, model.User{Email: "target@example.com", CreatedBy: operatorId})
+				return env.userService.UpdateUser(ctx, operatorId, &v1.UpdateUserRequest{UserId: target.UserId, RoleIds: []uint{999}})
+			},
+			wantErr: v1.ErrBadRequest,
+		},
+		{
+			name:            "super admin assigns any role",
+			operatorIsAdmin: true,
+			run: func(t *testing.T, env *testEnv, ctx context.Context, operatorId string, roles map[string]*model.Role) error {
+				_, err := env.userService.CreateUser(ctx, operatorId, &v1.CreateUserRequest{Email: "new@example.com", Password: "password", RoleIds: []uint{roles["root"].ID}})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			roles := map[string]*model.Role{}
+			for _, sid := range []string{"viewer", "editor", "root"} {
+				roles[sid] = env.createRole(t, sid, model.DataScopeSelf)
+			}
+			require.NoError(t, env.roleService.UpdateRoleParents(context.Background(), int64(roles["editor"].ID), []uint{roles["viewer"].ID}))
+			operatorId := model.AdminUserID
+			if !tt.operatorIsAdmin {
+				operator := env.createUser(t, model.User{Email: "operator@example.com"})
+				require.NoError(t, env.userService.UpdateUser(asUser(model.AdminUserID), model.AdminUserID, &v1.UpdateUserRequest{
+					UserId:  operator.UserId,
+					RoleIds: []uint{roles["editor"].ID},
+				}))
+				operatorId = operator.UserId
+			}
+
+			err := tt.run(t, env, asUser(operatorId), operatorId, roles)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUserRepository_EmailUniqueIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing model.User
+		run      func(t *testing.T, env *testEnv, ctx context.Context) error
+		wantErr  error
+	}{
+		{
+			name:     "create with the same email in another case",
+			existing: model.User{Email: "taken@example.com"},
+			run: func(t *testing.T, env *testEnv, ctx context.Context) error {
+				return env.userRepo.Create(ctx, &model.User{UserId: "new", Email: "Taken@Example.com", UserType: model.UserTypeRegistered})
+			},
+			wantErr: v1.ErrEmailAlreadyUse,
+		},
+		{
+			name:     "update to a taken email",
+			existing: model.User{Email: "taken@example.com"},
+			run: func(t *testing.T, env *testEnv, ctx context.Context) error {
+				user := env.createUser(t, model.User{Email: "member@example.com"})
+				user.Email = "TAKEN@example.com"
+				return env.userRepo.Update(ctx, user)
+			},
+			wantErr: v1.ErrEmailAlreadyUse,
+		},
+		{
+			name:     "empty emails do not conflict",
+			existing: model.User{},
+			run: func(t *testing.T, env *testEnv, ctx context.Context) error {
+				return env.userRepo.Create(ctx, &model.User{UserId: "new", UserType: model.UserTypeRegistered})
+			},
+		},
+		{
+			name:     "guests do not reserve an email",
+			existing: model.User{Email: "guest@example.com", UserType: model.UserTypeGuest},
+			run: func(t *testing.T, env *testEnv, ctx context.Context) error {
+				return env.userRepo.Create(ctx, &model.User{UserId: "new", Email: "guest@example.com", UserType: model.UserTypeRegistered})
+			},
+		},
+		{
+			name:     "deleted users do not reserve an email",
+			existing: model.User{Email: "deleted@example.com"},
+			run: func(t *testing.T, env *testEnv, ctx context.Context) error {
+				var existing model.User
+				require.NoError(t, env.db.Where("email = ?", "deleted@example.com").First(&existing).Error)
+				require.NoError(t, env.userRepo.Delete(ctx, existing.UserId))
+				return env.userRepo.Create(ctx, &model.User{UserId: "new", Email: "deleted@example.com", UserType: model.UserTypeRegistered})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			env.createUser(t, tt.existing)
+			err := tt.run(t, env, context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserService_EmailNormalization(t *testing.T) {
+	tests := []struct {
+		name string
+		// existing 已有用户的邮箱，可能是统一小写之前保存的
+		existing  string
+		run       func(env *testEnv, ctx context.Context) error
+		wantErr   error
+		wantEmail string // 操作后应当存在的邮箱
+	}{
+		{
+			name: "register stores lower case",
+			run: func(env *testEnv, ctx context.Context) error {
+				return env.userService.Register(ctx, &v1.RegisterRequest{Email: " New@Example.COM ", Password: "password"})
+			},
+			wantEmail: "new@example.com",
+		},
+		{
+			name:     "register rejects the same email in another case",
+			existing: "taken@example.com",
+			run: func(env *testEnv, ctx context.Context) error {
+				return env.userService.Register(ctx, &v1.RegisterRequest{Email: "Taken@Example.com", Password: "password"})
+			},
+			wantErr: v1.ErrEmailAlreadyUse,
+		},
+		{
+			name:     "register rejects an email saved before normalization",
+			existing: "Legacy@Example.com",
+			run: func(env *testEnv, ctx context.Context) error {
+				return env.userService.Register(ctx, &v1.RegisterRequest{Email: "legacy@example.com", Password: "password"})
+			},
+			wantErr: v1.ErrEmailAlreadyUse,
+		},
+		{
+			name: "create user stores lower case",
+			run: func(env *testEnv, ctx context.Context) error {
+				_, err := env.userService.CreateUser(ctx, model.AdminUserID, &v1.CreateUserRequest{Email: "Staff@Example.com", Password: "password"})
+				return err
+			},
+			wantEmail: "staff@example.com",
+		},
+		{
+			name:     "create user rejects the same email in another case",
+			existing: "staff@example.com",
+			run: func(env *testEnv, ctx context.Context) error {
+				_, err := env.userService.CreateUser(ctx, model.AdminUserID, &v1.CreateUserRequest{Email: "STAFF@example.com", Password: "password"})
+				return err
+			},
+			wantErr: v1.ErrEmailAlreadyUse,
+		},
+		{
+			name:     "login with another case",
+			existing: "Legacy@Example.com",
+			run: func(env *testEnv, ctx context.Context) error {
+				_, err := env.userService.Login(ctx, &v1.LoginRequest{Email: "legacy@EXAMPLE.com", Password: "password"}, testClient())
+				return err
+			},
+			wantEmail: "Legacy@Example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			ctx := context.Background()
+			if tt.existing != "" {
+				env.createUser(t, model.User{Email: tt.existing})
+			}
+
+			err := tt.run(env, ctx)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				var count int64
+				require.NoError(t, env.db.Model(&model.User{}).Count(&count).Error)
+				assert.Equal(t, int64(1), count)
+				return
+			}
+			require.NoError(t, err)
+			var count int64
+			require.NoError(t, env.db.Model(&model.User{}).Where("email = ?", tt.wantEmail).Count(&count).Error)
+			assert.Equal(t, int64(1), count)
+		})
+	}
+}